@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/admin"
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const adminUsage = `Usage: termflow-api admin <command> [arguments]
+
+Runs operator tasks straight against the database, for when the admin
+endpoints can not be reached.
+
+Commands:
+  users [-active true|false] [-verified true|false] [-search text] [-limit n] [-offset n]
+                                  list users
+  show <id|email>                 show a user and what they store
+  deactivate <id|email>           deactivate the account and sign the user out
+  reactivate <id|email>           reactivate the account
+  logout <id|email>               sign the user out of every session
+  set-role <id|email> <user|admin>
+                                  change the role of the user
+  usage                           show usage totals
+`
+
+// auditUserAgent is recorded as the user agent of the audit events of the
+// tasks run from the command line.
+const auditUserAgent = "termflow-api admin"
+
+// runAdmin runs the admin subcommand with the arguments that follow it and
+// returns the exit code.
+func runAdmin(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, adminUsage)
+		return 2
+	}
+
+	var cfg config.AppConfig
+	if err := config.LoadConfig(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
+		return 1
+	}
+
+	databaseConnectionUri, err := config.ConstructDatabaseUrl(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	connection, err := pgxpool.New(ctx, databaseConnectionUri)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: connecting to the database: %v\n", err)
+		return 1
+	}
+	defer connection.Close()
+
+	err = adminCommand(ctx, repository.NewStore(connection), os.Stdout, args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, flag.ErrHelp) || errors.Is(err, errAdminUsage) {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+var errAdminUsage = errors.New("invalid arguments")
+
+func adminCommand(ctx context.Context, store *repository.Store, out io.Writer, command string, args []string) error {
+	switch command {
+	case "users":
+		return adminListUsers(ctx, store, out, args)
+	case "show":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: show takes a user id or email", errAdminUsage)
+		}
+
+		return adminShowUser(ctx, store, out, args[0])
+	case "deactivate", "reactivate", "logout":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: %s takes a user id or email", errAdminUsage, command)
+		}
+
+		return adminUserAction(ctx, store, out, command, args[0], "")
+	case "set-role":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: set-role takes a user id or email and a role", errAdminUsage)
+		}
+
+		return adminUserAction(ctx, store, out, command, args[0], args[1])
+	case "usage":
+		return adminUsageTotals(ctx, store, out)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errAdminUsage, command)
+	}
+}
+
+func adminListUsers(ctx context.Context, store *repository.Store, out io.Writer, args []string) error {
+	flags := flag.NewFlagSet("users", flag.ContinueOnError)
+	active := flags.String("active", "", "only users that are active (true) or not (false)")
+	verified := flags.String("verified", "", "only users whose email is verified (true) or not (false)")
+	search := flags.String("search", "", "part of the email or of the name")
+	limit := flags.Int("limit", admin.DefaultListLimit, "number of users to list")
+	offset := flags.Int("offset", 0, "number of users to skip")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter := admin.UserFilter{
+		Search: *search,
+		Limit:  int32(*limit),
+		Offset: int32(*offset),
+	}
+
+	for name, value := range map[string]string{"active": *active, "verified": *verified} {
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: -%s takes true or false", errAdminUsage, name)
+		}
+
+		if name == "active" {
+			filter.IsActive = &parsed
+		} else {
+			filter.IsEmailVerified = &parsed
+		}
+	}
+
+	users, err := admin.ListUsers(ctx, store.Queries, filter)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMAIL\tNAME\tROLE\tACTIVE\tVERIFIED\tCREATED")
+	for _, user := range users {
+		fmt.Fprintf(tw, "%s\t%s\t%s %s\t%s\t%t\t%t\t%s\n",
+			user.ID, user.Email, user.FirstName, user.LastName, user.Role,
+			user.IsActive.Bool, user.IsEmailVerified.Bool, formatTime(user.CreatedAt))
+	}
+
+	return tw.Flush()
+}
+
+func adminShowUser(ctx context.Context, store *repository.Store, out io.Writer, idOrEmail string) error {
+	user, err := admin.FindUser(ctx, store.Queries, idOrEmail)
+	if err != nil {
+		return err
+	}
+
+	usage, err := store.GetUserUsageCounts(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\t%s\n", user.ID)
+	fmt.Fprintf(tw, "Email\t%s\n", user.Email)
+	fmt.Fprintf(tw, "Name\t%s %s\n", user.FirstName, user.LastName)
+	fmt.Fprintf(tw, "Role\t%s\n", user.Role)
+	fmt.Fprintf(tw, "Active\t%t\n", user.IsActive.Bool)
+	fmt.Fprintf(tw, "Email verified\t%t\n", user.IsEmailVerified.Bool)
+	fmt.Fprintf(tw, "Sessions revoked\t%s\n", formatTime(user.SessionsRevokedAt))
+	fmt.Fprintf(tw, "Created\t%s\n", formatTime(user.CreatedAt))
+	fmt.Fprintf(tw, "Commands\t%d\n", usage.Commands)
+	fmt.Fprintf(tw, "Tags\t%d\n", usage.Tags)
+	fmt.Fprintf(tw, "Collections\t%d\n", usage.Collections)
+	fmt.Fprintf(tw, "Runbooks\t%d\n", usage.Runbooks)
+	fmt.Fprintf(tw, "Share links\t%d\n", usage.ShareLinks)
+	fmt.Fprintf(tw, "Workspaces\t%d\n", usage.Workspaces)
+	fmt.Fprintf(tw, "Sessions\t%d\n", usage.Sessions)
+	fmt.Fprintf(tw, "Command uses\t%d\n", usage.CommandUses)
+	fmt.Fprintf(tw, "Last used\t%s\n", formatTime(usage.LastUsedAt))
+
+	return tw.Flush()
+}
+
+func adminUsageTotals(ctx context.Context, store *repository.Store, out io.Writer) error {
+	totals, err := store.GetUsageTotals(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Users\t%d\n", totals.Users)
+	fmt.Fprintf(tw, "Active users\t%d\n", totals.ActiveUsers)
+	fmt.Fprintf(tw, "Admins\t%d\n", totals.Admins)
+	fmt.Fprintf(tw, "Commands\t%d\n", totals.Commands)
+	fmt.Fprintf(tw, "Tags\t%d\n", totals.Tags)
+	fmt.Fprintf(tw, "Collections\t%d\n", totals.Collections)
+	fmt.Fprintf(tw, "Runbooks\t%d\n", totals.Runbooks)
+	fmt.Fprintf(tw, "Share links\t%d\n", totals.ShareLinks)
+	fmt.Fprintf(tw, "Workspaces\t%d\n", totals.Workspaces)
+	fmt.Fprintf(tw, "Command uses\t%d\n", totals.CommandUses)
+
+	return tw.Flush()
+}
+
+// adminUserAction runs one of the tasks that change an account and records
+// it in the audit events of the user.
+func adminUserAction(ctx context.Context, store *repository.Store, out io.Writer, command, idOrEmail, role string) error {
+	user, err := admin.FindUser(ctx, store.Queries, idOrEmail)
+	if err != nil {
+		return err
+	}
+
+	var event string
+	switch command {
+	case "deactivate":
+		event, err = admin.EventDeactivated, admin.Deactivate(ctx, store, user.ID)
+	case "reactivate":
+		event, err = admin.EventReactivated, admin.Reactivate(ctx, store.Queries, user.ID)
+	case "logout":
+		event, err = admin.EventSessionsRevoked, admin.ForceLogout(ctx, store, user.ID)
+	case "set-role":
+		event, err = admin.EventRoleChanged, admin.SetRole(ctx, store, user.ID, role)
+	}
+	if err != nil {
+		return err
+	}
+
+	recordAdminAuditEvent(ctx, store, user.ID, event)
+
+	fmt.Fprintf(out, "%s: %s (%s)\n", event, user.Email, user.ID)
+	return nil
+}
+
+func recordAdminAuditEvent(ctx context.Context, store *repository.Store, userId uuid.UUID, event string) {
+	err := store.InsertAuditEvent(ctx, repository.InsertAuditEventParams{
+		UserID:    userId,
+		Event:     event,
+		UserAgent: pgtype.Text{String: auditUserAgent, Valid: true},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to record audit event: %v\n", err)
+	}
+}
+
+func formatTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return "-"
+	}
+
+	return t.Time.Local().Format(time.DateTime)
+}
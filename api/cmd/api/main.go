@@ -1,18 +1,83 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/endalk200/termflow-api/internal/server"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/tracing"
 )
 
 func main() {
-	server := server.NewServer(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	envFileVariables, err := config.LoadEnvFile()
+	if err != nil {
+		panic(fmt.Sprintf("cannot load config: %s", err))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:]))
+	}
+
+	var cfg config.AppConfig
+	if err := config.LoadConfig(&cfg); err != nil {
+		panic(fmt.Sprintf("cannot load config: %s", err))
+	}
+
+	logger, err := newLogger(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("cannot create logger: %s", err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracesExporter, cfg.ServiceName)
+	if err != nil {
+		panic(fmt.Sprintf("cannot set up tracing: %s", err))
+	}
 
-	err := server.ListenAndServe()
+	err = run(ctx, cfg, envFileVariables, logger)
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error("Failed to flush traces", slog.String("ERROR", err.Error()))
+	}
+
+	if err != nil {
+		logger.Error("Server failed", slog.String("ERROR", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg config.AppConfig, envFileVariables []string, logger *slog.Logger) error {
+	server, err := server.NewServer(ctx, cfg, envFileVariables, logger)
 	if err != nil {
-		panic(fmt.Sprintf("cannot start server: %s", err))
+		return err
+	}
+
+	return server.Run(ctx)
+}
+
+// newLogger writes to stdout at cfg.LogLevel, as JSON or as text depending
+// on cfg.LogFormat.
+func newLogger(cfg config.AppConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q", cfg.LogLevel)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q, expected json or text", cfg.LogFormat)
 	}
 }
@@ -0,0 +1,177 @@
+// Package admin holds the tasks operators run on user accounts. They are
+// shared by the /api/admin endpoints and by the admin subcommand of the
+// server binary, which runs them straight against the database.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Roles a user can have, RoleAdmin grants access to the admin endpoints.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+var Roles = []string{RoleUser, RoleAdmin}
+
+// Events recorded in audit_events for the user an operator acted on.
+const (
+	EventDeactivated     = "account.deactivated"
+	EventReactivated     = "account.reactivated"
+	EventSessionsRevoked = "account.sessions_revoked"
+	EventRoleChanged     = "account.role_changed"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUnknownRole  = errors.New("unknown role")
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// UserFilter narrows down ListUsers, nil fields match every user.
+type UserFilter struct {
+	IsActive        *bool
+	IsEmailVerified *bool
+	// Search matches part of the email or of the full name, ignoring case.
+	Search string
+	Limit  int32
+	Offset int32
+}
+
+func ListUsers(ctx context.Context, q *repository.Queries, filter UserFilter) ([]repository.User, error) {
+	params := repository.ListUsersParams{
+		Search:    filter.Search,
+		RowLimit:  filter.Limit,
+		RowOffset: filter.Offset,
+	}
+
+	// A nil *bool would not be sent as NULL
+	if filter.IsActive != nil {
+		params.IsActive = *filter.IsActive
+	}
+	if filter.IsEmailVerified != nil {
+		params.IsEmailVerified = *filter.IsEmailVerified
+	}
+
+	if params.RowLimit <= 0 {
+		params.RowLimit = DefaultListLimit
+	}
+	if params.RowLimit > MaxListLimit {
+		params.RowLimit = MaxListLimit
+	}
+
+	return q.ListUsers(ctx, params)
+}
+
+// FindUser looks a user up by id or by email.
+func FindUser(ctx context.Context, q *repository.Queries, idOrEmail string) (repository.User, error) {
+	params := repository.GetUserParams{Email: idOrEmail}
+	if id, err := uuid.Parse(idOrEmail); err == nil {
+		params = repository.GetUserParams{ID: id}
+	}
+
+	user, err := q.GetUser(ctx, params)
+	if err == pgx.ErrNoRows {
+		return repository.User{}, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// Deactivate marks the account inactive and signs the user out everywhere.
+func Deactivate(ctx context.Context, store *repository.Store, userId uuid.UUID) error {
+	return store.ExecTx(ctx, func(q *repository.Queries) error {
+		rows, err := q.SetUserActive(ctx, repository.SetUserActiveParams{
+			ID:       userId,
+			IsActive: pgtype.Bool{Bool: false, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrUserNotFound
+		}
+
+		return revokeSessions(ctx, q, userId)
+	})
+}
+
+func Reactivate(ctx context.Context, q *repository.Queries, userId uuid.UUID) error {
+	rows, err := q.SetUserActive(ctx, repository.SetUserActiveParams{
+		ID:       userId,
+		IsActive: pgtype.Bool{Bool: true, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ForceLogout rejects the access tokens issued to the user so far and
+// deletes their refresh tokens.
+func ForceLogout(ctx context.Context, store *repository.Store, userId uuid.UUID) error {
+	return store.ExecTx(ctx, func(q *repository.Queries) error {
+		return revokeSessions(ctx, q, userId)
+	})
+}
+
+func revokeSessions(ctx context.Context, q *repository.Queries, userId uuid.UUID) error {
+	rows, err := q.RevokeUserSessions(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return q.DeleteRefreshTokensByUserID(ctx, pgtype.UUID{Bytes: userId, Valid: true})
+}
+
+// SetRole changes the role of the user. When the role changes the user is
+// signed out everywhere, so that no access token carries the previous role.
+func SetRole(ctx context.Context, store *repository.Store, userId uuid.UUID, role string) error {
+	if !slices.Contains(Roles, role) {
+		return fmt.Errorf("%w %q, expected %q or %q", ErrUnknownRole, role, RoleUser, RoleAdmin)
+	}
+
+	return store.ExecTx(ctx, func(q *repository.Queries) error {
+		user, err := q.GetUser(ctx, repository.GetUserParams{ID: userId})
+		if err == pgx.ErrNoRows {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if user.Role == role {
+			return nil
+		}
+
+		rows, err := q.SetUserRole(ctx, repository.SetUserRoleParams{ID: userId, Role: role})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrUserNotFound
+		}
+
+		return revokeSessions(ctx, q, userId)
+	})
+}
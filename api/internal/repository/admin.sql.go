@@ -0,0 +1,100 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: admin.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getUsageTotals = `-- name: GetUsageTotals :one
+SELECT
+  (SELECT COUNT(*) FROM users) AS users,
+  (SELECT COUNT(*) FROM users WHERE is_active) AS active_users,
+  (SELECT COUNT(*) FROM users WHERE role = 'admin') AS admins,
+  (SELECT COUNT(*) FROM commands WHERE deleted_at IS NULL) AS commands,
+  (SELECT COUNT(*) FROM tags WHERE deleted_at IS NULL) AS tags,
+  (SELECT COUNT(*) FROM collections) AS collections,
+  (SELECT COUNT(*) FROM runbooks) AS runbooks,
+  (SELECT COUNT(*) FROM share_links) AS share_links,
+  (SELECT COUNT(*) FROM workspaces) AS workspaces,
+  (SELECT COALESCE(SUM(use_count), 0) FROM command_usage)::bigint AS command_uses
+`
+
+type GetUsageTotalsRow struct {
+	Users       int64 `json:"users"`
+	ActiveUsers int64 `json:"active_users"`
+	Admins      int64 `json:"admins"`
+	Commands    int64 `json:"commands"`
+	Tags        int64 `json:"tags"`
+	Collections int64 `json:"collections"`
+	Runbooks    int64 `json:"runbooks"`
+	ShareLinks  int64 `json:"share_links"`
+	Workspaces  int64 `json:"workspaces"`
+	CommandUses int64 `json:"command_uses"`
+}
+
+func (q *Queries) GetUsageTotals(ctx context.Context) (GetUsageTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getUsageTotals)
+	var i GetUsageTotalsRow
+	err := row.Scan(
+		&i.Users,
+		&i.ActiveUsers,
+		&i.Admins,
+		&i.Commands,
+		&i.Tags,
+		&i.Collections,
+		&i.Runbooks,
+		&i.ShareLinks,
+		&i.Workspaces,
+		&i.CommandUses,
+	)
+	return i, err
+}
+
+const getUserUsageCounts = `-- name: GetUserUsageCounts :one
+SELECT
+  (SELECT COUNT(*) FROM commands c WHERE c.user_id = $1::UUID AND c.deleted_at IS NULL) AS commands,
+  (SELECT COUNT(*) FROM tags t WHERE t.user_id = $1::UUID AND t.deleted_at IS NULL) AS tags,
+  (SELECT COUNT(*) FROM collections l WHERE l.user_id = $1::UUID) AS collections,
+  (SELECT COUNT(*) FROM runbooks b WHERE b.user_id = $1::UUID) AS runbooks,
+  (SELECT COUNT(*) FROM share_links s WHERE s.user_id = $1::UUID) AS share_links,
+  (SELECT COUNT(*) FROM workspace_members m WHERE m.user_id = $1::UUID) AS workspaces,
+  (SELECT COUNT(*) FROM refresh_tokens r WHERE r.user_id = $1::UUID AND r.expires_at > NOW()) AS sessions,
+  (SELECT COALESCE(SUM(u.use_count), 0) FROM command_usage u WHERE u.user_id = $1::UUID)::bigint AS command_uses,
+  (SELECT MAX(u.last_used_at) FROM command_usage u WHERE u.user_id = $1::UUID)::timestamptz AS last_used_at
+`
+
+type GetUserUsageCountsRow struct {
+	Commands    int64              `json:"commands"`
+	Tags        int64              `json:"tags"`
+	Collections int64              `json:"collections"`
+	Runbooks    int64              `json:"runbooks"`
+	ShareLinks  int64              `json:"share_links"`
+	Workspaces  int64              `json:"workspaces"`
+	Sessions    int64              `json:"sessions"`
+	CommandUses int64              `json:"command_uses"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+}
+
+func (q *Queries) GetUserUsageCounts(ctx context.Context, userID uuid.UUID) (GetUserUsageCountsRow, error) {
+	row := q.db.QueryRow(ctx, getUserUsageCounts, userID)
+	var i GetUserUsageCountsRow
+	err := row.Scan(
+		&i.Commands,
+		&i.Tags,
+		&i.Collections,
+		&i.Runbooks,
+		&i.ShareLinks,
+		&i.Workspaces,
+		&i.Sessions,
+		&i.CommandUses,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
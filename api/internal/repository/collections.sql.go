@@ -0,0 +1,232 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: collections.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addCommandToCollection = `-- name: AddCommandToCollection :one
+INSERT INTO collection_commands (collection_id, command_id, position)
+VALUES (
+  $1, $2,
+  (SELECT COALESCE(MAX(position) + 1, 0) FROM collection_commands WHERE collection_id = $1)
+)
+RETURNING collection_id, command_id, position
+`
+
+type AddCommandToCollectionParams struct {
+	CollectionID uuid.UUID `json:"collection_id"`
+	CommandID    uuid.UUID `json:"command_id"`
+}
+
+func (q *Queries) AddCommandToCollection(ctx context.Context, arg AddCommandToCollectionParams) (CollectionCommand, error) {
+	row := q.db.QueryRow(ctx, addCommandToCollection, arg.CollectionID, arg.CommandID)
+	var i CollectionCommand
+	err := row.Scan(&i.CollectionID, &i.CommandID, &i.Position)
+	return i, err
+}
+
+const deleteCollection = `-- name: DeleteCollection :exec
+DELETE FROM collections
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCollection(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCollection, id)
+	return err
+}
+
+const findCollectionById = `-- name: FindCollectionById :one
+SELECT id, user_id, name, description, created_at, updated_at FROM collections
+WHERE (id = $1)
+`
+
+func (q *Queries) FindCollectionById(ctx context.Context, id uuid.UUID) (Collection, error) {
+	row := q.db.QueryRow(ctx, findCollectionById, id)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const findCollectionCommands = `-- name: FindCollectionCommands :many
+SELECT
+    c.id, c.command, c.description, c.created_at, c.updated_at,
+    cc.position
+FROM collection_commands cc
+JOIN commands c ON c.id = cc.command_id
+WHERE cc.collection_id = $1 AND c.deleted_at IS NULL
+ORDER BY cc.position
+`
+
+type FindCollectionCommandsRow struct {
+	ID          uuid.UUID          `json:"id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	Position    int32              `json:"position"`
+}
+
+func (q *Queries) FindCollectionCommands(ctx context.Context, collectionID uuid.UUID) ([]FindCollectionCommandsRow, error) {
+	rows, err := q.db.Query(ctx, findCollectionCommands, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindCollectionCommandsRow
+	for rows.Next() {
+		var i FindCollectionCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Command,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findCollections = `-- name: FindCollections :many
+SELECT id, user_id, name, description, created_at, updated_at FROM collections
+WHERE (user_id = $1)
+ORDER BY name
+`
+
+func (q *Queries) FindCollections(ctx context.Context, userID uuid.UUID) ([]Collection, error) {
+	rows, err := q.db.Query(ctx, findCollections, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collection
+	for rows.Next() {
+		var i Collection
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertCollection = `-- name: InsertCollection :one
+INSERT INTO collections (
+  id, user_id, name, description
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3::Text
+)
+RETURNING id, user_id, name, description, created_at, updated_at
+`
+
+type InsertCollectionParams struct {
+	UserID      uuid.UUID   `json:"user_id"`
+	Name        string      `json:"name"`
+	Description pgtype.Text `json:"description"`
+}
+
+func (q *Queries) InsertCollection(ctx context.Context, arg InsertCollectionParams) (Collection, error) {
+	row := q.db.QueryRow(ctx, insertCollection, arg.UserID, arg.Name, arg.Description)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const removeCommandFromCollection = `-- name: RemoveCommandFromCollection :exec
+DELETE FROM collection_commands
+WHERE collection_id = $1 AND command_id = $2
+`
+
+type RemoveCommandFromCollectionParams struct {
+	CollectionID uuid.UUID `json:"collection_id"`
+	CommandID    uuid.UUID `json:"command_id"`
+}
+
+func (q *Queries) RemoveCommandFromCollection(ctx context.Context, arg RemoveCommandFromCollectionParams) error {
+	_, err := q.db.Exec(ctx, removeCommandFromCollection, arg.CollectionID, arg.CommandID)
+	return err
+}
+
+const updateCollection = `-- name: UpdateCollection :one
+UPDATE collections
+SET name = COALESCE(NULLIF($1::Text, ''), name),
+    description = COALESCE($2::Text, description),
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, user_id, name, description, created_at, updated_at
+`
+
+type UpdateCollectionParams struct {
+	Name        string      `json:"name"`
+	Description pgtype.Text `json:"description"`
+	ID          uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateCollection(ctx context.Context, arg UpdateCollectionParams) (Collection, error) {
+	row := q.db.QueryRow(ctx, updateCollection, arg.Name, arg.Description, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateCollectionCommandPosition = `-- name: UpdateCollectionCommandPosition :exec
+UPDATE collection_commands
+SET position = $3
+WHERE collection_id = $1 AND command_id = $2
+`
+
+type UpdateCollectionCommandPositionParams struct {
+	CollectionID uuid.UUID `json:"collection_id"`
+	CommandID    uuid.UUID `json:"command_id"`
+	Position     int32     `json:"position"`
+}
+
+func (q *Queries) UpdateCollectionCommandPosition(ctx context.Context, arg UpdateCollectionCommandPositionParams) error {
+	_, err := q.db.Exec(ctx, updateCollectionCommandPosition, arg.CollectionID, arg.CommandID, arg.Position)
+	return err
+}
@@ -0,0 +1,119 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: command_usage.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteExpiredCommandUsageSyncs = `-- name: DeleteExpiredCommandUsageSyncs :execrows
+DELETE FROM command_usage_syncs
+WHERE created_at < $1
+`
+
+func (q *Queries) DeleteExpiredCommandUsageSyncs(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredCommandUsageSyncs, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findCommandUsage = `-- name: FindCommandUsage :many
+SELECT command_id, user_id, use_count, last_used_at, updated_at FROM command_usage
+WHERE (command_usage.user_id = $1)
+  AND command_usage.command_id IN (SELECT id FROM commands WHERE deleted_at IS NULL)
+ORDER BY use_count DESC
+`
+
+func (q *Queries) FindCommandUsage(ctx context.Context, userID uuid.UUID) ([]CommandUsage, error) {
+	rows, err := q.db.Query(ctx, findCommandUsage, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommandUsage
+	for rows.Next() {
+		var i CommandUsage
+		if err := rows.Scan(
+			&i.CommandID,
+			&i.UserID,
+			&i.UseCount,
+			&i.LastUsedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertCommandUsageSync = `-- name: InsertCommandUsageSync :execrows
+INSERT INTO command_usage_syncs (
+  command_id, sync_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING
+`
+
+type InsertCommandUsageSyncParams struct {
+	CommandID uuid.UUID `json:"command_id"`
+	SyncID    string    `json:"sync_id"`
+}
+
+func (q *Queries) InsertCommandUsageSync(ctx context.Context, arg InsertCommandUsageSyncParams) (int64, error) {
+	result, err := q.db.Exec(ctx, insertCommandUsageSync, arg.CommandID, arg.SyncID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertCommandUsage = `-- name: UpsertCommandUsage :one
+INSERT INTO command_usage (
+  command_id, user_id, use_count, last_used_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (command_id) DO UPDATE
+SET use_count = command_usage.use_count + EXCLUDED.use_count,
+    last_used_at = GREATEST(command_usage.last_used_at, EXCLUDED.last_used_at),
+    updated_at = NOW()
+RETURNING command_id, user_id, use_count, last_used_at, updated_at
+`
+
+type UpsertCommandUsageParams struct {
+	CommandID  uuid.UUID          `json:"command_id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	UseCount   int64              `json:"use_count"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+}
+
+func (q *Queries) UpsertCommandUsage(ctx context.Context, arg UpsertCommandUsageParams) (CommandUsage, error) {
+	row := q.db.QueryRow(ctx, upsertCommandUsage,
+		arg.CommandID,
+		arg.UserID,
+		arg.UseCount,
+		arg.LastUsedAt,
+	)
+	var i CommandUsage
+	err := row.Scan(
+		&i.CommandID,
+		&i.UserID,
+		&i.UseCount,
+		&i.LastUsedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -70,9 +70,31 @@ func (q *Queries) DeleteTag(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const findCommandById = `-- name: FindCommandById :one
+SELECT id, user_id, command, description, created_at, updated_at, workspace_id, deleted_at, version FROM commands
+WHERE (id = $1)
+`
+
+func (q *Queries) FindCommandById(ctx context.Context, id uuid.UUID) (Command, error) {
+	row := q.db.QueryRow(ctx, findCommandById, id)
+	var i Command
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Command,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
 const findCommands = `-- name: FindCommands :many
-SELECT id, user_id, command, description, created_at, updated_at FROM commands
-WHERE (user_id = $1)
+SELECT id, user_id, command, description, created_at, updated_at, workspace_id, deleted_at, version FROM commands
+WHERE (user_id = $1::UUID AND workspace_id IS NULL AND deleted_at IS NULL)
 `
 
 func (q *Queries) FindCommands(ctx context.Context, userID uuid.UUID) ([]Command, error) {
@@ -91,6 +113,9 @@ func (q *Queries) FindCommands(ctx context.Context, userID uuid.UUID) ([]Command
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.DeletedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -109,7 +134,7 @@ SELECT
 FROM commands c
 LEFT JOIN command_tags ct ON c.id = ct.command_id
 LEFT JOIN tags t ON ct.tag_id = t.id
-WHERE t.id = $1
+WHERE t.id = $1 AND c.deleted_at IS NULL
 `
 
 type FindCommandsByTagIdRow struct {
@@ -162,10 +187,16 @@ SELECT
     t.id AS tag_id, t.name AS tag_name, t.description AS tag_description, t.created_at AS tag_created_at, t.updated_at AS tag_updated_at
 FROM commands c
 LEFT JOIN command_tags ct ON c.id = ct.command_id
-LEFT JOIN tags t ON ct.tag_id = t.id
-WHERE c.user_id = $1
+LEFT JOIN tags t ON ct.tag_id = t.id AND t.deleted_at IS NULL
+WHERE (c.workspace_id = $1 OR ($1 IS NULL AND c.workspace_id IS NULL AND c.user_id = $2::UUID))
+  AND c.deleted_at IS NULL
 `
 
+type FindCommandsWithTagsParams struct {
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+}
+
 type FindCommandsWithTagsRow struct {
 	CommandID          uuid.UUID          `json:"command_id"`
 	CommandName        string             `json:"command_name"`
@@ -179,8 +210,8 @@ type FindCommandsWithTagsRow struct {
 	TagUpdatedAt       pgtype.Timestamptz `json:"tag_updated_at"`
 }
 
-func (q *Queries) FindCommandsWithTags(ctx context.Context, userID uuid.UUID) ([]FindCommandsWithTagsRow, error) {
-	rows, err := q.db.Query(ctx, findCommandsWithTags, userID)
+func (q *Queries) FindCommandsWithTags(ctx context.Context, arg FindCommandsWithTagsParams) ([]FindCommandsWithTagsRow, error) {
+	rows, err := q.db.Query(ctx, findCommandsWithTags, arg.WorkspaceID, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -211,7 +242,7 @@ func (q *Queries) FindCommandsWithTags(ctx context.Context, userID uuid.UUID) ([
 }
 
 const findTagById = `-- name: FindTagById :one
-SELECT id, user_id, name, description, created_at, updated_at FROM tags
+SELECT id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version FROM tags
 WHERE (id = $1)
 `
 
@@ -225,12 +256,15 @@ func (q *Queries) FindTagById(ctx context.Context, id uuid.UUID) (Tag, error) {
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const findTagByName = `-- name: FindTagByName :one
-SELECT id, user_id, name, description, created_at, updated_at FROM tags
+SELECT id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version FROM tags
 WHERE (name = $1)
 `
 
@@ -244,17 +278,26 @@ func (q *Queries) FindTagByName(ctx context.Context, name string) (Tag, error) {
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const findTags = `-- name: FindTags :many
-SELECT id, user_id, name, description, created_at, updated_at FROM tags
-WHERE (user_id = $1)
+SELECT id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version FROM tags
+WHERE (workspace_id = $1 OR ($1 IS NULL AND workspace_id IS NULL AND user_id = $2::UUID))
+  AND deleted_at IS NULL
 `
 
-func (q *Queries) FindTags(ctx context.Context, userID uuid.UUID) ([]Tag, error) {
-	rows, err := q.db.Query(ctx, findTags, userID)
+type FindTagsParams struct {
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+}
+
+func (q *Queries) FindTags(ctx context.Context, arg FindTagsParams) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, findTags, arg.WorkspaceID, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -269,6 +312,46 @@ func (q *Queries) FindTags(ctx context.Context, userID uuid.UUID) ([]Tag, error)
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.DeletedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findTagsByCommandId = `-- name: FindTagsByCommandId :many
+SELECT t.id, t.user_id, t.name, t.description, t.created_at, t.updated_at, t.workspace_id, t.deleted_at, t.version FROM tags t
+JOIN command_tags ct ON ct.tag_id = t.id
+WHERE ct.command_id = $1 AND t.deleted_at IS NULL
+ORDER BY t.name
+`
+
+func (q *Queries) FindTagsByCommandId(ctx context.Context, commandID uuid.UUID) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, findTagsByCommandId, commandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.DeletedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -286,8 +369,8 @@ SELECT
     c.id AS command_id, c.command AS command_name, c.description AS command_description
 FROM tags t
 LEFT JOIN command_tags ct ON t.id = ct.tag_id
-LEFT JOIN commands c ON ct.command_id = c.id
-WHERE t.user_id = $1
+LEFT JOIN commands c ON ct.command_id = c.id AND c.deleted_at IS NULL
+WHERE t.user_id = $1::UUID AND t.workspace_id IS NULL AND t.deleted_at IS NULL
 `
 
 type FindTagsWithCommandsRow struct {
@@ -328,21 +411,27 @@ func (q *Queries) FindTagsWithCommands(ctx context.Context, userID uuid.UUID) ([
 
 const insertCommands = `-- name: InsertCommands :one
 INSERT INTO commands (
-  id, user_id, command, description
+  id, user_id, command, description, workspace_id
 ) VALUES (
-  uuid_generate_v4(), $1, $2, $3::Text
+  uuid_generate_v4(), $1::UUID, $2, $3::Text, $4::UUID
 )
-RETURNING id, user_id, command, description, created_at, updated_at
+RETURNING id, user_id, command, description, created_at, updated_at, workspace_id, deleted_at, version
 `
 
 type InsertCommandsParams struct {
 	UserID      uuid.UUID   `json:"user_id"`
 	Command     string      `json:"command"`
 	Description pgtype.Text `json:"description"`
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) InsertCommands(ctx context.Context, arg InsertCommandsParams) (Command, error) {
-	row := q.db.QueryRow(ctx, insertCommands, arg.UserID, arg.Command, arg.Description)
+	row := q.db.QueryRow(ctx, insertCommands,
+		arg.UserID,
+		arg.Command,
+		arg.Description,
+		arg.WorkspaceID,
+	)
 	var i Command
 	err := row.Scan(
 		&i.ID,
@@ -351,6 +440,9 @@ func (q *Queries) InsertCommands(ctx context.Context, arg InsertCommandsParams)
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -358,17 +450,18 @@ func (q *Queries) InsertCommands(ctx context.Context, arg InsertCommandsParams)
 const insertTag = `-- name: InsertTag :one
 
 INSERT INTO tags (
-  id, user_id, name, description
+  id, user_id, name, description, workspace_id
 ) VALUES (
-  uuid_generate_v4(), $1, $2, $3::Text
+  uuid_generate_v4(), $1::UUID, $2, $3::Text, $4::UUID
 )
-RETURNING id, user_id, name, description, created_at, updated_at
+RETURNING id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version
 `
 
 type InsertTagParams struct {
 	UserID      uuid.UUID   `json:"user_id"`
 	Name        string      `json:"name"`
 	Description pgtype.Text `json:"description"`
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
 }
 
 // SELECT
@@ -392,7 +485,12 @@ type InsertTagParams struct {
 // WHERE c.user_id = $1
 // GROUP BY c.id;
 func (q *Queries) InsertTag(ctx context.Context, arg InsertTagParams) (Tag, error) {
-	row := q.db.QueryRow(ctx, insertTag, arg.UserID, arg.Name, arg.Description)
+	row := q.db.QueryRow(ctx, insertTag,
+		arg.UserID,
+		arg.Name,
+		arg.Description,
+		arg.WorkspaceID,
+	)
 	var i Tag
 	err := row.Scan(
 		&i.ID,
@@ -401,6 +499,9 @@ func (q *Queries) InsertTag(ctx context.Context, arg InsertTagParams) (Tag, erro
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -427,19 +528,27 @@ func (q *Queries) ReplaceCommandTag(ctx context.Context, arg ReplaceCommandTagPa
 const updateCommand = `-- name: UpdateCommand :one
 UPDATE commands
 SET command = COALESCE(NULLIF($2, ''), command),
-    description = COALESCE(NULLIF($3, ''), description)
-WHERE id = $1
-RETURNING id, user_id, command, description, created_at, updated_at
+    description = COALESCE(NULLIF($3, ''), description),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND version = $4
+RETURNING id, user_id, command, description, created_at, updated_at, workspace_id, deleted_at, version
 `
 
 type UpdateCommandParams struct {
 	ID      uuid.UUID   `json:"id"`
 	Column2 interface{} `json:"column_2"`
 	Column3 interface{} `json:"column_3"`
+	Version int32       `json:"version"`
 }
 
 func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) (Command, error) {
-	row := q.db.QueryRow(ctx, updateCommand, arg.ID, arg.Column2, arg.Column3)
+	row := q.db.QueryRow(ctx, updateCommand,
+		arg.ID,
+		arg.Column2,
+		arg.Column3,
+		arg.Version,
+	)
 	var i Command
 	err := row.Scan(
 		&i.ID,
@@ -448,6 +557,9 @@ func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) (C
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -455,19 +567,27 @@ func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) (C
 const updateTag = `-- name: UpdateTag :one
 UPDATE tags
 SET name = COALESCE(NULLIF($2, ''), name),
-    description = COALESCE(NULLIF($3, ''), description)
-WHERE id = $1
-RETURNING id, user_id, name, description, created_at, updated_at
+    description = COALESCE(NULLIF($3, ''), description),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND version = $4
+RETURNING id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version
 `
 
 type UpdateTagParams struct {
 	ID      uuid.UUID   `json:"id"`
 	Column2 interface{} `json:"column_2"`
 	Column3 interface{} `json:"column_3"`
+	Version int32       `json:"version"`
 }
 
 func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error) {
-	row := q.db.QueryRow(ctx, updateTag, arg.ID, arg.Column2, arg.Column3)
+	row := q.db.QueryRow(ctx, updateTag,
+		arg.ID,
+		arg.Column2,
+		arg.Column3,
+		arg.Version,
+	)
 	var i Tag
 	err := row.Scan(
 		&i.ID,
@@ -476,6 +596,9 @@ func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, erro
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -0,0 +1,208 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: export.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const exportCollections = `-- name: ExportCollections :many
+SELECT
+    col.id, col.name, col.description,
+    COALESCE(array_agg(c.id ORDER BY cc.position) FILTER (WHERE c.id IS NOT NULL), '{}')::UUID[] AS command_ids
+FROM collections col
+LEFT JOIN collection_commands cc ON cc.collection_id = col.id
+LEFT JOIN commands c ON c.id = cc.command_id AND c.workspace_id IS NULL AND c.deleted_at IS NULL
+WHERE col.user_id = $1 AND col.id > $2
+GROUP BY col.id
+ORDER BY col.id
+LIMIT $3
+`
+
+type ExportCollectionsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	Limit  int32     `json:"limit"`
+}
+
+type ExportCollectionsRow struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description pgtype.Text `json:"description"`
+	CommandIds  []uuid.UUID `json:"command_ids"`
+}
+
+func (q *Queries) ExportCollections(ctx context.Context, arg ExportCollectionsParams) ([]ExportCollectionsRow, error) {
+	rows, err := q.db.Query(ctx, exportCollections, arg.UserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportCollectionsRow
+	for rows.Next() {
+		var i ExportCollectionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CommandIds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportCommands = `-- name: ExportCommands :many
+SELECT
+    c.id, c.command, c.description,
+    COALESCE(array_agg(t.name ORDER BY t.name) FILTER (WHERE t.id IS NOT NULL), '{}')::TEXT[] AS tags
+FROM commands c
+LEFT JOIN command_tags ct ON ct.command_id = c.id
+LEFT JOIN tags t ON t.id = ct.tag_id AND t.deleted_at IS NULL
+WHERE c.user_id = $1::UUID AND c.workspace_id IS NULL AND c.deleted_at IS NULL AND c.id > $2
+GROUP BY c.id
+ORDER BY c.id
+LIMIT $3
+`
+
+type ExportCommandsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	Limit  int32     `json:"limit"`
+}
+
+type ExportCommandsRow struct {
+	ID          uuid.UUID `json:"id"`
+	Command     string    `json:"command"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+}
+
+func (q *Queries) ExportCommands(ctx context.Context, arg ExportCommandsParams) ([]ExportCommandsRow, error) {
+	rows, err := q.db.Query(ctx, exportCommands, arg.UserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportCommandsRow
+	for rows.Next() {
+		var i ExportCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Command,
+			&i.Description,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportShareLinks = `-- name: ExportShareLinks :many
+SELECT id, slug, user_id, command_id, collection_id, expires_at, revoked_at, created_at, workspace_id FROM share_links sl
+WHERE sl.user_id = $1 AND sl.id > $2
+  AND sl.revoked_at IS NULL AND (sl.expires_at IS NULL OR sl.expires_at > NOW())
+  AND (sl.command_id IS NULL OR EXISTS (
+    SELECT 1 FROM commands c
+    WHERE c.id = sl.command_id AND c.workspace_id IS NULL AND c.deleted_at IS NULL
+  ))
+ORDER BY sl.id
+LIMIT $3
+`
+
+type ExportShareLinksParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	Limit  int32     `json:"limit"`
+}
+
+func (q *Queries) ExportShareLinks(ctx context.Context, arg ExportShareLinksParams) ([]ShareLink, error) {
+	rows, err := q.db.Query(ctx, exportShareLinks, arg.UserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShareLink
+	for rows.Next() {
+		var i ShareLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.UserID,
+			&i.CommandID,
+			&i.CollectionID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportTags = `-- name: ExportTags :many
+SELECT id, user_id, name, description, created_at, updated_at, workspace_id, deleted_at, version FROM tags
+WHERE user_id = $1::UUID AND workspace_id IS NULL AND deleted_at IS NULL AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ExportTagsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	Limit  int32     `json:"limit"`
+}
+
+// Account exports page through each table by id so that they never hold
+// more than a page in memory.
+func (q *Queries) ExportTags(ctx context.Context, arg ExportTagsParams) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, exportTags, arg.UserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.DeletedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -9,13 +9,57 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Command struct {
+type AccountDeletion struct {
+	UserID       uuid.UUID          `json:"user_id"`
+	RequestedAt  pgtype.Timestamptz `json:"requested_at"`
+	ScheduledFor pgtype.Timestamptz `json:"scheduled_for"`
+}
+
+type AuditEvent struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Event     string             `json:"event"`
+	IpAddress pgtype.Text        `json:"ip_address"`
+	UserAgent pgtype.Text        `json:"user_agent"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Collection struct {
 	ID          uuid.UUID          `json:"id"`
 	UserID      uuid.UUID          `json:"user_id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CollectionCommand struct {
+	CollectionID uuid.UUID `json:"collection_id"`
+	CommandID    uuid.UUID `json:"command_id"`
+	Position     int32     `json:"position"`
+}
+
+type Command struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
 	Command     string             `json:"command"`
 	Description string             `json:"description"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	WorkspaceID pgtype.UUID        `json:"workspace_id"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	Version     int32              `json:"version"`
+}
+
+type CommandRevision struct {
+	ID          uuid.UUID          `json:"id"`
+	CommandID   uuid.UUID          `json:"command_id"`
+	Revision    int32              `json:"revision"`
+	AuthorID    pgtype.UUID        `json:"author_id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	Tags        []string           `json:"tags"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type CommandTag struct {
@@ -23,6 +67,46 @@ type CommandTag struct {
 	TagID     uuid.UUID `json:"tag_id"`
 }
 
+type CommandUsage struct {
+	CommandID  uuid.UUID          `json:"command_id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	UseCount   int64              `json:"use_count"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CommandUsageSync struct {
+	CommandID uuid.UUID          `json:"command_id"`
+	SyncID    string             `json:"sync_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type EmailChange struct {
+	UserID    uuid.UUID          `json:"user_id"`
+	NewEmail  string             `json:"new_email"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	UserID          uuid.UUID          `json:"user_id"`
+	Key             string             `json:"key"`
+	RequestHash     string             `json:"request_hash"`
+	StatusCode      pgtype.Int4        `json:"status_code"`
+	ResponseHeaders []byte             `json:"response_headers"`
+	ResponseBody    []byte             `json:"response_body"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	LeaseExpiresAt  pgtype.Timestamptz `json:"lease_expires_at"`
+}
+
+type LoginFailure struct {
+	UserID       uuid.UUID          `json:"user_id"`
+	FailedCount  int32              `json:"failed_count"`
+	LastFailedAt pgtype.Timestamptz `json:"last_failed_at"`
+	LockedUntil  pgtype.Timestamptz `json:"locked_until"`
+}
+
 type RefreshToken struct {
 	ID        uuid.UUID          `json:"id"`
 	UserID    pgtype.UUID        `json:"user_id"`
@@ -31,7 +115,7 @@ type RefreshToken struct {
 	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 }
 
-type Tag struct {
+type Runbook struct {
 	ID          uuid.UUID          `json:"id"`
 	UserID      uuid.UUID          `json:"user_id"`
 	Name        string             `json:"name"`
@@ -40,15 +124,94 @@ type Tag struct {
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
+type RunbookStep struct {
+	ID              uuid.UUID   `json:"id"`
+	RunbookID       uuid.UUID   `json:"runbook_id"`
+	Position        int32       `json:"position"`
+	Name            pgtype.Text `json:"name"`
+	CommandID       pgtype.UUID `json:"command_id"`
+	Command         pgtype.Text `json:"command"`
+	Confirm         bool        `json:"confirm"`
+	ContinueOnError bool        `json:"continue_on_error"`
+	CaptureAs       pgtype.Text `json:"capture_as"`
+}
+
+type RunbookVariable struct {
+	RunbookID    uuid.UUID   `json:"runbook_id"`
+	Name         string      `json:"name"`
+	DefaultValue pgtype.Text `json:"default_value"`
+}
+
+type ShareLink struct {
+	ID           uuid.UUID          `json:"id"`
+	Slug         string             `json:"slug"`
+	UserID       uuid.UUID          `json:"user_id"`
+	CommandID    pgtype.UUID        `json:"command_id"`
+	CollectionID pgtype.UUID        `json:"collection_id"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt    pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	WorkspaceID  pgtype.UUID        `json:"workspace_id"`
+}
+
+type Tag struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	WorkspaceID pgtype.UUID        `json:"workspace_id"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	Version     int32              `json:"version"`
+}
+
 type User struct {
-	ID              uuid.UUID          `json:"id"`
-	FirstName       string             `json:"first_name"`
-	LastName        string             `json:"last_name"`
-	Password        string             `json:"password"`
-	RefreshToken    pgtype.Text        `json:"refresh_token"`
-	Email           string             `json:"email"`
-	IsEmailVerified pgtype.Bool        `json:"is_email_verified"`
-	IsActive        pgtype.Bool        `json:"is_active"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	ID                uuid.UUID          `json:"id"`
+	FirstName         string             `json:"first_name"`
+	LastName          string             `json:"last_name"`
+	Password          string             `json:"password"`
+	RefreshToken      pgtype.Text        `json:"refresh_token"`
+	Email             string             `json:"email"`
+	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	IsActive          pgtype.Bool        `json:"is_active"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	Role              string             `json:"role"`
+	SessionsRevokedAt pgtype.Timestamptz `json:"sessions_revoked_at"`
+}
+
+type UserPreference struct {
+	UserID            uuid.UUID          `json:"user_id"`
+	DefaultTags       []string           `json:"default_tags"`
+	PickerTheme       string             `json:"picker_theme"`
+	DangerousPatterns []string           `json:"dangerous_patterns"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Workspace struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          uuid.UUID          `json:"id"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	Email       string             `json:"email"`
+	Role        string             `json:"role"`
+	TokenHash   string             `json:"token_hash"`
+	InvitedBy   pgtype.UUID        `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Role        string             `json:"role"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
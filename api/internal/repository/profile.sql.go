@@ -0,0 +1,137 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: profile.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteEmailChange = `-- name: DeleteEmailChange :exec
+DELETE FROM email_changes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteEmailChange(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEmailChange, userID)
+	return err
+}
+
+const findEmailChangeByTokenHash = `-- name: FindEmailChangeByTokenHash :one
+SELECT user_id, new_email, token_hash, expires_at, created_at FROM email_changes
+WHERE token_hash = $1
+`
+
+func (q *Queries) FindEmailChangeByTokenHash(ctx context.Context, tokenHash string) (EmailChange, error) {
+	row := q.db.QueryRow(ctx, findEmailChangeByTokenHash, tokenHash)
+	var i EmailChange
+	err := row.Scan(
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findUserPreferences = `-- name: FindUserPreferences :one
+SELECT user_id, default_tags, picker_theme, dangerous_patterns, updated_at FROM user_preferences
+WHERE user_id = $1
+`
+
+func (q *Queries) FindUserPreferences(ctx context.Context, userID uuid.UUID) (UserPreference, error) {
+	row := q.db.QueryRow(ctx, findUserPreferences, userID)
+	var i UserPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.DefaultTags,
+		&i.PickerTheme,
+		&i.DangerousPatterns,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertEmailChange = `-- name: UpsertEmailChange :one
+INSERT INTO email_changes (
+  user_id, new_email, token_hash, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET new_email = EXCLUDED.new_email,
+    token_hash = EXCLUDED.token_hash,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+RETURNING user_id, new_email, token_hash, expires_at, created_at
+`
+
+type UpsertEmailChangeParams struct {
+	UserID    uuid.UUID          `json:"user_id"`
+	NewEmail  string             `json:"new_email"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) UpsertEmailChange(ctx context.Context, arg UpsertEmailChangeParams) (EmailChange, error) {
+	row := q.db.QueryRow(ctx, upsertEmailChange,
+		arg.UserID,
+		arg.NewEmail,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i EmailChange
+	err := row.Scan(
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertUserPreferences = `-- name: UpsertUserPreferences :one
+INSERT INTO user_preferences (
+  user_id, default_tags, picker_theme, dangerous_patterns
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET default_tags = EXCLUDED.default_tags,
+    picker_theme = EXCLUDED.picker_theme,
+    dangerous_patterns = EXCLUDED.dangerous_patterns,
+    updated_at = NOW()
+RETURNING user_id, default_tags, picker_theme, dangerous_patterns, updated_at
+`
+
+type UpsertUserPreferencesParams struct {
+	UserID            uuid.UUID `json:"user_id"`
+	DefaultTags       []string  `json:"default_tags"`
+	PickerTheme       string    `json:"picker_theme"`
+	DangerousPatterns []string  `json:"dangerous_patterns"`
+}
+
+func (q *Queries) UpsertUserPreferences(ctx context.Context, arg UpsertUserPreferencesParams) (UserPreference, error) {
+	row := q.db.QueryRow(ctx, upsertUserPreferences,
+		arg.UserID,
+		arg.DefaultTags,
+		arg.PickerTheme,
+		arg.DangerousPatterns,
+	)
+	var i UserPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.DefaultTags,
+		&i.PickerTheme,
+		&i.DangerousPatterns,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -48,6 +48,16 @@ func (q *Queries) DeleteRefreshToken(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRefreshTokensByUserID = `-- name: DeleteRefreshTokensByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRefreshTokensByUserID(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRefreshTokensByUserID, userID)
+	return err
+}
+
 const getAllRefreshTokensByUserID = `-- name: GetAllRefreshTokensByUserID :many
 SELECT id, user_id, token_hash, issued_at, expires_at
 FROM refresh_tokens
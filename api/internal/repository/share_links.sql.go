@@ -0,0 +1,157 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: share_links.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const findShareLinkBySlug = `-- name: FindShareLinkBySlug :one
+SELECT id, slug, user_id, command_id, collection_id, expires_at, revoked_at, created_at, workspace_id FROM share_links
+WHERE slug = $1
+`
+
+func (q *Queries) FindShareLinkBySlug(ctx context.Context, slug string) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, findShareLinkBySlug, slug)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.UserID,
+		&i.CommandID,
+		&i.CollectionID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const findShareLinks = `-- name: FindShareLinks :many
+SELECT id, slug, user_id, command_id, collection_id, expires_at, revoked_at, created_at, workspace_id FROM share_links
+WHERE (workspace_id = $1 OR ($1 IS NULL AND workspace_id IS NULL AND user_id = $2::UUID))
+  AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC
+`
+
+type FindShareLinksParams struct {
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+}
+
+func (q *Queries) FindShareLinks(ctx context.Context, arg FindShareLinksParams) ([]ShareLink, error) {
+	rows, err := q.db.Query(ctx, findShareLinks, arg.WorkspaceID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShareLink
+	for rows.Next() {
+		var i ShareLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.UserID,
+			&i.CommandID,
+			&i.CollectionID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertShareLink = `-- name: InsertShareLink :one
+INSERT INTO share_links (
+  id, slug, user_id, command_id, collection_id, expires_at, workspace_id
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4, $5, $6
+)
+RETURNING id, slug, user_id, command_id, collection_id, expires_at, revoked_at, created_at, workspace_id
+`
+
+type InsertShareLinkParams struct {
+	Slug         string             `json:"slug"`
+	UserID       uuid.UUID          `json:"user_id"`
+	CommandID    pgtype.UUID        `json:"command_id"`
+	CollectionID pgtype.UUID        `json:"collection_id"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	WorkspaceID  pgtype.UUID        `json:"workspace_id"`
+}
+
+func (q *Queries) InsertShareLink(ctx context.Context, arg InsertShareLinkParams) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, insertShareLink,
+		arg.Slug,
+		arg.UserID,
+		arg.CommandID,
+		arg.CollectionID,
+		arg.ExpiresAt,
+		arg.WorkspaceID,
+	)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.UserID,
+		&i.CommandID,
+		&i.CollectionID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const revokeShareLink = `-- name: RevokeShareLink :execrows
+UPDATE share_links
+SET revoked_at = NOW()
+WHERE id = $1
+  AND (workspace_id = $2 OR ($2 IS NULL AND workspace_id IS NULL AND user_id = $3::UUID))
+  AND revoked_at IS NULL
+`
+
+type RevokeShareLinkParams struct {
+	ID          uuid.UUID   `json:"id"`
+	WorkspaceID pgtype.UUID `json:"workspace_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+}
+
+func (q *Queries) RevokeShareLink(ctx context.Context, arg RevokeShareLinkParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeShareLink, arg.ID, arg.WorkspaceID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeWorkspaceMemberShareLinks = `-- name: RevokeWorkspaceMemberShareLinks :exec
+UPDATE share_links
+SET revoked_at = NOW()
+WHERE workspace_id = $1::UUID AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeWorkspaceMemberShareLinksParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RevokeWorkspaceMemberShareLinks(ctx context.Context, arg RevokeWorkspaceMemberShareLinksParams) error {
+	_, err := q.db.Exec(ctx, revokeWorkspaceMemberShareLinks, arg.WorkspaceID, arg.UserID)
+	return err
+}
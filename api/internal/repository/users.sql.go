@@ -12,6 +12,24 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const changeUserEmail = `-- name: ChangeUserEmail :exec
+UPDATE users
+SET email = $2,
+    is_email_verified = TRUE,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type ChangeUserEmailParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) error {
+	_, err := q.db.Exec(ctx, changeUserEmail, arg.ID, arg.Email)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users 
 WHERE id = $1
@@ -23,7 +41,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at FROM users
+SELECT id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at, role, sessions_revoked_at FROM users
 WHERE (id = $1 OR email = $2)
 LIMIT 1
 `
@@ -47,33 +65,54 @@ func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (User, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
 
+const getUserSessionState = `-- name: GetUserSessionState :one
+SELECT is_active, sessions_revoked_at FROM users
+WHERE id = $1
+`
+
+type GetUserSessionStateRow struct {
+	IsActive          pgtype.Bool        `json:"is_active"`
+	SessionsRevokedAt pgtype.Timestamptz `json:"sessions_revoked_at"`
+}
+
+func (q *Queries) GetUserSessionState(ctx context.Context, id uuid.UUID) (GetUserSessionStateRow, error) {
+	row := q.db.QueryRow(ctx, getUserSessionState, id)
+	var i GetUserSessionStateRow
+	err := row.Scan(&i.IsActive, &i.SessionsRevokedAt)
+	return i, err
+}
+
 const getUserWithRefreshTokens = `-- name: GetUserWithRefreshTokens :one
-SELECT u.id, u.first_name, u.last_name, u.password, u.refresh_token, u.email, u.is_email_verified, u.is_active, u.created_at, u.updated_at, rt.id, rt.user_id, rt.token_hash, rt.issued_at, rt.expires_at
+SELECT u.id, u.first_name, u.last_name, u.password, u.refresh_token, u.email, u.is_email_verified, u.is_active, u.created_at, u.updated_at, u.role, u.sessions_revoked_at, rt.id, rt.user_id, rt.token_hash, rt.issued_at, rt.expires_at
 FROM users u
 LEFT JOIN refresh_tokens rt ON u.id = rt.user_id
 WHERE u.id = $1
 `
 
 type GetUserWithRefreshTokensRow struct {
-	ID              uuid.UUID          `json:"id"`
-	FirstName       string             `json:"first_name"`
-	LastName        string             `json:"last_name"`
-	Password        string             `json:"password"`
-	RefreshToken    pgtype.Text        `json:"refresh_token"`
-	Email           string             `json:"email"`
-	IsEmailVerified pgtype.Bool        `json:"is_email_verified"`
-	IsActive        pgtype.Bool        `json:"is_active"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
-	ID_2            pgtype.UUID        `json:"id_2"`
-	UserID          pgtype.UUID        `json:"user_id"`
-	TokenHash       pgtype.Text        `json:"token_hash"`
-	IssuedAt        pgtype.Timestamptz `json:"issued_at"`
-	ExpiresAt       pgtype.Timestamptz `json:"expires_at"`
+	ID                uuid.UUID          `json:"id"`
+	FirstName         string             `json:"first_name"`
+	LastName          string             `json:"last_name"`
+	Password          string             `json:"password"`
+	RefreshToken      pgtype.Text        `json:"refresh_token"`
+	Email             string             `json:"email"`
+	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	IsActive          pgtype.Bool        `json:"is_active"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	Role              string             `json:"role"`
+	SessionsRevokedAt pgtype.Timestamptz `json:"sessions_revoked_at"`
+	ID_2              pgtype.UUID        `json:"id_2"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	TokenHash         pgtype.Text        `json:"token_hash"`
+	IssuedAt          pgtype.Timestamptz `json:"issued_at"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
 }
 
 func (q *Queries) GetUserWithRefreshTokens(ctx context.Context, id uuid.UUID) (GetUserWithRefreshTokensRow, error) {
@@ -90,6 +129,8 @@ func (q *Queries) GetUserWithRefreshTokens(ctx context.Context, id uuid.UUID) (G
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SessionsRevokedAt,
 		&i.ID_2,
 		&i.UserID,
 		&i.TokenHash,
@@ -105,7 +146,7 @@ INSERT INTO users (
 ) VALUES (
   uuid_generate_v4(), $1, $2, $3, $4, $5, $6
 )
-RETURNING id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at
+RETURNING id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at, role, sessions_revoked_at
 `
 
 type InsertUserParams struct {
@@ -138,24 +179,40 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at FROM users
+SELECT id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at, role, sessions_revoked_at FROM users
 WHERE ($1 IS NULL OR is_active = $1)
 AND ($2 IS NULL OR is_email_verified = $2)
-ORDER BY first_name
+AND ($3::text = ''
+  OR strpos(lower(email), lower($3::text)) > 0
+  OR strpos(lower(first_name || ' ' || last_name), lower($3::text)) > 0)
+ORDER BY first_name, id
+LIMIT $5 OFFSET $4
 `
 
 type ListUsersParams struct {
 	IsActive        interface{} `json:"is_active"`
 	IsEmailVerified interface{} `json:"is_email_verified"`
+	Search          string      `json:"search"`
+	RowOffset       int32       `json:"row_offset"`
+	RowLimit        int32       `json:"row_limit"`
 }
 
+// The search is matched literally, % and _ in it are not wildcards
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.IsActive, arg.IsEmailVerified)
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.IsActive,
+		arg.IsEmailVerified,
+		arg.Search,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +231,8 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Role,
+			&i.SessionsRevokedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -185,11 +244,67 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
-const updateUser = `-- name: UpdateUser :exec
+const revokeUserSessions = `-- name: RevokeUserSessions :execrows
+UPDATE users
+SET sessions_revoked_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserSessions, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserActive = `-- name: SetUserActive :execrows
+UPDATE users
+SET is_active = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserActiveParams struct {
+	ID       uuid.UUID   `json:"id"`
+	IsActive pgtype.Bool `json:"is_active"`
+}
+
+func (q *Queries) SetUserActive(ctx context.Context, arg SetUserActiveParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserActive, arg.ID, arg.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUser = `-- name: UpdateUser :one
 UPDATE users 
   set first_name = $2,
-  last_name = $3
+  last_name = $3,
+  updated_at = NOW()
 WHERE id = $1
+RETURNING id, first_name, last_name, password, refresh_token, email, is_email_verified, is_active, created_at, updated_at, role, sessions_revoked_at
 `
 
 type UpdateUserParams struct {
@@ -198,7 +313,22 @@ type UpdateUserParams struct {
 	LastName  string    `json:"last_name"`
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.Exec(ctx, updateUser, arg.ID, arg.FirstName, arg.LastName)
-	return err
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUser, arg.ID, arg.FirstName, arg.LastName)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Password,
+		&i.RefreshToken,
+		&i.Email,
+		&i.IsEmailVerified,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.SessionsRevokedAt,
+	)
+	return i, err
 }
@@ -0,0 +1,407 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: workspaces.sql
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptWorkspaceInvitation = `-- name: AcceptWorkspaceInvitation :exec
+UPDATE workspace_invitations
+SET accepted_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) AcceptWorkspaceInvitation(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, acceptWorkspaceInvitation, id)
+	return err
+}
+
+const deleteWorkspace = `-- name: DeleteWorkspace :exec
+DELETE FROM workspaces
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWorkspace(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteWorkspace, id)
+	return err
+}
+
+const deleteWorkspaceInvitation = `-- name: DeleteWorkspaceInvitation :exec
+DELETE FROM workspace_invitations
+WHERE id = $1 AND workspace_id = $2
+`
+
+type DeleteWorkspaceInvitationParams struct {
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+func (q *Queries) DeleteWorkspaceInvitation(ctx context.Context, arg DeleteWorkspaceInvitationParams) error {
+	_, err := q.db.Exec(ctx, deleteWorkspaceInvitation, arg.ID, arg.WorkspaceID)
+	return err
+}
+
+const deleteWorkspaceMember = `-- name: DeleteWorkspaceMember :exec
+DELETE FROM workspace_members
+WHERE workspace_id = $1 AND user_id = $2
+`
+
+type DeleteWorkspaceMemberParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteWorkspaceMember(ctx context.Context, arg DeleteWorkspaceMemberParams) error {
+	_, err := q.db.Exec(ctx, deleteWorkspaceMember, arg.WorkspaceID, arg.UserID)
+	return err
+}
+
+const findWorkspaceById = `-- name: FindWorkspaceById :one
+SELECT id, name, created_by, created_at, updated_at FROM workspaces
+WHERE (id = $1)
+`
+
+func (q *Queries) FindWorkspaceById(ctx context.Context, id uuid.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, findWorkspaceById, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const findWorkspaceInvitationByTokenHash = `-- name: FindWorkspaceInvitationByTokenHash :one
+SELECT id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at FROM workspace_invitations
+WHERE token_hash = $1
+`
+
+func (q *Queries) FindWorkspaceInvitationByTokenHash(ctx context.Context, tokenHash string) (WorkspaceInvitation, error) {
+	row := q.db.QueryRow(ctx, findWorkspaceInvitationByTokenHash, tokenHash)
+	var i WorkspaceInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findWorkspaceInvitations = `-- name: FindWorkspaceInvitations :many
+SELECT id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at FROM workspace_invitations
+WHERE workspace_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+ORDER BY created_at
+`
+
+func (q *Queries) FindWorkspaceInvitations(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceInvitation, error) {
+	rows, err := q.db.Query(ctx, findWorkspaceInvitations, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceInvitation
+	for rows.Next() {
+		var i WorkspaceInvitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.Email,
+			&i.Role,
+			&i.TokenHash,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findWorkspaceMember = `-- name: FindWorkspaceMember :one
+SELECT workspace_id, user_id, role, created_at FROM workspace_members
+WHERE workspace_id = $1 AND user_id = $2
+`
+
+type FindWorkspaceMemberParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) FindWorkspaceMember(ctx context.Context, arg FindWorkspaceMemberParams) (WorkspaceMember, error) {
+	row := q.db.QueryRow(ctx, findWorkspaceMember, arg.WorkspaceID, arg.UserID)
+	var i WorkspaceMember
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findWorkspaceMembers = `-- name: FindWorkspaceMembers :many
+SELECT m.user_id, m.role, m.created_at, u.email, u.first_name, u.last_name
+FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = $1
+ORDER BY m.created_at
+`
+
+type FindWorkspaceMembersRow struct {
+	UserID    uuid.UUID          `json:"user_id"`
+	Role      string             `json:"role"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	Email     string             `json:"email"`
+	FirstName string             `json:"first_name"`
+	LastName  string             `json:"last_name"`
+}
+
+func (q *Queries) FindWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) ([]FindWorkspaceMembersRow, error) {
+	rows, err := q.db.Query(ctx, findWorkspaceMembers, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindWorkspaceMembersRow
+	for rows.Next() {
+		var i FindWorkspaceMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.Email,
+			&i.FirstName,
+			&i.LastName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findWorkspacesByUser = `-- name: FindWorkspacesByUser :many
+SELECT w.id, w.name, w.created_by, w.created_at, w.updated_at, m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = $1
+ORDER BY w.name
+`
+
+type FindWorkspacesByUserRow struct {
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Role      string             `json:"role"`
+}
+
+func (q *Queries) FindWorkspacesByUser(ctx context.Context, userID uuid.UUID) ([]FindWorkspacesByUserRow, error) {
+	rows, err := q.db.Query(ctx, findWorkspacesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindWorkspacesByUserRow
+	for rows.Next() {
+		var i FindWorkspacesByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspace = `-- name: InsertWorkspace :one
+INSERT INTO workspaces (
+  id, name, created_by
+) VALUES (
+  uuid_generate_v4(), $1, $2
+)
+RETURNING id, name, created_by, created_at, updated_at
+`
+
+type InsertWorkspaceParams struct {
+	Name      string      `json:"name"`
+	CreatedBy pgtype.UUID `json:"created_by"`
+}
+
+func (q *Queries) InsertWorkspace(ctx context.Context, arg InsertWorkspaceParams) (Workspace, error) {
+	row := q.db.QueryRow(ctx, insertWorkspace, arg.Name, arg.CreatedBy)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertWorkspaceInvitation = `-- name: InsertWorkspaceInvitation :one
+INSERT INTO workspace_invitations (
+  id, workspace_id, email, role, token_hash, invited_by, expires_at
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4, $5, $6
+)
+RETURNING id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+`
+
+type InsertWorkspaceInvitationParams struct {
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	Email       string             `json:"email"`
+	Role        string             `json:"role"`
+	TokenHash   string             `json:"token_hash"`
+	InvitedBy   pgtype.UUID        `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) InsertWorkspaceInvitation(ctx context.Context, arg InsertWorkspaceInvitationParams) (WorkspaceInvitation, error) {
+	row := q.db.QueryRow(ctx, insertWorkspaceInvitation,
+		arg.WorkspaceID,
+		arg.Email,
+		arg.Role,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i WorkspaceInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertWorkspaceMember = `-- name: InsertWorkspaceMember :exec
+INSERT INTO workspace_members (
+  workspace_id, user_id, role
+) VALUES (
+  $1, $2, $3
+)
+`
+
+type InsertWorkspaceMemberParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+}
+
+func (q *Queries) InsertWorkspaceMember(ctx context.Context, arg InsertWorkspaceMemberParams) error {
+	_, err := q.db.Exec(ctx, insertWorkspaceMember, arg.WorkspaceID, arg.UserID, arg.Role)
+	return err
+}
+
+const lockWorkspaceOwners = `-- name: LockWorkspaceOwners :many
+SELECT user_id FROM workspace_members
+WHERE workspace_id = $1 AND role = 'owner'
+FOR UPDATE
+`
+
+func (q *Queries) LockWorkspaceOwners(ctx context.Context, workspaceID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, lockWorkspaceOwners, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWorkspace = `-- name: UpdateWorkspace :one
+UPDATE workspaces
+SET name = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, created_by, created_at, updated_at
+`
+
+type UpdateWorkspaceParams struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams) (Workspace, error) {
+	row := q.db.QueryRow(ctx, updateWorkspace, arg.ID, arg.Name)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWorkspaceMemberRole = `-- name: UpdateWorkspaceMemberRole :exec
+UPDATE workspace_members
+SET role = $3
+WHERE workspace_id = $1 AND user_id = $2
+`
+
+type UpdateWorkspaceMemberRoleParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+}
+
+func (q *Queries) UpdateWorkspaceMemberRole(ctx context.Context, arg UpdateWorkspaceMemberRoleParams) error {
+	_, err := q.db.Exec(ctx, updateWorkspaceMemberRole, arg.WorkspaceID, arg.UserID, arg.Role)
+	return err
+}
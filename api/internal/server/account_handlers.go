@@ -0,0 +1,445 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/archive"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Events recorded in audit_events.
+const (
+	auditSignIn                   = "signin"
+	auditAccountExported          = "account.exported"
+	auditAccountDeletionRequested = "account.deletion_requested"
+	auditAccountDeletionCancelled = "account.deletion_cancelled"
+)
+
+// recordAuditEvent records an event of the user along with where the request
+// came from. Failing to record it does not fail the request.
+func (s *Server) recordAuditEvent(r *http.Request, userId uuid.UUID, event string) {
+	var ipAddress string
+	if ip, ok := middleware.GetClientIPFromContext(r); ok {
+		ipAddress = ip.String()
+	}
+
+	err := s.db.InsertAuditEvent(r.Context(), repository.InsertAuditEventParams{
+		UserID:    userId,
+		Event:     event,
+		IpAddress: pgtype.Text{String: ipAddress, Valid: ipAddress != ""},
+		UserAgent: pgtype.Text{String: r.UserAgent(), Valid: r.UserAgent() != ""},
+	})
+	if err != nil {
+		s.log(r).Error("Failed to record audit event", slog.String("event", event), slog.String("ERROR", err.Error()))
+	}
+}
+
+// ExportAccountData responds with a zip archive of everything stored about
+// the user: the profile, preferences, the personal library as an archive that
+// ImportAccount reads back, the trash, command revisions, the commands they
+// wrote in workspaces, runbooks, workspace memberships, command usage,
+// sessions and audit events.
+func (s *Server) ExportAccountData(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: _userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to export account")
+		return
+	}
+
+	// Recorded first so that the export lists itself
+	s.recordAuditEvent(r, _userId, auditAccountExported)
+
+	now := time.Now()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="termflow-account-%s.zip"`, now.Format("20060102")))
+	w.WriteHeader(http.StatusOK)
+
+	if err := s.exportAccountData(ctx, zip.NewWriter(w), user, now); err != nil {
+		// The status is sent already, the zip is left without its central
+		// directory so that readers notice
+		s.log(r).Error("Failed to export account data", slog.String("ERROR", err.Error()))
+	}
+}
+
+func (s *Server) exportAccountData(ctx context.Context, files *zip.Writer, user repository.User, now time.Time) error {
+	profile := map[string]interface{}{
+		"id":                user.ID,
+		"first_name":        user.FirstName,
+		"last_name":         user.LastName,
+		"email":             user.Email,
+		"is_email_verified": user.IsEmailVerified,
+		"is_active":         user.IsActive,
+		"created_at":        user.CreatedAt,
+		"updated_at":        user.UpdatedAt,
+	}
+
+	deletion, err := s.db.FindAccountDeletion(ctx, user.ID)
+	if err == nil {
+		profile["deletion_scheduled_for"] = deletion.ScheduledFor
+	} else if err != pgx.ErrNoRows {
+		return err
+	}
+
+	if err := writeJSONFile(files, "profile.json", now, profile); err != nil {
+		return err
+	}
+
+	preferences, err := s.db.FindUserPreferences(ctx, user.ID)
+	if err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+
+	if err := writeJSONFile(files, "preferences.json", now, preferencesPayload(preferences)); err != nil {
+		return err
+	}
+
+	library, err := files.CreateHeader(&zip.FileHeader{Name: "library.jsonl", Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return err
+	}
+
+	if err := s.exportAccount(ctx, archive.NewWriter(library, archive.JSONLines, now), user.ID); err != nil {
+		return err
+	}
+
+	// The library leaves out the trash, which ImportAccount would restore
+	deletedCommands, err := s.db.FindDeletedCommands(ctx, repository.FindDeletedCommandsParams{UserID: user.ID})
+	if err != nil {
+		return err
+	}
+
+	deletedTags, err := s.db.FindDeletedTags(ctx, repository.FindDeletedTagsParams{UserID: user.ID})
+	if err != nil {
+		return err
+	}
+
+	trash := map[string]interface{}{
+		"commands": orEmpty(deletedCommands),
+		"tags":     orEmpty(deletedTags),
+	}
+	if err := writeJSONFile(files, "trash.json", now, trash); err != nil {
+		return err
+	}
+
+	revisions, err := s.db.FindAccountCommandRevisions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(files, "command_revisions.json", now, orEmpty(revisions)); err != nil {
+		return err
+	}
+
+	workspaceCommands, err := s.db.FindWorkspaceCommandsByAuthor(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(files, "workspace_commands.json", now, orEmpty(workspaceCommands)); err != nil {
+		return err
+	}
+
+	runbooks, err := s.db.FindRunbooks(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	runbookPayloads := make([]map[string]interface{}, 0, len(runbooks))
+	for _, runbook := range runbooks {
+		steps, err := s.db.FindRunbookSteps(ctx, runbook.ID)
+		if err != nil {
+			return err
+		}
+
+		variables, err := s.db.FindRunbookVariables(ctx, runbook.ID)
+		if err != nil {
+			return err
+		}
+
+		runbookPayloads = append(runbookPayloads, map[string]interface{}{
+			"id":          runbook.ID,
+			"name":        runbook.Name,
+			"description": runbook.Description,
+			"created_at":  runbook.CreatedAt,
+			"updated_at":  runbook.UpdatedAt,
+			"variables":   orEmpty(variables),
+			"steps":       orEmpty(steps),
+		})
+	}
+
+	if err := writeJSONFile(files, "runbooks.json", now, runbookPayloads); err != nil {
+		return err
+	}
+
+	workspaces, err := s.db.FindWorkspacesByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(files, "workspaces.json", now, orEmpty(workspaces)); err != nil {
+		return err
+	}
+
+	usage, err := s.db.FindCommandUsage(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(files, "command_usage.json", now, orEmpty(usage)); err != nil {
+		return err
+	}
+
+	refreshTokens, err := s.db.GetAllRefreshTokensByUserID(ctx, pgtype.UUID{Bytes: user.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	// Sessions leave out the token hashes
+	sessions := make([]map[string]interface{}, 0, len(refreshTokens))
+	for _, refreshToken := range refreshTokens {
+		sessions = append(sessions, map[string]interface{}{
+			"id":         refreshToken.ID,
+			"issued_at":  refreshToken.IssuedAt,
+			"expires_at": refreshToken.ExpiresAt,
+		})
+	}
+
+	if err := writeJSONFile(files, "sessions.json", now, sessions); err != nil {
+		return err
+	}
+
+	events, err := s.db.FindAuditEvents(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(files, "audit_events.json", now, orEmpty(events)); err != nil {
+		return err
+	}
+
+	return files.Close()
+}
+
+func writeJSONFile(files *zip.Writer, name string, modified time.Time, v interface{}) error {
+	file, err := files.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// orEmpty turns a nil slice into an empty one so that it is written as [].
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
+
+type deleteAccountRequestPayloadSchema struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type accountDeletionResponsePayloadSchema struct {
+	Message      string             `json:"message"`
+	RequestedAt  pgtype.Timestamptz `json:"requested_at"`
+	ScheduledFor pgtype.Timestamptz `json:"scheduled_for"`
+}
+
+// DeleteAccount schedules the deletion of the account of the user, who has
+// to confirm it with their password. Every session is signed out. The
+// account, and everything that belongs to it, is deleted once the grace
+// period is over, until then CancelAccountDeletion keeps it.
+func (s *Server) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload deleteAccountRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: _userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	isMatch, err := auth.CompareHash(requestPayload.Password, user.Password, auth.Bcrypt)
+	if err != nil {
+		s.log(r).Error("Error during password hash comparison", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+	if !isMatch {
+		utils.ResponseError(w, http.StatusForbidden, "Invalid password")
+		return
+	}
+
+	ownedAlone, err := s.db.CountSharedWorkspacesOwnedAlone(ctx, _userId)
+	if err != nil {
+		s.log(r).Error("Failed to count owned workspaces", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+	if ownedAlone > 0 {
+		utils.ResponseError(w, http.StatusConflict, "You are the only owner of workspaces with other members, make someone else an owner or remove the members first")
+		return
+	}
+
+	if s.cfg.AccountDeletionGraceDays <= 0 {
+		if err := s.deleteAccount(ctx, _userId); err != nil {
+			s.log(r).Error("Failed to delete account", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+
+		utils.Response(w, http.StatusOK, messageResponsePayloadSchema{Message: "Account deleted"})
+		return
+	}
+
+	var deletion repository.AccountDeletion
+	err = s.db.ExecTx(ctx, func(q *repository.Queries) error {
+		deletion, err = q.ScheduleAccountDeletion(ctx, repository.ScheduleAccountDeletionParams{
+			UserID: _userId,
+			ScheduledFor: pgtype.Timestamptz{
+				Time:  time.Now().AddDate(0, 0, s.cfg.AccountDeletionGraceDays),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Access tokens issued so far are rejected from now on.
+		if _, err := q.RevokeUserSessions(ctx, _userId); err != nil {
+			return err
+		}
+
+		return q.DeleteRefreshTokensByUserID(ctx, pgtype.UUID{Bytes: _userId, Valid: true})
+	})
+	if err != nil {
+		s.log(r).Error("Failed to schedule account deletion", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	s.recordAuditEvent(r, _userId, auditAccountDeletionRequested)
+
+	responsePayload := accountDeletionResponsePayloadSchema{
+		Message:      "Account scheduled for deletion",
+		RequestedAt:  deletion.RequestedAt,
+		ScheduledFor: deletion.ScheduledFor,
+	}
+
+	utils.Response(w, http.StatusAccepted, responsePayload)
+}
+
+// CancelAccountDeletion keeps an account that is scheduled for deletion.
+func (s *Server) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := s.db.CancelAccountDeletion(r.Context(), _userId)
+	if err != nil {
+		s.log(r).Error("Failed to cancel account deletion", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to cancel account deletion")
+		return
+	}
+	if rows == 0 {
+		utils.ResponseError(w, http.StatusNotFound, "Account is not scheduled for deletion")
+		return
+	}
+
+	s.recordAuditEvent(r, _userId, auditAccountDeletionCancelled)
+
+	utils.Response(w, http.StatusOK, messageResponsePayloadSchema{Message: "Account deletion cancelled"})
+}
+
+// deleteAccount deletes the user, everything they own goes with them. The
+// workspaces they were the only member of are deleted first, as nobody could
+// reach them anymore, and their runbooks before their commands, which the
+// runbook steps keep from being deleted. Commands and tags they created in
+// the other workspaces stay there without an author.
+func (s *Server) deleteAccount(ctx context.Context, userId uuid.UUID) error {
+	return s.db.ExecTx(ctx, func(q *repository.Queries) error {
+		if _, err := q.DeleteWorkspacesWithOnlyMember(ctx, userId); err != nil {
+			return err
+		}
+		if err := q.DeleteRunbooksByUserID(ctx, userId); err != nil {
+			return err
+		}
+		if err := q.DeletePersonalCommands(ctx, userId); err != nil {
+			return err
+		}
+		if err := q.DeletePersonalTags(ctx, userId); err != nil {
+			return err
+		}
+
+		return q.DeleteUser(ctx, userId)
+	})
+}
+
+// runAccountPurger deletes the accounts whose grace period is over every
+// hour until ctx is done.
+func (s *Server) runAccountPurger(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		s.purgeDueAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) purgeDueAccounts(ctx context.Context) {
+	userIds, err := s.db.FindDueAccountDeletions(ctx, pgtype.Timestamptz{Time: time.Now(), Valid: true})
+	if err != nil {
+		s.logger.Error("Failed to fetch due account deletions", slog.String("ERROR", err.Error()))
+		return
+	}
+
+	for _, userId := range userIds {
+		if err := s.deleteAccount(ctx, userId); err != nil {
+			s.logger.Error("Failed to delete account", slog.String("user_id", userId.String()), slog.String("ERROR", err.Error()))
+			continue
+		}
+
+		s.logger.Info("Deleted account", slog.String("user_id", userId.String()))
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAccountWithRunbook(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "runbook-owner@example.com")
+	runbook := insertTestRunbook(t, s, user.ID, insertTestCommand(t, s, user.ID).ID)
+
+	require.NoError(t, s.deleteAccount(ctx, user.ID))
+
+	_, err := s.db.FindRunbookById(ctx, runbook.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = s.db.GetUserSessionState(ctx, user.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestPurgeDueAccountsWithRunbook(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "runbook-owner@example.com")
+	insertTestRunbook(t, s, user.ID, insertTestCommand(t, s, user.ID).ID)
+
+	_, err := s.db.ScheduleAccountDeletion(ctx, repository.ScheduleAccountDeletionParams{
+		UserID:       user.ID,
+		ScheduledFor: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	s.purgeDueAccounts(ctx)
+
+	_, err = s.db.GetUserSessionState(ctx, user.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestExportAccountDataIncludesTrashRevisionsAndWorkspaceCommands(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "export@example.com")
+	command := insertTestCommand(t, s, user.ID)
+	_, err := s.db.InsertCommandRevision(ctx, repository.InsertCommandRevisionParams{
+		AuthorID:  pgtype.UUID{Bytes: user.ID, Valid: true},
+		CommandID: command.ID,
+	})
+	require.NoError(t, err)
+	_, err = s.db.SoftDeleteCommand(ctx, repository.SoftDeleteCommandParams{ID: command.ID, Version: command.Version})
+	require.NoError(t, err)
+
+	workspace, err := s.db.InsertWorkspace(ctx, repository.InsertWorkspaceParams{
+		Name:      "team",
+		CreatedBy: pgtype.UUID{Bytes: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	workspaceCommand, err := s.db.InsertCommands(ctx, repository.InsertCommandsParams{
+		UserID:      user.ID,
+		Command:     "make release",
+		Description: pgtype.Text{String: "Releases the app", Valid: true},
+		WorkspaceID: pgtype.UUID{Bytes: workspace.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, s.exportAccountData(ctx, zip.NewWriter(&buffer), user, time.Now()))
+
+	files, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+
+	readJSON := func(name string, v interface{}) {
+		t.Helper()
+		file, err := files.Open(name)
+		require.NoError(t, err)
+		defer file.Close()
+		require.NoError(t, json.NewDecoder(file).Decode(v))
+	}
+
+	var trash struct {
+		Commands []repository.Command `json:"commands"`
+	}
+	readJSON("trash.json", &trash)
+	require.Len(t, trash.Commands, 1)
+	assert.Equal(t, command.ID, trash.Commands[0].ID)
+
+	var revisions []repository.CommandRevision
+	readJSON("command_revisions.json", &revisions)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, command.ID, revisions[0].CommandID)
+
+	var workspaceCommands []repository.Command
+	readJSON("workspace_commands.json", &workspaceCommands)
+	require.Len(t, workspaceCommands, 1)
+	assert.Equal(t, workspaceCommand.ID, workspaceCommands[0].ID)
+}
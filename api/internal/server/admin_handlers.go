@@ -0,0 +1,258 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/endalk200/termflow-api/internal/admin"
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// adminUserResponsePayloadSchema is a user as shown to operators, without
+// the password hash.
+type adminUserResponsePayloadSchema struct {
+	ID                uuid.UUID          `json:"id"`
+	FirstName         string             `json:"first_name"`
+	LastName          string             `json:"last_name"`
+	Email             string             `json:"email"`
+	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	IsActive          pgtype.Bool        `json:"is_active"`
+	Role              string             `json:"role"`
+	SessionsRevokedAt pgtype.Timestamptz `json:"sessions_revoked_at"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
+type adminUserDetailsResponsePayloadSchema struct {
+	adminUserResponsePayloadSchema
+	Usage repository.GetUserUsageCountsRow `json:"usage"`
+}
+
+func adminUserPayload(user repository.User) adminUserResponsePayloadSchema {
+	return adminUserResponsePayloadSchema{
+		ID:                user.ID,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		Email:             user.Email,
+		IsEmailVerified:   user.IsEmailVerified,
+		IsActive:          user.IsActive,
+		Role:              user.Role,
+		SessionsRevokedAt: user.SessionsRevokedAt,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+	}
+}
+
+// AdminListUsers lists users, optionally filtered with the is_active,
+// is_email_verified and search query parameters and paginated with limit
+// and offset.
+func (s *Server) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := admin.UserFilter{Search: query.Get("search")}
+
+	for name, target := range map[string]**bool{
+		"is_active":         &filter.IsActive,
+		"is_email_verified": &filter.IsEmailVerified,
+	} {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		value, err := strconv.ParseBool(query.Get(name))
+		if err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid "+name+" parameter")
+			return
+		}
+		*target = &value
+	}
+
+	for name, target := range map[string]*int32{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		value, err := strconv.ParseInt(query.Get(name), 10, 32)
+		if err != nil || value < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid "+name+" parameter")
+			return
+		}
+		*target = int32(value)
+	}
+
+	users, err := admin.ListUsers(r.Context(), s.db.Queries, filter)
+	if err != nil {
+		s.log(r).Error("Failed to list users", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
+	responsePayload := make([]adminUserResponsePayloadSchema, 0, len(users))
+	for _, user := range users {
+		responsePayload = append(responsePayload, adminUserPayload(user))
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// AdminGetUser responds with a user, found by id or email, along with the
+// counts of what they store and use.
+func (s *Server) AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.findAdminUser(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := s.db.GetUserUsageCounts(r.Context(), user.ID)
+	if err != nil {
+		s.log(r).Error("Failed to count user usage", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	responsePayload := adminUserDetailsResponsePayloadSchema{
+		adminUserResponsePayloadSchema: adminUserPayload(user),
+		Usage:                          usage,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// AdminGetUsage responds with the counts of users and of what they store
+// across the whole instance.
+func (s *Server) AdminGetUsage(w http.ResponseWriter, r *http.Request) {
+	totals, err := s.db.GetUsageTotals(r.Context())
+	if err != nil {
+		s.log(r).Error("Failed to count usage", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch usage")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, totals)
+}
+
+func (s *Server) AdminDeactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.findAdminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondAdminAction(w, r, user, admin.EventDeactivated, admin.Deactivate(r.Context(), s.db, user.ID))
+}
+
+func (s *Server) AdminReactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.findAdminUser(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondAdminAction(w, r, user, admin.EventReactivated, admin.Reactivate(r.Context(), s.db.Queries, user.ID))
+}
+
+// AdminLogoutUser signs the user out of every session.
+func (s *Server) AdminLogoutUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.findAdminUser(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondAdminAction(w, r, user, admin.EventSessionsRevoked, admin.ForceLogout(r.Context(), s.db, user.ID))
+}
+
+type adminRoleRequestPayloadSchema struct {
+	Role string `json:"role" validate:"required"`
+}
+
+func (s *Server) AdminSetUserRole(w http.ResponseWriter, r *http.Request) {
+	var requestPayload adminRoleRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	user, ok := s.findAdminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondAdminAction(w, r, user, admin.EventRoleChanged, admin.SetRole(r.Context(), s.db, user.ID, requestPayload.Role))
+}
+
+// findAdminUser loads the user from the id URL parameter, which may also
+// be an email address.
+func (s *Server) findAdminUser(w http.ResponseWriter, r *http.Request) (repository.User, bool) {
+	user, err := admin.FindUser(r.Context(), s.db.Queries, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, admin.ErrUserNotFound) {
+			utils.ResponseError(w, http.StatusNotFound, "User not found")
+		} else {
+			s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch user")
+		}
+
+		return repository.User{}, false
+	}
+
+	return user, true
+}
+
+// findAdminTarget is findAdminUser for actions an admin may not take on
+// their own account, so that they can not lock themselves out.
+func (s *Server) findAdminTarget(w http.ResponseWriter, r *http.Request) (repository.User, bool) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return repository.User{}, false
+	}
+
+	user, ok := s.findAdminUser(w, r)
+	if !ok {
+		return repository.User{}, false
+	}
+
+	if user.ID == _userId {
+		utils.ResponseError(w, http.StatusBadRequest, "Admins can not do this to their own account")
+		return repository.User{}, false
+	}
+
+	return user, true
+}
+
+// respondAdminAction records event for the user once the action went
+// through and responds with the user as it is now.
+func (s *Server) respondAdminAction(w http.ResponseWriter, r *http.Request, user repository.User, event string, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, admin.ErrUserNotFound):
+			utils.ResponseError(w, http.StatusNotFound, "User not found")
+		case errors.Is(err, admin.ErrUnknownRole):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		default:
+			s.log(r).Error("Failed to update user", slog.String("event", event), slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to update user")
+		}
+
+		return
+	}
+
+	s.recordAuditEvent(r, user.ID, event)
+
+	if adminId, ok := s.authenticatedUserId(w, r); ok {
+		s.log(r).Info("Admin action", slog.String("event", event), slog.String("user_id", user.ID.String()), slog.String("admin_id", adminId.String()))
+	}
+
+	updated, err := s.db.GetUser(r.Context(), repository.GetUserParams{ID: user.ID})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, adminUserPayload(updated))
+}
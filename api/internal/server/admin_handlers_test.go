@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/admin"
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdminTestServer returns a database test server serving requests with
+// signed access tokens, along with an admin and the token of the admin.
+func newAdminTestServer(t *testing.T) (*Server, repository.User, string) {
+	t.Helper()
+
+	s := newDatabaseTestServer(t, config.AppConfig{MaxBodyBytes: 1 << 20})
+	useTestSigningKeys(t)
+
+	operator := insertTestUser(t, s, "operator@example.com")
+	_, err := s.db.SetUserRole(context.Background(), repository.SetUserRoleParams{ID: operator.ID, Role: admin.RoleAdmin})
+	require.NoError(t, err)
+	operator.Role = admin.RoleAdmin
+
+	return s, operator, testAccessToken(t, operator)
+}
+
+func decodeAdminUser(t *testing.T, body []byte) adminUserResponsePayloadSchema {
+	t.Helper()
+
+	var user adminUserResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(body, &user))
+
+	return user
+}
+
+func TestAdminRoutesRequireAdminRole(t *testing.T) {
+	s, _, _ := newAdminTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+
+	res := serveTestRequest(t, s, http.MethodGet, "/api/admin/users", testAccessToken(t, user), nil)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/admin/users/"+user.ID.String()+"/deactivate", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestAdminListUsersSearchIsLiteral(t *testing.T) {
+	s, _, token := newAdminTestServer(t)
+	insertTestUser(t, s, "a_b@example.com")
+	insertTestUser(t, s, "axb@example.com")
+	insertTestUser(t, s, "100%@example.com")
+
+	search := func(text string) []string {
+		t.Helper()
+
+		res := serveTestRequest(t, s, http.MethodGet, "/api/admin/users?search="+url.QueryEscape(text), token, nil)
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+		var users []adminUserResponsePayloadSchema
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &users))
+
+		emails := make([]string, 0, len(users))
+		for _, user := range users {
+			emails = append(emails, user.Email)
+		}
+		return emails
+	}
+
+	assert.ElementsMatch(t, []string{"a_b@example.com"}, search("A_B"))
+	assert.ElementsMatch(t, []string{"100%@example.com"}, search("%"))
+	assert.Empty(t, search(`\`))
+	assert.Len(t, search("test user"), 4, "the full name matches too")
+}
+
+func TestAdminGetUser(t *testing.T) {
+	s, _, token := newAdminTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+	insertTestCommand(t, s, user.ID)
+
+	res := serveTestRequest(t, s, http.MethodGet, "/api/admin/users/user@example.com", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var payload adminUserDetailsResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &payload))
+	assert.Equal(t, user.ID, payload.ID)
+	assert.EqualValues(t, 1, payload.Usage.Commands)
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/admin/users/nobody@example.com", token, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestAdminGetUsage(t *testing.T) {
+	s, _, token := newAdminTestServer(t)
+	insertTestUser(t, s, "user@example.com")
+
+	res := serveTestRequest(t, s, http.MethodGet, "/api/admin/usage", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var totals repository.GetUsageTotalsRow
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &totals))
+	assert.EqualValues(t, 2, totals.Users)
+	assert.EqualValues(t, 1, totals.Admins)
+}
+
+func TestAdminDeactivateAndReactivateUser(t *testing.T) {
+	s, operator, token := newAdminTestServer(t)
+	ctx := context.Background()
+	user := insertTestUser(t, s, "user@example.com")
+	userToken := testAccessToken(t, user)
+
+	res := serveTestRequest(t, s, http.MethodPost, "/api/admin/users/"+user.ID.String()+"/deactivate", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.False(t, decodeAdminUser(t, res.Body.Bytes()).IsActive.Bool)
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/auth/me", userToken, nil)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/admin/users/"+user.ID.String()+"/reactivate", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.True(t, decodeAdminUser(t, res.Body.Bytes()).IsActive.Bool)
+
+	// Deactivating signed the user out
+	res = serveTestRequest(t, s, http.MethodGet, "/api/auth/me", userToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Contains(t, res.Body.String(), utils.CodeSessionRevoked)
+
+	events, err := s.db.FindAuditEvents(ctx, user.ID)
+	require.NoError(t, err)
+	var recorded []string
+	for _, event := range events {
+		recorded = append(recorded, event.Event)
+	}
+	assert.ElementsMatch(t, []string{admin.EventDeactivated, admin.EventReactivated}, recorded)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/admin/users/"+operator.ID.String()+"/deactivate", token, nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code, "admins can not deactivate themselves")
+}
+
+func TestAdminLogoutUser(t *testing.T) {
+	s, _, token := newAdminTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+	userToken := testAccessToken(t, user)
+
+	res := serveTestRequest(t, s, http.MethodPost, "/api/admin/users/"+user.ID.String()+"/logout", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.True(t, decodeAdminUser(t, res.Body.Bytes()).SessionsRevokedAt.Valid)
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/auth/me", userToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestAdminSetUserRole(t *testing.T) {
+	s, operator, token := newAdminTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+	path := "/api/admin/users/" + user.ID.String() + "/role"
+
+	res := serveTestRequest(t, s, http.MethodPut, path, token, adminRoleRequestPayloadSchema{Role: admin.RoleAdmin})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, admin.RoleAdmin, decodeAdminUser(t, res.Body.Bytes()).Role)
+
+	res = serveTestRequest(t, s, http.MethodPut, path, token, adminRoleRequestPayloadSchema{Role: "owner"})
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPut, "/api/admin/users/"+operator.ID.String()+"/role", token, adminRoleRequestPayloadSchema{Role: admin.RoleUser})
+	assert.Equal(t, http.StatusBadRequest, res.Code, "admins can not demote themselves")
+}
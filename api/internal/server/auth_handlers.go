@@ -1,7 +1,6 @@
 package server
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,15 +8,12 @@ import (
 
 	"github.com/endalk200/termflow-api/internal/repository"
 	"github.com/endalk200/termflow-api/pkgs/auth"
-	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/metrics"
 	"github.com/endalk200/termflow-api/pkgs/utils"
-	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
-	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -36,7 +32,7 @@ func (s *Server) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	hash, err := auth.HashPassword(requestPayload.Password, auth.Bcrypt)
 	if err != nil {
-		s.logger.Error("Error while hashing user password", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Error while hashing user password", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while creating a user account")
 		return
 	}
@@ -47,40 +43,35 @@ func (s *Server) CreateUser(w http.ResponseWriter, r *http.Request) {
 		LastName:        requestPayload.LastName,
 		Email:           requestPayload.Email,
 		IsEmailVerified: pgtype.Bool{Bool: false, Valid: true},
-		IsActive:        pgtype.Bool{Bool: false, Valid: true},
+		IsActive:        pgtype.Bool{Bool: true, Valid: true},
 		Password:        string(hash),
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "users_email_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Email already exists")
-					return
-				}
-				if pgErr.ConstraintName == "users_github_handle_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "GitHub handle already exists")
-					return
-				}
-			}
-		}
-
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create user")
+		s.respondDBError(w, r, err, "Failed to create user")
 		return
 	}
 
-	responsePayload := map[string]interface{}{
-		"id":                user.ID,
-		"first_name":        user.FirstName,
-		"last_name":         user.LastName,
-		"email":             user.Email,
-		"is_email_verified": user.IsEmailVerified,
-		"isActive":          user.IsActive,
-	}
+	utils.Response(w, http.StatusCreated, userPayload(user))
+}
 
-	utils.Response(w, http.StatusCreated, responsePayload)
+type userResponsePayloadSchema struct {
+	ID              uuid.UUID   `json:"id"`
+	FirstName       string      `json:"first_name"`
+	LastName        string      `json:"last_name"`
+	Email           string      `json:"email"`
+	IsEmailVerified pgtype.Bool `json:"is_email_verified"`
+	IsActive        pgtype.Bool `json:"isActive"`
+}
+
+func userPayload(user repository.User) userResponsePayloadSchema {
+	return userResponsePayloadSchema{
+		ID:              user.ID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		IsEmailVerified: user.IsEmailVerified,
+		IsActive:        user.IsActive,
+	}
 }
 
 type signInRequestPayloadSchema struct {
@@ -88,19 +79,16 @@ type signInRequestPayloadSchema struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type signInResponsePayloadSchema struct {
+	userResponsePayloadSchema
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
 func (s *Server) SignIn(w http.ResponseWriter, r *http.Request) {
 	var requestPayload signInRequestPayloadSchema
 
-	if err := json.NewDecoder(r.Body).Decode(&requestPayload); err != nil {
-		s.logger.Error("Error during request payload decoding", slog.String("ERROR", err.Error()))
-		utils.ResponseError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
-
-	if err := Validate.Struct(requestPayload); err != nil {
-		validationErrors := err.(validator.ValidationErrors)
-		s.logger.Error("Error during request payload validation", slog.String("ERROR", validationErrors.Error()))
-		utils.ResponseError(w, http.StatusBadRequest, validationErrors.Error())
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
 		return
 	}
 
@@ -109,40 +97,60 @@ func (s *Server) SignIn(w http.ResponseWriter, r *http.Request) {
 		Email: requestPayload.Email,
 	})
 	if err != nil {
+		// An unknown email is not told apart from a wrong password
 		if err == pgx.ErrNoRows {
-			s.logger.Error("No user with specified email", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			s.logger.Error("Failed to fetch user due to", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusInternalServerError, "Invalid credentials")
+			s.log(r).Warn("Sign-in with an unknown email")
+			s.metrics.SignIn(metrics.SignInInvalidCredentials)
+			utils.ResponseErrorCode(w, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
+			return
 		}
 
+		s.respondDBError(w, r, err, "Failed to sign in")
+		return
+	}
+
+	failure, ok := s.reserveLoginAttempt(w, r, user)
+	if !ok {
 		return
 	}
 
 	isMatch, err := auth.CompareHash(requestPayload.Password, user.Password, auth.Bcrypt)
 	if !isMatch || err != nil {
 		if err != nil {
-			s.logger.Error("Error during password hash comparison", slog.String("ERROR", err.Error()))
+			s.log(r).Error("Error during password hash comparison", slog.String("ERROR", err.Error()))
 			utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
 		} else {
-			s.logger.Error("Invalid password attempt", slog.String("email", requestPayload.Email))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
+			s.log(r).Error("Invalid password attempt", slog.String("email", requestPayload.Email))
+			s.recordLoginFailure(w, r, user, failure)
 		}
 		return
 	}
 
-	jwtClaims := jwt.RegisteredClaims{
-		Issuer:    "Termflow",
-		Subject:   user.ID.String(),
-		Audience:  jwt.ClaimStrings{"https://example.com"},
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	if err := s.db.DeleteLoginFailure(ctx, user.ID); err != nil {
+		s.log(r).Error("Failed to reset login failures", slog.String("ERROR", err.Error()))
+	}
+
+	if isInactive(user.IsActive) {
+		s.log(r).Warn("Sign-in to a deactivated account", slog.String("user_id", user.ID.String()))
+		s.metrics.SignIn(metrics.SignInInactive)
+		utils.ResponseErrorCode(w, http.StatusForbidden, utils.CodeAccountInactive, "Account is deactivated")
+		return
+	}
+
+	jwtClaims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "Termflow",
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{"https://example.com"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Role: user.Role,
 	}
 
 	token, err := auth.GenerateJWT(jwtClaims)
 	if err != nil {
-		s.logger.Warn("Error while generating jwt", slog.String("ERROR", err.Error()))
+		s.log(r).Warn("Error while generating jwt", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
 		return
 	}
@@ -156,14 +164,14 @@ func (s *Server) SignIn(w http.ResponseWriter, r *http.Request) {
 
 	refreshToken, err := auth.GenerateJWT(refreshTokenClaims)
 	if err != nil {
-		s.logger.Warn("Error while generating refresh token", slog.String("ERROR", err.Error()))
+		s.log(r).Warn("Error while generating refresh token", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
 		return
 	}
 
 	hashedRefreshToken, err := auth.HashPassword(refreshToken, auth.SHA256)
 	if err != nil {
-		s.logger.Error("Error during refreshToken hash", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Error during refreshToken hash", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to singin")
 		return
 	}
@@ -174,60 +182,56 @@ func (s *Server) SignIn(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
 	})
 	if err != nil {
-		s.logger.Error("Error during recording of the refresh token", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Error during recording of the refresh token", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to singin")
 		return
 	}
 
-	responsePayload := map[string]interface{}{
-		"id":                user.ID,
-		"first_name":        user.FirstName,
-		"last_name":         user.LastName,
-		"email":             user.Email,
-		"is_email_verified": user.IsEmailVerified,
-		"isActive":          user.IsActive,
-		"token":             token,
-		"refreshToken":      refreshToken,
+	s.recordAuditEvent(r, user.ID, auditSignIn)
+	s.metrics.SignIn(metrics.SignInSucceeded)
+
+	responsePayload := signInResponsePayloadSchema{
+		userResponsePayloadSchema: userPayload(user),
+		Token:                     token,
+		RefreshToken:              refreshToken,
 	}
 
 	utils.Response(w, http.StatusOK, responsePayload)
 }
 
+type meResponsePayloadSchema struct {
+	userResponsePayloadSchema
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// DeletionScheduledFor is set while the account is scheduled for
+	// deletion.
+	DeletionScheduledFor *pgtype.Timestamptz `json:"deletion_scheduled_for,omitempty"`
+}
+
 func (s *Server) Me(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserFromContext(r)
+	_userId, ok := s.authenticatedUserId(w, r)
 	if !ok {
-		s.logger.Error("userId not found in request context")
-		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// TODO: Handle uuid parse error
-	_userId, _ := uuid.Parse(userID)
-
 	ctx := r.Context()
 	user, err := s.db.GetUser(ctx, repository.GetUserParams{
 		ID: _userId,
 	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			s.logger.Error("No user with specified email", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			s.logger.Error("Failed to fetch user due to", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusInternalServerError, "Invalid credentials")
-		}
-
+		s.respondDBError(w, r, err, "Failed to fetch user")
 		return
 	}
 
-	responsePayload := map[string]interface{}{
-		"id":                user.ID,
-		"first_name":        user.FirstName,
-		"last_name":         user.LastName,
-		"email":             user.Email,
-		"is_email_verified": user.IsEmailVerified,
-		"isActive":          user.IsActive,
-		"created_at":        user.CreatedAt,
+	responsePayload := meResponsePayloadSchema{
+		userResponsePayloadSchema: userPayload(user),
+		CreatedAt:                 user.CreatedAt,
+	}
+
+	deletion, err := s.db.FindAccountDeletion(ctx, user.ID)
+	if err == nil {
+		responsePayload.DeletionScheduledFor = &deletion.ScheduledFor
+	} else if err != pgx.ErrNoRows {
+		s.log(r).Error("Failed to fetch account deletion", slog.String("ERROR", err.Error()))
 	}
 
 	utils.Response(w, http.StatusOK, responsePayload)
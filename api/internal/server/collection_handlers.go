@@ -0,0 +1,392 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type createCollectionRequestPayloadSchema struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:""`
+}
+
+func (s *Server) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload createCollectionRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	collection, err := s.db.InsertCollection(ctx, repository.InsertCollectionParams{
+		UserID:      _userId,
+		Name:        requestPayload.Name,
+		Description: pgtype.Text{String: requestPayload.Description, Valid: true},
+	})
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to create collection")
+		return
+	}
+
+	utils.Response(w, http.StatusCreated, collection)
+}
+
+func (s *Server) GetCollections(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	collections, err := s.db.FindCollections(ctx, _userId)
+	if err != nil {
+		s.log(r).Error("Failed to fetch collections", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch collections")
+		return
+	}
+
+	if collections == nil {
+		collections = []repository.Collection{}
+	}
+
+	utils.Response(w, http.StatusOK, collections)
+}
+
+// collectionResponsePayloadSchema is a collection along with its commands,
+// in order.
+type collectionResponsePayloadSchema struct {
+	ID          uuid.UUID                              `json:"id"`
+	Name        string                                 `json:"name"`
+	Description pgtype.Text                            `json:"description"`
+	CreatedAt   pgtype.Timestamptz                     `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz                     `json:"updated_at"`
+	Commands    []repository.FindCollectionCommandsRow `json:"commands"`
+}
+
+func (s *Server) GetCollection(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	commands, err := s.db.FindCollectionCommands(ctx, collection.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch collection commands", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch collection")
+		return
+	}
+
+	if commands == nil {
+		commands = []repository.FindCollectionCommandsRow{}
+	}
+
+	responsePayload := collectionResponsePayloadSchema{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		CreatedAt:   collection.CreatedAt,
+		UpdatedAt:   collection.UpdatedAt,
+		Commands:    commands,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type updateCollectionRequestPayloadSchema struct {
+	Name        string  `json:"name" validate:""`
+	Description *string `json:"description" validate:""`
+}
+
+func (s *Server) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload updateCollectionRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	description := pgtype.Text{}
+	if requestPayload.Description != nil {
+		description = pgtype.Text{String: *requestPayload.Description, Valid: true}
+	}
+
+	ctx := r.Context()
+	collection, err := s.db.UpdateCollection(ctx, repository.UpdateCollectionParams{
+		ID:          collection.ID,
+		Name:        requestPayload.Name,
+		Description: description,
+	})
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to update collection")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, collection)
+}
+
+func (s *Server) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.db.DeleteCollection(ctx, collection.ID); err != nil {
+		s.log(r).Error("Failed to delete collection", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete collection")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Collection deleted successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type addCollectionCommandRequestPayloadSchema struct {
+	CommandId string `json:"command_id" validate:"required,uuid"`
+}
+
+// AddCollectionCommand appends a command at the end of a collection.
+func (s *Server) AddCollectionCommand(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload addCollectionCommandRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(requestPayload.CommandId)
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	ctx := r.Context()
+	command, err := s.db.FindCommandById(ctx, _commandId)
+	if err != nil || command.UserID.Bytes != _userId || command.WorkspaceID.Valid || command.DeletedAt.Valid {
+		if err != nil && err != pgx.ErrNoRows {
+			s.log(r).Error("Failed to fetch command", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to add command to collection")
+			return
+		}
+
+		utils.ResponseError(w, http.StatusNotFound, "Command not found")
+		return
+	}
+
+	collectionCommand, err := s.db.AddCommandToCollection(ctx, repository.AddCommandToCollectionParams{
+		CollectionID: collection.ID,
+		CommandID:    command.ID,
+	})
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to add command to collection")
+		return
+	}
+
+	utils.Response(w, http.StatusCreated, collectionCommand)
+}
+
+type reorderCollectionCommandsRequestPayloadSchema struct {
+	CommandIds []string `json:"command_ids" validate:"required,dive,uuid"`
+}
+
+// ReorderCollectionCommands sets the order of the commands in a collection.
+// The payload must list every command of the collection exactly once.
+func (s *Server) ReorderCollectionCommands(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload reorderCollectionCommandsRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to reorder collection")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	commands, err := queriesWithTx.FindCollectionCommands(ctx, collection.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch collection commands", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to reorder collection")
+		return
+	}
+
+	members := make(map[uuid.UUID]bool, len(commands))
+	for _, command := range commands {
+		members[command.ID] = false
+	}
+
+	order := make([]uuid.UUID, 0, len(requestPayload.CommandIds))
+	for _, commandId := range requestPayload.CommandIds {
+		_commandId, _ := uuid.Parse(commandId)
+		seen, exists := members[_commandId]
+		if !exists || seen {
+			utils.ResponseError(w, http.StatusBadRequest, "command_ids must list every command of the collection exactly once")
+			return
+		}
+
+		members[_commandId] = true
+		order = append(order, _commandId)
+	}
+
+	if len(order) != len(members) {
+		utils.ResponseError(w, http.StatusBadRequest, "command_ids must list every command of the collection exactly once")
+		return
+	}
+
+	for position, commandId := range order {
+		err := queriesWithTx.UpdateCollectionCommandPosition(ctx, repository.UpdateCollectionCommandPositionParams{
+			CollectionID: collection.ID,
+			CommandID:    commandId,
+			Position:     int32(position),
+		})
+		if err != nil {
+			s.log(r).Error("Failed to update command position", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to reorder collection")
+			return
+		}
+	}
+
+	commands, err = queriesWithTx.FindCollectionCommands(ctx, collection.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch collection commands", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to reorder collection")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to reorder collection")
+		return
+	}
+
+	if commands == nil {
+		commands = []repository.FindCollectionCommandsRow{}
+	}
+
+	utils.Response(w, http.StatusOK, commands)
+}
+
+func (s *Server) RemoveCollectionCommand(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "commandId"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	ctx := r.Context()
+	err = s.db.RemoveCommandFromCollection(ctx, repository.RemoveCommandFromCollectionParams{
+		CollectionID: collection.ID,
+		CommandID:    _commandId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to remove command from collection", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to remove command from collection")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Command removed from collection successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// findOwnedCollection loads the collection from the id URL parameter. It
+// responds with 404 when the collection belongs to another user.
+func (s *Server) findOwnedCollection(w http.ResponseWriter, r *http.Request, userId uuid.UUID) (repository.Collection, bool) {
+	_collectionId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid collection ID")
+		return repository.Collection{}, false
+	}
+
+	collection, err := s.db.FindCollectionById(r.Context(), _collectionId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Collection not found")
+		} else {
+			s.log(r).Error("Failed to fetch collection", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch collection")
+		}
+
+		return repository.Collection{}, false
+	}
+
+	if collection.UserID != userId {
+		utils.ResponseError(w, http.StatusNotFound, "Collection not found")
+		return repository.Collection{}, false
+	}
+
+	return collection, true
+}
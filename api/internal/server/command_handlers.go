@@ -5,14 +5,11 @@ import (
 	"net/http"
 
 	"github.com/endalk200/termflow-api/internal/repository"
-	"github.com/endalk200/termflow-api/pkgs/middleware"
 	"github.com/endalk200/termflow-api/pkgs/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
-	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -22,32 +19,50 @@ type createCommandsRequestPayloadSchema struct {
 	TagId       string `json:"tag_id" validate:"required"`
 }
 
+type createCommandResponsePayloadSchema struct {
+	Command createdCommandSchema    `json:"command"`
+	Tag     createdCommandTagSchema `json:"tag"`
+}
+
+type createdCommandSchema struct {
+	ID          uuid.UUID `json:"id"`
+	Command     string    `json:"command"`
+	Description string    `json:"description"`
+	Version     int32     `json:"version"`
+}
+
+type createdCommandTagSchema struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description pgtype.Text `json:"description"`
+}
+
 func (s *Server) CreateCommand(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserFromContext(r)
+	sc, ok := s.requestScope(w, r, RoleEditor)
 	if !ok {
-		s.logger.Error("userId not found in request context")
-		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	_userId, err := uuid.Parse(userID)
-	if err != nil {
-		s.logger.Error("Failed to parse userId from request context" + err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to parse auth")
+	var requestPayload createCommandsRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
 		return
 	}
 
-	var requestPayload createCommandsRequestPayloadSchema
-	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+	_tagId, err := uuid.Parse(requestPayload.TagId)
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
 		return
 	}
 
-	_tagId, _ := uuid.Parse(requestPayload.TagId)
+	tag, ok := s.findScopedTag(w, r, sc, _tagId)
+	if !ok {
+		return
+	}
 
 	ctx := r.Context()
 	tx, err := s.conn.Begin(ctx)
 	if err != nil {
-		s.logger.Error("Failed to start transaction: " + err.Error())
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create command")
 		return
 	}
@@ -62,215 +77,154 @@ func (s *Server) CreateCommand(w http.ResponseWriter, r *http.Request) {
 	queriesWithTx := s.db.WithTx(tx)
 
 	command, err := queriesWithTx.InsertCommands(ctx, repository.InsertCommandsParams{
-		UserID:      _userId,
+		UserID:      sc.userId,
 		Command:     requestPayload.Command,
 		Description: pgtype.Text{String: requestPayload.Description, Valid: true},
+		WorkspaceID: sc.workspaceId,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
-		}
-
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create user")
+		s.respondDBError(w, r, err, "Failed to create command")
 		return
 	}
 
-	tag, _ := queriesWithTx.FindTagById(ctx, _tagId)
-
-	commandTagRelation, err := queriesWithTx.AttachCommandToTag(ctx, repository.AttachCommandToTagParams{
+	_, err = queriesWithTx.AttachCommandToTag(ctx, repository.AttachCommandToTagParams{
 		CommandID: command.ID,
 		TagID:     tag.ID,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				if pgErr.ConstraintName == "command_tags_tag_id_fkey" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Foreign key constraint error")
-					return
-				}
-
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "Foreign key constraint")
-				return
-			}
-		}
+		s.respondDBError(w, r, err, "Failed to create command")
+		return
+	}
 
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create user")
+	_, err = queriesWithTx.InsertCommandRevision(ctx, repository.InsertCommandRevisionParams{
+		AuthorID:  pgtype.UUID{Bytes: sc.userId, Valid: true},
+		CommandID: command.ID,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to record command revision", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create command")
 		return
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		s.logger.Error("Failed to commit transaction: " + err.Error())
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create command")
 		return
 	}
-
-	s.logger.Info("Command tag relation", slog.String("command", commandTagRelation.TagID.String()))
-
-	responsePayload := map[string]interface{}{
-		"command": map[string]interface{}{
-			"id":          command.ID,
-			"command":     command.Command,
-			"description": command.Description,
+	s.metrics.CommandsCreated(1)
+
+	responsePayload := createCommandResponsePayloadSchema{
+		Command: createdCommandSchema{
+			ID:          command.ID,
+			Command:     command.Command,
+			Description: command.Description,
+			Version:     command.Version,
 		},
-		"tag": map[string]interface{}{
-			"id":          tag.ID,
-			"name":        tag.Name,
-			"description": tag.Description,
+		Tag: createdCommandTagSchema{
+			ID:          tag.ID,
+			Name:        tag.Name,
+			Description: tag.Description,
 		},
 	}
 
 	utils.Response(w, http.StatusCreated, responsePayload)
 }
 
-func (s *Server) GetCommands(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserFromContext(r)
-	if !ok {
-		s.logger.Error("userId not found in request context")
-		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-		return
+// commandWithTagResponsePayloadSchema is a command along with one of its
+// tags, commands are listed once per tag.
+type commandWithTagResponsePayloadSchema struct {
+	Command commandRowSchema `json:"command"`
+	Tag     tagRowSchema     `json:"tag"`
+}
+
+type commandRowSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// tagRowSchema is null but for its timestamps when the command has no tag.
+type tagRowSchema struct {
+	ID          pgtype.UUID        `json:"id"`
+	Name        pgtype.Text        `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func commandWithTagPayload(row repository.FindCommandsWithTagsRow) commandWithTagResponsePayloadSchema {
+	return commandWithTagResponsePayloadSchema{
+		Command: commandRowSchema{
+			ID:          row.CommandID,
+			Command:     row.CommandName,
+			Description: row.CommandDescription,
+			CreatedAt:   row.CommandCreatedAt,
+			UpdatedAt:   row.CommandUpdatedAt,
+		},
+		Tag: tagRowSchema{
+			ID:          row.TagID,
+			Name:        row.TagName,
+			Description: row.TagDescription,
+			CreatedAt:   row.TagCreatedAt,
+			UpdatedAt:   row.TagUpdatedAt,
+		},
 	}
+}
 
-	_userId, err := uuid.Parse(userID)
-	if err != nil {
-		s.logger.Error("Failed to parse userId from request context" + err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to parse auth")
+func (s *Server) GetCommands(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
 		return
 	}
 
 	ctx := r.Context()
-	commands, err := s.db.FindCommandsWithTags(ctx, _userId)
+	commands, err := s.db.FindCommandsWithTags(ctx, repository.FindCommandsWithTagsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			s.logger.Error("No user with specified email", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			s.logger.Error("Failed to fetch user due to", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusInternalServerError, "Invalid credentials")
-		}
-
+		s.respondDBError(w, r, err, "Failed to fetch commands")
 		return
 	}
 
-	responsePayload := []map[string]interface{}{}
-	commandMap := make(map[uuid.UUID]map[string]interface{})
-
+	responsePayload := make([]commandWithTagResponsePayloadSchema, 0, len(commands))
 	for _, row := range commands {
-		// Check if the command is already in the map
-		command, exists := commandMap[row.CommandID]
-		if !exists {
-			command = map[string]interface{}{
-				"id":          row.CommandID,
-				"command":     row.CommandName,
-				"description": row.CommandDescription,
-				"created_at":  row.CommandCreatedAt,
-				"updated_at":  row.CommandUpdatedAt,
-			}
-			commandMap[row.CommandID] = command
-		}
-
-		// Create the tag response
-		tag := map[string]interface{}{
-			"id":          row.TagID,
-			"name":        row.TagName,
-			"description": row.TagDescription,
-			"created_at":  row.TagCreatedAt,
-			"updated_at":  row.TagUpdatedAt,
-		}
-
-		// Add the command and associated tag to the response
-		responsePayload = append(responsePayload, map[string]interface{}{
-			"command": command,
-			"tag":     tag,
-		})
+		responsePayload = append(responsePayload, commandWithTagPayload(row))
 	}
 
 	utils.Response(w, http.StatusOK, responsePayload)
 }
 
 func (s *Server) GetCommandsWithTag(w http.ResponseWriter, r *http.Request) {
-	// userID, ok := middleware.GetUserFromContext(r)
-	// if !ok {
-	// 	s.logger.Error("userId not found in request context")
-	// 	utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-	// 	return
-	// }
-
-	// TODO: Handle uuid parse error
-	// _userId, _ := uuid.Parse(userID)
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
 
 	tagID := chi.URLParam(r, "id")
 	_tagID, err := uuid.Parse(tagID)
 	if err != nil {
-		s.logger.Error("Invalid tag id format", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Invalid tag id format", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
 		return
 	}
 
+	if _, ok := s.findScopedTag(w, r, sc, _tagID); !ok {
+		return
+	}
+
 	ctx := r.Context()
 	commands, err := s.db.FindCommandsByTagId(ctx, _tagID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			s.logger.Error("No user with specified email", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			s.logger.Error("Failed to fetch user due to", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusInternalServerError, "Invalid credentials")
-		}
-
+		s.respondDBError(w, r, err, "Failed to fetch commands")
 		return
 	}
 
-	responsePayload := []map[string]interface{}{}
-	commandMap := make(map[uuid.UUID]map[string]interface{})
-
+	responsePayload := make([]commandWithTagResponsePayloadSchema, 0, len(commands))
 	for _, row := range commands {
-		// Check if the command is already in the map
-		command, exists := commandMap[row.CommandID]
-		if !exists {
-			command = map[string]interface{}{
-				"id":          row.CommandID,
-				"command":     row.CommandName,
-				"description": row.CommandDescription,
-				"created_at":  row.CommandCreatedAt,
-				"updated_at":  row.CommandUpdatedAt,
-			}
-			commandMap[row.CommandID] = command
-		}
-
-		// Create the tag response
-		tag := map[string]interface{}{
-			"id":          row.TagID,
-			"name":        row.TagName,
-			"description": row.TagDescription,
-			"created_at":  row.TagCreatedAt,
-			"updated_at":  row.TagUpdatedAt,
-		}
-
-		// Add the command and associated tag to the response
-		responsePayload = append(responsePayload, map[string]interface{}{
-			"command": command,
-			"tag":     tag,
-		})
+		responsePayload = append(responsePayload, commandWithTagPayload(repository.FindCommandsWithTagsRow(row)))
 	}
 
 	utils.Response(w, http.StatusOK, responsePayload)
@@ -282,130 +236,241 @@ type updateCommandRequestPayloadSchema struct {
 }
 
 func (s *Server) UpdateCommand(w http.ResponseWriter, r *http.Request) {
-	// userID, ok := middleware.GetUserFromContext(r)
-	// if !ok {
-	// 	s.logger.Error("userId not found in request context")
-	// 	utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-	// 	return
-	// }
-	//
-	// // TODO: Handle uuid parse error
-	// _userId, _ := uuid.Parse(userID)
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
 
 	var requestPayload updateCommandRequestPayloadSchema
 	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
 		return
 	}
 
-	tagID := chi.URLParam(r, "id")
-	_tagID, err := uuid.Parse(tagID)
+	commandID := chi.URLParam(r, "id")
+	_commandId, err := uuid.Parse(commandID)
 	if err != nil {
-		s.logger.Error("Invalid tag id format", slog.String("ERROR", err.Error()))
-		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
+		s.log(r).Error("Invalid command id format", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	current, ok := s.findScopedCommand(w, r, sc, _commandId)
+	if !ok {
+		return
+	}
+
+	if !s.checkIfMatch(w, r, current.Version, commandPayload(current)) {
 		return
 	}
 
 	ctx := r.Context()
-	tag, err := s.db.UpdateCommand(ctx, repository.UpdateCommandParams{
-		ID:      _tagID,
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update command")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	command, err := queriesWithTx.UpdateCommand(ctx, repository.UpdateCommandParams{
+		ID:      _commandId,
 		Column2: requestPayload.Command,
 		Column3: pgtype.Text{String: requestPayload.Description, Valid: true},
+		Version: current.Version,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
+		// The version changed between reading and updating the command
+		if err == pgx.ErrNoRows {
+			s.respondCommandConflict(w, r, _commandId)
+			return
 		}
 
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update tag")
+		s.respondDBError(w, r, err, "Failed to update command")
 		return
 	}
 
-	// responsePayload := map[string]interface{
-	//    Name: tag.Name,
-	//  }
+	_, err = queriesWithTx.InsertCommandRevision(ctx, repository.InsertCommandRevisionParams{
+		AuthorID:  pgtype.UUID{Bytes: sc.userId, Valid: true},
+		CommandID: command.ID,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to record command revision", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update command")
+		return
+	}
 
-	utils.Response(w, http.StatusCreated, tag)
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update command")
+		return
+	}
+
+	w.Header().Set("ETag", utils.ETag(command.Version))
+	utils.Response(w, http.StatusOK, command)
 }
 
 func (s *Server) DeleteCommand(w http.ResponseWriter, r *http.Request) {
-	// userID, ok := middleware.GetUserFromContext(r)
-	// if !ok {
-	// 	s.logger.Error("userId not found in request context")
-	// 	utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-	// 	return
-	// }
-	//
-	// // TODO: Handle uuid parse error
-	// _userId, _ := uuid.Parse(userID)
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
 
 	commandID := chi.URLParam(r, "id")
 	_commandId, err := uuid.Parse(commandID)
 	if err != nil {
-		s.logger.Error("Invalid tag id format", slog.String("ERROR", err.Error()))
-		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
+		s.log(r).Error("Invalid command id format", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
 		return
 	}
 
-	ctx := r.Context()
-	tx, err := s.conn.Begin(ctx)
+	current, ok := s.findScopedCommand(w, r, sc, _commandId)
+	if !ok {
+		return
+	}
+
+	if !s.checkIfMatch(w, r, current.Version, commandPayload(current)) {
+		return
+	}
+
+	// The command keeps its tags while in the trash so that restoring it
+	// brings them back. Commands a runbook uses can not be trashed.
+	rows, err := s.db.SoftDeleteCommand(r.Context(), repository.SoftDeleteCommandParams{
+		ID:      _commandId,
+		Version: current.Version,
+	})
 	if err != nil {
-		s.logger.Error("Failed to start transaction: " + err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create command")
+		s.log(r).Error("Failed to delete command", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete command")
 		return
 	}
 
-	// Rollback the transaction in case of failure
-	defer func() {
+	if rows == 0 {
+		used, err := s.db.IsCommandUsedByRunbook(r.Context(), pgtype.UUID{Bytes: _commandId, Valid: true})
 		if err != nil {
-			_ = tx.Rollback(ctx)
+			s.log(r).Error("Failed to delete command", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete command")
+			return
+		}
+		if used {
+			apiErr := referencedErrors["runbook_steps_command_id_fkey"]
+			utils.ResponseProblem(w, &apiErr)
+			return
 		}
-	}()
 
-	queriesWithTx := s.db.WithTx(tx)
+		s.respondCommandConflict(w, r, _commandId)
+		return
+	}
 
-	_ = queriesWithTx.DeleteCommandTagRelationByCommandId(ctx, _commandId)
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Command moved to trash",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
 
-	err = queriesWithTx.DeleteCommand(ctx, _commandId)
+// GetCommand returns a single command with its tags. The ETag header carries
+// the version of the command to send back as If-Match when changing it.
+func (s *Server) GetCommand(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
-		}
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
 
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update tag")
+	command, ok := s.findScopedCommand(w, r, sc, _commandId)
+	if !ok {
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		s.logger.Error("Failed to commit transaction: " + err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create command")
+	if notModified(w, r, command.Version) {
 		return
 	}
 
-	responsePayload := map[string]interface{}{
-		"message": "Command deleted successfully",
+	tags, err := s.db.FindTagsByCommandId(r.Context(), command.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch command tags", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command")
+		return
 	}
 
-	utils.Response(w, http.StatusCreated, responsePayload)
+	responsePayload := commandDetailsResponsePayloadSchema{
+		commandResponsePayloadSchema: commandPayload(command),
+		Tags:                         tags,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// respondCommandConflict is used when a conditional write matched no row
+// because the command changed after it was loaded. It reloads the command
+// and responds with 412 and its current representation.
+func (s *Server) respondCommandConflict(w http.ResponseWriter, r *http.Request, commandId uuid.UUID) {
+	command, err := s.db.FindCommandById(r.Context(), commandId)
+	if err != nil || command.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Command not found")
+		return
+	}
+
+	respondPreconditionFailed(w, command.Version, commandPayload(command))
+}
+
+type commandResponsePayloadSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	Version     int32              `json:"version"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type commandDetailsResponsePayloadSchema struct {
+	commandResponsePayloadSchema
+	Tags []repository.Tag `json:"tags"`
+}
+
+func commandPayload(command repository.Command) commandResponsePayloadSchema {
+	return commandResponsePayloadSchema{
+		ID:          command.ID,
+		Command:     command.Command,
+		Description: command.Description,
+		Version:     command.Version,
+		CreatedAt:   command.CreatedAt,
+		UpdatedAt:   command.UpdatedAt,
+	}
+}
+
+// findScopedCommand loads a command and responds with 404 when it does not
+// belong to the scope of the request or is in the trash.
+func (s *Server) findScopedCommand(w http.ResponseWriter, r *http.Request, sc scope, commandId uuid.UUID) (repository.Command, bool) {
+	command, err := s.db.FindCommandById(r.Context(), commandId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Command not found")
+		} else {
+			s.log(r).Error("Failed to fetch command", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command")
+		}
+
+		return repository.Command{}, false
+	}
+
+	if !sc.owns(command.UserID, command.WorkspaceID) || command.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Command not found")
+		return repository.Command{}, false
+	}
+
+	return command, true
 }
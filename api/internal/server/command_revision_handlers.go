@@ -0,0 +1,337 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/diff"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type commandRevisionResponsePayloadSchema struct {
+	Revision    int32              `json:"revision"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	Tags        []string           `json:"tags"`
+	AuthorID    pgtype.UUID        `json:"author_id"`
+	AuthorEmail pgtype.Text        `json:"author_email"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+func (s *Server) GetCommandRevisions(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	if _, ok := s.findScopedCommand(w, r, sc, _commandId); !ok {
+		return
+	}
+
+	revisions, err := s.db.FindCommandRevisions(r.Context(), _commandId)
+	if err != nil {
+		s.log(r).Error("Failed to fetch command revisions", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command revisions")
+		return
+	}
+
+	responsePayload := make([]commandRevisionResponsePayloadSchema, 0, len(revisions))
+	for _, revision := range revisions {
+		responsePayload = append(responsePayload, commandRevisionResponsePayloadSchema{
+			Revision:    revision.Revision,
+			Command:     revision.Command,
+			Description: revision.Description,
+			Tags:        revision.Tags,
+			AuthorID:    revision.AuthorID,
+			AuthorEmail: revision.AuthorEmail,
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type commandRevisionDiffResponsePayloadSchema struct {
+	From        int32            `json:"from"`
+	To          int32            `json:"to"`
+	Command     []diff.Op        `json:"command"`
+	Description []diff.Op        `json:"description"`
+	Tags        tagChangesSchema `json:"tags"`
+}
+
+type tagChangesSchema struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// GetCommandRevisionDiff compares a revision with an earlier one, the one
+// right before it unless the against query parameter names another.
+func (s *Server) GetCommandRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	revisionNumber, ok := parseRevision(w, chi.URLParam(r, "revision"))
+	if !ok {
+		return
+	}
+
+	againstNumber := revisionNumber - 1
+	if against := r.URL.Query().Get("against"); against != "" {
+		if againstNumber, ok = parseRevision(w, against); !ok {
+			return
+		}
+	}
+
+	if _, ok := s.findScopedCommand(w, r, sc, _commandId); !ok {
+		return
+	}
+
+	revision, ok := s.findCommandRevision(w, r, _commandId, revisionNumber)
+	if !ok {
+		return
+	}
+
+	// The first revision is compared with an empty command
+	var previous repository.CommandRevision
+	if againstNumber > 0 {
+		if previous, ok = s.findCommandRevision(w, r, _commandId, againstNumber); !ok {
+			return
+		}
+	}
+
+	responsePayload := commandRevisionDiffResponsePayloadSchema{
+		From:        againstNumber,
+		To:          revisionNumber,
+		Command:     diff.Lines(previous.Command, revision.Command),
+		Description: diff.Lines(previous.Description, revision.Description),
+		Tags: tagChangesSchema{
+			Added:   missingFrom(revision.Tags, previous.Tags),
+			Removed: missingFrom(previous.Tags, revision.Tags),
+		},
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type restoreCommandRevisionResponsePayloadSchema struct {
+	Command      restoredCommandSchema `json:"command"`
+	RestoredFrom int32                 `json:"restored_from"`
+	Revision     int32                 `json:"revision"`
+	SkippedTags  []string              `json:"skipped_tags"`
+}
+
+type restoredCommandSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	Tags        []string           `json:"tags"`
+	Version     int32              `json:"version"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// RestoreCommandRevision puts a command back to how it was at a revision.
+// Tags are re-attached by name, tags that no longer exist in the scope are
+// reported as skipped. The restore itself is recorded as a new revision.
+func (s *Server) RestoreCommandRevision(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	revisionNumber, ok := parseRevision(w, chi.URLParam(r, "revision"))
+	if !ok {
+		return
+	}
+
+	current, ok := s.findScopedCommand(w, r, sc, _commandId)
+	if !ok {
+		return
+	}
+
+	if !s.checkIfMatch(w, r, current.Version, commandPayload(current)) {
+		return
+	}
+
+	revision, ok := s.findCommandRevision(w, r, _commandId, revisionNumber)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	command, err := queriesWithTx.RestoreCommand(ctx, repository.RestoreCommandParams{
+		ID:          _commandId,
+		Command:     revision.Command,
+		Description: revision.Description,
+		Version:     current.Version,
+	})
+	if err != nil {
+		// The version changed between reading and restoring the command
+		if err == pgx.ErrNoRows {
+			s.respondCommandConflict(w, r, _commandId)
+			return
+		}
+
+		s.log(r).Error("Failed to restore command", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	if err := queriesWithTx.DeleteCommandTagRelationByCommandId(ctx, _commandId); err != nil {
+		s.log(r).Error("Failed to detach command tags", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	tags, err := queriesWithTx.FindTags(ctx, repository.FindTagsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to fetch tags", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	tagIds := make(map[string]uuid.UUID, len(tags))
+	for _, tag := range tags {
+		tagIds[tag.Name] = tag.ID
+	}
+
+	skippedTags := []string{}
+	for _, name := range revision.Tags {
+		tagId, exists := tagIds[name]
+		if !exists {
+			skippedTags = append(skippedTags, name)
+			continue
+		}
+
+		_, err := queriesWithTx.AttachCommandToTag(ctx, repository.AttachCommandToTagParams{
+			CommandID: _commandId,
+			TagID:     tagId,
+		})
+		if err != nil {
+			s.log(r).Error("Failed to attach command tag", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+			return
+		}
+	}
+
+	restored, err := queriesWithTx.InsertCommandRevision(ctx, repository.InsertCommandRevisionParams{
+		AuthorID:  pgtype.UUID{Bytes: sc.userId, Valid: true},
+		CommandID: _commandId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to record command revision", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	responsePayload := restoreCommandRevisionResponsePayloadSchema{
+		Command: restoredCommandSchema{
+			ID:          command.ID,
+			Command:     command.Command,
+			Description: command.Description,
+			Tags:        restored.Tags,
+			Version:     command.Version,
+			UpdatedAt:   command.UpdatedAt,
+		},
+		RestoredFrom: revision.Revision,
+		Revision:     restored.Revision,
+		SkippedTags:  skippedTags,
+	}
+
+	w.Header().Set("ETag", utils.ETag(command.Version))
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) findCommandRevision(w http.ResponseWriter, r *http.Request, commandId uuid.UUID, revision int32) (repository.CommandRevision, bool) {
+	commandRevision, err := s.db.FindCommandRevision(r.Context(), repository.FindCommandRevisionParams{
+		CommandID: commandId,
+		Revision:  revision,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Revision "+strconv.Itoa(int(revision))+" not found")
+		} else {
+			s.log(r).Error("Failed to fetch command revision", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command revision")
+		}
+
+		return repository.CommandRevision{}, false
+	}
+
+	return commandRevision, true
+}
+
+func parseRevision(w http.ResponseWriter, value string) (int32, bool) {
+	revision, err := strconv.ParseInt(strings.TrimPrefix(value, "r"), 10, 32)
+	if err != nil || revision < 1 {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid revision")
+		return 0, false
+	}
+
+	return int32(revision), true
+}
+
+// missingFrom returns the names in a that are not in b.
+func missingFrom(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, name := range b {
+		seen[name] = true
+	}
+
+	missing := []string{}
+	for _, name := range a {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
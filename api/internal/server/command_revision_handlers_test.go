@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreCommandChecksVersion(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "restore@example.com")
+	command := insertTestCommand(t, s, user.ID)
+
+	restore := repository.RestoreCommandParams{
+		ID:          command.ID,
+		Command:     "make release",
+		Description: command.Description,
+		Version:     command.Version,
+	}
+
+	restored, err := s.db.RestoreCommand(ctx, restore)
+	require.NoError(t, err)
+	assert.Equal(t, command.Version+1, restored.Version)
+
+	// A second restore read the command before the first one wrote it
+	_, err = s.db.RestoreCommand(ctx, restore)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// newDatabaseTestServer returns a test server backed by the database at
+// TEST_DATABASE_URL, migrated in a schema of its own that is dropped when
+// the test ends. Tests that need it are skipped when the variable is unset.
+func newDatabaseTestServer(t *testing.T, cfg config.AppConfig) *Server {
+	t.Helper()
+
+	databaseUrl := os.Getenv("TEST_DATABASE_URL")
+	if databaseUrl == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	admin, err := pgxpool.New(ctx, databaseUrl)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+
+	_, err = admin.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`)
+	require.NoError(t, err)
+	_, err = admin.Exec(ctx, fmt.Sprintf("CREATE SCHEMA %s", schema))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), fmt.Sprintf("DROP SCHEMA %s CASCADE", schema))
+	})
+
+	poolConfig, err := pgxpool.ParseConfig(databaseUrl)
+	require.NoError(t, err)
+	poolConfig.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+
+	connection, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	require.NoError(t, err)
+	t.Cleanup(connection.Close)
+
+	migrateTestDatabase(t, connection)
+
+	s := newTestServer(cfg)
+	s.db = repository.NewStore(connection)
+	s.conn = connection
+
+	return s
+}
+
+// migrateTestDatabase applies the up part of each migration, in order.
+func migrateTestDatabase(t *testing.T, connection *pgxpool.Pool) {
+	t.Helper()
+
+	files, err := filepath.Glob("../../sql/migrations/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		up := string(content)
+		if _, after, ok := strings.Cut(up, "\n-- +goose Up\n"); ok {
+			up = after
+		} else {
+			up = strings.TrimPrefix(up, "-- +goose Up\n")
+		}
+		up, _, _ = strings.Cut(up, "-- +goose Down")
+
+		_, err = connection.Exec(context.Background(), up)
+		require.NoError(t, err, "applying %s", filepath.Base(file))
+	}
+}
+
+// insertTestUser adds an active user to the database of s.
+func insertTestUser(t *testing.T, s *Server, email string) repository.User {
+	t.Helper()
+
+	user, err := s.db.InsertUser(context.Background(), repository.InsertUserParams{
+		FirstName: "Test",
+		LastName:  "User",
+		Email:     email,
+		IsActive:  pgtype.Bool{Bool: true, Valid: true},
+		Password:  "not a hash",
+	})
+	require.NoError(t, err)
+
+	return user
+}
+
+// insertTestCommand adds a personal command of userId to the database of s.
+func insertTestCommand(t *testing.T, s *Server, userId uuid.UUID) repository.Command {
+	t.Helper()
+
+	command, err := s.db.InsertCommands(context.Background(), repository.InsertCommandsParams{
+		UserID:      userId,
+		Command:     "make deploy",
+		Description: pgtype.Text{String: "Deploys the app", Valid: true},
+	})
+	require.NoError(t, err)
+
+	return command
+}
+
+// insertTestRunbook adds a runbook of userId with a step using commandId.
+func insertTestRunbook(t *testing.T, s *Server, userId, commandId uuid.UUID) repository.Runbook {
+	t.Helper()
+	ctx := context.Background()
+
+	runbook, err := s.db.InsertRunbook(ctx, repository.InsertRunbookParams{
+		UserID: userId,
+		Name:   "deploy",
+	})
+	require.NoError(t, err)
+
+	_, err = s.db.InsertRunbookStep(ctx, repository.InsertRunbookStepParams{
+		RunbookID: runbook.ID,
+		CommandID: pgtype.UUID{Bytes: commandId, Valid: true},
+	})
+	require.NoError(t, err)
+
+	return runbook
+}
+
+// useTestSigningKeys moves the test into a directory of its own holding a
+// fresh key pair in ./test, where auth.GenerateJWT and auth.VerifyJWT read
+// it. It goes after newDatabaseTestServer, which reads the migrations
+// relative to the package directory.
+func useTestSigningKeys(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateKeyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir("test", 0o755))
+	require.NoError(t, os.WriteFile("test/test_private_key.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyDER}), 0o600))
+	require.NoError(t, os.WriteFile("test/test_public_key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0o644))
+}
+
+// testAccessToken returns an access token of user as SignIn issues it.
+func testAccessToken(t *testing.T, user repository.User) string {
+	t.Helper()
+
+	token, err := auth.GenerateJWT(auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "Termflow",
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{"https://example.com"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Role: user.Role,
+	})
+	require.NoError(t, err)
+
+	return token
+}
+
+// serveTestRequest sends a request through the routes of s, with body as
+// JSON unless it is nil and authenticated with token unless it is empty.
+func serveTestRequest(t *testing.T, s *Server, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var requestBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		require.NoError(t, err)
+		requestBody = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, path, requestBody)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(res, req)
+
+	return res
+}
+
+// insertTestWorkspace adds a workspace with each user of members as a
+// member with the role given for them.
+func insertTestWorkspace(t *testing.T, s *Server, members map[uuid.UUID]string) repository.Workspace {
+	t.Helper()
+	ctx := context.Background()
+
+	workspace, err := s.db.InsertWorkspace(ctx, repository.InsertWorkspaceParams{Name: "team"})
+	require.NoError(t, err)
+
+	for userId, role := range members {
+		err := s.db.InsertWorkspaceMember(ctx, repository.InsertWorkspaceMemberParams{
+			WorkspaceID: workspace.ID,
+			UserID:      userId,
+			Role:        role,
+		})
+		require.NoError(t, err)
+	}
+
+	return workspace
+}
+
+// recordingMailer keeps the messages sent through it instead of sending
+// them.
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, message mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// last returns the message sent last, failing the test when none was.
+func (m *recordingMailer) last(t *testing.T) mailer.Message {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	require.NotEmpty(t, m.messages, "no message was sent")
+	return m.messages[len(m.messages)-1]
+}
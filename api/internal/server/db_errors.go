@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// constraintErrors is what violating a unique or check constraint of the
+// schema means to clients.
+var constraintErrors = map[string]utils.Error{
+	"users_email_key":              {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Email already exists"},
+	"tags_user_name_key":           {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Tag name already exists"},
+	"tags_workspace_name_key":      {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Tag name already exists"},
+	"collections_user_id_name_key": {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Collection name already exists"},
+	"collection_commands_pkey":     {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Command is already in the collection"},
+	"runbooks_user_id_name_key":    {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Runbook name already exists"},
+	"runbook_variables_pkey":       {Status: http.StatusBadRequest, Code: utils.CodeInvalidValue, Detail: "Runbook variable names must be unique"},
+	"runbook_steps_check":          {Status: http.StatusBadRequest, Code: utils.CodeInvalidValue, Detail: "Runbook steps need either a command_id or a command"},
+	"workspace_members_pkey":       {Status: http.StatusConflict, Code: utils.CodeAlreadyExists, Detail: "Already a member of the workspace"},
+}
+
+// referencedErrors is what failing to delete a row still referenced
+// through a foreign key means to clients.
+var referencedErrors = map[string]utils.Error{
+	"runbook_steps_command_id_fkey": {Status: http.StatusConflict, Code: utils.CodeStillReferenced, Detail: "Command is still used by a runbook"},
+}
+
+// dbError maps the errors of queries that clients cause, such as a name
+// that is taken or a row that does not exist, to the error to respond with.
+// It returns false for the other errors.
+func dbError(err error) (*utils.Error, bool) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return utils.NewError(http.StatusNotFound, "", "Resource not found"), true
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil, false
+	}
+
+	switch pgErr.Code {
+	case pgerrcode.UniqueViolation, pgerrcode.CheckViolation:
+		if apiErr, ok := constraintErrors[pgErr.ConstraintName]; ok {
+			return &apiErr, true
+		}
+
+		if pgErr.Code == pgerrcode.UniqueViolation {
+			return utils.NewError(http.StatusConflict, utils.CodeAlreadyExists, "Resource already exists"), true
+		}
+
+		return utils.NewError(http.StatusBadRequest, utils.CodeInvalidValue, "Value is not allowed"), true
+	case pgerrcode.ForeignKeyViolation:
+		// Postgres reports the constraint either way, deleting a row that
+		// is still referenced or referencing a row that does not exist
+		if strings.HasPrefix(pgErr.Message, "update or delete on table") {
+			if apiErr, ok := referencedErrors[pgErr.ConstraintName]; ok {
+				return &apiErr, true
+			}
+
+			return utils.NewError(http.StatusConflict, utils.CodeStillReferenced, "Resource is still in use"), true
+		}
+
+		return utils.NewError(http.StatusConflict, utils.CodeReferenceMissing, "Referenced resource does not exist"), true
+	case pgerrcode.NotNullViolation:
+		return utils.NewError(http.StatusBadRequest, utils.CodeInvalidValue, "Value is required"), true
+	case pgerrcode.StringDataRightTruncationDataException:
+		return utils.NewError(http.StatusBadRequest, utils.CodeInvalidValue, "Value is too long"), true
+	}
+
+	return nil, false
+}
+
+// respondDBError responds with what err means to the client when it caused
+// it, and logs err and responds with 500 and msg otherwise.
+func (s *Server) respondDBError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if apiErr, ok := dbError(err); ok {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			s.log(r).Warn("Database constraint violated", slog.String("constraint", pgErr.ConstraintName), slog.String("ERROR", pgErr.Message))
+		}
+
+		utils.ResponseProblem(w, apiErr)
+		return
+	}
+
+	s.log(r).Error(msg, slog.String("ERROR", err.Error()))
+	utils.ResponseError(w, http.StatusInternalServerError, msg)
+}
@@ -0,0 +1,567 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/archive"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// exportPageSize is how many rows an export reads from a table at once.
+	exportPageSize = 500
+
+	// maxImportErrors bounds the errors listed in an import report, the
+	// rest are only counted.
+	maxImportErrors = 100
+)
+
+// ExportAccount streams the tags, commands, collections and share links of
+// the personal library of the user as an archive. The format query
+// parameter picks JSON Lines, the default, or a single JSON document.
+func (s *Server) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := archive.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="termflow-export-%s.%s"`, now.Format("20060102"), format))
+	w.WriteHeader(http.StatusOK)
+
+	writer := archive.NewWriter(w, format, now)
+	if err := s.exportAccount(r.Context(), writer, _userId); err != nil {
+		// The status is sent already, the archive is left incomplete so that
+		// readers notice
+		s.log(r).Error("Failed to export account", slog.String("ERROR", err.Error()))
+	}
+}
+
+func (s *Server) exportAccount(ctx context.Context, writer *archive.Writer, userId uuid.UUID) error {
+	err := exportPages(
+		func(after uuid.UUID) ([]repository.Tag, error) {
+			return s.db.ExportTags(ctx, repository.ExportTagsParams{UserID: userId, ID: after, Limit: exportPageSize})
+		},
+		func(tag repository.Tag) uuid.UUID { return tag.ID },
+		func(tag repository.Tag) error {
+			return writer.Write(archive.Record{Tag: &archive.Tag{
+				ID:          tag.ID.String(),
+				Name:        tag.Name,
+				Description: tag.Description.String,
+			}})
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = exportPages(
+		func(after uuid.UUID) ([]repository.ExportCommandsRow, error) {
+			return s.db.ExportCommands(ctx, repository.ExportCommandsParams{UserID: userId, ID: after, Limit: exportPageSize})
+		},
+		func(command repository.ExportCommandsRow) uuid.UUID { return command.ID },
+		func(command repository.ExportCommandsRow) error {
+			return writer.Write(archive.Record{Command: &archive.Command{
+				ID:          command.ID.String(),
+				Command:     command.Command,
+				Description: command.Description,
+				Tags:        command.Tags,
+			}})
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = exportPages(
+		func(after uuid.UUID) ([]repository.ExportCollectionsRow, error) {
+			return s.db.ExportCollections(ctx, repository.ExportCollectionsParams{UserID: userId, ID: after, Limit: exportPageSize})
+		},
+		func(collection repository.ExportCollectionsRow) uuid.UUID { return collection.ID },
+		func(collection repository.ExportCollectionsRow) error {
+			commandIds := make([]string, 0, len(collection.CommandIds))
+			for _, commandId := range collection.CommandIds {
+				commandIds = append(commandIds, commandId.String())
+			}
+
+			return writer.Write(archive.Record{Collection: &archive.Collection{
+				ID:          collection.ID.String(),
+				Name:        collection.Name,
+				Description: collection.Description.String,
+				Commands:    commandIds,
+			}})
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = exportPages(
+		func(after uuid.UUID) ([]repository.ShareLink, error) {
+			return s.db.ExportShareLinks(ctx, repository.ExportShareLinksParams{UserID: userId, ID: after, Limit: exportPageSize})
+		},
+		func(link repository.ShareLink) uuid.UUID { return link.ID },
+		func(link repository.ShareLink) error {
+			shareLink := &archive.ShareLink{ID: link.ID.String(), Slug: link.Slug}
+			if link.CommandID.Valid {
+				shareLink.CommandID = uuid.UUID(link.CommandID.Bytes).String()
+			}
+			if link.CollectionID.Valid {
+				shareLink.CollectionID = uuid.UUID(link.CollectionID.Bytes).String()
+			}
+			if link.ExpiresAt.Valid {
+				shareLink.ExpiresAt = &link.ExpiresAt.Time
+			}
+
+			return writer.Write(archive.Record{ShareLink: shareLink})
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// exportPages fetches the rows after the last id of the previous page, and
+// writes them, until a page comes back short.
+func exportPages[T any](fetch func(after uuid.UUID) ([]T, error), id func(T) uuid.UUID, write func(T) error) error {
+	var after uuid.UUID
+	for {
+		rows, err := fetch(after)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < exportPageSize {
+			return nil
+		}
+
+		after = id(rows[len(rows)-1])
+	}
+}
+
+type importIssue struct {
+	Position string `json:"position"`
+	Message  string `json:"message"`
+}
+
+// importReport is the outcome of an import. Nothing is applied when it lists
+// errors or when the import is a dry run.
+type importReport struct {
+	DryRun     bool           `json:"dry_run"`
+	Applied    bool           `json:"applied"`
+	Created    map[string]int `json:"created"`
+	Existing   map[string]int `json:"existing"`
+	Skipped    []importIssue  `json:"skipped"`
+	Changed    []importIssue  `json:"changed"`
+	Errors     []importIssue  `json:"errors"`
+	ErrorCount int            `json:"error_count"`
+}
+
+func (report *importReport) addError(position, message string) {
+	report.ErrorCount++
+	if len(report.Errors) < maxImportErrors {
+		report.Errors = append(report.Errors, importIssue{Position: position, Message: message})
+	}
+}
+
+// errImportRolledBack rolls back the transaction of an import that is a dry
+// run or has errors.
+var errImportRolledBack = errors.New("import rolled back")
+
+// ImportAccount reads an archive, as written by ExportAccount, into the
+// personal library of the user. The archive is applied in a single
+// transaction, so either all of it is imported or nothing is. With dry_run
+// the import is carried out and rolled back, to get the report only.
+//
+// Tags and collections that exist with the same name are reused. Share links
+// get a new slug when theirs is taken or is not one the API could have
+// generated, so that slugs stay unguessable.
+func (s *Server) ImportAccount(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	formatName := r.URL.Query().Get("format")
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); formatName == "" && mediaType == "application/json" {
+		formatName = string(archive.JSON)
+	}
+
+	format, err := archive.ParseFormat(formatName)
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid dry_run")
+			return
+		}
+	}
+
+	report := &importReport{
+		DryRun:   dryRun,
+		Created:  map[string]int{},
+		Existing: map[string]int{},
+		Skipped:  []importIssue{},
+		Changed:  []importIssue{},
+		Errors:   []importIssue{},
+	}
+
+	ctx := r.Context()
+	reader := archive.NewReader(r.Body, format)
+
+	err = s.db.ExecTx(ctx, func(q *repository.Queries) error {
+		importer := &accountImporter{
+			ctx:           ctx,
+			q:             q,
+			userId:        _userId,
+			report:        report,
+			commandIds:    map[string]uuid.UUID{},
+			collectionIds: map[string]uuid.UUID{},
+		}
+
+		if err := importer.load(); err != nil {
+			return err
+		}
+
+		for {
+			record, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return err
+			}
+
+			var recordErr *archive.RecordError
+			if errors.As(err, &recordErr) {
+				report.addError(recordErr.Position, recordErr.Err.Error())
+				continue
+			}
+
+			// The archive cannot be read any further
+			if err != nil {
+				report.addError(reader.Position(), err.Error())
+				break
+			}
+
+			if err := importer.apply(reader.Position(), record); err != nil {
+				return err
+			}
+		}
+
+		if report.ErrorCount > 0 || dryRun {
+			return errImportRolledBack
+		}
+
+		return nil
+	})
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		utils.ResponseError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Archive must be at most %d bytes", maxBytesErr.Limit))
+		return
+	}
+
+	if err != nil && err != errImportRolledBack {
+		s.log(r).Error("Failed to import account", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to import account")
+		return
+	}
+
+	if report.ErrorCount > 0 {
+		utils.Response(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	report.Applied = !dryRun
+	if report.Applied {
+		s.metrics.CommandsCreated(report.Created["commands"])
+	}
+	utils.Response(w, http.StatusOK, report)
+}
+
+// accountImporter applies the records of an archive. Records refer to each
+// other by the ids they were exported with, which are mapped to the ids of
+// the rows created for them.
+type accountImporter struct {
+	ctx    context.Context
+	q      *repository.Queries
+	userId uuid.UUID
+	report *importReport
+
+	tagIds        map[string]uuid.UUID
+	collectionIds map[string]uuid.UUID
+	commandIds    map[string]uuid.UUID
+	// collectionNames holds the collections of the user by name.
+	collectionNames map[string]uuid.UUID
+}
+
+func (importer *accountImporter) load() error {
+	tags, err := importer.q.FindTags(importer.ctx, repository.FindTagsParams{UserID: importer.userId})
+	if err != nil {
+		return err
+	}
+
+	importer.tagIds = make(map[string]uuid.UUID, len(tags))
+	for _, tag := range tags {
+		importer.tagIds[tag.Name] = tag.ID
+	}
+
+	collections, err := importer.q.FindCollections(importer.ctx, importer.userId)
+	if err != nil {
+		return err
+	}
+
+	importer.collectionNames = make(map[string]uuid.UUID, len(collections))
+	for _, collection := range collections {
+		importer.collectionNames[collection.Name] = collection.ID
+	}
+
+	return nil
+}
+
+// apply imports a single record. Problems with the record go to the report,
+// the error returned is a database error that ends the import.
+func (importer *accountImporter) apply(position string, record archive.Record) error {
+	switch {
+	case record.Tag != nil:
+		_, err := importer.tag(record.Tag.Name, record.Tag.Description)
+		return err
+	case record.Command != nil:
+		return importer.command(position, record.Command)
+	case record.Collection != nil:
+		return importer.collection(position, record.Collection)
+	default:
+		return importer.shareLink(position, record.ShareLink)
+	}
+}
+
+// tag returns the id of the tag with name, creating it when needed.
+func (importer *accountImporter) tag(name, description string) (uuid.UUID, error) {
+	if tagId, exists := importer.tagIds[name]; exists {
+		importer.report.Existing["tags"]++
+		return tagId, nil
+	}
+
+	tag, err := importer.q.InsertTag(importer.ctx, repository.InsertTagParams{
+		UserID:      importer.userId,
+		Name:        name,
+		Description: pgtype.Text{String: description, Valid: true},
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	importer.tagIds[name] = tag.ID
+	importer.report.Created["tags"]++
+
+	return tag.ID, nil
+}
+
+func (importer *accountImporter) command(position string, command *archive.Command) error {
+	if _, exists := importer.commandIds[command.ID]; exists {
+		importer.report.addError(position, "duplicate command id "+command.ID)
+		return nil
+	}
+
+	inserted, err := importer.q.InsertCommands(importer.ctx, repository.InsertCommandsParams{
+		UserID:      importer.userId,
+		Command:     command.Command,
+		Description: pgtype.Text{String: command.Description, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	attached := map[string]bool{}
+	for _, name := range command.Tags {
+		if attached[name] {
+			continue
+		}
+		attached[name] = true
+
+		// Tags of a command that were not exported on their own are created
+		tagId, exists := importer.tagIds[name]
+		if !exists {
+			if tagId, err = importer.tag(name, ""); err != nil {
+				return err
+			}
+		}
+
+		_, err := importer.q.AttachCommandToTag(importer.ctx, repository.AttachCommandToTagParams{
+			CommandID: inserted.ID,
+			TagID:     tagId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = importer.q.InsertCommandRevision(importer.ctx, repository.InsertCommandRevisionParams{
+		AuthorID:  pgtype.UUID{Bytes: importer.userId, Valid: true},
+		CommandID: inserted.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	importer.commandIds[command.ID] = inserted.ID
+	importer.report.Created["commands"]++
+
+	return nil
+}
+
+func (importer *accountImporter) collection(position string, collection *archive.Collection) error {
+	if _, exists := importer.collectionIds[collection.ID]; exists {
+		importer.report.addError(position, "duplicate collection id "+collection.ID)
+		return nil
+	}
+
+	commandIds := make([]uuid.UUID, 0, len(collection.Commands))
+	added := map[uuid.UUID]bool{}
+	for _, id := range collection.Commands {
+		commandId, exists := importer.commandIds[id]
+		if !exists {
+			importer.report.addError(position, "collection refers to unknown command "+id)
+			return nil
+		}
+
+		if !added[commandId] {
+			added[commandId] = true
+			commandIds = append(commandIds, commandId)
+		}
+	}
+
+	collectionId, exists := importer.collectionNames[collection.Name]
+	if exists {
+		importer.report.Existing["collections"]++
+	} else {
+		inserted, err := importer.q.InsertCollection(importer.ctx, repository.InsertCollectionParams{
+			UserID:      importer.userId,
+			Name:        collection.Name,
+			Description: pgtype.Text{String: collection.Description, Valid: collection.Description != ""},
+		})
+		if err != nil {
+			return err
+		}
+
+		collectionId = inserted.ID
+		importer.collectionNames[collection.Name] = collectionId
+		importer.report.Created["collections"]++
+	}
+
+	for _, commandId := range commandIds {
+		_, err := importer.q.AddCommandToCollection(importer.ctx, repository.AddCommandToCollectionParams{
+			CollectionID: collectionId,
+			CommandID:    commandId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	importer.collectionIds[collection.ID] = collectionId
+
+	return nil
+}
+
+func (importer *accountImporter) shareLink(position string, link *archive.ShareLink) error {
+	params := repository.InsertShareLinkParams{
+		UserID: importer.userId,
+	}
+
+	if link.CommandID != "" {
+		commandId, exists := importer.commandIds[link.CommandID]
+		if !exists {
+			importer.report.addError(position, "share link refers to unknown command "+link.CommandID)
+			return nil
+		}
+
+		params.CommandID = pgtype.UUID{Bytes: commandId, Valid: true}
+	} else {
+		collectionId, exists := importer.collectionIds[link.CollectionID]
+		if !exists {
+			importer.report.addError(position, "share link refers to unknown collection "+link.CollectionID)
+			return nil
+		}
+
+		params.CollectionID = pgtype.UUID{Bytes: collectionId, Valid: true}
+	}
+
+	if link.ExpiresAt != nil {
+		if link.ExpiresAt.Before(time.Now()) {
+			importer.report.Skipped = append(importer.report.Skipped, importIssue{Position: position, Message: "share link " + link.Slug + " has expired"})
+			return nil
+		}
+
+		params.ExpiresAt = pgtype.Timestamptz{Time: *link.ExpiresAt, Valid: true}
+	}
+
+	slug, err := importer.shareSlug(link.Slug)
+	if err != nil {
+		return err
+	}
+	if slug != link.Slug {
+		importer.report.Changed = append(importer.report.Changed, importIssue{Position: position, Message: "share link " + link.Slug + " is now " + slug})
+	}
+	params.Slug = slug
+
+	if _, err := importer.q.InsertShareLink(importer.ctx, params); err != nil {
+		return err
+	}
+
+	importer.report.Created["share_links"]++
+
+	return nil
+}
+
+// shareSlug returns slug when it could have been generated by the API and is
+// free, and a new slug otherwise. Slugs are global, the slug may belong to
+// another share link.
+func (importer *accountImporter) shareSlug(slug string) (string, error) {
+	if isShareSlug(slug) {
+		_, err := importer.q.FindShareLinkBySlug(importer.ctx, slug)
+		if err == pgx.ErrNoRows {
+			return slug, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return auth.GenerateToken(shareSlugSize)
+}
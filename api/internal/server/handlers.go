@@ -1,28 +1,126 @@
 package server
 
 import (
-	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/endalk200/termflow-api/pkgs/middleware"
 	"github.com/endalk200/termflow-api/pkgs/utils"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 func (s *Server) DecodeAndValidate(w http.ResponseWriter, r *http.Request, payload interface{}) error {
-	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
-		utils.ResponseError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := s.decodeJSON(w, r, payload); err != nil {
 		return err
 	}
 
-	if customErrors, err := utils.ValidateAndFormatErrors(payload); err != nil {
-		s.logger.Error("Error during request payload validation", slog.String("ERROR", err.Error()))
-
-		utils.Response(w, http.StatusBadRequest, customErrors)
-		return err
+	if problem := utils.ValidationError(Validate.Struct(payload)); problem != nil {
+		s.log(r).Warn("Invalid request payload", slog.Any("fields", problem.Fields))
+		utils.ResponseProblem(w, problem)
+		return problem
 	}
 
 	return nil
 }
 
+// log returns the logger of the request, which carries its id and the
+// authenticated user.
+func (s *Server) log(r *http.Request) *slog.Logger {
+	return middleware.LoggerFromContext(r.Context(), s.logger)
+}
+
+// decodeJSON decodes the request body into payload, responding with what
+// is wrong with it when it can not.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, payload interface{}) error {
+	err := utils.DecodeJSON(r.Body, payload)
+	if err == nil {
+		return nil
+	}
+
+	var decodeErr *utils.DecodeError
+	switch {
+	case errors.As(err, &decodeErr) && decodeErr.Status == http.StatusRequestEntityTooLarge:
+		utils.ResponseErrorCode(w, decodeErr.Status, utils.CodeBodyTooLarge, decodeErr.Message)
+	case errors.As(err, &decodeErr):
+		utils.ResponseErrorCode(w, decodeErr.Status, utils.CodeInvalidBody, decodeErr.Message)
+	default:
+		utils.ResponseErrorCode(w, http.StatusBadRequest, utils.CodeInvalidBody, "Invalid request payload")
+	}
+
+	return err
+}
+
 var Validate *validator.Validate
+
+// messageResponsePayloadSchema is the response of the routes that only
+// report what they did.
+type messageResponsePayloadSchema struct {
+	Message string `json:"message"`
+}
+
+// authenticatedUserId returns the id of the authenticated user, responding
+// with an error when it is missing or malformed.
+func (s *Server) authenticatedUserId(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		s.log(r).Error("userId not found in request context")
+		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
+		return uuid.UUID{}, false
+	}
+
+	_userId, err := uuid.Parse(userID)
+	if err != nil {
+		s.log(r).Error("Failed to parse userId from request context", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to parse auth")
+		return uuid.UUID{}, false
+	}
+
+	return _userId, true
+}
+
+// checkIfMatch compares the If-Match header of a write with the version the
+// resource has now. A missing header is accepted unless RequireIfMatch is
+// set. On a mismatch it responds with 412 and the current representation so
+// that the client can merge.
+func (s *Server) checkIfMatch(w http.ResponseWriter, r *http.Request, version int32, current interface{}) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		if s.cfg.RequireIfMatch {
+			utils.ResponseError(w, http.StatusPreconditionRequired, "If-Match header is required")
+			return false
+		}
+
+		return true
+	}
+
+	if !utils.MatchesETag(ifMatch, version) {
+		respondPreconditionFailed(w, version, current)
+		return false
+	}
+
+	return true
+}
+
+// notModified responds with 304 when the If-None-Match header of a read
+// matches version, and sets the ETag header otherwise.
+func notModified(w http.ResponseWriter, r *http.Request, version int32) bool {
+	w.Header().Set("ETag", utils.ETag(version))
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && utils.MatchesETag(ifNoneMatch, version) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+func respondPreconditionFailed(w http.ResponseWriter, version int32, current interface{}) {
+	w.Header().Set("ETag", utils.ETag(version))
+
+	problem := utils.NewError(http.StatusPreconditionFailed, utils.CodeVersionMismatch, "The resource has been modified since it was read")
+	problem.Extensions = map[string]interface{}{"current": current}
+
+	utils.ResponseProblem(w, problem)
+}
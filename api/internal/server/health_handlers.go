@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// readinessTimeout bounds the checks of Readyz, so that a hanging database
+// fails the probe instead of timing it out.
+const readinessTimeout = 2 * time.Second
+
+type healthResponsePayloadSchema struct {
+	Status string `json:"status"`
+}
+
+// readinessResponsePayloadSchema reports the outcome of each check by name.
+type readinessResponsePayloadSchema struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Healthz reports that the process is up and serving requests.
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	utils.Response(w, http.StatusOK, healthResponsePayloadSchema{Status: "ok"})
+}
+
+// Readyz reports whether the server can take traffic: the database is
+// reachable and migrated to the latest migration this build knows of.
+// It responds 503 along with the failing checks otherwise.
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{"database": "ok", "migrations": "ok"}
+	ready := true
+
+	if err := s.conn.Ping(ctx); err != nil {
+		s.log(r).Error("Readiness check failed to reach the database", slog.String("ERROR", err.Error()))
+		checks["database"] = "unreachable"
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if version, err := s.migrationVersion(ctx); err != nil {
+		s.log(r).Error("Readiness check failed to read the migration version", slog.String("ERROR", err.Error()))
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if version != s.latestMigration {
+		checks["migrations"] = fmt.Sprintf("at version %d, expected %d", version, s.latestMigration)
+		ready = false
+	}
+
+	status, statusCode := "ok", http.StatusOK
+	if !ready {
+		status, statusCode = "unavailable", http.StatusServiceUnavailable
+	}
+
+	utils.Response(w, statusCode, readinessResponsePayloadSchema{Status: status, Checks: checks})
+}
+
+// migrationVersion returns the last migration goose applied, zero when the
+// database was never migrated.
+func (s *Server) migrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.conn.QueryRow(ctx, `
+		SELECT COALESCE(MAX(version_id), 0)::bigint
+		FROM (
+			SELECT DISTINCT ON (version_id) version_id, is_applied
+			FROM goose_db_version
+			ORDER BY version_id, id DESC
+		) latest
+		WHERE is_applied`).Scan(&version)
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UndefinedTable {
+		return 0, nil
+	}
+
+	return version, err
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// postgresIdempotencyStore keeps the responses of idempotent requests in
+// the idempotency_keys table.
+type postgresIdempotencyStore struct {
+	db  *repository.Queries
+	ttl time.Duration
+}
+
+func (s *Server) idempotencyStore() *postgresIdempotencyStore {
+	return &postgresIdempotencyStore{
+		db:  s.db.Queries,
+		ttl: time.Duration(s.cfg.IdempotencyKeyTTLHours) * time.Hour,
+	}
+}
+
+func (store *postgresIdempotencyStore) Reserve(ctx context.Context, userId, key, requestHash string, leaseUntil time.Time) (bool, error) {
+	_userId, err := uuid.Parse(userId)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := store.db.ReserveIdempotencyKey(ctx, repository.ReserveIdempotencyKeyParams{
+		UserID:         _userId,
+		Key:            key,
+		RequestHash:    requestHash,
+		CreatedAt:      store.cutoff(),
+		LeaseExpiresAt: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+	})
+
+	return rows > 0, err
+}
+
+func (store *postgresIdempotencyStore) Find(ctx context.Context, userId, key string) (middleware.IdempotentResponse, bool, error) {
+	_userId, err := uuid.Parse(userId)
+	if err != nil {
+		return middleware.IdempotentResponse{}, false, err
+	}
+
+	idempotencyKey, err := store.db.FindIdempotencyKey(ctx, repository.FindIdempotencyKeyParams{
+		UserID:    _userId,
+		Key:       key,
+		CreatedAt: store.cutoff(),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return middleware.IdempotentResponse{}, false, nil
+		}
+
+		return middleware.IdempotentResponse{}, false, err
+	}
+
+	response := middleware.IdempotentResponse{
+		RequestHash: idempotencyKey.RequestHash,
+		StatusCode:  int(idempotencyKey.StatusCode.Int32),
+		Body:        idempotencyKey.ResponseBody,
+	}
+
+	if idempotencyKey.ResponseHeaders != nil {
+		if err := json.Unmarshal(idempotencyKey.ResponseHeaders, &response.Header); err != nil {
+			return middleware.IdempotentResponse{}, false, err
+		}
+	}
+
+	return response, true, nil
+}
+
+func (store *postgresIdempotencyStore) Complete(ctx context.Context, userId, key string, response middleware.IdempotentResponse) error {
+	_userId, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	return store.db.CompleteIdempotencyKey(ctx, repository.CompleteIdempotencyKeyParams{
+		UserID:          _userId,
+		Key:             key,
+		StatusCode:      pgtype.Int4{Int32: int32(response.StatusCode), Valid: true},
+		ResponseHeaders: headers,
+		ResponseBody:    response.Body,
+	})
+}
+
+func (store *postgresIdempotencyStore) Release(ctx context.Context, userId, key string) error {
+	_userId, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+
+	return store.db.DeleteIdempotencyKey(ctx, repository.DeleteIdempotencyKeyParams{
+		UserID: _userId,
+		Key:    key,
+	})
+}
+
+// cutoff is the creation time before which keys have expired.
+func (store *postgresIdempotencyStore) cutoff() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().Add(-store.ttl), Valid: true}
+}
+
+// runIdempotencyKeyPurger removes expired idempotency keys, along with the
+// expired usage syncs, every hour until ctx is done.
+func (s *Server) runIdempotencyKeyPurger(ctx context.Context) {
+	store := s.idempotencyStore()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.db.DeleteExpiredIdempotencyKeys(ctx, store.cutoff()); err != nil {
+			s.logger.Error("Failed to purge expired idempotency keys", slog.String("ERROR", err.Error()))
+		}
+		s.purgeExpiredUsageSyncs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyStoreLeases(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{IdempotencyKeyTTLHours: 24})
+	ctx := context.Background()
+	store := s.idempotencyStore()
+
+	userId := insertTestUser(t, s, "idempotency@example.com").ID.String()
+
+	reserved, err := store.Reserve(ctx, userId, "leased", "hash", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	reserved, err = store.Reserve(ctx, userId, "leased", "hash", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, reserved, "a key should stay reserved while its lease runs")
+
+	// Left in progress by a request that never finished
+	reserved, err = store.Reserve(ctx, userId, "crashed", "hash", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	reserved, err = store.Reserve(ctx, userId, "crashed", "hash", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, reserved, "a key whose lease ran out should be reserved again")
+
+	// Completed keys are kept until they expire, whatever their lease
+	reserved, err = store.Reserve(ctx, userId, "completed", "hash", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NoError(t, store.Complete(ctx, userId, "completed", middleware.IdempotentResponse{StatusCode: 201}))
+
+	reserved, err = store.Reserve(ctx, userId, "completed", "hash", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, reserved)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/netip"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/joho/godotenv"
+)
+
+// ipAccessLists parses the allow and deny lists of cfg.
+func ipAccessLists(cfg config.AppConfig) (allow, deny []netip.Prefix, err error) {
+	allow, err = middleware.ParsePrefixes(cfg.IPAllowList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deny, err = middleware.ParsePrefixes(cfg.IPDenyList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allow, deny, nil
+}
+
+// runIPAccessReloader reloads the IP allow and deny lists on SIGHUP until
+// ctx is done. Invalid lists are logged and the previous ones kept.
+func (s *Server) runIPAccessReloader(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			s.reloadIPAccess()
+		}
+	}
+}
+
+// reloadIPAccess reads IP_ALLOW_LIST and IP_DENY_LIST from the .env file
+// again. Only lists that came from .env at startup, or were not set at all,
+// are reloaded: those set in the environment of the process take precedence
+// over .env and can not change until a restart. The environment and the
+// rest of the configuration are left as they are.
+func (s *Server) reloadIPAccess() {
+	env, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.logger.Error("Failed to read .env", slog.String("ERROR", err.Error()))
+		return
+	}
+
+	lookup := func(name string) string {
+		if value, ok := os.LookupEnv(name); ok && !s.envFileVariables[name] {
+			return value
+		}
+
+		return env[name]
+	}
+
+	cfg := config.AppConfig{
+		IPAllowList: lookup("IP_ALLOW_LIST"),
+		IPDenyList:  lookup("IP_DENY_LIST"),
+	}
+
+	allow, deny, err := ipAccessLists(cfg)
+	if err != nil {
+		s.logger.Error("Invalid IP access lists, keeping the previous ones", slog.String("ERROR", err.Error()))
+		return
+	}
+
+	s.ipFilter.Update(allow, deny)
+	s.logger.Info("Reloaded IP access lists", slog.Int("allow", len(allow)), slog.Int("deny", len(deny)))
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadIPAccess(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// IP_ALLOW_LIST is set in the environment, IP_DENY_LIST was loaded
+	// from .env at startup
+	t.Setenv("IP_ALLOW_LIST", "192.168.0.0/16,10.0.0.0/8")
+	t.Setenv("IP_DENY_LIST", "10.0.0.1/32")
+	require.NoError(t, os.WriteFile(".env", []byte("IP_ALLOW_LIST=10.0.0.0/8\nIP_DENY_LIST=10.0.0.2/32\n"), 0o644))
+
+	s := newTestServer(config.AppConfig{})
+	s.envFileVariables = map[string]bool{"IP_DENY_LIST": true}
+	s.reloadIPAccess()
+
+	assert.True(t, s.ipFilter.Allowed(netip.MustParseAddr("192.168.1.1")), "the environment takes precedence over .env")
+	assert.True(t, s.ipFilter.Allowed(netip.MustParseAddr("10.0.0.1")), "the deny list is read again from .env")
+	assert.False(t, s.ipFilter.Allowed(netip.MustParseAddr("10.0.0.2")))
+}
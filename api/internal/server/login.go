@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/lockout"
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/endalk200/termflow-api/pkgs/metrics"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const auditAccountLocked = "account.locked"
+
+func (s *Server) loginPolicy() lockout.Policy {
+	return lockout.Policy{
+		FreeAttempts:    s.cfg.LoginFreeAttempts,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Duration(s.cfg.LoginMaxDelaySeconds) * time.Second,
+		LockoutAttempts: s.cfg.LoginLockoutAttempts,
+		LockoutDuration: time.Duration(s.cfg.LoginLockoutMinutes) * time.Minute,
+	}
+}
+
+// reserveLoginAttempt counts the attempt as failed before the password is
+// checked and decides how long the next one has to wait, in one transaction.
+// Concurrent attempts are counted one after the other, so each of them waits
+// for the failures before it. It responds and returns false while the user
+// has to wait. The password is not checked until then, so that waiting can
+// not be skipped by guessing right.
+func (s *Server) reserveLoginAttempt(w http.ResponseWriter, r *http.Request, user repository.User) (repository.LoginFailure, bool) {
+	ctx := r.Context()
+	now := time.Now()
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
+		return repository.LoginFailure{}, false
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	failure, err := queriesWithTx.ReserveLoginAttempt(ctx, repository.ReserveLoginAttemptParams{
+		UserID:      user.ID,
+		ResetBefore: pgtype.Timestamptz{Time: now.Add(-time.Duration(s.cfg.LoginFailureResetHours) * time.Hour), Valid: true},
+	})
+	if err == pgx.ErrNoRows {
+		failure, err = queriesWithTx.FindLoginFailure(ctx, user.ID)
+		if err == nil {
+			_, locked := s.loginPolicy().Wait(int(failure.FailedCount))
+			s.metrics.SignIn(loginWaitOutcome(locked))
+			respondLoginWait(w, failure.LockedUntil.Time, locked)
+			return repository.LoginFailure{}, false
+		}
+	}
+	if err != nil {
+		s.log(r).Error("Failed to record login attempt", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
+		return repository.LoginFailure{}, false
+	}
+
+	wait, _ := s.loginPolicy().Wait(int(failure.FailedCount))
+	failure.LockedUntil = pgtype.Timestamptz{Time: now.Add(wait), Valid: wait > 0}
+
+	err = queriesWithTx.SetLoginLockedUntil(ctx, repository.SetLoginLockedUntilParams{
+		UserID:      user.ID,
+		LockedUntil: failure.LockedUntil,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to delay the next login", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
+		return repository.LoginFailure{}, false
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong while trying to log you in")
+		return repository.LoginFailure{}, false
+	}
+
+	return failure, true
+}
+
+// recordLoginFailure responds to a wrong password, whose attempt failure
+// was reserved for, locking the account and telling the user about it once
+// there were too many.
+func (s *Server) recordLoginFailure(w http.ResponseWriter, r *http.Request, user repository.User, failure repository.LoginFailure) {
+	ctx := r.Context()
+
+	wait, locked := s.loginPolicy().Wait(int(failure.FailedCount))
+	if wait <= 0 {
+		s.metrics.SignIn(metrics.SignInInvalidCredentials)
+		utils.ResponseErrorCode(w, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
+		return
+	}
+
+	lockedUntil := failure.LockedUntil.Time
+	if !locked {
+		s.metrics.SignIn(metrics.SignInInvalidCredentials)
+		w.Header().Set("Retry-After", retryAfter(lockedUntil))
+		utils.ResponseErrorCode(w, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
+		return
+	}
+
+	s.log(r).Warn("Account locked after failed sign-ins", slog.String("user_id", user.ID.String()), slog.Int("failures", int(failure.FailedCount)))
+	s.recordAuditEvent(r, user.ID, auditAccountLocked)
+	s.metrics.SignIn(metrics.SignInLocked)
+
+	err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account was locked",
+		Body: fmt.Sprintf("Hi %s,\n\nThere were %d failed attempts to sign in to your Termflow account, it is locked until %s. If these were not you, change your password once you can sign in again.\n",
+			user.FirstName, failure.FailedCount, lockedUntil.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		s.log(r).Error("Failed to send lockout notification", slog.String("ERROR", err.Error()))
+	}
+
+	respondLoginWait(w, lockedUntil, true)
+}
+
+// loginWaitOutcome is the sign-in outcome counted for a request turned
+// away while the user has to wait.
+func loginWaitOutcome(locked bool) string {
+	if locked {
+		return metrics.SignInLocked
+	}
+
+	return metrics.SignInThrottled
+}
+
+func respondLoginWait(w http.ResponseWriter, until time.Time, locked bool) {
+	w.Header().Set("Retry-After", retryAfter(until))
+
+	if locked {
+		utils.ResponseErrorCode(w, http.StatusLocked, utils.CodeAccountLocked, "Account locked after too many failed sign-in attempts, try again later")
+		return
+	}
+
+	utils.ResponseErrorCode(w, http.StatusTooManyRequests, utils.CodeLoginThrottled, "Too many failed sign-in attempts, try again later")
+}
+
+// retryAfter formats the seconds left until t for a Retry-After header.
+func retryAfter(t time.Time) string {
+	return strconv.Itoa(int(math.Ceil(time.Until(t).Seconds())))
+}
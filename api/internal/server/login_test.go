@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveLoginAttemptConcurrently(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{
+		LoginFreeAttempts:      1,
+		LoginMaxDelaySeconds:   30,
+		LoginFailureResetHours: 24,
+	})
+	user := insertTestUser(t, s, "guesser@example.com")
+
+	// The first attempt is free and the second one makes the next wait, so
+	// only two of the concurrent attempts may check a password
+	const attempts = 5
+	var wg sync.WaitGroup
+	results := make(chan int, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/auth/signin", nil)
+			if _, ok := s.reserveLoginAttempt(w, r, user); ok {
+				results <- http.StatusOK
+				return
+			}
+			results <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	codes := map[int]int{}
+	for code := range results {
+		codes[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 2, http.StatusTooManyRequests: attempts - 2}, codes)
+
+	failure, err := s.db.FindLoginFailure(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), failure.FailedCount, "attempts turned away are not counted")
+	assert.True(t, failure.LockedUntil.Valid)
+}
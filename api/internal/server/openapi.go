@@ -0,0 +1,359 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/openapi"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+)
+
+// apiVersion is the version of the API the OpenAPI document describes.
+const apiVersion = "1.0.0"
+
+// problemSchema documents the problem details utils.ResponseProblem writes.
+// Some problems carry extra members, such as current with 412.
+type problemSchema struct {
+	Type      string             `json:"type"`
+	Title     string             `json:"title"`
+	Status    int                `json:"status"`
+	Code      string             `json:"code"`
+	Detail    string             `json:"detail"`
+	RequestID string             `json:"request_id,omitempty"`
+	Errors    []utils.FieldError `json:"errors,omitempty"`
+}
+
+var openAPITags = []openapi.Tag{
+	{Name: "auth", Description: "Signing up and in, the profile and preferences of the user"},
+	{Name: "account", Description: "Exporting, importing and deleting the account"},
+	{Name: "tags"},
+	{Name: "commands"},
+	{Name: "collections"},
+	{Name: "runbooks"},
+	{Name: "trash", Description: "Deleted commands and tags, until they are purged"},
+	{Name: "workspaces", Description: "Shared libraries of tags and commands and their members"},
+	{Name: "shares", Description: "Public, read-only links to commands and collections"},
+	{Name: "admin"},
+	{Name: "system", Description: "Probes, metrics and this document"},
+}
+
+// Bounds of the numeric parameters.
+var (
+	firstRevision     = 1.0
+	noOffset          = 0.0
+	maxIdempotencyKey = 255
+)
+
+// openAPIPathParams are the schemas of the path parameters of the routes,
+// the ids are UUIDs.
+var openAPIPathParams = map[string]*openapi.Schema{
+	"id":           {Type: "string", Format: "uuid"},
+	"commandId":    {Type: "string", Format: "uuid"},
+	"workspaceId":  {Type: "string", Format: "uuid"},
+	"userId":       {Type: "string", Format: "uuid"},
+	"invitationId": {Type: "string", Format: "uuid"},
+	"revision":     {Type: "integer", Format: "int32", Minimum: &firstRevision},
+	"slug":         {Type: "string"},
+}
+
+// openAPIDocument describes the routes of RegisterRoutes. Every route has
+// to be listed by openAPIRoutes, TestOpenAPIMatchesRoutes fails otherwise.
+func (s *Server) openAPIDocument() *openapi.Document {
+	return openapi.Builder{
+		Info: openapi.Info{
+			Title:       "Termflow API",
+			Version:     apiVersion,
+			Description: "Errors are RFC 7807 problem details, their code tells them apart.",
+		},
+		Tags:       openAPITags,
+		Problem:    problemSchema{},
+		PathParams: openAPIPathParams,
+	}.Build(s.openAPIRoutes())
+}
+
+var (
+	ifMatchParam = openapi.Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag of the version the change is based on",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	ifNoneMatchParam = openapi.Parameter{
+		Name:        "If-None-Match",
+		In:          "header",
+		Description: "ETag of the version the client has, 304 when it is current",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	idempotencyKeyParam = openapi.Parameter{
+		Name:        middleware.IdempotencyKeyHeader,
+		In:          "header",
+		Description: "Makes the request safe to retry, retries get the response of the first request",
+		Schema:      &openapi.Schema{Type: "string", MaxLength: &maxIdempotencyKey},
+	}
+	archiveFormatParam = openapi.Parameter{
+		Name:        "format",
+		In:          "query",
+		Description: "Format of the archive, JSON Lines by default",
+		Schema:      &openapi.Schema{Type: "string", Enum: []interface{}{"jsonl", "json"}},
+	}
+)
+
+// Statuses of the problems the routes with an If-Match header respond with.
+var versionedErrors = []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired}
+
+func (s *Server) openAPIRoutes() []openapi.Route {
+	routes := []openapi.Route{
+		{Method: http.MethodGet, Path: "/healthz", ID: "healthz", Summary: "Report that the process is up", Tag: "system", Public: true, Response: healthResponsePayloadSchema{}},
+		{Method: http.MethodGet, Path: "/readyz", ID: "readyz", Summary: "Report whether the server can take traffic", Tag: "system", Public: true, Response: readinessResponsePayloadSchema{},
+			Alternatives: map[int]interface{}{http.StatusServiceUnavailable: readinessResponsePayloadSchema{}}},
+		{Method: http.MethodGet, Path: "/api/openapi.json", ID: "getOpenAPI", Summary: "Get this document", Tag: "system", Public: true, Response: json.RawMessage{}},
+		{Method: http.MethodGet, Path: "/api/docs", ID: "getDocs", Summary: "Browse this document", Tag: "system", Public: true, Response: "", ContentType: "text/html"},
+
+		{Method: http.MethodPost, Path: "/api/auth/signup", ID: "signUp", Summary: "Create an account", Tag: "auth", Public: true,
+			Request: signUpRequestPayloadSchema{}, Response: userResponsePayloadSchema{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodPost, Path: "/api/auth/signin", ID: "signIn", Summary: "Sign in with an email and password", Tag: "auth", Public: true,
+			Request: signInRequestPayloadSchema{}, Response: signInResponsePayloadSchema{}, Errors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusLocked}},
+		{Method: http.MethodPost, Path: "/api/auth/email/confirm", ID: "confirmEmailChange", Summary: "Confirm a new email address", Tag: "auth", Public: true,
+			Request: confirmEmailChangeRequestPayloadSchema{}, Response: emailChangeResponsePayloadSchema{}, Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusGone}},
+		{Method: http.MethodGet, Path: "/api/shared/{slug}", ID: "getShared", Summary: "View a shared command or collection", Tag: "shares", Public: true,
+			Response: sharedResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+
+		{Method: http.MethodGet, Path: "/api/auth/me", ID: "getMe", Summary: "Get the authenticated user", Tag: "auth", Response: meResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPatch, Path: "/api/auth/me", ID: "updateMe", Summary: "Update the profile", Tag: "auth",
+			Request: updateProfileRequestPayloadSchema{}, Response: profileResponsePayloadSchema{}},
+		{Method: http.MethodPost, Path: "/api/auth/me/email", ID: "requestEmailChange", Summary: "Change the email address, once confirmed", Tag: "auth",
+			Request: emailChangeRequestPayloadSchema{}, Response: emailChangeResponsePayloadSchema{}, Status: http.StatusAccepted, Errors: []int{http.StatusForbidden, http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/api/auth/me/preferences", ID: "getPreferences", Summary: "Get the preferences", Tag: "auth", Response: preferencesResponsePayloadSchema{}},
+		{Method: http.MethodPut, Path: "/api/auth/me/preferences", ID: "updatePreferences", Summary: "Replace the preferences", Tag: "auth",
+			Request: preferencesRequestPayloadSchema{}, Response: preferencesResponsePayloadSchema{}},
+
+		{Method: http.MethodGet, Path: "/api/export", ID: "exportAccount", Summary: "Export the personal library as an archive", Tag: "account",
+			Params: []openapi.Parameter{archiveFormatParam}, Response: json.RawMessage{}, ContentType: "application/x-ndjson"},
+		{Method: http.MethodPost, Path: "/api/import", ID: "importAccount", Summary: "Import an archive into the personal library", Tag: "account",
+			Params:  []openapi.Parameter{archiveFormatParam, {Name: "dry_run", In: "query", Description: "Report what would be imported without applying it", Schema: &openapi.Schema{Type: "boolean"}}},
+			Request: json.RawMessage{}, RequestContentType: "application/x-ndjson", Response: importReport{},
+			Alternatives: map[int]interface{}{http.StatusUnprocessableEntity: importReport{}}, Errors: []int{http.StatusRequestEntityTooLarge}},
+		{Method: http.MethodGet, Path: "/api/account/export", ID: "exportAccountData", Summary: "Export everything stored about the user as a zip file", Tag: "account",
+			Response: []byte{}, ContentType: "application/zip"},
+		{Method: http.MethodDelete, Path: "/api/account", ID: "deleteAccount", Summary: "Delete the account, after a grace period when there is one", Tag: "account",
+			Request: deleteAccountRequestPayloadSchema{}, Response: messageResponsePayloadSchema{},
+			Alternatives: map[int]interface{}{http.StatusAccepted: accountDeletionResponsePayloadSchema{}}, Errors: []int{http.StatusForbidden, http.StatusConflict}},
+		{Method: http.MethodDelete, Path: "/api/account/deletion", ID: "cancelAccountDeletion", Summary: "Cancel the scheduled deletion of the account", Tag: "account",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+
+		{Method: http.MethodGet, Path: "/api/commands/usage", ID: "getCommandUsage", Summary: "List the usage of the commands", Tag: "commands", Response: []repository.CommandUsage{}},
+		{Method: http.MethodPost, Path: "/api/commands/usage", ID: "recordCommandUsage", Summary: "Add usage counts to commands", Tag: "commands",
+			Request: recordCommandUsageRequestPayloadSchema{}, Response: []repository.CommandUsage{}, Errors: []int{http.StatusNotFound}},
+
+		{Method: http.MethodGet, Path: "/api/collections", ID: "getCollections", Summary: "List the collections", Tag: "collections", Response: []repository.Collection{}},
+		{Method: http.MethodPost, Path: "/api/collections", ID: "createCollection", Summary: "Create a collection", Tag: "collections",
+			Request: createCollectionRequestPayloadSchema{}, Response: repository.Collection{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/api/collections/{id}", ID: "getCollection", Summary: "Get a collection with its commands", Tag: "collections",
+			Response: collectionResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/api/collections/{id}", ID: "updateCollection", Summary: "Update a collection", Tag: "collections",
+			Request: updateCollectionRequestPayloadSchema{}, Response: repository.Collection{}, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodDelete, Path: "/api/collections/{id}", ID: "deleteCollection", Summary: "Delete a collection", Tag: "collections",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/api/collections/{id}/commands", ID: "addCollectionCommand", Summary: "Add a command to a collection", Tag: "collections",
+			Request: addCollectionCommandRequestPayloadSchema{}, Response: repository.CollectionCommand{}, Status: http.StatusCreated, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodPut, Path: "/api/collections/{id}/commands", ID: "reorderCollectionCommands", Summary: "Reorder the commands of a collection", Tag: "collections",
+			Request: reorderCollectionCommandsRequestPayloadSchema{}, Response: []repository.FindCollectionCommandsRow{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: "/api/collections/{id}/commands/{commandId}", ID: "removeCollectionCommand", Summary: "Remove a command from a collection", Tag: "collections",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/api/collections/{id}/share", ID: "shareCollection", Summary: "Create a share link to a collection", Tag: "shares",
+			Request: shareRequestPayloadSchema{}, OptionalRequest: true, Response: shareLinkResponsePayloadSchema{}, Status: http.StatusCreated, Errors: []int{http.StatusNotFound}},
+
+		{Method: http.MethodGet, Path: "/api/runbooks", ID: "getRunbooks", Summary: "List the runbooks", Tag: "runbooks", Response: []repository.Runbook{}},
+		{Method: http.MethodPost, Path: "/api/runbooks", ID: "createRunbook", Summary: "Create a runbook", Tag: "runbooks",
+			Request: runbookRequestPayloadSchema{}, Response: runbookResponsePayloadSchema{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/api/runbooks/{id}", ID: "getRunbook", Summary: "Get a runbook with its steps and variables", Tag: "runbooks",
+			Response: runbookResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/api/runbooks/{id}", ID: "replaceRunbook", Summary: "Replace a runbook", Tag: "runbooks",
+			Request: runbookRequestPayloadSchema{}, Response: runbookResponsePayloadSchema{}, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodDelete, Path: "/api/runbooks/{id}", ID: "deleteRunbook", Summary: "Delete a runbook", Tag: "runbooks",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+
+		{Method: http.MethodGet, Path: "/api/workspaces", ID: "getWorkspaces", Summary: "List the workspaces of the user", Tag: "workspaces", Response: []repository.FindWorkspacesByUserRow{}},
+		{Method: http.MethodPost, Path: "/api/workspaces", ID: "createWorkspace", Summary: "Create a workspace owned by the user", Tag: "workspaces",
+			Request: workspaceRequestPayloadSchema{}, Response: repository.Workspace{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/api/invitations/accept", ID: "acceptWorkspaceInvitation", Summary: "Join a workspace with an invitation", Tag: "workspaces",
+			Request: acceptInvitationRequestPayloadSchema{}, Response: acceptInvitationResponsePayloadSchema{}, Errors: []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusGone}},
+	}
+
+	if s.cfg.MetricsPort == 0 {
+		routes = append(routes, openapi.Route{Method: http.MethodGet, Path: "/metrics", ID: "metrics", Summary: "Get the Prometheus metrics", Tag: "system", Public: true, Response: "", ContentType: "text/plain"})
+	}
+
+	routes = append(routes, tagOpenAPIRoutes("/api/tags", "")...)
+	routes = append(routes, commandOpenAPIRoutes("/api/commands", "")...)
+	routes = append(routes, trashOpenAPIRoutes("/api/trash", "")...)
+	routes = append(routes, shareOpenAPIRoutes("/api/shares", "")...)
+	routes = append(routes, workspaceOpenAPIRoutes()...)
+	routes = append(routes, adminOpenAPIRoutes()...)
+
+	for i := range routes {
+		addCommonErrors(&routes[i])
+	}
+
+	return routes
+}
+
+// tagOpenAPIRoutes, commandOpenAPIRoutes, trashOpenAPIRoutes and
+// shareOpenAPIRoutes document tagRoutes, commandRoutes, trashRoutes and
+// shareRoutes mounted at prefix. The operation ids of each mount are told
+// apart by scope.
+func tagOpenAPIRoutes(prefix, scope string) []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: prefix, ID: "get" + scope + "Tags", Summary: "List the tags", Tag: "tags", Response: []repository.Tag{}},
+		{Method: http.MethodPost, Path: prefix, ID: "create" + scope + "Tag", Summary: "Create a tag", Tag: "tags",
+			Request: createTagRequestPayloadSchema{}, Response: repository.Tag{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: prefix + "/{id}", ID: "get" + scope + "Tag", Summary: "Get a tag", Tag: "tags",
+			Params: []openapi.Parameter{ifNoneMatchParam}, Response: repository.Tag{}, Alternatives: map[int]interface{}{http.StatusNotModified: nil}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: prefix + "/{id}", ID: "update" + scope + "Tag", Summary: "Update a tag", Tag: "tags",
+			Params: []openapi.Parameter{ifMatchParam}, Request: updateTagRequestPayloadSchema{}, Response: repository.Tag{}, Status: http.StatusCreated, Errors: append([]int{http.StatusConflict}, versionedErrors...)},
+		{Method: http.MethodDelete, Path: prefix + "/{id}", ID: "delete" + scope + "Tag", Summary: "Move a tag to the trash", Tag: "tags",
+			Params: []openapi.Parameter{ifMatchParam}, Response: messageResponsePayloadSchema{}, Status: http.StatusCreated, Errors: versionedErrors},
+		{Method: http.MethodGet, Path: prefix + "/{id}/commands", ID: "get" + scope + "CommandsWithTag", Summary: "List the commands with a tag", Tag: "tags",
+			Response: []commandWithTagResponsePayloadSchema{}},
+	}
+}
+
+func commandOpenAPIRoutes(prefix, scope string) []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: prefix, ID: "get" + scope + "Commands", Summary: "List the commands with their tags", Tag: "commands", Response: []commandWithTagResponsePayloadSchema{}},
+		{Method: http.MethodPost, Path: prefix, ID: "create" + scope + "Command", Summary: "Create a command", Tag: "commands",
+			Request: createCommandsRequestPayloadSchema{}, Response: createCommandResponsePayloadSchema{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: prefix + "/{id}", ID: "get" + scope + "Command", Summary: "Get a command with its tags", Tag: "commands",
+			Params: []openapi.Parameter{ifNoneMatchParam}, Response: commandDetailsResponsePayloadSchema{}, Alternatives: map[int]interface{}{http.StatusNotModified: nil}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: prefix + "/{id}", ID: "update" + scope + "Command", Summary: "Update a command", Tag: "commands",
+			Params: []openapi.Parameter{ifMatchParam}, Request: updateCommandRequestPayloadSchema{}, Response: repository.Command{}, Errors: append([]int{http.StatusConflict}, versionedErrors...)},
+		{Method: http.MethodDelete, Path: prefix + "/{id}", ID: "delete" + scope + "Command", Summary: "Move a command to the trash", Tag: "commands",
+			Params: []openapi.Parameter{ifMatchParam}, Response: messageResponsePayloadSchema{}, Errors: append([]int{http.StatusConflict}, versionedErrors...)},
+		{Method: http.MethodPost, Path: prefix + "/{id}/share", ID: "share" + scope + "Command", Summary: "Create a share link to a command", Tag: "shares",
+			Request: shareRequestPayloadSchema{}, OptionalRequest: true, Response: shareLinkResponsePayloadSchema{}, Status: http.StatusCreated, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: prefix + "/{id}/revisions", ID: "get" + scope + "CommandRevisions", Summary: "List the revisions of a command", Tag: "commands",
+			Response: []commandRevisionResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: prefix + "/{id}/revisions/{revision}/diff", ID: "get" + scope + "CommandRevisionDiff", Summary: "Compare a revision of a command with another", Tag: "commands",
+			Params:   []openapi.Parameter{{Name: "against", In: "query", Description: "Revision to compare with, the previous one by default", Schema: &openapi.Schema{Type: "integer", Format: "int32"}}},
+			Response: commandRevisionDiffResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: prefix + "/{id}/restore/{revision}", ID: "restore" + scope + "CommandRevision", Summary: "Restore a command to a revision", Tag: "commands",
+			Params: []openapi.Parameter{ifMatchParam}, Response: restoreCommandRevisionResponsePayloadSchema{}, Errors: versionedErrors},
+	}
+}
+
+func trashOpenAPIRoutes(prefix, scope string) []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: prefix, ID: "get" + scope + "Trash", Summary: "List the trashed commands and tags", Tag: "trash", Response: trashResponsePayloadSchema{}},
+		{Method: http.MethodDelete, Path: prefix, ID: "empty" + scope + "Trash", Summary: "Purge everything in the trash", Tag: "trash", Response: emptyTrashResponsePayloadSchema{}},
+		{Method: http.MethodPost, Path: prefix + "/commands/{id}/restore", ID: "restore" + scope + "TrashedCommand", Summary: "Restore a command from the trash", Tag: "trash",
+			Response: commandRowSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: prefix + "/commands/{id}", ID: "purge" + scope + "TrashedCommand", Summary: "Purge a command from the trash", Tag: "trash",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: prefix + "/tags/{id}/restore", ID: "restore" + scope + "TrashedTag", Summary: "Restore a tag from the trash", Tag: "trash",
+			Response: restoredTagResponsePayloadSchema{}, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodDelete, Path: prefix + "/tags/{id}", ID: "purge" + scope + "TrashedTag", Summary: "Purge a tag from the trash", Tag: "trash",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+	}
+}
+
+func shareOpenAPIRoutes(prefix, scope string) []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: prefix, ID: "get" + scope + "ShareLinks", Summary: "List the share links", Tag: "shares", Response: []shareLinkResponsePayloadSchema{}},
+		{Method: http.MethodDelete, Path: prefix + "/{id}", ID: "revoke" + scope + "ShareLink", Summary: "Revoke a share link", Tag: "shares",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+	}
+}
+
+func workspaceOpenAPIRoutes() []openapi.Route {
+	const prefix = "/api/workspaces/{workspaceId}"
+
+	routes := []openapi.Route{
+		{Method: http.MethodGet, Path: prefix, ID: "getWorkspace", Summary: "Get a workspace with its members", Tag: "workspaces", Response: workspaceResponsePayloadSchema{}},
+		{Method: http.MethodPut, Path: prefix, ID: "updateWorkspace", Summary: "Rename a workspace", Tag: "workspaces",
+			Request: workspaceRequestPayloadSchema{}, Response: repository.Workspace{}},
+		{Method: http.MethodDelete, Path: prefix, ID: "deleteWorkspace", Summary: "Delete a workspace with its commands and tags", Tag: "workspaces", Response: messageResponsePayloadSchema{}},
+		{Method: http.MethodPut, Path: prefix + "/members/{userId}", ID: "updateWorkspaceMember", Summary: "Change the role of a member", Tag: "workspaces",
+			Request: workspaceMemberRequestPayloadSchema{}, Response: repository.WorkspaceMember{}, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodDelete, Path: prefix + "/members/{userId}", ID: "removeWorkspaceMember", Summary: "Remove a member, or leave the workspace", Tag: "workspaces",
+			Response: messageResponsePayloadSchema{}, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: prefix + "/invitations", ID: "getWorkspaceInvitations", Summary: "List the pending invitations", Tag: "workspaces", Response: []invitationResponsePayloadSchema{}},
+		{Method: http.MethodPost, Path: prefix + "/invitations", ID: "createWorkspaceInvitation", Summary: "Invite an email address to the workspace", Tag: "workspaces",
+			Request: workspaceInvitationRequestPayloadSchema{}, Response: invitationResponsePayloadSchema{}, Status: http.StatusCreated},
+		{Method: http.MethodDelete, Path: prefix + "/invitations/{invitationId}", ID: "deleteWorkspaceInvitation", Summary: "Withdraw an invitation", Tag: "workspaces", Response: messageResponsePayloadSchema{}},
+	}
+	routes = append(routes, tagOpenAPIRoutes(prefix+"/tags", "Workspace")...)
+	routes = append(routes, commandOpenAPIRoutes(prefix+"/commands", "Workspace")...)
+	routes = append(routes, trashOpenAPIRoutes(prefix+"/trash", "Workspace")...)
+	routes = append(routes, shareOpenAPIRoutes(prefix+"/shares", "Workspace")...)
+
+	// Members of other workspaces are told the workspace is not found
+	for i := range routes {
+		routes[i].Errors = append(routes[i].Errors, http.StatusForbidden, http.StatusNotFound)
+	}
+
+	return routes
+}
+
+func adminOpenAPIRoutes() []openapi.Route {
+	// Users are found by id or by email address
+	userParam := openapi.Parameter{Name: "id", In: "path", Description: "Id or email address of the user", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	userParams := []openapi.Parameter{userParam}
+
+	routes := []openapi.Route{
+		{Method: http.MethodGet, Path: "/api/admin/usage", ID: "adminGetUsage", Summary: "Get the totals of what users store", Tag: "admin", Response: repository.GetUsageTotalsRow{}},
+		{Method: http.MethodGet, Path: "/api/admin/users", ID: "adminListUsers", Summary: "List users", Tag: "admin",
+			Params: []openapi.Parameter{
+				{Name: "search", In: "query", Description: "Part of the name or email address", Schema: &openapi.Schema{Type: "string"}},
+				{Name: "is_active", In: "query", Schema: &openapi.Schema{Type: "boolean"}},
+				{Name: "is_email_verified", In: "query", Schema: &openapi.Schema{Type: "boolean"}},
+				{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: &noOffset}},
+				{Name: "offset", In: "query", Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: &noOffset}},
+			},
+			Response: []adminUserResponsePayloadSchema{}},
+		{Method: http.MethodGet, Path: "/api/admin/users/{id}", ID: "adminGetUser", Summary: "Get a user with their usage", Tag: "admin",
+			Params: userParams, Response: adminUserDetailsResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/api/admin/users/{id}/deactivate", ID: "adminDeactivateUser", Summary: "Deactivate a user and sign them out", Tag: "admin",
+			Params: userParams, Response: adminUserResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/api/admin/users/{id}/reactivate", ID: "adminReactivateUser", Summary: "Reactivate a user", Tag: "admin",
+			Params: userParams, Response: adminUserResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/api/admin/users/{id}/logout", ID: "adminLogoutUser", Summary: "Sign a user out of every session", Tag: "admin",
+			Params: userParams, Response: adminUserResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/api/admin/users/{id}/role", ID: "adminSetUserRole", Summary: "Change the role of a user", Tag: "admin",
+			Params: userParams, Request: adminRoleRequestPayloadSchema{}, Response: adminUserResponsePayloadSchema{}, Errors: []int{http.StatusNotFound}},
+	}
+
+	for i := range routes {
+		routes[i].Errors = append(routes[i].Errors, http.StatusForbidden)
+	}
+
+	return routes
+}
+
+// addCommonErrors adds the problems the middlewares respond with: invalid
+// bodies, missing tokens and rate limits. It also documents the
+// Idempotency-Key header of authenticated POST routes.
+func addCommonErrors(route *openapi.Route) {
+	route.Errors = append(append([]int(nil), route.Errors...), http.StatusInternalServerError)
+
+	if !strings.HasPrefix(route.Path, "/api/") {
+		return
+	}
+	route.Errors = append(route.Errors, http.StatusTooManyRequests)
+
+	if route.Request != nil {
+		route.Errors = append(route.Errors, http.StatusBadRequest, http.StatusRequestEntityTooLarge)
+	}
+
+	if route.Public {
+		return
+	}
+	route.Errors = append(route.Errors, http.StatusUnauthorized)
+
+	if route.Method == http.MethodPost {
+		route.Params = append(route.Params, idempotencyKeyParam)
+		route.Errors = append(route.Errors, http.StatusUnprocessableEntity)
+	}
+}
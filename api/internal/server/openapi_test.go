@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/metrics"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer returns a server without a database, enough to register
+// the routes and serve those that do not query it.
+func newTestServer(cfg config.AppConfig) *Server {
+	return &Server{
+		cfg:        cfg,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:         repository.NewStore(nil),
+		metrics:    metrics.New(nil),
+		rateLimits: middleware.NewMemoryRateLimitStore(),
+		clientIPs:  middleware.NewClientIPResolver(nil, middleware.ProxyHeaderXForwardedFor),
+		ipFilter:   middleware.NewIPFilter(nil, nil),
+	}
+}
+
+var routeParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// registeredOperations lists the method and path of each route of handler,
+// as in openapi.Document.Operations. Routes mounted at / of a sub router
+// are served without the trailing slash too, the document lists them
+// without it.
+func registeredOperations(t *testing.T, handler http.Handler) []string {
+	t.Helper()
+
+	var operations []string
+	err := chi.Walk(handler.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// The assets of the docs UI are not operations of the API
+		if strings.HasSuffix(route, "/*") {
+			return nil
+		}
+
+		route = routeParamPattern.ReplaceAllString(route, "{$1}")
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		operations = append(operations, method+" "+route)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	sort.Strings(operations)
+
+	return operations
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	for name, cfg := range map[string]config.AppConfig{
+		"metrics on the API port":   {},
+		"metrics on their own port": {MetricsPort: 9090},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newTestServer(cfg)
+
+			registered := registeredOperations(t, s.RegisterRoutes())
+			documented := s.openAPIDocument().Operations()
+
+			assert.Equal(t, registered, documented, "routes and the OpenAPI document diverge, update openAPIRoutes")
+		})
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	handler := newTestServer(config.AppConfig{RateLimitPublicPerMinute: 100}).RegisterRoutes()
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/api/workspaces/{workspaceId}/commands/{id}")
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/docs/", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `data-spec="/api/openapi.json"`)
+}
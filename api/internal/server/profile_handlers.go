@@ -0,0 +1,385 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const auditEmailChanged = "account.email_changed"
+
+type updateProfileRequestPayloadSchema struct {
+	FirstName *string `json:"firstName" validate:"omitempty,min=1,max=255"`
+	LastName  *string `json:"lastName" validate:"omitempty,min=1,max=255"`
+}
+
+type profileResponsePayloadSchema struct {
+	userResponsePayloadSchema
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// UpdateMe updates the profile fields that are sent, the email address is
+// changed with RequestEmailChange instead.
+func (s *Server) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload updateProfileRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: _userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	params := repository.UpdateUserParams{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+	if requestPayload.FirstName != nil {
+		params.FirstName = strings.TrimSpace(*requestPayload.FirstName)
+	}
+	if requestPayload.LastName != nil {
+		params.LastName = strings.TrimSpace(*requestPayload.LastName)
+	}
+
+	if params.FirstName == "" || params.LastName == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "First and last name can not be empty")
+		return
+	}
+
+	user, err = s.db.UpdateUser(ctx, params)
+	if err != nil {
+		s.log(r).Error("Failed to update user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	responsePayload := profileResponsePayloadSchema{
+		userResponsePayloadSchema: userPayload(user),
+		CreatedAt:                 user.CreatedAt,
+		UpdatedAt:                 user.UpdatedAt,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type emailChangeRequestPayloadSchema struct {
+	// Email is validated once trimmed
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type emailChangeResponsePayloadSchema struct {
+	Message string `json:"message"`
+	Email   string `json:"email"`
+	// ExpiresAt is only set while the change waits for confirmation.
+	ExpiresAt *pgtype.Timestamptz `json:"expires_at,omitempty"`
+}
+
+// RequestEmailChange sends a confirmation token to the new address. The
+// email of the user only changes once ConfirmEmailChange receives it, a new
+// request replaces the pending one.
+func (s *Server) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload emailChangeRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	email := strings.TrimSpace(requestPayload.Email)
+	if err := Validate.Var(email, "required,email"); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid email address")
+		return
+	}
+
+	ctx := r.Context()
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: _userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	isMatch, err := auth.CompareHash(requestPayload.Password, user.Password, auth.Bcrypt)
+	if err != nil {
+		s.log(r).Error("Error during password hash comparison", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+	if !isMatch {
+		utils.ResponseError(w, http.StatusForbidden, "Invalid password")
+		return
+	}
+
+	if strings.EqualFold(email, user.Email) {
+		utils.ResponseError(w, http.StatusBadRequest, "That is already your email address")
+		return
+	}
+
+	_, err = s.db.GetUser(ctx, repository.GetUserParams{Email: email})
+	if err == nil {
+		utils.ResponseError(w, http.StatusConflict, "Email already exists")
+		return
+	} else if err != pgx.ErrNoRows {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		s.log(r).Error("Failed to generate email change token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	tokenHash, err := auth.HashPassword(token, auth.SHA256)
+	if err != nil {
+		s.log(r).Error("Failed to hash email change token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	emailChange, err := s.db.UpsertEmailChange(ctx, repository.UpsertEmailChangeParams{
+		UserID:    _userId,
+		NewEmail:  email,
+		TokenHash: tokenHash,
+		ExpiresAt: pgtype.Timestamptz{
+			Time:  time.Now().Add(time.Duration(s.cfg.EmailChangeTTLHours) * time.Hour),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		s.log(r).Error("Failed to record email change", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm %s as the new email address of your Termflow account with this code:\n\n%s\n", user.FirstName, email, token)
+	if s.cfg.PublicURL != "" {
+		body += fmt.Sprintf("\nor by opening %s/confirm-email?token=%s\n", strings.TrimRight(s.cfg.PublicURL, "/"), url.QueryEscape(token))
+	}
+	body += fmt.Sprintf("\nThe code expires on %s. If you did not ask for this, ignore this email.\n", emailChange.ExpiresAt.Time.UTC().Format(time.RFC1123))
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body:    body,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to send email change confirmation", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to send the confirmation email")
+		return
+	}
+
+	responsePayload := emailChangeResponsePayloadSchema{
+		Message:   "A confirmation code was sent to the new email address",
+		Email:     emailChange.NewEmail,
+		ExpiresAt: &emailChange.ExpiresAt,
+	}
+
+	utils.Response(w, http.StatusAccepted, responsePayload)
+}
+
+type confirmEmailChangeRequestPayloadSchema struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChange switches the email of the user to the address the
+// token was sent to. It does not need authentication, the token proves who
+// the user is. The previous address is told about the change.
+func (s *Server) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var requestPayload confirmEmailChangeRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	tokenHash, err := auth.HashPassword(requestPayload.Token, auth.SHA256)
+	if err != nil {
+		s.log(r).Error("Failed to hash email change token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to confirm email")
+		return
+	}
+
+	ctx := r.Context()
+	emailChange, err := s.db.FindEmailChangeByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Email change not found")
+		} else {
+			s.log(r).Error("Failed to fetch email change", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to confirm email")
+		}
+
+		return
+	}
+
+	if emailChange.ExpiresAt.Time.Before(time.Now()) {
+		utils.ResponseError(w, http.StatusGone, "Email change expired")
+		return
+	}
+
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: emailChange.UserID})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to confirm email")
+		return
+	}
+
+	err = s.db.ExecTx(ctx, func(q *repository.Queries) error {
+		err := q.ChangeUserEmail(ctx, repository.ChangeUserEmailParams{
+			ID:    emailChange.UserID,
+			Email: emailChange.NewEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteEmailChange(ctx, emailChange.UserID)
+	})
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to confirm email")
+		return
+	}
+
+	s.recordAuditEvent(r, emailChange.UserID, auditEmailChanged)
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nThe email address of your Termflow account was changed to %s. If you did not do this, contact support right away.\n", user.FirstName, emailChange.NewEmail),
+	})
+	if err != nil {
+		s.log(r).Error("Failed to notify the previous email address", slog.String("ERROR", err.Error()))
+	}
+
+	responsePayload := emailChangeResponsePayloadSchema{
+		Message: "Email address changed",
+		Email:   emailChange.NewEmail,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// pickerThemes are the themes of the CLI command picker.
+var pickerThemes = []string{"base", "base16", "catppuccin", "charm", "dracula"}
+
+type preferencesRequestPayloadSchema struct {
+	DefaultTags       []string `json:"default_tags"`
+	PickerTheme       string   `json:"picker_theme"`
+	DangerousPatterns []string `json:"dangerous_patterns"`
+}
+
+type preferencesResponsePayloadSchema struct {
+	DefaultTags       []string           `json:"default_tags"`
+	PickerTheme       string             `json:"picker_theme"`
+	DangerousPatterns []string           `json:"dangerous_patterns"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
+func preferencesPayload(preferences repository.UserPreference) preferencesResponsePayloadSchema {
+	return preferencesResponsePayloadSchema{
+		DefaultTags:       orEmpty(preferences.DefaultTags),
+		PickerTheme:       preferences.PickerTheme,
+		DangerousPatterns: orEmpty(preferences.DangerousPatterns),
+		UpdatedAt:         preferences.UpdatedAt,
+	}
+}
+
+// GetPreferences responds with the preferences of the user, empty when they
+// never set any.
+func (s *Server) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := s.db.FindUserPreferences(r.Context(), _userId)
+	if err != nil && err != pgx.ErrNoRows {
+		s.log(r).Error("Failed to fetch preferences", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch preferences")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, preferencesPayload(preferences))
+}
+
+// UpdatePreferences replaces the preferences of the user. Dangerous patterns
+// are case insensitive regular expressions, as the CLI matches them. The CLI
+// adds them to its built-in patterns when pulling preferences, so they do not
+// need to repeat those.
+func (s *Server) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload preferencesRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	defaultTags := make([]string, 0, len(requestPayload.DefaultTags))
+	for _, tag := range requestPayload.DefaultTags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			utils.ResponseError(w, http.StatusBadRequest, "Default tags can not be empty")
+			return
+		}
+
+		defaultTags = append(defaultTags, tag)
+	}
+
+	if requestPayload.PickerTheme != "" && !slices.Contains(pickerThemes, requestPayload.PickerTheme) {
+		utils.ResponseError(w, http.StatusBadRequest, fmt.Sprintf("Unknown picker theme %q, expected one of %s", requestPayload.PickerTheme, strings.Join(pickerThemes, ", ")))
+		return
+	}
+
+	for _, pattern := range requestPayload.DangerousPatterns {
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil || pattern == "" {
+			utils.ResponseError(w, http.StatusBadRequest, fmt.Sprintf("Invalid dangerous pattern %q", pattern))
+			return
+		}
+	}
+
+	preferences, err := s.db.UpsertUserPreferences(r.Context(), repository.UpsertUserPreferencesParams{
+		UserID:            _userId,
+		DefaultTags:       defaultTags,
+		PickerTheme:       requestPayload.PickerTheme,
+		DangerousPatterns: orEmpty(requestPayload.DangerousPatterns),
+	})
+	if err != nil {
+		s.log(r).Error("Failed to update preferences", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update preferences")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, preferencesPayload(preferences))
+}
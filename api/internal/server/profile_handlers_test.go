@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newProfileTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	s := newDatabaseTestServer(t, config.AppConfig{MaxBodyBytes: 1 << 20, EmailChangeTTLHours: 1})
+	useTestSigningKeys(t)
+
+	return s
+}
+
+func TestUpdateMe(t *testing.T) {
+	s := newProfileTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+	token := testAccessToken(t, user)
+
+	firstName := "  Ada "
+	res := serveTestRequest(t, s, http.MethodPatch, "/api/auth/me", token, updateProfileRequestPayloadSchema{FirstName: &firstName})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var profile profileResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &profile))
+	assert.Equal(t, "Ada", profile.FirstName)
+	assert.Equal(t, user.LastName, profile.LastName, "fields that are not sent are kept")
+	assert.Equal(t, user.Email, profile.Email)
+
+	empty := " "
+	res = serveTestRequest(t, s, http.MethodPatch, "/api/auth/me", token, updateProfileRequestPayloadSchema{LastName: &empty})
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	// The email is only changed once the new address is confirmed
+	res = serveTestRequest(t, s, http.MethodPatch, "/api/auth/me", token, map[string]string{"email": "other@example.com"})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &profile))
+	assert.Equal(t, user.Email, profile.Email)
+}
+
+func TestEmailChangeIsConfirmedBeforeSwitching(t *testing.T) {
+	s := newProfileTestServer(t)
+	ctx := context.Background()
+	mail := &recordingMailer{}
+	s.mailer = mail
+
+	password, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := insertTestUser(t, s, "user@example.com")
+	_, err = s.conn.Exec(ctx, "UPDATE users SET password = $1 WHERE id = $2", string(password), user.ID)
+	require.NoError(t, err)
+	insertTestUser(t, s, "taken@example.com")
+	token := testAccessToken(t, user)
+
+	for _, test := range []struct {
+		payload emailChangeRequestPayloadSchema
+		code    int
+	}{
+		{emailChangeRequestPayloadSchema{Email: "new@example.com", Password: "wrong"}, http.StatusForbidden},
+		{emailChangeRequestPayloadSchema{Email: "not an email", Password: "password"}, http.StatusBadRequest},
+		{emailChangeRequestPayloadSchema{Email: "User@example.com", Password: "password"}, http.StatusBadRequest},
+		{emailChangeRequestPayloadSchema{Email: "taken@example.com", Password: "password"}, http.StatusConflict},
+	} {
+		res := serveTestRequest(t, s, http.MethodPost, "/api/auth/me/email", token, test.payload)
+		assert.Equal(t, test.code, res.Code, "%+v: %s", test.payload, res.Body.String())
+	}
+
+	res := serveTestRequest(t, s, http.MethodPost, "/api/auth/me/email", token, emailChangeRequestPayloadSchema{Email: " new@example.com ", Password: "password"})
+	require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+
+	message := mail.last(t)
+	assert.Equal(t, "new@example.com", message.To)
+	_, code, _ := strings.Cut(message.Body, "code:\n\n")
+	code, _, _ = strings.Cut(code, "\n")
+	require.NotEmpty(t, code)
+
+	// Nothing changes until the new address is confirmed
+	current, err := s.db.GetUser(ctx, repository.GetUserParams{ID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", current.Email)
+	_, err = s.db.GetUser(ctx, repository.GetUserParams{Email: "new@example.com"})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/auth/email/confirm", "", confirmEmailChangeRequestPayloadSchema{Token: "not-" + code})
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/auth/email/confirm", "", confirmEmailChangeRequestPayloadSchema{Token: code})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	notice := mail.last(t)
+	assert.Equal(t, "user@example.com", notice.To, "the previous address is told about the change")
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/auth/me", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var me meResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &me))
+	assert.Equal(t, "new@example.com", me.Email)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/auth/email/confirm", "", confirmEmailChangeRequestPayloadSchema{Token: code})
+	assert.Equal(t, http.StatusNotFound, res.Code, "a code confirms one change")
+}
+
+func TestPreferences(t *testing.T) {
+	s := newProfileTestServer(t)
+	user := insertTestUser(t, s, "user@example.com")
+	token := testAccessToken(t, user)
+
+	readPreferences := func(body []byte) preferencesResponsePayloadSchema {
+		t.Helper()
+		var preferences preferencesResponsePayloadSchema
+		require.NoError(t, json.Unmarshal(body, &preferences))
+		return preferences
+	}
+
+	res := serveTestRequest(t, s, http.MethodGet, "/api/auth/me/preferences", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, preferencesResponsePayloadSchema{DefaultTags: []string{}, DangerousPatterns: []string{}}, readPreferences(res.Body.Bytes()))
+
+	for _, payload := range []preferencesRequestPayloadSchema{
+		{DefaultTags: []string{" "}},
+		{PickerTheme: "neon"},
+		{DangerousPatterns: []string{"rm -rf ("}},
+		{DangerousPatterns: []string{""}},
+	} {
+		res := serveTestRequest(t, s, http.MethodPut, "/api/auth/me/preferences", token, payload)
+		assert.Equal(t, http.StatusBadRequest, res.Code, "%+v", payload)
+	}
+
+	res = serveTestRequest(t, s, http.MethodPut, "/api/auth/me/preferences", token, preferencesRequestPayloadSchema{
+		DefaultTags:       []string{" ops "},
+		PickerTheme:       "dracula",
+		DangerousPatterns: []string{`kubectl\s+delete`},
+	})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/auth/me/preferences", token, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	preferences := readPreferences(res.Body.Bytes())
+	assert.Equal(t, []string{"ops"}, preferences.DefaultTags)
+	assert.Equal(t, "dracula", preferences.PickerTheme)
+	assert.Equal(t, []string{`kubectl\s+delete`}, preferences.DangerousPatterns)
+	assert.True(t, preferences.UpdatedAt.Valid)
+}
@@ -2,43 +2,254 @@ package server
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/endalk200/termflow-api/internal/admin"
 	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/openapi"
+	"github.com/endalk200/termflow-api/pkgs/tracing"
+	"github.com/endalk200/termflow-api/pkgs/utils"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
 )
 
+// streamTimeouts are the routes streaming large bodies, which have longer
+// than requestTimeout to be read and answered.
+func (s *Server) streamTimeouts() map[string]time.Duration {
+	timeout := time.Duration(s.cfg.StreamTimeoutSeconds) * time.Second
+
+	return map[string]time.Duration{
+		"GET /api/export":         timeout,
+		"POST /api/import":        timeout,
+		"GET /api/account/export": timeout,
+	}
+}
+
 func (s *Server) RegisterRoutes() http.Handler {
-	Validate = validator.New(validator.WithRequiredStructEnabled())
+	Validate = utils.NewValidator()
 
 	r := chi.NewRouter()
+	r.Use(s.metrics.Middleware)
+	r.Use(middleware.RequestID(s.logger))
+	r.Use(middleware.ClientIP(s.clientIPs))
+	r.Use(middleware.Tracing(tracing.Tracer()))
 	r.Use(middleware.Logging(s.logger))
+	r.Use(middleware.SecurityHeaders(time.Duration(s.cfg.HstsMaxAgeSeconds) * time.Second))
+	r.Use(middleware.IPAccess(s.ipFilter, s.logger))
+	r.Use(middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   splitList(s.cfg.CorsAllowedOrigins),
+		AllowedMethods:   splitList(s.cfg.CorsAllowedMethods),
+		AllowedHeaders:   splitList(s.cfg.CorsAllowedHeaders),
+		ExposedHeaders:   corsExposedHeaders,
+		AllowCredentials: s.cfg.CorsAllowCredentials,
+		MaxAge:           time.Duration(s.cfg.CorsMaxAgeSeconds) * time.Second,
+	}))
+	r.Use(middleware.MaxBodySize(int64(s.cfg.MaxBodyBytes), map[string]int64{
+		"POST /api/import": int64(s.cfg.MaxImportBodyBytes),
+	}))
+	r.Use(middleware.RouteDeadlines(s.streamTimeouts()))
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.ResponseError(w, http.StatusNotFound, "Route not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		utils.ResponseError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+
+	r.Get("/healthz", s.Healthz)
+	r.Get("/readyz", s.Readyz)
+
+	if s.cfg.MetricsPort == 0 {
+		r.Method(http.MethodGet, "/metrics", s.metrics.Handler())
+	}
 
 	r.Route("/api", func(r chi.Router) {
-		r.Post("/auth/signup", s.CreateUser)
-		r.Post("/auth/signin", s.SignIn)
+		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimit("auth", s.cfg.RateLimitAuthPerMinute))
+			r.Post("/auth/signup", s.CreateUser)
+			r.Post("/auth/signin", s.SignIn)
+			r.Post("/auth/email/confirm", s.ConfirmEmailChange)
+		})
+
+		r.With(s.rateLimit("shared", s.cfg.RateLimitPublicPerMinute)).Get("/shared/{slug}", s.GetShared)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimit("docs", s.cfg.RateLimitPublicPerMinute))
+
+			doc := s.openAPIDocument()
+			r.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+				utils.Response(w, http.StatusOK, doc)
+			})
+
+			docs := openapi.DocsHandler("/api/docs", doc.Info.Title, "/api/openapi.json", s.logger)
+			r.Method(http.MethodGet, "/docs", docs)
+			r.Method(http.MethodGet, "/docs/*", docs)
+		})
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.Authentication(s.logger))
+			r.Use(middleware.Authentication(s.logger, s.sessionChecker()))
+			r.Use(s.rateLimit("api", s.cfg.RateLimitUserPerMinute))
+			r.Use(middleware.Idempotency(s.idempotencyStore(), time.Duration(s.cfg.IdempotencyWaitSeconds)*time.Second, requestTimeout, s.streamTimeouts(), s.logger))
 			r.Get("/auth/me", s.Me)
+			r.Patch("/auth/me", s.UpdateMe)
+			r.Post("/auth/me/email", s.RequestEmailChange)
+			r.Get("/auth/me/preferences", s.GetPreferences)
+			r.Put("/auth/me/preferences", s.UpdatePreferences)
 
-			r.Route("/tags", func(r chi.Router) {
-				r.Get("/", s.GetTags)
-				r.Post("/", s.CreateTag)
-				r.Put("/{id}", s.UpdateTag)
-				r.Delete("/{id}", s.DeleteTag)
+			r.Get("/export", s.ExportAccount)
+			r.Post("/import", s.ImportAccount)
 
-				r.Get("/{id}/commands", s.GetCommandsWithTag)
+			r.Route("/account", func(r chi.Router) {
+				r.Get("/export", s.ExportAccountData)
+				r.Delete("/", s.DeleteAccount)
+				r.Delete("/deletion", s.CancelAccountDeletion)
 			})
 
+			r.Route("/tags", s.tagRoutes)
+			r.Route("/trash", s.trashRoutes)
+
 			r.Route("/commands", func(r chi.Router) {
-				r.Get("/", s.GetCommands)
-				r.Post("/", s.CreateCommand)
-				r.Put("/{id}", s.UpdateCommand)
-				r.Delete("/{id}", s.DeleteCommand)
+				s.commandRoutes(r)
+
+				r.Get("/usage", s.GetCommandUsage)
+				r.Post("/usage", s.RecordCommandUsage)
+			})
+
+			r.Route("/collections", func(r chi.Router) {
+				r.Get("/", s.GetCollections)
+				r.Post("/", s.CreateCollection)
+				r.Get("/{id}", s.GetCollection)
+				r.Put("/{id}", s.UpdateCollection)
+				r.Delete("/{id}", s.DeleteCollection)
+
+				r.Post("/{id}/commands", s.AddCollectionCommand)
+				r.Put("/{id}/commands", s.ReorderCollectionCommands)
+				r.Delete("/{id}/commands/{commandId}", s.RemoveCollectionCommand)
+
+				r.Post("/{id}/share", s.ShareCollection)
+			})
+
+			r.Route("/runbooks", func(r chi.Router) {
+				r.Get("/", s.GetRunbooks)
+				r.Post("/", s.CreateRunbook)
+				r.Get("/{id}", s.GetRunbook)
+				r.Put("/{id}", s.ReplaceRunbook)
+				r.Delete("/{id}", s.DeleteRunbook)
+			})
+
+			r.Route("/workspaces", func(r chi.Router) {
+				r.Get("/", s.GetWorkspaces)
+				r.Post("/", s.CreateWorkspace)
+
+				r.Route("/{workspaceId}", func(r chi.Router) {
+					r.Get("/", s.GetWorkspace)
+					r.Put("/", s.UpdateWorkspace)
+					r.Delete("/", s.DeleteWorkspace)
+
+					r.Put("/members/{userId}", s.UpdateWorkspaceMember)
+					r.Delete("/members/{userId}", s.RemoveWorkspaceMember)
+
+					r.Get("/invitations", s.GetWorkspaceInvitations)
+					r.Post("/invitations", s.CreateWorkspaceInvitation)
+					r.Delete("/invitations/{invitationId}", s.DeleteWorkspaceInvitation)
+
+					r.Route("/tags", s.tagRoutes)
+					r.Route("/commands", s.commandRoutes)
+					r.Route("/trash", s.trashRoutes)
+					r.Route("/shares", s.shareRoutes)
+				})
+			})
+
+			r.Post("/invitations/accept", s.AcceptWorkspaceInvitation)
+
+			r.Route("/shares", s.shareRoutes)
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(middleware.RequireRole(admin.RoleAdmin, s.logger))
+
+				r.Get("/usage", s.AdminGetUsage)
+				r.Get("/users", s.AdminListUsers)
+				r.Get("/users/{id}", s.AdminGetUser)
+				r.Post("/users/{id}/deactivate", s.AdminDeactivateUser)
+				r.Post("/users/{id}/reactivate", s.AdminReactivateUser)
+				r.Post("/users/{id}/logout", s.AdminLogoutUser)
+				r.Put("/users/{id}/role", s.AdminSetUserRole)
 			})
 		})
 	})
 
 	return r
 }
+
+// corsExposedHeaders are the response headers the web app reads.
+var corsExposedHeaders = []string{
+	"ETag",
+	"Location",
+	"Retry-After",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	middleware.IdempotentReplayedHeader,
+	utils.RequestIDHeader,
+}
+
+// splitList splits a list separated by commas, dropping empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// rateLimit limits the requests of each client to perMinute for the routes
+// of group.
+func (s *Server) rateLimit(group string, perMinute int) func(next http.Handler) http.Handler {
+	return middleware.RateLimit(s.rateLimits, group, middleware.Limit{Requests: perMinute, Per: time.Minute}, s.logger)
+}
+
+// tagRoutes, commandRoutes, trashRoutes and shareRoutes are mounted both for
+// the personal library and under a workspace, handlers tell them apart with
+// requestScope.
+func (s *Server) tagRoutes(r chi.Router) {
+	r.Get("/", s.GetTags)
+	r.Post("/", s.CreateTag)
+	r.Get("/{id}", s.GetTag)
+	r.Put("/{id}", s.UpdateTag)
+	r.Delete("/{id}", s.DeleteTag)
+
+	r.Get("/{id}/commands", s.GetCommandsWithTag)
+}
+
+func (s *Server) commandRoutes(r chi.Router) {
+	r.Get("/", s.GetCommands)
+	r.Post("/", s.CreateCommand)
+	r.Get("/{id}", s.GetCommand)
+	r.Put("/{id}", s.UpdateCommand)
+	r.Delete("/{id}", s.DeleteCommand)
+
+	r.Post("/{id}/share", s.ShareCommand)
+
+	r.Get("/{id}/revisions", s.GetCommandRevisions)
+	r.Get("/{id}/revisions/{revision}/diff", s.GetCommandRevisionDiff)
+	r.Post("/{id}/restore/{revision}", s.RestoreCommandRevision)
+}
+
+func (s *Server) trashRoutes(r chi.Router) {
+	r.Get("/", s.GetTrash)
+	r.Delete("/", s.EmptyTrash)
+
+	r.Post("/commands/{id}/restore", s.RestoreTrashedCommand)
+	r.Delete("/commands/{id}", s.PurgeTrashedCommand)
+	r.Post("/tags/{id}/restore", s.RestoreTrashedTag)
+	r.Delete("/tags/{id}", s.PurgeTrashedTag)
+}
+
+func (s *Server) shareRoutes(r chi.Router) {
+	r.Get("/", s.GetShareLinks)
+	r.Delete("/{id}", s.RevokeShareLink)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// rejectingRateLimitStore rejects every request and records the buckets
+// requests were counted against.
+type rejectingRateLimitStore struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (s *rejectingRateLimitStore) Take(_ context.Context, key string, _ middleware.Limit, _ time.Time) (middleware.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+
+	return middleware.RateLimitResult{RetryAfter: time.Second, Reset: time.Second}, nil
+}
+
+func TestPublicRoutesRateLimitGroups(t *testing.T) {
+	s := newTestServer(config.AppConfig{RateLimitPublicPerMinute: 60})
+	store := &rejectingRateLimitStore{}
+	s.rateLimits = store
+	handler := s.RegisterRoutes()
+
+	groups := map[string]string{}
+	for _, path := range []string{"/api/shared/abc", "/api/openapi.json", "/api/docs/"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusTooManyRequests, res.Code, path)
+
+		group, _, _ := strings.Cut(store.keys[len(store.keys)-1], ":")
+		groups[path] = group
+	}
+
+	// Browsing the docs does not use up the quota of shared links
+	assert.NotEqual(t, groups["/api/shared/abc"], groups["/api/openapi.json"])
+	assert.Equal(t, groups["/api/openapi.json"], groups["/api/docs/"])
+}
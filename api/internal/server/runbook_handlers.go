@@ -0,0 +1,366 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type runbookVariableSchema struct {
+	Name    string  `json:"name" validate:"required"`
+	Default *string `json:"default" validate:""`
+}
+
+type runbookStepSchema struct {
+	Name            string `json:"name" validate:""`
+	CommandId       string `json:"command_id" validate:"omitempty,uuid"`
+	Command         string `json:"command" validate:"required_without=CommandId"`
+	Confirm         bool   `json:"confirm" validate:""`
+	ContinueOnError bool   `json:"continue_on_error" validate:""`
+	CaptureAs       string `json:"capture_as" validate:""`
+}
+
+type runbookRequestPayloadSchema struct {
+	Name        string                  `json:"name" validate:"required"`
+	Description string                  `json:"description" validate:""`
+	Variables   []runbookVariableSchema `json:"variables" validate:"dive"`
+	Steps       []runbookStepSchema     `json:"steps" validate:"required,min=1,dive"`
+}
+
+// errRunbookCommandNotFound is returned when a step references a command the
+// user does not own.
+var errRunbookCommandNotFound = errors.New("runbook step references an unknown command")
+
+func (s *Server) CreateRunbook(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload runbookRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create runbook")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	runbook, err := queriesWithTx.InsertRunbook(ctx, repository.InsertRunbookParams{
+		UserID:      _userId,
+		Name:        requestPayload.Name,
+		Description: pgtype.Text{String: requestPayload.Description, Valid: true},
+	})
+	if err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to create runbook")
+		return
+	}
+
+	if err := writeRunbookDefinition(ctx, queriesWithTx, runbook.ID, _userId, requestPayload); err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to create runbook")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create runbook")
+		return
+	}
+
+	s.respondRunbook(w, r, http.StatusCreated, runbook)
+}
+
+func (s *Server) GetRunbooks(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	runbooks, err := s.db.FindRunbooks(ctx, _userId)
+	if err != nil {
+		s.log(r).Error("Failed to fetch runbooks", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch runbooks")
+		return
+	}
+
+	if runbooks == nil {
+		runbooks = []repository.Runbook{}
+	}
+
+	utils.Response(w, http.StatusOK, runbooks)
+}
+
+func (s *Server) GetRunbook(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	runbook, ok := s.findOwnedRunbook(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	s.respondRunbook(w, r, http.StatusOK, runbook)
+}
+
+// ReplaceRunbook replaces the whole definition of a runbook, its steps and
+// variables included.
+func (s *Server) ReplaceRunbook(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload runbookRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	runbook, ok := s.findOwnedRunbook(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update runbook")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	runbook, err = queriesWithTx.UpdateRunbook(ctx, repository.UpdateRunbookParams{
+		ID:          runbook.ID,
+		Name:        requestPayload.Name,
+		Description: pgtype.Text{String: requestPayload.Description, Valid: true},
+	})
+	if err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to update runbook")
+		return
+	}
+
+	if err := queriesWithTx.DeleteRunbookSteps(ctx, runbook.ID); err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to update runbook")
+		return
+	}
+
+	if err := queriesWithTx.DeleteRunbookVariables(ctx, runbook.ID); err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to update runbook")
+		return
+	}
+
+	if err := writeRunbookDefinition(ctx, queriesWithTx, runbook.ID, _userId, requestPayload); err != nil {
+		s.respondRunbookWriteError(w, r, err, "Failed to update runbook")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update runbook")
+		return
+	}
+
+	s.respondRunbook(w, r, http.StatusOK, runbook)
+}
+
+func (s *Server) DeleteRunbook(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	runbook, ok := s.findOwnedRunbook(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.db.DeleteRunbook(ctx, runbook.ID); err != nil {
+		s.log(r).Error("Failed to delete runbook", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete runbook")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Runbook deleted successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// writeRunbookDefinition inserts the steps, in order, and the variables of a
+// runbook. Steps may only reference commands owned by userId.
+func writeRunbookDefinition(ctx context.Context, queries *repository.Queries, runbookId, userId uuid.UUID, payload runbookRequestPayloadSchema) error {
+	for _, variable := range payload.Variables {
+		defaultValue := pgtype.Text{}
+		if variable.Default != nil {
+			defaultValue = pgtype.Text{String: *variable.Default, Valid: true}
+		}
+
+		err := queries.InsertRunbookVariable(ctx, repository.InsertRunbookVariableParams{
+			RunbookID:    runbookId,
+			Name:         variable.Name,
+			DefaultValue: defaultValue,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for position, step := range payload.Steps {
+		commandId := pgtype.UUID{}
+		if step.CommandId != "" {
+			_commandId, err := uuid.Parse(step.CommandId)
+			if err != nil {
+				return errRunbookCommandNotFound
+			}
+
+			command, err := queries.FindCommandById(ctx, _commandId)
+			if err != nil {
+				if err == pgx.ErrNoRows {
+					return errRunbookCommandNotFound
+				}
+				return err
+			}
+
+			if command.UserID.Bytes != userId || command.WorkspaceID.Valid || command.DeletedAt.Valid {
+				return errRunbookCommandNotFound
+			}
+
+			commandId = pgtype.UUID{Bytes: command.ID, Valid: true}
+		}
+
+		_, err := queries.InsertRunbookStep(ctx, repository.InsertRunbookStepParams{
+			RunbookID:       runbookId,
+			Position:        int32(position),
+			Name:            pgtype.Text{String: step.Name, Valid: step.Name != ""},
+			CommandID:       commandId,
+			Command:         pgtype.Text{String: step.Command, Valid: step.Command != ""},
+			Confirm:         step.Confirm,
+			ContinueOnError: step.ContinueOnError,
+			CaptureAs:       pgtype.Text{String: step.CaptureAs, Valid: step.CaptureAs != ""},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) respondRunbookWriteError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, errRunbookCommandNotFound) {
+		utils.ResponseError(w, http.StatusBadRequest, "Runbook step references an unknown command")
+		return
+	}
+
+	s.respondDBError(w, r, err, msg)
+}
+
+// runbookResponsePayloadSchema is a runbook along with its variables and
+// steps, in order.
+type runbookResponsePayloadSchema struct {
+	ID          uuid.UUID                    `json:"id"`
+	Name        string                       `json:"name"`
+	Description pgtype.Text                  `json:"description"`
+	CreatedAt   pgtype.Timestamptz           `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz           `json:"updated_at"`
+	Variables   []repository.RunbookVariable `json:"variables"`
+	Steps       []repository.RunbookStep     `json:"steps"`
+}
+
+// respondRunbook responds with a runbook along with its steps and variables.
+func (s *Server) respondRunbook(w http.ResponseWriter, r *http.Request, statusCode int, runbook repository.Runbook) {
+	ctx := r.Context()
+
+	steps, err := s.db.FindRunbookSteps(ctx, runbook.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch runbook steps", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch runbook")
+		return
+	}
+
+	variables, err := s.db.FindRunbookVariables(ctx, runbook.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch runbook variables", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch runbook")
+		return
+	}
+
+	if steps == nil {
+		steps = []repository.RunbookStep{}
+	}
+	if variables == nil {
+		variables = []repository.RunbookVariable{}
+	}
+
+	responsePayload := runbookResponsePayloadSchema{
+		ID:          runbook.ID,
+		Name:        runbook.Name,
+		Description: runbook.Description,
+		CreatedAt:   runbook.CreatedAt,
+		UpdatedAt:   runbook.UpdatedAt,
+		Variables:   variables,
+		Steps:       steps,
+	}
+
+	utils.Response(w, statusCode, responsePayload)
+}
+
+// findOwnedRunbook loads the runbook from the id URL parameter. It responds
+// with 404 when the runbook belongs to another user.
+func (s *Server) findOwnedRunbook(w http.ResponseWriter, r *http.Request, userId uuid.UUID) (repository.Runbook, bool) {
+	_runbookId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid runbook ID")
+		return repository.Runbook{}, false
+	}
+
+	runbook, err := s.db.FindRunbookById(r.Context(), _runbookId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Runbook not found")
+		} else {
+			s.log(r).Error("Failed to fetch runbook", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch runbook")
+		}
+
+		return repository.Runbook{}, false
+	}
+
+	if runbook.UserID != userId {
+		utils.ResponseError(w, http.StatusNotFound, "Runbook not found")
+		return repository.Runbook{}, false
+	}
+
+	return runbook, true
+}
@@ -2,59 +2,221 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/endalk200/termflow-api/internal/repository"
 	"github.com/endalk200/termflow-api/pkgs/config"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/endalk200/termflow-api/pkgs/metrics"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/tracing"
+	migrations "github.com/endalk200/termflow-api/sql"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Server struct {
-	port   int
-	cfg    config.AppConfig
-	logger *slog.Logger
-	db     *repository.Queries
-	conn   *pgxpool.Pool
+	port    int
+	cfg     config.AppConfig
+	logger  *slog.Logger
+	db      *repository.Store
+	conn    *pgxpool.Pool
+	mailer  mailer.Mailer
+	metrics *metrics.Metrics
+
+	rateLimits middleware.RateLimitStore
+	clientIPs  *middleware.ClientIPResolver
+	ipFilter   *middleware.IPFilter
+
+	// envFileVariables are the variables loaded from the .env file rather
+	// than set in the environment, which SIGHUP reloads.
+	envFileVariables map[string]bool
+
+	// latestMigration is the version the database has to be migrated to
+	// for the server to be ready.
+	latestMigration int64
 }
 
-func NewServer(logger *slog.Logger) *http.Server {
-	var cfg config.AppConfig
-	err := config.LoadConfig(&cfg)
+// startupTimeout bounds how long NewServer waits for the database.
+const startupTimeout = 10 * time.Second
 
+// requestTimeout is how long requests have to be read and answered, except
+// for the routes of streamTimeouts.
+const requestTimeout = 30 * time.Second
+
+// NewServer connects to the database and sets up the server, failing when
+// the config is invalid or the database can not be reached. envFileVariables
+// are the variables config.LoadEnvFile loaded.
+func NewServer(ctx context.Context, cfg config.AppConfig, envFileVariables []string, logger *slog.Logger) (*Server, error) {
 	databaseConnectionUri, err := config.ConstructDatabaseUrl(cfg)
 	if err != nil {
-		logger.Error("Error while constructing DATABASE_URI", slog.String("ERROR", err.Error()))
+		return nil, fmt.Errorf("constructing DATABASE_URI: %w", err)
 	}
 
-	connection, err := pgxpool.New(context.Background(), databaseConnectionUri)
+	poolConfig, err := pgxpool.ParseConfig(databaseConnectionUri)
 	if err != nil {
-		logger.Error("Unable to create connection pool", slog.String("ERROR", err.Error()))
+		return nil, fmt.Errorf("invalid database connection settings: %w", err)
 	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
-	queries := repository.New(connection)
+	connection, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating connection pool: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+	if err := connection.Ping(pingCtx); err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("connecting to the database: %w", err)
+	}
+
+	queries := repository.NewStore(connection)
+
+	var mail mailer.Mailer = &mailer.Log{Logger: logger}
+	if cfg.SmtpHost != "" {
+		mail = &mailer.SMTP{
+			Host:     cfg.SmtpHost,
+			Port:     cfg.SmtpPort,
+			Username: cfg.SmtpUsername,
+			Password: cfg.SmtpPassword,
+			From:     cfg.MailFrom,
+		}
+	}
 
-	NewServer := &Server{
-		port:   cfg.ApplicationPort,
-		logger: logger,
-		cfg:    cfg,
-		db:     queries,
-		conn:   connection,
+	trustedProxies, err := middleware.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	proxyHeader, err := middleware.ParseProxyHeader(cfg.TrustedProxyHeader)
+	if err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("invalid TRUSTED_PROXY_HEADER: %w", err)
+	}
+
+	allowedIPs, deniedIPs, err := ipAccessLists(cfg)
+	if err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("invalid IP access lists: %w", err)
+	}
+
+	latestMigration, err := migrations.LatestMigration()
+	if err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("reading migrations: %w", err)
 	}
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      NewServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+	loaded := map[string]bool{}
+	for _, name := range envFileVariables {
+		loaded[name] = true
+	}
+
+	return &Server{
+		port:    cfg.ApplicationPort,
+		logger:  logger,
+		cfg:     cfg,
+		db:      queries,
+		conn:    connection,
+		mailer:  mail,
+		metrics: metrics.New(connection),
+
+		rateLimits: middleware.NewMemoryRateLimitStore(),
+		clientIPs:  middleware.NewClientIPResolver(trustedProxies, proxyHeader),
+		ipFilter:   middleware.NewIPFilter(allowedIPs, deniedIPs),
+
+		envFileVariables: loaded,
+
+		latestMigration: latestMigration,
+	}, nil
+}
+
+// Run serves the API, and the metrics on their own port when there is
+// one, until ctx is done or a server fails. It then stops accepting
+// connections, waits up to the shutdown timeout for the requests in flight
+// and closes the connection pool.
+func (s *Server) Run(ctx context.Context) error {
+	servers := []*http.Server{{
+		Addr:              fmt.Sprintf(":%d", s.port),
+		Handler:           s.RegisterRoutes(),
+		IdleTimeout:       time.Minute,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Exports and imports extend these with RouteDeadlines
+		ReadTimeout:  requestTimeout,
+		WriteTimeout: requestTimeout,
+	}}
+
+	if s.cfg.MetricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", s.metrics.Handler())
+
+		servers = append(servers, &http.Server{
+			Addr:              fmt.Sprintf(":%d", s.cfg.MetricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		})
+	}
+
+	// Listen before serving so that a port in use fails right away
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, server := range servers {
+		listener, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			s.conn.Close()
+			return fmt.Errorf("listening on %s: %w", server.Addr, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	go s.runTrashPurger(jobs)
+	go s.runIdempotencyKeyPurger(jobs)
+	go s.runAccountPurger(jobs)
+	go s.runIPAccessReloader(jobs)
+
+	errs := make(chan error, len(servers))
+	for i, server := range servers {
+		s.logger.Info("Server listening", slog.String("addr", listeners[i].Addr().String()))
+
+		go func() {
+			if err := server.Serve(listeners[i]); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("serving on %s: %w", server.Addr, err)
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Shutting down, draining requests", slog.Int("timeout_seconds", s.cfg.ShutdownTimeoutSeconds))
+	case err = <-errs:
+		s.logger.Error("Server failed, shutting down", slog.String("ERROR", err.Error()))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn("Requests still in flight after the shutdown timeout", slog.String("addr", server.Addr), slog.String("ERROR", err.Error()))
+			server.Close()
+		}
 	}
 
-	logger.Info("Server listening", slog.String("addr", ":8080"))
+	stopJobs()
+	s.conn.Close()
+	s.logger.Info("Server stopped")
 
-	return server
+	return err
 }
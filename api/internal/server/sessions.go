@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// sessionChecker rejects the access tokens of deleted and deactivated users
+// and the ones issued before the sessions of the user were revoked.
+type sessionChecker struct {
+	db *repository.Queries
+}
+
+func (s *Server) sessionChecker() *sessionChecker {
+	return &sessionChecker{db: s.db.Queries}
+}
+
+func (checker *sessionChecker) CheckSession(ctx context.Context, userId string, issuedAt time.Time) error {
+	_userId, err := uuid.Parse(userId)
+	if err != nil {
+		return middleware.ErrSessionRevoked
+	}
+
+	state, err := checker.db.GetUserSessionState(ctx, _userId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return middleware.ErrSessionRevoked
+		}
+
+		return err
+	}
+
+	if isInactive(state.IsActive) {
+		return middleware.ErrAccountInactive
+	}
+
+	// Tokens only carry seconds, one issued in the same second as the
+	// revocation is rejected too
+	if state.SessionsRevokedAt.Valid && !issuedAt.After(state.SessionsRevokedAt.Time) {
+		return middleware.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// isInactive reports whether an account was deactivated, accounts created
+// before is_active was enforced may have it unset.
+func isInactive(isActive pgtype.Bool) bool {
+	return isActive.Valid && !isActive.Bool
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type shareRequestPayloadSchema struct {
+	// ExpiresInHours is zero for links that never expire, and at most a year.
+	ExpiresInHours int `json:"expires_in_hours" validate:"gte=0,lte=8760"`
+}
+
+// ShareCommand mints a public, read-only link to a command.
+func (s *Server) ShareCommand(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return
+	}
+
+	command, ok := s.findScopedCommand(w, r, sc, _commandId)
+	if !ok {
+		return
+	}
+
+	s.createShareLink(w, r, repository.InsertShareLinkParams{
+		UserID:      sc.userId,
+		CommandID:   pgtype.UUID{Bytes: command.ID, Valid: true},
+		WorkspaceID: sc.workspaceId,
+	})
+}
+
+// ShareCollection mints a public, read-only link to a collection.
+func (s *Server) ShareCollection(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	collection, ok := s.findOwnedCollection(w, r, _userId)
+	if !ok {
+		return
+	}
+
+	s.createShareLink(w, r, repository.InsertShareLinkParams{
+		UserID:       _userId,
+		CollectionID: pgtype.UUID{Bytes: collection.ID, Valid: true},
+	})
+}
+
+// GetShareLinks lists the active share links of the scope. Those of a
+// workspace are listed to its editors, whoever created them.
+func (s *Server) GetShareLinks(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	links, err := s.db.FindShareLinks(r.Context(), repository.FindShareLinksParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to fetch share links", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share links")
+		return
+	}
+
+	responsePayload := make([]shareLinkResponsePayloadSchema, 0, len(links))
+	for _, link := range links {
+		responsePayload = append(responsePayload, s.shareLinkPayload(link))
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	_linkId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid share link ID")
+		return
+	}
+
+	revoked, err := s.db.RevokeShareLink(r.Context(), repository.RevokeShareLinkParams{
+		ID:          _linkId,
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to revoke share link", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to revoke share link")
+		return
+	}
+
+	if revoked == 0 {
+		utils.ResponseError(w, http.StatusNotFound, "Share link not found")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Share link revoked successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// sharedResponsePayloadSchema is what a share link shows, Type tells
+// whether it is a command or a collection.
+type sharedResponsePayloadSchema struct {
+	Type       string                  `json:"type"`
+	Command    *sharedCommandSchema    `json:"command,omitempty"`
+	Collection *sharedCollectionSchema `json:"collection,omitempty"`
+	ExpiresAt  pgtype.Timestamptz      `json:"expires_at"`
+}
+
+type sharedCommandSchema struct {
+	Command     string   `json:"command"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+type sharedCollectionSchema struct {
+	Name        string                          `json:"name"`
+	Description pgtype.Text                     `json:"description"`
+	Commands    []sharedCollectionCommandSchema `json:"commands"`
+}
+
+type sharedCollectionCommandSchema struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// GetShared is the unauthenticated, read-only view of a shared command or
+// collection. Revoked and expired links are reported as not found.
+func (s *Server) GetShared(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	link, err := s.db.FindShareLinkBySlug(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Share link not found")
+		} else {
+			s.log(r).Error("Failed to fetch share link", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share link")
+		}
+
+		return
+	}
+
+	if link.RevokedAt.Valid || (link.ExpiresAt.Valid && link.ExpiresAt.Time.Before(time.Now())) {
+		utils.ResponseError(w, http.StatusNotFound, "Share link not found")
+		return
+	}
+
+	if link.CommandID.Valid {
+		command, err := s.db.FindCommandById(ctx, link.CommandID.Bytes)
+		if err != nil {
+			s.log(r).Error("Failed to fetch shared command", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share link")
+			return
+		}
+
+		if command.DeletedAt.Valid {
+			utils.ResponseError(w, http.StatusNotFound, "Share link not found")
+			return
+		}
+
+		tags, err := s.db.FindTagsByCommandId(ctx, command.ID)
+		if err != nil {
+			s.log(r).Error("Failed to fetch shared command tags", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share link")
+			return
+		}
+
+		tagNames := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tagNames = append(tagNames, tag.Name)
+		}
+
+		responsePayload := sharedResponsePayloadSchema{
+			Type: "command",
+			Command: &sharedCommandSchema{
+				Command:     command.Command,
+				Description: command.Description,
+				Tags:        tagNames,
+			},
+			ExpiresAt: link.ExpiresAt,
+		}
+
+		utils.Response(w, http.StatusOK, responsePayload)
+		return
+	}
+
+	collection, err := s.db.FindCollectionById(ctx, link.CollectionID.Bytes)
+	if err != nil {
+		s.log(r).Error("Failed to fetch shared collection", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share link")
+		return
+	}
+
+	commands, err := s.db.FindCollectionCommands(ctx, collection.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch shared collection commands", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch share link")
+		return
+	}
+
+	sharedCommands := make([]sharedCollectionCommandSchema, 0, len(commands))
+	for _, command := range commands {
+		sharedCommands = append(sharedCommands, sharedCollectionCommandSchema{
+			Command:     command.Command,
+			Description: command.Description,
+		})
+	}
+
+	responsePayload := sharedResponsePayloadSchema{
+		Type: "collection",
+		Collection: &sharedCollectionSchema{
+			Name:        collection.Name,
+			Description: collection.Description,
+			Commands:    sharedCommands,
+		},
+		ExpiresAt: link.ExpiresAt,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// shareSlugSize is the number of random bytes in a share link slug, enough
+// for slugs to be unguessable.
+const shareSlugSize = 16
+
+// isShareSlug reports whether slug has the format of the slugs
+// createShareLink generates.
+func isShareSlug(slug string) bool {
+	decoded, err := base64.RawURLEncoding.Strict().DecodeString(slug)
+	return err == nil && len(decoded) == shareSlugSize
+}
+
+// createShareLink reads the optional expiry from the request body and
+// inserts a share link with a fresh slug.
+func (s *Server) createShareLink(w http.ResponseWriter, r *http.Request, params repository.InsertShareLinkParams) {
+	var requestPayload shareRequestPayloadSchema
+	if r.ContentLength != 0 {
+		if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+			return
+		}
+	}
+
+	if requestPayload.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(requestPayload.ExpiresInHours) * time.Hour)
+		params.ExpiresAt = pgtype.Timestamptz{Time: expiresAt, Valid: true}
+	}
+
+	slug, err := auth.GenerateToken(shareSlugSize)
+	if err != nil {
+		s.log(r).Error("Failed to generate share slug", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create share link")
+		return
+	}
+	params.Slug = slug
+
+	link, err := s.db.InsertShareLink(r.Context(), params)
+	if err != nil {
+		s.log(r).Error("Failed to create share link", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create share link")
+		return
+	}
+
+	utils.Response(w, http.StatusCreated, s.shareLinkPayload(link))
+}
+
+// shareLinkResponsePayloadSchema is a share link, pointing at either a
+// command or a collection. WorkspaceID is set on links to the commands of a
+// workspace.
+type shareLinkResponsePayloadSchema struct {
+	ID           uuid.UUID          `json:"id"`
+	Slug         string             `json:"slug"`
+	URL          string             `json:"url"`
+	CommandID    pgtype.UUID        `json:"command_id"`
+	CollectionID pgtype.UUID        `json:"collection_id"`
+	WorkspaceID  pgtype.UUID        `json:"workspace_id"`
+	CreatedBy    uuid.UUID          `json:"created_by"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+func (s *Server) shareLinkPayload(link repository.ShareLink) shareLinkResponsePayloadSchema {
+	return shareLinkResponsePayloadSchema{
+		ID:           link.ID,
+		Slug:         link.Slug,
+		URL:          strings.TrimRight(s.cfg.PublicURL, "/") + "/api/shared/" + link.Slug,
+		CommandID:    link.CommandID,
+		CollectionID: link.CollectionID,
+		WorkspaceID:  link.WorkspaceID,
+		CreatedBy:    link.UserID,
+		ExpiresAt:    link.ExpiresAt,
+		CreatedAt:    link.CreatedAt,
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsShareSlug(t *testing.T) {
+	slug, err := auth.GenerateToken(shareSlugSize)
+	require.NoError(t, err)
+	assert.True(t, isShareSlug(slug))
+
+	for _, slug := range []string{
+		"",
+		"deploy",
+		"my-favourite-commands",
+		slug[:len(slug)-1],
+		slug + "A",
+		slug[:len(slug)-2] + "!!",
+		// Not how 16 bytes encode, the last character carries unused bits
+		slug[:len(slug)-1] + "B",
+	} {
+		assert.False(t, isShareSlug(slug), slug)
+	}
+}
+
+func TestWorkspaceShareLinks(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{MaxBodyBytes: 1 << 20})
+	useTestSigningKeys(t)
+	ctx := context.Background()
+
+	owner := insertTestUser(t, s, "owner@example.com")
+	editor := insertTestUser(t, s, "editor@example.com")
+	viewer := insertTestUser(t, s, "viewer@example.com")
+	workspace := insertTestWorkspace(t, s, map[uuid.UUID]string{
+		owner.ID:  RoleOwner,
+		editor.ID: RoleEditor,
+		viewer.ID: RoleViewer,
+	})
+	command, err := s.db.InsertCommands(ctx, repository.InsertCommandsParams{
+		UserID:      editor.ID,
+		Command:     "make deploy",
+		WorkspaceID: pgtype.UUID{Bytes: workspace.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	workspacePath := "/api/workspaces/" + workspace.ID.String()
+	ownerToken, editorToken := testAccessToken(t, owner), testAccessToken(t, editor)
+
+	res := serveTestRequest(t, s, http.MethodPost, workspacePath+"/commands/"+command.ID.String()+"/share", editorToken, nil)
+	require.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+	var link shareLinkResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &link))
+	assert.Equal(t, workspace.ID, uuid.UUID(link.WorkspaceID.Bytes))
+
+	listLinks := func(path, token string) []shareLinkResponsePayloadSchema {
+		t.Helper()
+		res := serveTestRequest(t, s, http.MethodGet, path, token, nil)
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var links []shareLinkResponsePayloadSchema
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &links))
+		return links
+	}
+
+	assert.Empty(t, listLinks("/api/shares", editorToken), "workspace links are not personal")
+	links := listLinks(workspacePath+"/shares", ownerToken)
+	require.Len(t, links, 1)
+	assert.Equal(t, link.ID, links[0].ID)
+	assert.Equal(t, editor.ID, links[0].CreatedBy)
+
+	res = serveTestRequest(t, s, http.MethodGet, workspacePath+"/shares", testAccessToken(t, viewer), nil)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodDelete, "/api/shares/"+link.ID.String(), editorToken, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code, "workspace links are revoked in the workspace")
+	res = serveTestRequest(t, s, http.MethodDelete, workspacePath+"/shares/"+link.ID.String(), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	// Removing a member revokes the links they shared
+	res = serveTestRequest(t, s, http.MethodPost, workspacePath+"/commands/"+command.ID.String()+"/share", editorToken, nil)
+	require.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &link))
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/shared/"+link.Slug, "", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodDelete, workspacePath+"/members/"+editor.ID.String(), ownerToken, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = serveTestRequest(t, s, http.MethodGet, "/api/shared/"+link.Slug, "", nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
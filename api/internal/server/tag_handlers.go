@@ -5,14 +5,11 @@ import (
 	"net/http"
 
 	"github.com/endalk200/termflow-api/internal/repository"
-	"github.com/endalk200/termflow-api/pkgs/middleware"
 	"github.com/endalk200/termflow-api/pkgs/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
-	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -22,16 +19,11 @@ type createTagRequestPayloadSchema struct {
 }
 
 func (s *Server) CreateTag(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserFromContext(r)
+	sc, ok := s.requestScope(w, r, RoleEditor)
 	if !ok {
-		s.logger.Error("userId not found in request context")
-		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// TODO: Handle uuid parse error
-	_userId, _ := uuid.Parse(userID)
-
 	var requestPayload createTagRequestPayloadSchema
 	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
 		return
@@ -39,27 +31,13 @@ func (s *Server) CreateTag(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	tag, err := s.db.InsertTag(ctx, repository.InsertTagParams{
-		UserID:      _userId,
+		UserID:      sc.userId,
 		Name:        requestPayload.Name,
 		Description: pgtype.Text{String: requestPayload.Description, Valid: true},
+		WorkspaceID: sc.workspaceId,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
-		}
-
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create user")
+		s.respondDBError(w, r, err, "Failed to create tag")
 		return
 	}
 
@@ -67,31 +45,23 @@ func (s *Server) CreateTag(w http.ResponseWriter, r *http.Request) {
 	//    Name: tag.Name,
 	//  }
 
+	w.Header().Set("ETag", utils.ETag(tag.Version))
 	utils.Response(w, http.StatusCreated, tag)
 }
 
 func (s *Server) GetTags(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserFromContext(r)
+	sc, ok := s.requestScope(w, r, RoleViewer)
 	if !ok {
-		s.logger.Error("userId not found in request context")
-		utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// TODO: Handle uuid parse error
-	_userId, _ := uuid.Parse(userID)
-
 	ctx := r.Context()
-	tags, err := s.db.FindTags(ctx, _userId)
+	tags, err := s.db.FindTags(ctx, repository.FindTagsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			s.logger.Error("No user with specified email", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			s.logger.Error("Failed to fetch user due to", slog.String("ERROR", err.Error()))
-			utils.ResponseError(w, http.StatusInternalServerError, "Invalid credentials")
-		}
-
+		s.respondDBError(w, r, err, "Failed to fetch tags")
 		return
 	}
 
@@ -106,17 +76,12 @@ type updateTagRequestPayloadSchema struct {
 }
 
 func (s *Server) UpdateTag(w http.ResponseWriter, r *http.Request) {
-	// userID, ok := middleware.GetUserFromContext(r)
-	// if !ok {
-	// 	s.logger.Error("userId not found in request context")
-	// 	utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-	// 	return
-	// }
-	//
-	// // TODO: Handle uuid parse error
-	// _userId, _ := uuid.Parse(userID)
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
 
-	var requestPayload createTagRequestPayloadSchema
+	var requestPayload updateTagRequestPayloadSchema
 	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
 		return
 	}
@@ -124,34 +89,35 @@ func (s *Server) UpdateTag(w http.ResponseWriter, r *http.Request) {
 	tagID := chi.URLParam(r, "id")
 	_tagID, err := uuid.Parse(tagID)
 	if err != nil {
-		s.logger.Error("Invalid tag id format", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Invalid tag id format", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
 		return
 	}
 
+	current, ok := s.findScopedTag(w, r, sc, _tagID)
+	if !ok {
+		return
+	}
+
+	if !s.checkIfMatch(w, r, current.Version, current) {
+		return
+	}
+
 	ctx := r.Context()
 	tag, err := s.db.UpdateTag(ctx, repository.UpdateTagParams{
 		ID:      _tagID,
 		Column2: requestPayload.Name,
 		Column3: pgtype.Text{String: requestPayload.Description, Valid: true},
+		Version: current.Version,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
+		// The version changed between reading and updating the tag
+		if err == pgx.ErrNoRows {
+			s.respondTagConflict(w, r, _tagID)
+			return
 		}
 
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update tag")
+		s.respondDBError(w, r, err, "Failed to update tag")
 		return
 	}
 
@@ -159,53 +125,112 @@ func (s *Server) UpdateTag(w http.ResponseWriter, r *http.Request) {
 	//    Name: tag.Name,
 	//  }
 
+	w.Header().Set("ETag", utils.ETag(tag.Version))
 	utils.Response(w, http.StatusCreated, tag)
 }
 
 func (s *Server) DeleteTag(w http.ResponseWriter, r *http.Request) {
-	// userID, ok := middleware.GetUserFromContext(r)
-	// if !ok {
-	// 	s.logger.Error("userId not found in request context")
-	// 	utils.ResponseError(w, http.StatusUnauthorized, "Unauthorized")
-	// 	return
-	// }
-	//
-	// // TODO: Handle uuid parse error
-	// _userId, _ := uuid.Parse(userID)
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
 
 	tagID := chi.URLParam(r, "id")
 	_tagID, err := uuid.Parse(tagID)
 	if err != nil {
-		s.logger.Error("Invalid tag id format", slog.String("ERROR", err.Error()))
+		s.log(r).Error("Invalid tag id format", slog.String("ERROR", err.Error()))
 		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
 		return
 	}
 
+	current, ok := s.findScopedTag(w, r, sc, _tagID)
+	if !ok {
+		return
+	}
+
+	if !s.checkIfMatch(w, r, current.Version, current) {
+		return
+	}
+
 	ctx := r.Context()
-	err = s.db.DeleteTag(ctx, _tagID)
+	rows, err := s.db.SoftDeleteTag(ctx, repository.SoftDeleteTagParams{
+		ID:      _tagID,
+		Version: current.Version,
+	})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				if pgErr.ConstraintName == "tags_name_key" {
-					s.logger.Error(pgErr.Message)
-					utils.ResponseError(w, http.StatusConflict, "Tag name already exists")
-					return
-				}
-			} else if pgErr.Code == pgerrcode.ForeignKeyViolation {
-				s.logger.Error(pgErr.Message)
-				utils.ResponseError(w, http.StatusConflict, "User does not exist")
-				return
-			}
-		}
+		s.log(r).Error("Failed to delete tag", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
 
-		s.logger.Error(err.Error())
-		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update tag")
+	if rows == 0 {
+		s.respondTagConflict(w, r, _tagID)
 		return
 	}
 
-	responsePayload := map[string]interface{}{
-		"message": "Tag deleted successfully",
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Tag moved to trash",
 	}
 
 	utils.Response(w, http.StatusCreated, responsePayload)
 }
+
+// GetTag returns a single tag. The ETag header carries the version of the
+// tag to send back as If-Match when changing it.
+func (s *Server) GetTag(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	_tagID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	tag, ok := s.findScopedTag(w, r, sc, _tagID)
+	if !ok {
+		return
+	}
+
+	if notModified(w, r, tag.Version) {
+		return
+	}
+
+	utils.Response(w, http.StatusOK, tag)
+}
+
+// respondTagConflict is the tag counterpart of respondCommandConflict.
+func (s *Server) respondTagConflict(w http.ResponseWriter, r *http.Request, tagId uuid.UUID) {
+	tag, err := s.db.FindTagById(r.Context(), tagId)
+	if err != nil || tag.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	respondPreconditionFailed(w, tag.Version, tag)
+}
+
+// findScopedTag loads a tag and responds with 404 when it does not belong to
+// the scope of the request or is in the trash.
+func (s *Server) findScopedTag(w http.ResponseWriter, r *http.Request, sc scope, tagId uuid.UUID) (repository.Tag, bool) {
+	tag, err := s.db.FindTagById(r.Context(), tagId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Tag not found")
+		} else {
+			s.log(r).Error("Failed to fetch tag", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch tag")
+		}
+
+		return repository.Tag{}, false
+	}
+
+	if !sc.owns(tag.UserID, tag.WorkspaceID) || tag.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Tag not found")
+		return repository.Tag{}, false
+	}
+
+	return tag, true
+}
@@ -0,0 +1,373 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type trashResponsePayloadSchema struct {
+	Commands []trashedCommandSchema `json:"commands"`
+	Tags     []trashedTagSchema     `json:"tags"`
+}
+
+// trashedCommandSchema and trashedTagSchema have no purge_at when trashed
+// items are kept forever.
+type trashedCommandSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Command     string             `json:"command"`
+	Description string             `json:"description"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	PurgeAt     pgtype.Timestamptz `json:"purge_at"`
+}
+
+type trashedTagSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	PurgeAt     pgtype.Timestamptz `json:"purge_at"`
+}
+
+// GetTrash lists the deleted commands and tags of the scope along with when
+// the purge job will remove them.
+func (s *Server) GetTrash(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	commands, err := s.db.FindDeletedCommands(ctx, repository.FindDeletedCommandsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to fetch deleted commands", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch trash")
+		return
+	}
+
+	tags, err := s.db.FindDeletedTags(ctx, repository.FindDeletedTagsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to fetch deleted tags", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch trash")
+		return
+	}
+
+	responsePayload := trashResponsePayloadSchema{
+		Commands: make([]trashedCommandSchema, 0, len(commands)),
+		Tags:     make([]trashedTagSchema, 0, len(tags)),
+	}
+	for _, command := range commands {
+		responsePayload.Commands = append(responsePayload.Commands, trashedCommandSchema{
+			ID:          command.ID,
+			Command:     command.Command,
+			Description: command.Description,
+			DeletedAt:   command.DeletedAt,
+			PurgeAt:     s.purgeAt(command.DeletedAt),
+		})
+	}
+	for _, tag := range tags {
+		responsePayload.Tags = append(responsePayload.Tags, trashedTagSchema{
+			ID:          tag.ID,
+			Name:        tag.Name,
+			Description: tag.Description,
+			DeletedAt:   tag.DeletedAt,
+			PurgeAt:     s.purgeAt(tag.DeletedAt),
+		})
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) RestoreTrashedCommand(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	command, ok := s.findTrashedCommand(w, r, sc)
+	if !ok {
+		return
+	}
+
+	restored, err := s.db.UndeleteCommand(r.Context(), command.ID)
+	if err != nil {
+		s.log(r).Error("Failed to restore command", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to restore command")
+		return
+	}
+
+	responsePayload := commandRowSchema{
+		ID:          restored.ID,
+		Command:     restored.Command,
+		Description: restored.Description,
+		CreatedAt:   restored.CreatedAt,
+		UpdatedAt:   restored.UpdatedAt,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type restoredTagResponsePayloadSchema struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (s *Server) RestoreTrashedTag(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	tag, ok := s.findTrashedTag(w, r, sc)
+	if !ok {
+		return
+	}
+
+	restored, err := s.db.UndeleteTag(r.Context(), tag.ID)
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to restore tag")
+		return
+	}
+
+	responsePayload := restoredTagResponsePayloadSchema{
+		ID:          restored.ID,
+		Name:        restored.Name,
+		Description: restored.Description,
+		CreatedAt:   restored.CreatedAt,
+		UpdatedAt:   restored.UpdatedAt,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) PurgeTrashedCommand(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	command, ok := s.findTrashedCommand(w, r, sc)
+	if !ok {
+		return
+	}
+
+	if err := s.db.PurgeCommand(r.Context(), command.ID); err != nil {
+		s.respondPurgeError(w, r, err)
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Command deleted permanently",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) PurgeTrashedTag(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	tag, ok := s.findTrashedTag(w, r, sc)
+	if !ok {
+		return
+	}
+
+	if err := s.db.PurgeTag(r.Context(), tag.ID); err != nil {
+		s.respondPurgeError(w, r, err)
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Tag deleted permanently",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// emptyTrashResponsePayloadSchema counts what was deleted.
+type emptyTrashResponsePayloadSchema struct {
+	Commands int64 `json:"commands"`
+	Tags     int64 `json:"tags"`
+}
+
+// EmptyTrash permanently deletes everything in the trash of the scope.
+func (s *Server) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleEditor)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to empty trash")
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	commands, err := queriesWithTx.PurgeDeletedCommands(ctx, repository.PurgeDeletedCommandsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.respondPurgeError(w, r, err)
+		return
+	}
+
+	tags, err := queriesWithTx.PurgeDeletedTags(ctx, repository.PurgeDeletedTagsParams{
+		WorkspaceID: sc.workspaceId,
+		UserID:      sc.userId,
+	})
+	if err != nil {
+		s.respondPurgeError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to empty trash")
+		return
+	}
+
+	responsePayload := emptyTrashResponsePayloadSchema{
+		Commands: commands,
+		Tags:     tags,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// runTrashPurger removes trashed commands and tags older than the retention
+// period until ctx is done.
+func (s *Server) runTrashPurger(ctx context.Context) {
+	if s.cfg.TrashRetentionDays <= 0 || s.cfg.TrashPurgeIntervalMinutes <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.cfg.TrashPurgeIntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.purgeExpiredTrash(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) purgeExpiredTrash(ctx context.Context) {
+	cutoff := pgtype.Timestamptz{
+		Time:  time.Now().AddDate(0, 0, -s.cfg.TrashRetentionDays),
+		Valid: true,
+	}
+
+	commands, err := s.db.PurgeExpiredCommands(ctx, cutoff)
+	if err != nil {
+		s.logger.Error("Failed to purge expired commands", slog.String("ERROR", err.Error()))
+	}
+
+	tags, err := s.db.PurgeExpiredTags(ctx, cutoff)
+	if err != nil {
+		s.logger.Error("Failed to purge expired tags", slog.String("ERROR", err.Error()))
+	}
+
+	if commands > 0 || tags > 0 {
+		s.logger.Info("Purged expired trash", slog.Int64("commands", commands), slog.Int64("tags", tags))
+	}
+}
+
+// purgeAt is when the purge job removes an item deleted at deletedAt, null
+// when the trash is never purged automatically.
+func (s *Server) purgeAt(deletedAt pgtype.Timestamptz) pgtype.Timestamptz {
+	if s.cfg.TrashRetentionDays <= 0 || !deletedAt.Valid {
+		return pgtype.Timestamptz{}
+	}
+
+	return pgtype.Timestamptz{Time: deletedAt.Time.AddDate(0, 0, s.cfg.TrashRetentionDays), Valid: true}
+}
+
+func (s *Server) respondPurgeError(w http.ResponseWriter, r *http.Request, err error) {
+	s.respondDBError(w, r, err, "Failed to purge trash")
+}
+
+func (s *Server) findTrashedCommand(w http.ResponseWriter, r *http.Request, sc scope) (repository.Command, bool) {
+	_commandId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid command ID")
+		return repository.Command{}, false
+	}
+
+	command, err := s.db.FindCommandById(r.Context(), _commandId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Command not found in trash")
+		} else {
+			s.log(r).Error("Failed to fetch command", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command")
+		}
+
+		return repository.Command{}, false
+	}
+
+	if !sc.owns(command.UserID, command.WorkspaceID) || !command.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Command not found in trash")
+		return repository.Command{}, false
+	}
+
+	return command, true
+}
+
+func (s *Server) findTrashedTag(w http.ResponseWriter, r *http.Request, sc scope) (repository.Tag, bool) {
+	_tagId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid tag ID")
+		return repository.Tag{}, false
+	}
+
+	tag, err := s.db.FindTagById(r.Context(), _tagId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Tag not found in trash")
+		} else {
+			s.log(r).Error("Failed to fetch tag", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch tag")
+		}
+
+		return repository.Tag{}, false
+	}
+
+	if !sc.owns(tag.UserID, tag.WorkspaceID) || !tag.DeletedAt.Valid {
+		utils.ResponseError(w, http.StatusNotFound, "Tag not found in trash")
+		return repository.Tag{}, false
+	}
+
+	return tag, true
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteCommandUsedByRunbook(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "trash@example.com")
+	command := insertTestCommand(t, s, user.ID)
+	insertTestRunbook(t, s, user.ID, command.ID)
+
+	rows, err := s.db.SoftDeleteCommand(ctx, repository.SoftDeleteCommandParams{
+		ID:      command.ID,
+		Version: command.Version,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, rows, "a command a runbook uses should not be trashed")
+}
+
+func TestPurgeExpiredTrashSkipsCommandsUsedByRunbooks(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{TrashRetentionDays: 30})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "trash@example.com")
+	used := insertTestCommand(t, s, user.ID)
+	unused := insertTestCommand(t, s, user.ID)
+	insertTestRunbook(t, s, user.ID, used.ID)
+
+	// Trashed before runbooks kept their commands out of the trash
+	_, err := s.conn.Exec(ctx, "UPDATE commands SET deleted_at = NOW() - INTERVAL '31 days'")
+	require.NoError(t, err)
+
+	s.purgeExpiredTrash(ctx)
+
+	_, err = s.db.FindCommandById(ctx, used.ID)
+	assert.NoError(t, err)
+	_, err = s.db.FindCommandById(ctx, unused.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type commandUsageSchema struct {
+	CommandId  string    `json:"command_id" validate:"required,uuid"`
+	Count      int64     `json:"count" validate:"required,gt=0"`
+	LastUsedAt time.Time `json:"last_used_at" validate:"required"`
+	// SyncId identifies the sync the count was pushed in, a count pushed
+	// again with the same id is only counted once.
+	SyncId string `json:"sync_id" validate:"omitempty,max=64"`
+}
+
+type recordCommandUsageRequestPayloadSchema struct {
+	Usage []commandUsageSchema `json:"usage" validate:"required,dive"`
+}
+
+// RecordCommandUsage adds usage counts pushed by the CLI to the commands of
+// the authenticated user.
+func (s *Server) RecordCommandUsage(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload recordCommandUsageRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to record command usage")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	responsePayload := []repository.CommandUsage{}
+	for _, usage := range requestPayload.Usage {
+		_commandId, _ := uuid.Parse(usage.CommandId)
+
+		command, err := queriesWithTx.FindCommandById(ctx, _commandId)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				utils.ResponseError(w, http.StatusNotFound, "Command "+usage.CommandId+" not found")
+			} else {
+				s.log(r).Error("Failed to fetch command", slog.String("ERROR", err.Error()))
+				utils.ResponseError(w, http.StatusInternalServerError, "Failed to record command usage")
+			}
+
+			return
+		}
+
+		if command.UserID.Bytes != _userId || command.WorkspaceID.Valid || command.DeletedAt.Valid {
+			utils.ResponseError(w, http.StatusNotFound, "Command "+usage.CommandId+" not found")
+			return
+		}
+
+		count := usage.Count
+		if usage.SyncId != "" {
+			inserted, err := queriesWithTx.InsertCommandUsageSync(ctx, repository.InsertCommandUsageSyncParams{
+				CommandID: command.ID,
+				SyncID:    usage.SyncId,
+			})
+			if err != nil {
+				s.log(r).Error("Failed to record command usage sync", slog.String("ERROR", err.Error()))
+				utils.ResponseError(w, http.StatusInternalServerError, "Failed to record command usage")
+				return
+			}
+
+			// The sync was counted already, a retry adds nothing and is
+			// answered with the usage as it is now
+			if inserted == 0 {
+				count = 0
+			}
+		}
+
+		commandUsage, err := queriesWithTx.UpsertCommandUsage(ctx, repository.UpsertCommandUsageParams{
+			CommandID:  command.ID,
+			UserID:     _userId,
+			UseCount:   count,
+			LastUsedAt: pgtype.Timestamptz{Time: usage.LastUsedAt, Valid: true},
+		})
+		if err != nil {
+			s.log(r).Error("Failed to record command usage", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to record command usage")
+			return
+		}
+
+		responsePayload = append(responsePayload, commandUsage)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to record command usage")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) GetCommandUsage(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	usage, err := s.db.FindCommandUsage(ctx, _userId)
+	if err != nil {
+		s.log(r).Error("Failed to fetch command usage", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch command usage")
+		return
+	}
+
+	if usage == nil {
+		usage = []repository.CommandUsage{}
+	}
+
+	utils.Response(w, http.StatusOK, usage)
+}
+
+// purgeExpiredUsageSyncs forgets the usage syncs older than
+// UsageSyncRetentionDays.
+func (s *Server) purgeExpiredUsageSyncs(ctx context.Context) {
+	if s.cfg.UsageSyncRetentionDays <= 0 {
+		return
+	}
+
+	cutoff := pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -s.cfg.UsageSyncRetentionDays), Valid: true}
+	if _, err := s.db.DeleteExpiredCommandUsageSyncs(ctx, cutoff); err != nil {
+		s.logger.Error("Failed to purge expired command usage syncs", slog.String("ERROR", err.Error()))
+	}
+}
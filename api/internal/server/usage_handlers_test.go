@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeExpiredUsageSyncs(t *testing.T) {
+	s := newDatabaseTestServer(t, config.AppConfig{UsageSyncRetentionDays: 90})
+	ctx := context.Background()
+
+	user := insertTestUser(t, s, "usage@example.com")
+	command := insertTestCommand(t, s, user.ID)
+
+	for _, syncId := range []string{"old", "recent"} {
+		_, err := s.db.InsertCommandUsageSync(ctx, repository.InsertCommandUsageSyncParams{CommandID: command.ID, SyncID: syncId})
+		require.NoError(t, err)
+	}
+	_, err := s.conn.Exec(ctx, "UPDATE command_usage_syncs SET created_at = NOW() - INTERVAL '91 days' WHERE sync_id = 'old'")
+	require.NoError(t, err)
+
+	s.purgeExpiredUsageSyncs(ctx)
+
+	// A retried sync is only counted again once it was forgotten
+	inserted, err := s.db.InsertCommandUsageSync(ctx, repository.InsertCommandUsageSyncParams{CommandID: command.ID, SyncID: "old"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, inserted)
+	inserted, err = s.db.InsertCommandUsageSync(ctx, repository.InsertCommandUsageSyncParams{CommandID: command.ID, SyncID: "recent"})
+	require.NoError(t, err)
+	assert.Zero(t, inserted)
+}
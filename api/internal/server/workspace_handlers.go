@@ -0,0 +1,723 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/auth"
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Workspace roles. Viewers read the workspace library, editors also change
+// it and owners also manage the workspace and its members.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// scope identifies who owns the commands and tags a request works on: the
+// authenticated user, or a workspace the user is a member of when the route
+// has a workspaceId parameter.
+type scope struct {
+	userId      uuid.UUID
+	workspaceId pgtype.UUID
+	role        string
+}
+
+// owns reports whether a resource created by userId in workspaceId belongs
+// to the scope. userId is not set on workspace resources whose author was
+// deleted.
+func (sc scope) owns(userId pgtype.UUID, workspaceId pgtype.UUID) bool {
+	if sc.workspaceId.Valid {
+		return workspaceId.Valid && workspaceId.Bytes == sc.workspaceId.Bytes
+	}
+
+	return !workspaceId.Valid && userId.Valid && userId.Bytes == sc.userId
+}
+
+// requestScope resolves the scope of the request. Users have every role in
+// their personal scope. In a workspace it responds with 404 to non members
+// and with 403 to members below minRole.
+func (s *Server) requestScope(w http.ResponseWriter, r *http.Request, minRole string) (scope, bool) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return scope{}, false
+	}
+
+	workspaceID := chi.URLParam(r, "workspaceId")
+	if workspaceID == "" {
+		return scope{userId: _userId, role: RoleOwner}, true
+	}
+
+	_workspaceId, err := uuid.Parse(workspaceID)
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid workspace ID")
+		return scope{}, false
+	}
+
+	member, err := s.db.FindWorkspaceMember(r.Context(), repository.FindWorkspaceMemberParams{
+		WorkspaceID: _workspaceId,
+		UserID:      _userId,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Workspace not found")
+		} else {
+			s.log(r).Error("Failed to fetch workspace membership", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch workspace")
+		}
+
+		return scope{}, false
+	}
+
+	if roleRanks[member.Role] < roleRanks[minRole] {
+		utils.ResponseError(w, http.StatusForbidden, "Insufficient workspace role")
+		return scope{}, false
+	}
+
+	return scope{
+		userId:      _userId,
+		workspaceId: pgtype.UUID{Bytes: _workspaceId, Valid: true},
+		role:        member.Role,
+	}, true
+}
+
+type workspaceRequestPayloadSchema struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (s *Server) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload workspaceRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	workspace, err := queriesWithTx.InsertWorkspace(ctx, repository.InsertWorkspaceParams{
+		Name:      requestPayload.Name,
+		CreatedBy: pgtype.UUID{Bytes: _userId, Valid: true},
+	})
+	if err != nil {
+		s.log(r).Error("Failed to create workspace", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+
+	err = queriesWithTx.InsertWorkspaceMember(ctx, repository.InsertWorkspaceMemberParams{
+		WorkspaceID: workspace.ID,
+		UserID:      _userId,
+		Role:        RoleOwner,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to add workspace owner", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+
+	utils.Response(w, http.StatusCreated, workspace)
+}
+
+func (s *Server) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	workspaces, err := s.db.FindWorkspacesByUser(r.Context(), _userId)
+	if err != nil {
+		s.log(r).Error("Failed to fetch workspaces", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch workspaces")
+		return
+	}
+
+	if workspaces == nil {
+		workspaces = []repository.FindWorkspacesByUserRow{}
+	}
+
+	utils.Response(w, http.StatusOK, workspaces)
+}
+
+// workspaceResponsePayloadSchema is a workspace with its members and the
+// role of the authenticated user in it.
+type workspaceResponsePayloadSchema struct {
+	repository.Workspace
+	Role    string                               `json:"role"`
+	Members []repository.FindWorkspaceMembersRow `json:"members"`
+}
+
+func (s *Server) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	workspace, err := s.db.FindWorkspaceById(ctx, sc.workspaceId.Bytes)
+	if err != nil {
+		s.log(r).Error("Failed to fetch workspace", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch workspace")
+		return
+	}
+
+	members, err := s.db.FindWorkspaceMembers(ctx, workspace.ID)
+	if err != nil {
+		s.log(r).Error("Failed to fetch workspace members", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch workspace")
+		return
+	}
+
+	responsePayload := workspaceResponsePayloadSchema{
+		Workspace: workspace,
+		Role:      sc.role,
+		Members:   members,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var requestPayload workspaceRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	workspace, err := s.db.UpdateWorkspace(r.Context(), repository.UpdateWorkspaceParams{
+		ID:   sc.workspaceId.Bytes,
+		Name: requestPayload.Name,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to update workspace", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update workspace")
+		return
+	}
+
+	utils.Response(w, http.StatusOK, workspace)
+}
+
+// DeleteWorkspace deletes a workspace along with its commands and tags.
+func (s *Server) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteWorkspace(r.Context(), sc.workspaceId.Bytes); err != nil {
+		s.log(r).Error("Failed to delete workspace", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete workspace")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Workspace deleted successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type workspaceMemberRequestPayloadSchema struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+func (s *Server) UpdateWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	var requestPayload workspaceMemberRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	if _, exists := roleRanks[requestPayload.Role]; !exists {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	member, ok := s.findWorkspaceMember(w, r, sc)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update workspace member")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	if requestPayload.Role != RoleOwner && !s.hasOtherOwner(w, r, queriesWithTx, member) {
+		return
+	}
+
+	err = queriesWithTx.UpdateWorkspaceMemberRole(ctx, repository.UpdateWorkspaceMemberRoleParams{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Role:        requestPayload.Role,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to update workspace member", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update workspace member")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update workspace member")
+		return
+	}
+
+	member.Role = requestPayload.Role
+	utils.Response(w, http.StatusOK, member)
+}
+
+// RemoveWorkspaceMember removes a member from a workspace and revokes the
+// share links they created in it. Owners remove anyone, other members may
+// only leave.
+func (s *Server) RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleViewer)
+	if !ok {
+		return
+	}
+
+	member, ok := s.findWorkspaceMember(w, r, sc)
+	if !ok {
+		return
+	}
+
+	if member.UserID != sc.userId && sc.role != RoleOwner {
+		utils.ResponseError(w, http.StatusForbidden, "Insufficient workspace role")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to remove workspace member")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	if !s.hasOtherOwner(w, r, queriesWithTx, member) {
+		return
+	}
+
+	err = queriesWithTx.DeleteWorkspaceMember(ctx, repository.DeleteWorkspaceMemberParams{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to remove workspace member", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to remove workspace member")
+		return
+	}
+
+	// Links the member shared stop working once they no longer have access
+	err = queriesWithTx.RevokeWorkspaceMemberShareLinks(ctx, repository.RevokeWorkspaceMemberShareLinksParams{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to revoke share links of workspace member", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to remove workspace member")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to remove workspace member")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Member removed successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type workspaceInvitationRequestPayloadSchema struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// CreateWorkspaceInvitation invites an email address to the workspace. The
+// token is only sent to that address, the invitee accepts it through
+// AcceptWorkspaceInvitation before it expires.
+func (s *Server) CreateWorkspaceInvitation(w http.ResponseWriter, r *http.Request) {
+	var requestPayload workspaceInvitationRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	if _, exists := roleRanks[requestPayload.Role]; !exists {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		s.log(r).Error("Failed to generate invitation token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	tokenHash, err := auth.HashPassword(token, auth.SHA256)
+	if err != nil {
+		s.log(r).Error("Failed to hash invitation token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	ctx := r.Context()
+	workspace, err := s.db.FindWorkspaceById(ctx, sc.workspaceId.Bytes)
+	if err != nil {
+		s.log(r).Error("Failed to fetch workspace", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	inviter, err := s.db.GetUser(ctx, repository.GetUserParams{ID: sc.userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.InvitationTTLHours) * time.Hour)
+
+	invitation, err := s.db.InsertWorkspaceInvitation(ctx, repository.InsertWorkspaceInvitationParams{
+		WorkspaceID: sc.workspaceId.Bytes,
+		Email:       strings.ToLower(requestPayload.Email),
+		Role:        requestPayload.Role,
+		TokenHash:   tokenHash,
+		InvitedBy:   pgtype.UUID{Bytes: sc.userId, Valid: true},
+		ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		s.log(r).Error("Failed to create invitation", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	body := fmt.Sprintf("Hi,\n\n%s %s invited you to join the %s workspace on Termflow as %s. Accept the invitation with this code:\n\n%s\n",
+		inviter.FirstName, inviter.LastName, workspace.Name, invitation.Role, token)
+	if s.cfg.PublicURL != "" {
+		body += fmt.Sprintf("\nor by opening %s/accept-invitation?token=%s\n", strings.TrimRight(s.cfg.PublicURL, "/"), url.QueryEscape(token))
+	}
+	body += fmt.Sprintf("\nThe invitation expires on %s. If you were not expecting it, ignore this email.\n", invitation.ExpiresAt.Time.UTC().Format(time.RFC1123))
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: "You are invited to the " + workspace.Name + " workspace",
+		Body:    body,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to send invitation", slog.String("ERROR", err.Error()))
+
+		// Nobody can accept an invitation that was not sent
+		if err := s.db.DeleteWorkspaceInvitation(ctx, repository.DeleteWorkspaceInvitationParams{
+			ID:          invitation.ID,
+			WorkspaceID: invitation.WorkspaceID,
+		}); err != nil {
+			s.log(r).Error("Failed to delete unsent invitation", slog.String("ERROR", err.Error()))
+		}
+
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to send the invitation email")
+		return
+	}
+
+	responsePayload := invitationResponsePayloadSchema{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+
+	utils.Response(w, http.StatusCreated, responsePayload)
+}
+
+type invitationResponsePayloadSchema struct {
+	ID        uuid.UUID          `json:"id"`
+	Email     string             `json:"email"`
+	Role      string             `json:"role"`
+	InvitedBy pgtype.UUID        `json:"invited_by"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (s *Server) GetWorkspaceInvitations(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	invitations, err := s.db.FindWorkspaceInvitations(r.Context(), sc.workspaceId.Bytes)
+	if err != nil {
+		s.log(r).Error("Failed to fetch invitations", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	responsePayload := make([]invitationResponsePayloadSchema, 0, len(invitations))
+	for _, invitation := range invitations {
+		responsePayload = append(responsePayload, invitationResponsePayloadSchema{
+			ID:        invitation.ID,
+			Email:     invitation.Email,
+			Role:      invitation.Role,
+			InvitedBy: invitation.InvitedBy,
+			ExpiresAt: invitation.ExpiresAt,
+			CreatedAt: invitation.CreatedAt,
+		})
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+func (s *Server) DeleteWorkspaceInvitation(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	_invitationId, err := uuid.Parse(chi.URLParam(r, "invitationId"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	err = s.db.DeleteWorkspaceInvitation(r.Context(), repository.DeleteWorkspaceInvitationParams{
+		ID:          _invitationId,
+		WorkspaceID: sc.workspaceId.Bytes,
+	})
+	if err != nil {
+		s.log(r).Error("Failed to delete invitation", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to delete invitation")
+		return
+	}
+
+	responsePayload := messageResponsePayloadSchema{
+		Message: "Invitation deleted successfully",
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+type acceptInvitationRequestPayloadSchema struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type acceptInvitationResponsePayloadSchema struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Role        string    `json:"role"`
+}
+
+// AcceptWorkspaceInvitation adds the authenticated user to the workspace of
+// an invitation sent to their email address.
+func (s *Server) AcceptWorkspaceInvitation(w http.ResponseWriter, r *http.Request) {
+	_userId, ok := s.authenticatedUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var requestPayload acceptInvitationRequestPayloadSchema
+	if err := s.DecodeAndValidate(w, r, &requestPayload); err != nil {
+		return
+	}
+
+	tokenHash, err := auth.HashPassword(requestPayload.Token, auth.SHA256)
+	if err != nil {
+		s.log(r).Error("Failed to hash invitation token", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	ctx := r.Context()
+	invitation, err := s.db.FindWorkspaceInvitationByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Invitation not found")
+		} else {
+			s.log(r).Error("Failed to fetch invitation", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		}
+
+		return
+	}
+
+	if invitation.AcceptedAt.Valid || invitation.ExpiresAt.Time.Before(time.Now()) {
+		utils.ResponseError(w, http.StatusGone, "Invitation expired")
+		return
+	}
+
+	user, err := s.db.GetUser(ctx, repository.GetUserParams{ID: _userId})
+	if err != nil {
+		s.log(r).Error("Failed to fetch user", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		utils.ResponseError(w, http.StatusForbidden, "Invitation was sent to another email address")
+		return
+	}
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		s.log(r).Error("Failed to start transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	// Rollback the transaction in case of failure
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	queriesWithTx := s.db.WithTx(tx)
+
+	err = queriesWithTx.InsertWorkspaceMember(ctx, repository.InsertWorkspaceMemberParams{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      _userId,
+		Role:        invitation.Role,
+	})
+	if err != nil {
+		s.respondDBError(w, r, err, "Failed to accept invitation")
+		return
+	}
+
+	if err := queriesWithTx.AcceptWorkspaceInvitation(ctx, invitation.ID); err != nil {
+		s.log(r).Error("Failed to accept invitation", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.log(r).Error("Failed to commit transaction", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	responsePayload := acceptInvitationResponsePayloadSchema{
+		WorkspaceID: invitation.WorkspaceID,
+		Role:        invitation.Role,
+	}
+
+	utils.Response(w, http.StatusOK, responsePayload)
+}
+
+// findWorkspaceMember loads the member from the userId URL parameter.
+func (s *Server) findWorkspaceMember(w http.ResponseWriter, r *http.Request, sc scope) (repository.WorkspaceMember, bool) {
+	_memberId, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid user ID")
+		return repository.WorkspaceMember{}, false
+	}
+
+	member, err := s.db.FindWorkspaceMember(r.Context(), repository.FindWorkspaceMemberParams{
+		WorkspaceID: sc.workspaceId.Bytes,
+		UserID:      _memberId,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			utils.ResponseError(w, http.StatusNotFound, "Member not found")
+		} else {
+			s.log(r).Error("Failed to fetch workspace member", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to fetch workspace member")
+		}
+
+		return repository.WorkspaceMember{}, false
+	}
+
+	return member, true
+}
+
+// hasOtherOwner responds with a conflict when member is the last owner of
+// the workspace. The owners stay locked until the transaction of q ends, so
+// that concurrent requests can not take away the other owners meanwhile.
+func (s *Server) hasOtherOwner(w http.ResponseWriter, r *http.Request, q *repository.Queries, member repository.WorkspaceMember) bool {
+	owners, err := q.LockWorkspaceOwners(r.Context(), member.WorkspaceID)
+	if err != nil {
+		s.log(r).Error("Failed to lock workspace owners", slog.String("ERROR", err.Error()))
+		utils.ResponseError(w, http.StatusInternalServerError, "Failed to update workspace member")
+		return false
+	}
+
+	if slices.Contains(owners, member.UserID) && len(owners) < 2 {
+		utils.ResponseError(w, http.StatusConflict, "A workspace needs at least one owner")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/endalk200/termflow-api/internal/repository"
+	"github.com/endalk200/termflow-api/pkgs/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWorkspaceTestServer returns a database test server serving requests
+// with signed access tokens and a workspace of an owner, an editor and a
+// viewer.
+func newWorkspaceTestServer(t *testing.T) (*Server, string, map[string]repository.User) {
+	t.Helper()
+
+	s := newDatabaseTestServer(t, config.AppConfig{MaxBodyBytes: 1 << 20, InvitationTTLHours: 1})
+	useTestSigningKeys(t)
+
+	users := map[string]repository.User{}
+	members := map[uuid.UUID]string{}
+	for _, role := range []string{RoleOwner, RoleEditor, RoleViewer} {
+		users[role] = insertTestUser(t, s, role+"@example.com")
+		members[users[role].ID] = role
+	}
+	workspace := insertTestWorkspace(t, s, members)
+
+	return s, "/api/workspaces/" + workspace.ID.String(), users
+}
+
+func TestWorkspaceRoleEnforcement(t *testing.T) {
+	s, path, users := newWorkspaceTestServer(t)
+	outsider := insertTestUser(t, s, "outsider@example.com")
+
+	for _, test := range []struct {
+		method string
+		path   string
+		body   interface{}
+		role   string
+		code   int
+	}{
+		{http.MethodGet, "/tags", nil, RoleViewer, http.StatusOK},
+		{http.MethodPost, "/tags", createTagRequestPayloadSchema{Name: "viewer"}, RoleViewer, http.StatusForbidden},
+		{http.MethodPost, "/tags", createTagRequestPayloadSchema{Name: "editor"}, RoleEditor, http.StatusCreated},
+		{http.MethodPut, "", workspaceRequestPayloadSchema{Name: "renamed"}, RoleEditor, http.StatusForbidden},
+		{http.MethodPut, "", workspaceRequestPayloadSchema{Name: "renamed"}, RoleOwner, http.StatusOK},
+		{http.MethodGet, "/invitations", nil, RoleEditor, http.StatusForbidden},
+		{http.MethodPut, "/members/" + users[RoleViewer].ID.String(), workspaceMemberRequestPayloadSchema{Role: RoleEditor}, RoleEditor, http.StatusForbidden},
+	} {
+		res := serveTestRequest(t, s, test.method, path+test.path, testAccessToken(t, users[test.role]), test.body)
+		assert.Equal(t, test.code, res.Code, "%s %s as %s: %s", test.method, test.path, test.role, res.Body.String())
+	}
+
+	res := serveTestRequest(t, s, http.MethodGet, path+"/tags", testAccessToken(t, outsider), nil)
+	assert.Equal(t, http.StatusNotFound, res.Code, "non members are told the workspace is not found")
+}
+
+func TestUpdateWorkspaceMemberKeepsAnOwner(t *testing.T) {
+	s, path, users := newWorkspaceTestServer(t)
+	ownerToken := testAccessToken(t, users[RoleOwner])
+	ownerPath := path + "/members/" + users[RoleOwner].ID.String()
+
+	res := serveTestRequest(t, s, http.MethodPut, ownerPath, ownerToken, workspaceMemberRequestPayloadSchema{Role: RoleEditor})
+	assert.Equal(t, http.StatusConflict, res.Code, "the only owner can not step down")
+
+	res = serveTestRequest(t, s, http.MethodPut, path+"/members/"+users[RoleEditor].ID.String(), ownerToken, workspaceMemberRequestPayloadSchema{Role: RoleOwner})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = serveTestRequest(t, s, http.MethodPut, ownerPath, ownerToken, workspaceMemberRequestPayloadSchema{Role: RoleViewer})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var member repository.WorkspaceMember
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &member))
+	assert.Equal(t, RoleViewer, member.Role)
+}
+
+func TestRemoveWorkspaceMember(t *testing.T) {
+	s, path, users := newWorkspaceTestServer(t)
+	ownerToken, viewerToken := testAccessToken(t, users[RoleOwner]), testAccessToken(t, users[RoleViewer])
+	memberPath := func(role string) string {
+		return path + "/members/" + users[role].ID.String()
+	}
+
+	res := serveTestRequest(t, s, http.MethodDelete, memberPath(RoleEditor), viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, res.Code, "members other than owners may only leave")
+
+	res = serveTestRequest(t, s, http.MethodDelete, memberPath(RoleViewer), viewerToken, nil)
+	assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = serveTestRequest(t, s, http.MethodGet, path+"/tags", viewerToken, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodDelete, memberPath(RoleOwner), ownerToken, nil)
+	assert.Equal(t, http.StatusConflict, res.Code, "the only owner can not leave")
+
+	res = serveTestRequest(t, s, http.MethodDelete, memberPath(RoleEditor), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = serveTestRequest(t, s, http.MethodDelete, memberPath(RoleEditor), ownerToken, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestWorkspaceInvitations(t *testing.T) {
+	s, path, users := newWorkspaceTestServer(t)
+	mail := &recordingMailer{}
+	s.mailer = mail
+	ownerToken := testAccessToken(t, users[RoleOwner])
+	invitee := insertTestUser(t, s, "invitee@example.com")
+	inviteeToken := testAccessToken(t, invitee)
+
+	res := serveTestRequest(t, s, http.MethodPost, path+"/invitations", testAccessToken(t, users[RoleEditor]), workspaceInvitationRequestPayloadSchema{Email: "invitee@example.com", Role: RoleEditor})
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, path+"/invitations", ownerToken, workspaceInvitationRequestPayloadSchema{Email: "Invitee@example.com", Role: RoleEditor})
+	require.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+
+	message := mail.last(t)
+	assert.Equal(t, "invitee@example.com", message.To)
+	_, token, _ := strings.Cut(message.Body, "code:\n\n")
+	token, _, _ = strings.Cut(token, "\n")
+	require.NotEmpty(t, token)
+
+	res = serveTestRequest(t, s, http.MethodGet, path+"/invitations", ownerToken, nil)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var invitations []invitationResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &invitations))
+	require.Len(t, invitations, 1)
+
+	accept := acceptInvitationRequestPayloadSchema{Token: token}
+	res = serveTestRequest(t, s, http.MethodPost, "/api/invitations/accept", testAccessToken(t, users[RoleViewer]), accept)
+	assert.Equal(t, http.StatusForbidden, res.Code, "invitations are accepted by the invited address")
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/invitations/accept", inviteeToken, acceptInvitationRequestPayloadSchema{Token: "not-" + token})
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/invitations/accept", inviteeToken, accept)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var accepted acceptInvitationResponsePayloadSchema
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &accepted))
+	assert.Equal(t, RoleEditor, accepted.Role)
+
+	res = serveTestRequest(t, s, http.MethodPost, "/api/invitations/accept", inviteeToken, accept)
+	assert.Equal(t, http.StatusGone, res.Code)
+
+	res = serveTestRequest(t, s, http.MethodPost, path+"/tags", inviteeToken, createTagRequestPayloadSchema{Name: "invitee"})
+	assert.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+
+	_, err := s.db.FindWorkspaceMember(context.Background(), repository.FindWorkspaceMemberParams{
+		WorkspaceID: accepted.WorkspaceID,
+		UserID:      invitee.ID,
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,189 @@
+// Package archive defines the format of account exports. An archive holds
+// the tags, commands, collections and share links of an account and is
+// written and read one record at a time, either as JSON Lines or as a single
+// JSON document, so that large accounts never have to fit in memory.
+//
+// Records refer to each other by the ids they had when exported. A record
+// may only refer to records that come before it, which is why the sections
+// are always in the order tags, commands, collections, share links.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Version is the version of the format written by this package. Archives
+// with a newer version are rejected.
+const Version = 1
+
+type Format string
+
+const (
+	JSONLines Format = "jsonl"
+	JSON      Format = "json"
+)
+
+// ContentType is the media type of an archive in format.
+func (format Format) ContentType() string {
+	if format == JSON {
+		return "application/json"
+	}
+
+	return "application/x-ndjson"
+}
+
+// ParseFormat accepts the name of a format, defaulting to JSON Lines.
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", JSONLines:
+		return JSONLines, nil
+	case JSON:
+		return JSON, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected %q or %q", name, JSONLines, JSON)
+	}
+}
+
+// Record types, in the order they appear in an archive.
+const (
+	TypeTag        = "tag"
+	TypeCommand    = "command"
+	TypeCollection = "collection"
+	TypeShareLink  = "share_link"
+)
+
+// sections lists the record types with the key of their array in a JSON
+// document.
+var sections = []struct {
+	recordType string
+	key        string
+}{
+	{TypeTag, "tags"},
+	{TypeCommand, "commands"},
+	{TypeCollection, "collections"},
+	{TypeShareLink, "share_links"},
+}
+
+type Header struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type Tag struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Command lists its tags by name.
+type Command struct {
+	ID          string   `json:"id"`
+	Command     string   `json:"command"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags"`
+}
+
+// Collection lists the ids of its commands in order.
+type Collection struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Commands    []string `json:"commands"`
+}
+
+// ShareLink points at either a command or a collection.
+type ShareLink struct {
+	ID           string     `json:"id"`
+	Slug         string     `json:"slug"`
+	CommandID    string     `json:"command_id,omitempty"`
+	CollectionID string     `json:"collection_id,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// Record is a single item of an archive, exactly one of its fields is set.
+type Record struct {
+	Tag        *Tag
+	Command    *Command
+	Collection *Collection
+	ShareLink  *ShareLink
+}
+
+// Type is the type of the item held by the record.
+func (record Record) Type() string {
+	switch {
+	case record.Tag != nil:
+		return TypeTag
+	case record.Command != nil:
+		return TypeCommand
+	case record.Collection != nil:
+		return TypeCollection
+	case record.ShareLink != nil:
+		return TypeShareLink
+	default:
+		return ""
+	}
+}
+
+func (record Record) data() interface{} {
+	switch {
+	case record.Tag != nil:
+		return record.Tag
+	case record.Command != nil:
+		return record.Command
+	case record.Collection != nil:
+		return record.Collection
+	default:
+		return record.ShareLink
+	}
+}
+
+// Validate checks that the record has the fields an import needs. It does
+// not check references to other records.
+func (record Record) Validate() error {
+	switch {
+	case record.Tag != nil:
+		if record.Tag.ID == "" {
+			return errors.New("tag has no id")
+		}
+		if record.Tag.Name == "" {
+			return errors.New("tag has no name")
+		}
+	case record.Command != nil:
+		if record.Command.ID == "" {
+			return errors.New("command has no id")
+		}
+		if record.Command.Command == "" {
+			return errors.New("command is empty")
+		}
+	case record.Collection != nil:
+		if record.Collection.ID == "" {
+			return errors.New("collection has no id")
+		}
+		if record.Collection.Name == "" {
+			return errors.New("collection has no name")
+		}
+	case record.ShareLink != nil:
+		if record.ShareLink.Slug == "" {
+			return errors.New("share link has no slug")
+		}
+		if (record.ShareLink.CommandID == "") == (record.ShareLink.CollectionID == "") {
+			return errors.New("share link must point at either a command or a collection")
+		}
+	default:
+		return errors.New("record is empty")
+	}
+
+	return nil
+}
+
+func sectionIndex(recordType string) int {
+	for i, section := range sections {
+		if section.recordType == recordType {
+			return i
+		}
+	}
+
+	return -1
+}
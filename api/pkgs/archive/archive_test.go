@@ -0,0 +1,158 @@
+package archive_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/archive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var records = []archive.Record{
+	{Tag: &archive.Tag{ID: "t1", Name: "git"}},
+	{Tag: &archive.Tag{ID: "t2", Name: "docker", Description: "containers"}},
+	{Command: &archive.Command{ID: "c1", Command: "git status && git diff", Tags: []string{"git"}}},
+	{Collection: &archive.Collection{ID: "l1", Name: "daily", Commands: []string{"c1"}}},
+	{ShareLink: &archive.ShareLink{ID: "s1", Slug: "abc", CommandID: "c1"}},
+}
+
+func readAll(t *testing.T, reader *archive.Reader) ([]archive.Record, []error) {
+	var read []archive.Record
+	var recordErrors []error
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return read, recordErrors
+		}
+
+		var recordErr *archive.RecordError
+		if errors.As(err, &recordErr) {
+			recordErrors = append(recordErrors, err)
+			continue
+		}
+		require.NoError(t, err)
+
+		read = append(read, record)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	exportedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []archive.Format{archive.JSONLines, archive.JSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer := archive.NewWriter(&buffer, format, exportedAt)
+			for _, record := range records {
+				require.NoError(t, writer.Write(record))
+			}
+			require.NoError(t, writer.Close())
+
+			assert.Contains(t, buffer.String(), "git status && git diff")
+
+			reader := archive.NewReader(&buffer, format)
+			read, recordErrors := readAll(t, reader)
+
+			assert.Empty(t, recordErrors)
+			assert.Equal(t, records, read)
+			assert.Equal(t, archive.Version, reader.Header.Version)
+			assert.True(t, exportedAt.Equal(reader.Header.ExportedAt))
+		})
+	}
+}
+
+func TestWriterEmptyDocument(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := archive.NewWriter(&buffer, archive.JSON, time.Unix(0, 0))
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, `{"version":1,"exported_at":"1970-01-01T00:00:00Z","tags":[],"commands":[],"collections":[],"share_links":[]}`+"\n", buffer.String())
+}
+
+func TestWriterOrder(t *testing.T) {
+	writer := archive.NewWriter(io.Discard, archive.JSONLines, time.Now())
+	require.NoError(t, writer.Write(records[2]))
+
+	assert.Error(t, writer.Write(records[0]))
+}
+
+func TestReaderJSONLines(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"header","data":{"version":1}}`,
+		`{"type":"tag","data":{"id":"t1","name":"git"}}`,
+		``,
+		`{"type":"tag","data":{"id":"t2"}}`,
+		`not json`,
+		`{"type":"runbook","data":{}}`,
+		`{"type":"command","data":{"id":"c1","command":"ls","tags":["git"]}}`,
+		`{"type":"tag","data":{"id":"t3","name":"late"}}`,
+	}, "\n")
+
+	read, recordErrors := readAll(t, archive.NewReader(strings.NewReader(input), archive.JSONLines))
+
+	require.Len(t, read, 2)
+	assert.Equal(t, "git", read[0].Tag.Name)
+	assert.Equal(t, "ls", read[1].Command.Command)
+
+	require.Len(t, recordErrors, 4)
+	assert.Contains(t, recordErrors[0].Error(), "line 4: tag has no name")
+	assert.Contains(t, recordErrors[1].Error(), "line 5")
+	assert.Contains(t, recordErrors[2].Error(), `unknown record type "runbook"`)
+	assert.Contains(t, recordErrors[3].Error(), "tag records must come before command records")
+}
+
+func TestReaderJSONDocument(t *testing.T) {
+	t.Run("Invalid records", func(t *testing.T) {
+		input := `{
+			"version": 1,
+			"extra": {"ignored": true},
+			"tags": [{"id": "t1", "name": "git"}, {"id": "t2", "name": 5}],
+			"commands": [{"id": "c1", "command": ""}],
+			"share_links": [{"slug": "abc", "command_id": "c1", "collection_id": "l1"}]
+		}`
+
+		read, recordErrors := readAll(t, archive.NewReader(strings.NewReader(input), archive.JSON))
+
+		require.Len(t, read, 1)
+		require.Len(t, recordErrors, 3)
+		assert.Contains(t, recordErrors[0].Error(), "tags[1]")
+		assert.Contains(t, recordErrors[1].Error(), "commands[0]: command is empty")
+		assert.Contains(t, recordErrors[2].Error(), "either a command or a collection")
+	})
+
+	t.Run("Sections out of order", func(t *testing.T) {
+		reader := archive.NewReader(strings.NewReader(`{"commands": [{"id": "c1", "command": "ls"}], "tags": []}`), archive.JSON)
+
+		_, err := reader.Next()
+		require.NoError(t, err)
+
+		_, err = reader.Next()
+		assert.ErrorContains(t, err, `"tags" must come before "commands"`)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		reader := archive.NewReader(strings.NewReader(`{"tags": [{"id": "t1", "name": "git"}`), archive.JSON)
+
+		_, err := reader.Next()
+		require.NoError(t, err)
+
+		// Not a record error, the archive cannot be read any further
+		_, err = reader.Next()
+		var recordErr *archive.RecordError
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &recordErr))
+	})
+
+	t.Run("Newer version", func(t *testing.T) {
+		reader := archive.NewReader(strings.NewReader(`{"version": 99, "tags": []}`), archive.JSON)
+
+		_, err := reader.Next()
+		assert.ErrorContains(t, err, "version 99")
+	})
+}
@@ -0,0 +1,287 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RecordError is returned by Reader.Next for a record that is invalid. The
+// reader can go on with the next record after it, any other error ends the
+// archive.
+type RecordError struct {
+	Position string
+	Err      error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Position, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads the records of an archive one at a time.
+type Reader struct {
+	format Format
+	// Header is filled in as soon as the reader comes across it.
+	Header Header
+
+	// section is the index of the section of the last record, records may
+	// not go back to an earlier section.
+	section  int
+	position string
+
+	// JSON Lines
+	lines *bufio.Reader
+	line  int
+
+	// JSON document
+	decoder *json.Decoder
+	started bool
+	inArray bool
+	index   int
+}
+
+func NewReader(r io.Reader, format Format) *Reader {
+	reader := &Reader{format: format, section: -1}
+
+	if format == JSONLines {
+		reader.lines = bufio.NewReader(r)
+	} else {
+		reader.decoder = json.NewDecoder(r)
+	}
+
+	return reader
+}
+
+// Position describes where the last record was read, as a line number for
+// JSON Lines and as an array index for a JSON document.
+func (r *Reader) Position() string {
+	return r.position
+}
+
+// Next returns the next valid record and io.EOF at the end of the archive.
+// Invalid records are reported as a *RecordError.
+func (r *Reader) Next() (Record, error) {
+	if r.format == JSONLines {
+		return r.nextLine()
+	}
+
+	return r.nextElement()
+}
+
+func (r *Reader) nextLine() (Record, error) {
+	for {
+		line, err := r.lines.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return Record{}, err
+			}
+
+			r.line++
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return Record{}, err
+		}
+
+		r.line++
+		r.position = fmt.Sprintf("line %d", r.line)
+
+		var envelope struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(line, &envelope); err != nil {
+			return Record{}, r.recordError(err)
+		}
+
+		if envelope.Type == "header" {
+			if r.line > 1 {
+				return Record{}, r.recordError(errors.New("the header must be the first line"))
+			}
+
+			if err := json.Unmarshal(envelope.Data, &r.Header); err != nil {
+				return Record{}, r.recordError(err)
+			}
+
+			if err := checkVersion(r.Header.Version); err != nil {
+				return Record{}, err
+			}
+
+			continue
+		}
+
+		record, err := newRecord(envelope.Type)
+		if err != nil {
+			return Record{}, r.recordError(err)
+		}
+
+		if err := json.Unmarshal(envelope.Data, record.data()); err != nil {
+			return Record{}, r.recordError(err)
+		}
+
+		return r.checkRecord(record)
+	}
+}
+
+func (r *Reader) nextElement() (Record, error) {
+	if !r.started {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+
+		if token != json.Delim('{') {
+			return Record{}, errors.New("archive must be a JSON object")
+		}
+
+		r.started = true
+	}
+
+	for {
+		if r.inArray {
+			if r.decoder.More() {
+				r.index++
+				r.position = fmt.Sprintf("%s[%d]", sections[r.section].key, r.index)
+
+				record, _ := newRecord(sections[r.section].recordType)
+				if err := r.decoder.Decode(record.data()); err != nil {
+					// The decoder stays usable after a value of the wrong
+					// type but not after a syntax error
+					var typeErr *json.UnmarshalTypeError
+					if errors.As(err, &typeErr) {
+						return Record{}, r.recordError(err)
+					}
+
+					return Record{}, unexpectedEOF(err)
+				}
+
+				return r.checkRecord(record)
+			}
+
+			if _, err := r.decoder.Token(); err != nil {
+				return Record{}, unexpectedEOF(err)
+			}
+
+			r.inArray = false
+			continue
+		}
+
+		token, err := r.decoder.Token()
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+
+		if token == json.Delim('}') {
+			return Record{}, io.EOF
+		}
+
+		key, _ := token.(string)
+		switch key {
+		case "version":
+			if err := r.decoder.Decode(&r.Header.Version); err != nil {
+				return Record{}, err
+			}
+
+			if err := checkVersion(r.Header.Version); err != nil {
+				return Record{}, err
+			}
+		case "exported_at":
+			if err := r.decoder.Decode(&r.Header.ExportedAt); err != nil {
+				return Record{}, err
+			}
+		default:
+			index := -1
+			for i, section := range sections {
+				if section.key == key {
+					index = i
+				}
+			}
+
+			// Unknown keys are skipped
+			if index < 0 {
+				var skipped json.RawMessage
+				if err := r.decoder.Decode(&skipped); err != nil {
+					return Record{}, unexpectedEOF(err)
+				}
+
+				continue
+			}
+
+			if index < r.section {
+				return Record{}, fmt.Errorf("%q must come before %q", key, sections[r.section].key)
+			}
+
+			token, err := r.decoder.Token()
+			if err != nil {
+				return Record{}, unexpectedEOF(err)
+			}
+
+			if token != json.Delim('[') {
+				return Record{}, fmt.Errorf("%q must be an array", key)
+			}
+
+			r.section = index
+			r.inArray = true
+			r.index = -1
+		}
+	}
+}
+
+// checkRecord validates a record and makes sure it does not come after a
+// record of a later section.
+func (r *Reader) checkRecord(record Record) (Record, error) {
+	index := sectionIndex(record.Type())
+	if index < r.section {
+		return Record{}, r.recordError(fmt.Errorf("%s records must come before %s records", record.Type(), sections[r.section].recordType))
+	}
+	r.section = index
+
+	if err := record.Validate(); err != nil {
+		return Record{}, r.recordError(err)
+	}
+
+	return record, nil
+}
+
+func (r *Reader) recordError(err error) error {
+	return &RecordError{Position: r.position, Err: err}
+}
+
+func newRecord(recordType string) (Record, error) {
+	switch recordType {
+	case TypeTag:
+		return Record{Tag: &Tag{}}, nil
+	case TypeCommand:
+		return Record{Command: &Command{}}, nil
+	case TypeCollection:
+		return Record{Collection: &Collection{}}, nil
+	case TypeShareLink:
+		return Record{ShareLink: &ShareLink{}}, nil
+	default:
+		return Record{}, fmt.Errorf("unknown record type %q", recordType)
+	}
+}
+
+func checkVersion(version int) error {
+	if version > Version {
+		return fmt.Errorf("archive version %d is newer than the supported version %d", version, Version)
+	}
+
+	return nil
+}
+
+// unexpectedEOF reports an archive that ends in the middle of the document.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
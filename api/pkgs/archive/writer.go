@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer streams records to an archive. Records have to be written in
+// section order, and Close has to be called to complete the archive.
+type Writer struct {
+	w      io.Writer
+	format Format
+	header Header
+
+	started bool
+	// section is the index of the section written to last, count the number
+	// of records written to it.
+	section int
+	count   int
+}
+
+func NewWriter(w io.Writer, format Format, exportedAt time.Time) *Writer {
+	return &Writer{
+		w:       w,
+		format:  format,
+		header:  Header{Version: Version, ExportedAt: exportedAt.UTC()},
+		section: -1,
+	}
+}
+
+func (w *Writer) Write(record Record) error {
+	index := sectionIndex(record.Type())
+	if index < 0 {
+		return fmt.Errorf("record is empty")
+	}
+	if index < w.section {
+		return fmt.Errorf("%s records must be written before %s records", record.Type(), sections[w.section].recordType)
+	}
+
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if w.format == JSONLines {
+		w.section = index
+		return w.writeLine(record.Type(), record.data())
+	}
+
+	if err := w.openSection(index); err != nil {
+		return err
+	}
+
+	data, err := marshal(record.data())
+	if err != nil {
+		return err
+	}
+
+	if w.count > 0 {
+		data = append([]byte(","), data...)
+	}
+	w.count++
+
+	_, err = w.w.Write(data)
+	return err
+}
+
+// Close completes the archive, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if w.format == JSONLines {
+		return nil
+	}
+
+	if err := w.openSection(len(sections) - 1); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w.w, "]}\n")
+	return err
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	if w.format == JSONLines {
+		return w.writeLine("header", w.header)
+	}
+
+	// The header fields open the document, the sections follow them
+	header, err := marshal(w.header)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(bytes.TrimSuffix(header, []byte("}")))
+	return err
+}
+
+// openSection closes the section written to last and opens every section up
+// to index, so that empty sections still appear in the document.
+func (w *Writer) openSection(index int) error {
+	for w.section < index {
+		if w.section >= 0 {
+			if _, err := io.WriteString(w.w, "]"); err != nil {
+				return err
+			}
+		}
+
+		w.section++
+		w.count = 0
+
+		if _, err := io.WriteString(w.w, `,"`+sections[w.section].key+`":[`); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeLine(recordType string, data interface{}) error {
+	line, err := marshal(map[string]interface{}{
+		"type": recordType,
+		"data": data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(append(line, '\n'))
+	return err
+}
+
+// marshal encodes v without escaping the HTML characters that are common
+// in shell commands.
+func marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -64,7 +64,14 @@ func LoadPublicKey(path string) (ed25519.PublicKey, error) {
 	return ed25519PublicKey, nil
 }
 
-func GenerateJWT(claims jwt.RegisteredClaims) (string, error) {
+// Claims are the claims of access tokens. Role is the role the user had
+// when the token was issued.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
+}
+
+func GenerateJWT(claims jwt.Claims) (string, error) {
 	privateKey, err := LoadPrivateKey("./test/test_private_key.pem")
 	if err != nil {
 		return "", fmt.Errorf("Error loading private key: %s", err)
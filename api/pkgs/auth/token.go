@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// GenerateToken returns a random URL safe token made of size random bytes,
+// for links sent to users such as invitations. Store it hashed with SHA256.
+func GenerateToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
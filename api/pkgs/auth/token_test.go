@@ -0,0 +1,31 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/auth"
+)
+
+func TestGenerateToken(t *testing.T) {
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatalf("Expected a URL safe base64 token, got %v", err)
+	}
+	if len(decoded) != 32 {
+		t.Errorf("Expected 32 random bytes, got %d", len(decoded))
+	}
+
+	other, err := auth.GenerateToken(32)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token == other {
+		t.Error("Expected two tokens to differ")
+	}
+}
@@ -1,10 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
+
+	"github.com/joho/godotenv"
 )
 
 // AppConfig holds all configuration for the application
@@ -16,6 +22,142 @@ type AppConfig struct {
 	DbPassword      string `env:"DB_PASSWORD" required:"true"`
 	DbName          string `env:"DB_NAME" required:"true"`
 	LogLevel        string `env:"LOG_LEVEL" default:"INFO"`
+	// LogFormat is "json" or "text".
+	LogFormat string `env:"LOG_FORMAT" default:"json"`
+	// MetricsPort serves /metrics on a port of its own, to keep it off the
+	// public one. Zero serves it along with the API.
+	MetricsPort int `env:"METRICS_PORT" default:"0"`
+	// On SIGTERM or SIGINT requests in flight have ShutdownTimeoutSeconds
+	// to finish before their connections are closed.
+	ShutdownTimeoutSeconds int `env:"SHUTDOWN_TIMEOUT_SECONDS" default:"30"`
+	// TracesExporter is "none" or "otlp", which sends spans to the
+	// collector set with the standard OTEL_EXPORTER_OTLP_* variables.
+	TracesExporter string `env:"OTEL_TRACES_EXPORTER" default:"none"`
+	ServiceName    string `env:"OTEL_SERVICE_NAME" default:"termflow-api"`
+
+	InvitationTTLHours int `env:"INVITATION_TTL_HOURS" default:"168"`
+	// PublicURL prefixes links handed out to users, such as share links.
+	PublicURL string `env:"PUBLIC_URL"`
+
+	// Deleted commands and tags stay in the trash for TrashRetentionDays
+	// before the purge job, running every TrashPurgeIntervalMinutes, removes
+	// them for good. A retention of zero keeps them until purged by hand.
+	TrashRetentionDays        int `env:"TRASH_RETENTION_DAYS" default:"30"`
+	TrashPurgeIntervalMinutes int `env:"TRASH_PURGE_INTERVAL_MINUTES" default:"60"`
+
+	// RequireIfMatch rejects updates and deletes of commands and tags that
+	// do not send an If-Match header, instead of only honoring it.
+	RequireIfMatch bool `env:"REQUIRE_IF_MATCH" default:"false"`
+
+	// Responses to POST requests sent with an Idempotency-Key are kept for
+	// IdempotencyKeyTTLHours. A retry arriving while the first request is in
+	// flight waits up to IdempotencyWaitSeconds for its response. A key
+	// whose request never finished is free again once that request would
+	// have timed out.
+	IdempotencyKeyTTLHours int `env:"IDEMPOTENCY_KEY_TTL_HOURS" default:"24"`
+	IdempotencyWaitSeconds int `env:"IDEMPOTENCY_WAIT_SECONDS" default:"10"`
+
+	// Usage syncs are remembered for UsageSyncRetentionDays so that the CLI
+	// retrying one is not counted twice, a retry coming later than that is.
+	// A retention of zero keeps them.
+	UsageSyncRetentionDays int `env:"USAGE_SYNC_RETENTION_DAYS" default:"90"`
+
+	// An account is deleted AccountDeletionGraceDays after the user asks
+	// for it, until then the deletion can be cancelled. Zero deletes the
+	// account right away.
+	AccountDeletionGraceDays int `env:"ACCOUNT_DELETION_GRACE_DAYS" default:"30"`
+
+	// Emails are sent through SMTP_HOST, without it they are only logged.
+	SmtpHost     string `env:"SMTP_HOST"`
+	SmtpPort     int    `env:"SMTP_PORT" default:"587"`
+	SmtpUsername string `env:"SMTP_USERNAME"`
+	SmtpPassword string `env:"SMTP_PASSWORD"`
+	MailFrom     string `env:"MAIL_FROM" default:"Termflow <no-reply@localhost>"`
+
+	// EmailChangeTTLHours is how long the link confirming a new email
+	// address stays valid.
+	EmailChangeTTLHours int `env:"EMAIL_CHANGE_TTL_HOURS" default:"24"`
+
+	// After LoginFreeAttempts failed sign-ins in a row each attempt has to
+	// wait, starting at one second and doubling up to LoginMaxDelaySeconds.
+	// LoginLockoutAttempts failures lock the account for
+	// LoginLockoutMinutes, zero never locks it. The count starts over once
+	// no attempt failed for LoginFailureResetHours.
+	LoginFreeAttempts      int `env:"LOGIN_FREE_ATTEMPTS" default:"3"`
+	LoginMaxDelaySeconds   int `env:"LOGIN_MAX_DELAY_SECONDS" default:"30"`
+	LoginLockoutAttempts   int `env:"LOGIN_LOCKOUT_ATTEMPTS" default:"10"`
+	LoginLockoutMinutes    int `env:"LOGIN_LOCKOUT_MINUTES" default:"15"`
+	LoginFailureResetHours int `env:"LOGIN_FAILURE_RESET_HOURS" default:"24"`
+
+	// Requests allowed per minute and client, in bursts up to the same
+	// number. Sign-up, sign-in and email confirmation count against
+	// RateLimitAuthPerMinute per IP address, shared links and the API docs
+	// against RateLimitPublicPerMinute each and authenticated requests
+	// against RateLimitUserPerMinute per user. Zero disables a limit.
+	RateLimitAuthPerMinute   int `env:"RATE_LIMIT_AUTH_PER_MINUTE" default:"10"`
+	RateLimitPublicPerMinute int `env:"RATE_LIMIT_PUBLIC_PER_MINUTE" default:"60"`
+	RateLimitUserPerMinute   int `env:"RATE_LIMIT_USER_PER_MINUTE" default:"300"`
+
+	// TrustedProxyHeader, "forwarded" or "x-forwarded-for", is the header
+	// the proxies set. It is the only one read, and only on requests from
+	// TrustedProxies. IPAllowList, when set, is the only clients let in and
+	// IPDenyList the clients kept out. All three are lists of CIDRs or
+	// addresses separated by commas. The two access lists are read again
+	// from .env on SIGHUP, unless they are set in the environment.
+	TrustedProxies     string `env:"TRUSTED_PROXIES"`
+	TrustedProxyHeader string `env:"TRUSTED_PROXY_HEADER" default:"x-forwarded-for"`
+	IPAllowList        string `env:"IP_ALLOW_LIST"`
+	IPDenyList         string `env:"IP_DENY_LIST"`
+
+	// Browsers may call the API from CorsAllowedOrigins, a list separated
+	// by commas where "*" stands for any origin. Without origins CORS is
+	// disabled.
+	CorsAllowedOrigins   string `env:"CORS_ALLOWED_ORIGINS"`
+	CorsAllowedMethods   string `env:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE"`
+	CorsAllowedHeaders   string `env:"CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,If-Match,If-None-Match,Idempotency-Key,X-Request-ID"`
+	CorsAllowCredentials bool   `env:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	CorsMaxAgeSeconds    int    `env:"CORS_MAX_AGE_SECONDS" default:"600"`
+
+	// HstsMaxAgeSeconds is sent in Strict-Transport-Security, zero leaves
+	// the header out for deployments not behind HTTPS.
+	HstsMaxAgeSeconds int `env:"HSTS_MAX_AGE_SECONDS" default:"31536000"`
+
+	// Request bodies are limited to MaxBodyBytes, except for imports that
+	// may be up to MaxImportBodyBytes.
+	MaxBodyBytes       int `env:"MAX_BODY_BYTES" default:"1048576"`
+	MaxImportBodyBytes int `env:"MAX_IMPORT_BODY_BYTES" default:"33554432"`
+
+	// Requests have 30 seconds to be read and answered, except for exports
+	// and imports that stream for up to StreamTimeoutSeconds.
+	StreamTimeoutSeconds int `env:"STREAM_TIMEOUT_SECONDS" default:"600"`
+}
+
+// LoadEnvFile adds the variables of the .env file in the working directory
+// to the environment, except those the environment sets already, and
+// returns the names of the variables it added. A missing file is not an
+// error.
+func LoadEnvFile() ([]string, error) {
+	env, err := godotenv.Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	var loaded []string
+	for name, value := range env {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+
+		if err := os.Setenv(name, value); err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, name)
+	}
+
+	return loaded, nil
 }
 
 // LoadConfig dynamically loads environment variables into the config struct
@@ -85,7 +227,23 @@ func setFieldValue(field reflect.Value, value string) error {
 	return nil
 }
 
+// ConstructDatabaseUrl builds the connection URL of the database from the
+// DB_* settings, escaping the credentials.
 func ConstructDatabaseUrl(cfg AppConfig) (string, error) {
+	if cfg.DbHost == "" {
+		return "", errors.New("DB_HOST is not set")
+	}
+	if cfg.DbPort <= 0 || cfg.DbPort > 65535 {
+		return "", fmt.Errorf("invalid DB_PORT %d", cfg.DbPort)
+	}
+
+	databaseUrl := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DbUsername, cfg.DbPassword),
+		Host:     net.JoinHostPort(cfg.DbHost, strconv.Itoa(cfg.DbPort)),
+		Path:     "/" + cfg.DbName,
+		RawQuery: "sslmode=disable",
+	}
 
-	return fmt.Sprintf("postgres://%s:%s@localhost:5432/%s?sslmode=disable", cfg.DbUsername, cfg.DbPassword, cfg.DbName), nil
+	return databaseUrl.String(), nil
 }
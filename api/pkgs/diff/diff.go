@@ -0,0 +1,88 @@
+// Package diff computes line-oriented differences between two texts. It is
+// used to show what changed between two revisions of a command.
+package diff
+
+import "strings"
+
+type Kind string
+
+const (
+	Equal  Kind = "equal"
+	Insert Kind = "insert"
+	Delete Kind = "delete"
+)
+
+// Op is a single line of a diff. Text is the line without its newline.
+type Op struct {
+	Kind Kind   `json:"kind"`
+	Text string `json:"text"`
+}
+
+// Lines diffs a and b line by line.
+func Lines(a, b string) []Op {
+	return Diff(split(a), split(b))
+}
+
+// Diff returns the shortest edit script turning a into b, based on the
+// longest common subsequence of the two slices. Deletions are reported
+// before insertions at the same position.
+func Diff(a, b []string) []Op {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Kind: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Op{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, Op{Kind: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, Op{Kind: Insert, Text: b[j]})
+	}
+
+	return ops
+}
+
+// Changed reports whether ops contains anything other than equal lines.
+func Changed(ops []Op) bool {
+	for _, op := range ops {
+		if op.Kind != Equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,55 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("Identical", func(t *testing.T) {
+		ops := diff.Lines("ls -la\npwd", "ls -la\npwd")
+
+		assert.False(t, diff.Changed(ops))
+		assert.Equal(t, []diff.Op{
+			{Kind: diff.Equal, Text: "ls -la"},
+			{Kind: diff.Equal, Text: "pwd"},
+		}, ops)
+	})
+
+	t.Run("Replaced line", func(t *testing.T) {
+		ops := diff.Lines("cd /tmp\nls -la\npwd", "cd /tmp\nls -lah\npwd")
+
+		assert.True(t, diff.Changed(ops))
+		assert.Equal(t, []diff.Op{
+			{Kind: diff.Equal, Text: "cd /tmp"},
+			{Kind: diff.Delete, Text: "ls -la"},
+			{Kind: diff.Insert, Text: "ls -lah"},
+			{Kind: diff.Equal, Text: "pwd"},
+		}, ops)
+	})
+
+	t.Run("From empty", func(t *testing.T) {
+		ops := diff.Lines("", "echo hi\n")
+
+		assert.Equal(t, []diff.Op{{Kind: diff.Insert, Text: "echo hi"}}, ops)
+	})
+
+	t.Run("To empty", func(t *testing.T) {
+		ops := diff.Lines("echo hi", "")
+
+		assert.Equal(t, []diff.Op{{Kind: diff.Delete, Text: "echo hi"}}, ops)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	ops := diff.Diff([]string{"a", "b", "c"}, []string{"b", "c", "d"})
+
+	assert.Equal(t, []diff.Op{
+		{Kind: diff.Delete, Text: "a"},
+		{Kind: diff.Equal, Text: "b"},
+		{Kind: diff.Equal, Text: "c"},
+		{Kind: diff.Insert, Text: "d"},
+	}, ops)
+}
@@ -0,0 +1,45 @@
+// Package lockout decides how long an account has to wait before its next
+// sign-in attempt, given how many attempts failed in a row.
+package lockout
+
+import (
+	"math"
+	"time"
+)
+
+// Policy lets FreeAttempts failures through without a delay. Each further
+// failure doubles the wait, starting at BaseDelay and capped at MaxDelay,
+// until LockoutAttempts failures lock the account for LockoutDuration.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAttempts int
+	LockoutDuration time.Duration
+}
+
+// Wait returns how long to wait after the given number of failures in a
+// row and whether the account is locked, as opposed to only slowed down.
+// A zero LockoutAttempts never locks the account.
+func (p Policy) Wait(failures int) (time.Duration, bool) {
+	if p.LockoutAttempts > 0 && failures >= p.LockoutAttempts {
+		return p.LockoutDuration, true
+	}
+
+	if failures < p.FreeAttempts || p.BaseDelay <= 0 {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures && delay <= math.MaxInt64/2; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay, false
+}
@@ -0,0 +1,50 @@
+package lockout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/lockout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyWait(t *testing.T) {
+	policy := lockout.Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutAttempts: 10,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		wait     time.Duration
+		locked   bool
+	}{
+		{"None", 0, 0, false},
+		{"Free", 2, 0, false},
+		{"FirstDelay", 3, time.Second, false},
+		{"Doubles", 5, 4 * time.Second, false},
+		{"Capped", 9, 30 * time.Second, false},
+		{"Locked", 10, 15 * time.Minute, true},
+		{"StillLocked", 12, 15 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, locked := policy.Wait(tt.failures)
+			assert.Equal(t, tt.wait, wait)
+			assert.Equal(t, tt.locked, locked)
+		})
+	}
+}
+
+func TestPolicyWaitWithoutLockout(t *testing.T) {
+	policy := lockout.Policy{FreeAttempts: 1, BaseDelay: time.Second}
+
+	wait, locked := policy.Wait(100)
+	assert.False(t, locked)
+	assert.Greater(t, wait, time.Second)
+}
@@ -0,0 +1,113 @@
+// Package mailer sends the emails of the application, such as address
+// verifications. Messages are plain text.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTP sends messages through an SMTP server. Username may be empty for
+// servers that do not ask for authentication.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender, either a bare address or one with a name such as
+	// "Termflow <no-reply@example.com>".
+	From string
+}
+
+func (m *SMTP) Send(ctx context.Context, message Message) error {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %v", m.From, err)
+	}
+
+	data, err := Format(from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// net/smtp does not take a context, the send runs to completion in the
+	// background when ctx is done first
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.Host, strconv.Itoa(m.Port)), auth, from.Address, []string{message.To}, data)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Log writes messages to a logger instead of sending them, for development
+// where no SMTP server is configured.
+type Log struct {
+	Logger *slog.Logger
+}
+
+func (m *Log) Send(ctx context.Context, message Message) error {
+	m.Logger.Info("Email not sent, no SMTP server is configured",
+		slog.String("to", message.To),
+		slog.String("subject", message.Subject),
+		slog.String("body", message.Body),
+	)
+
+	return nil
+}
+
+// Format encodes message as an RFC 5322 message from the sender.
+func Format(from *mail.Address, message Message, date time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %v", message.To, err)
+	}
+
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, errors.New("subject must be a single line")
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buffer, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	buffer.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,42 @@
+package mailer_test
+
+import (
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	from := &mail.Address{Name: "Termflow", Address: "no-reply@example.com"}
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Message", func(t *testing.T) {
+		data, err := mailer.Format(from, mailer.Message{
+			To:      "user@example.com",
+			Subject: "Confirm your email",
+			Body:    "Hello,\nyour code is 123",
+		}, date)
+		require.NoError(t, err)
+
+		assert.Equal(t, "From: \"Termflow\" <no-reply@example.com>\r\n"+
+			"To: <user@example.com>\r\n"+
+			"Subject: Confirm your email\r\n"+
+			"Date: Wed, 01 May 2024 12:00:00 +0000\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: text/plain; charset=utf-8\r\n"+
+			"\r\n"+
+			"Hello,\r\nyour code is 123", string(data))
+	})
+
+	t.Run("Header injection", func(t *testing.T) {
+		_, err := mailer.Format(from, mailer.Message{To: "user@example.com", Subject: "Hi\r\nBcc: other@example.com"}, date)
+		assert.Error(t, err)
+
+		_, err = mailer.Format(from, mailer.Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "Hi"}, date)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,135 @@
+// Package metrics collects the Prometheus metrics of the API: HTTP
+// requests by route, connection pool statistics, sign-in outcomes and
+// business counters.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "termflow"
+
+// Outcomes of a sign-in attempt.
+const (
+	SignInSucceeded          = "success"
+	SignInInvalidCredentials = "invalid_credentials"
+	SignInThrottled          = "throttled"
+	SignInLocked             = "locked"
+	SignInInactive           = "inactive"
+)
+
+// unmatchedRoute labels requests no route matched, so that unknown paths
+// do not each get their own series.
+const unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	signIns         *prometheus.CounterVec
+	commandsCreated prometheus.Counter
+}
+
+// New registers the metrics of the API, with the Go runtime and process
+// metrics, and the statistics of pool when it is not nil.
+func New(pool *pgxpool.Pool) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		signIns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_signins_total",
+			Help:      "Sign-in attempts by outcome.",
+		}, []string{"outcome"}),
+		commandsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_created_total",
+			Help:      "Commands created, including imported ones.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.signIns,
+		m.commandsCreated,
+	)
+	if pool != nil {
+		m.registry.MustRegister(newPoolCollector(pool))
+	}
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// SignIn counts a sign-in attempt with one of the SignIn outcomes.
+func (m *Metrics) SignIn(outcome string) {
+	m.signIns.WithLabelValues(outcome).Inc()
+}
+
+// CommandsCreated counts commands created, one at a time or n at once by
+// an import.
+func (m *Metrics) CommandsCreated(n int) {
+	m.commandsCreated.Add(float64(n))
+}
+
+// Middleware counts and times requests by the chi route pattern they
+// matched, such as /api/commands/{id}, rather than by their path.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := strconv.Itoa(rw.statusCode)
+		m.requests.WithLabelValues(r.Method, route, status).Inc()
+		m.requestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -2,17 +2,23 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/endalk200/termflow-api/pkgs/auth"
 	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type contextKey string
 
-const userContextKey contextKey = "userId"
+const (
+	userContextKey contextKey = "userId"
+	roleContextKey contextKey = "role"
+)
 
 func GetUserFromContext(r *http.Request) (string, bool) {
 	userID, ok := r.Context().Value(userContextKey).(string)
@@ -20,12 +26,44 @@ func GetUserFromContext(r *http.Request) (string, bool) {
 	return userID, ok
 }
 
-func Authentication(logger *slog.Logger) func(next http.Handler) http.Handler {
+// ContextWithUser returns a copy of ctx carrying userId, as the
+// Authentication middleware does for verified requests.
+func ContextWithUser(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userContextKey, userId)
+}
+
+// GetRoleFromContext returns the role claim of the access token, empty for
+// tokens without one.
+func GetRoleFromContext(r *http.Request) string {
+	role, _ := r.Context().Value(roleContextKey).(string)
+
+	return role
+}
+
+func ContextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleContextKey, role)
+}
+
+// Errors returned by a SessionChecker. ErrSessionRevoked is for a token
+// issued before the sessions of the user were revoked, ErrAccountInactive
+// for the token of a deactivated account.
+var (
+	ErrSessionRevoked  = errors.New("session revoked")
+	ErrAccountInactive = errors.New("account inactive")
+)
+
+// SessionChecker is asked about every verified access token so that tokens
+// can be rejected before they expire.
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userId string, issuedAt time.Time) error
+}
+
+func Authentication(logger *slog.Logger, sessions SessionChecker) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				logger.Error("Missing Authorization header")
+				LoggerFromContext(r.Context(), logger).Error("Missing Authorization header")
 				utils.ResponseError(w, http.StatusUnauthorized, "Missing authorization header")
 				return
 			}
@@ -33,7 +71,7 @@ func Authentication(logger *slog.Logger) func(next http.Handler) http.Handler {
 			// Split the header into type and token (e.g., "Bearer <token>")
 			tokenParts := strings.Split(authHeader, " ")
 			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				logger.Error("Invalid authorization header format", slog.String("header", authHeader))
+				LoggerFromContext(r.Context(), logger).Error("Invalid authorization header format", slog.String("header", authHeader))
 				utils.ResponseError(w, http.StatusUnauthorized, "Invalid authorization header format")
 				return
 			}
@@ -41,17 +79,63 @@ func Authentication(logger *slog.Logger) func(next http.Handler) http.Handler {
 			token := tokenParts[1]
 			verifiedToken, err := auth.VerifyJWT(token)
 			if err != nil {
-				logger.Error("Invalid jwt", slog.String("ERROR", err.Error()))
+				LoggerFromContext(r.Context(), logger).Error("Invalid jwt", slog.String("ERROR", err.Error()))
 				utils.ResponseError(w, http.StatusUnauthorized, "Invalid authorization header format")
 				return
 			}
 
 			userId, _ := verifiedToken.Claims.GetSubject()
 
-			ctx := context.WithValue(r.Context(), userContextKey, userId)
+			var issuedAt time.Time
+			if iat, _ := verifiedToken.Claims.GetIssuedAt(); iat != nil {
+				issuedAt = iat.Time
+			}
+
+			if err := sessions.CheckSession(r.Context(), userId, issuedAt); err != nil {
+				if errors.Is(err, ErrAccountInactive) {
+					utils.ResponseErrorCode(w, http.StatusForbidden, utils.CodeAccountInactive, "Account is deactivated")
+					return
+				}
+				if errors.Is(err, ErrSessionRevoked) {
+					utils.ResponseErrorCode(w, http.StatusUnauthorized, utils.CodeSessionRevoked, "Session revoked, sign in again")
+					return
+				}
+
+				LoggerFromContext(r.Context(), logger).Error("Failed to check session", slog.String("ERROR", err.Error()))
+				utils.ResponseError(w, http.StatusInternalServerError, "Something went wrong")
+				return
+			}
+
+			var role string
+			if claims, ok := verifiedToken.Claims.(jwt.MapClaims); ok {
+				role, _ = claims["role"].(string)
+			}
+
+			AddLogAttrs(r.Context(), slog.String("user_id", userId))
+			setSpanUser(r, userId)
+
+			ctx := ContextWithUser(r.Context(), userId)
+			ctx = ContextWithRole(ctx, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 
 			// next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// RequireRole only lets requests through whose access token has role,
+// others are rejected with 403. It goes after Authentication.
+func RequireRole(role string, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetRoleFromContext(r) != role {
+				userId, _ := GetUserFromContext(r)
+				LoggerFromContext(r.Context(), logger).Warn("Request without the required role", slog.String("role", role), slog.String("user_id", userId))
+				utils.ResponseError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
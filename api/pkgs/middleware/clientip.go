@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+)
+
+const clientIPContextKey contextKey = "clientIP"
+
+// GetClientIPFromContext returns the address of the client as resolved by
+// the ClientIP middleware.
+func GetClientIPFromContext(r *http.Request) (netip.Addr, bool) {
+	ip, ok := r.Context().Value(clientIPContextKey).(netip.Addr)
+
+	return ip, ok
+}
+
+func ContextWithClientIP(ctx context.Context, ip netip.Addr) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// ParsePrefixes parses a list of CIDRs separated by commas or spaces. A bare
+// address stands for itself.
+func ParsePrefixes(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }) {
+		if !strings.Contains(field, "/") {
+			addr, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", field, err)
+			}
+
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", field, err)
+		}
+
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ProxyHeader names the header the trusted proxies add the address of the
+// client to.
+type ProxyHeader string
+
+const (
+	ProxyHeaderForwarded     ProxyHeader = "forwarded"
+	ProxyHeaderXForwardedFor ProxyHeader = "x-forwarded-for"
+)
+
+// ParseProxyHeader accepts "forwarded" and "x-forwarded-for", in any case.
+func ParseProxyHeader(name string) (ProxyHeader, error) {
+	header := ProxyHeader(strings.ToLower(strings.TrimSpace(name)))
+	if header != ProxyHeaderForwarded && header != ProxyHeaderXForwardedFor {
+		return "", fmt.Errorf("unknown proxy header %q, expected %q or %q", name, ProxyHeaderForwarded, ProxyHeaderXForwardedFor)
+	}
+
+	return header, nil
+}
+
+// ClientIPResolver finds the address of the client behind the proxies in
+// front of the API. Only the header the proxies are configured to set is
+// read, a client could send the other one itself. It is only honored on
+// requests coming from a trusted proxy, and only up to the first hop that
+// is not a trusted proxy itself.
+type ClientIPResolver struct {
+	trustedProxies []netip.Prefix
+	header         ProxyHeader
+}
+
+func NewClientIPResolver(trustedProxies []netip.Prefix, header ProxyHeader) *ClientIPResolver {
+	return &ClientIPResolver{trustedProxies: trustedProxies, header: header}
+}
+
+// Resolve returns the address of the client, it is invalid when the remote
+// address of the request can not be parsed.
+func (c *ClientIPResolver) Resolve(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	remote = remote.Unmap()
+
+	if !containsAddr(c.trustedProxies, remote) {
+		return remote
+	}
+
+	var hops []string
+	switch c.header {
+	case ProxyHeaderForwarded:
+		hops = forwardedHops(r.Header)
+	case ProxyHeaderXForwardedFor:
+		hops = xForwardedForHops(r.Header)
+	}
+
+	// Hops are appended by each proxy, the client is the last one that was
+	// not added by a trusted proxy
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+
+		client = hop.Unmap()
+		if !containsAddr(c.trustedProxies, client) {
+			break
+		}
+	}
+
+	return client
+}
+
+// forwardedHops lists the addresses of the Forwarded header from the first
+// hop to the last.
+func forwardedHops(header http.Header) []string {
+	var hops []string
+	for _, element := range strings.Split(strings.Join(header.Values("Forwarded"), ","), ",") {
+		for _, pair := range strings.Split(element, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(name, "for") {
+				continue
+			}
+
+			// for="[2001:db8::1]:4711" and for=192.0.2.60:4711
+			value = strings.Trim(value, `"`)
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			}
+			hops = append(hops, strings.Trim(value, "[]"))
+		}
+	}
+
+	return hops
+}
+
+// xForwardedForHops lists the addresses of the X-Forwarded-For header from
+// the first hop to the last.
+func xForwardedForHops(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops
+}
+
+// ClientIP stores the address resolved for each request in its context,
+// for GetClientIPFromContext, and adds it to the logger of the request.
+func ClientIP(resolver *ClientIPResolver) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolver.Resolve(r)
+			if !ip.IsValid() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			AddLogAttrs(r.Context(), slog.String("client_ip", ip.String()))
+			next.ServeHTTP(w, r.WithContext(ContextWithClientIP(r.Context(), ip)))
+		})
+	}
+}
+
+type ipLists struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// IPFilter holds the allow and deny lists of client addresses. The lists
+// can be replaced while requests are served.
+type IPFilter struct {
+	lists atomic.Pointer[ipLists]
+}
+
+func NewIPFilter(allow, deny []netip.Prefix) *IPFilter {
+	filter := &IPFilter{}
+	filter.Update(allow, deny)
+
+	return filter
+}
+
+// Update replaces both lists.
+func (f *IPFilter) Update(allow, deny []netip.Prefix) {
+	f.lists.Store(&ipLists{allow: allow, deny: deny})
+}
+
+// Allowed reports whether ip is not denied and, when there is an allow
+// list, whether it is on it.
+func (f *IPFilter) Allowed(ip netip.Addr) bool {
+	lists := f.lists.Load()
+	if containsAddr(lists.deny, ip) {
+		return false
+	}
+
+	return len(lists.allow) == 0 || containsAddr(lists.allow, ip)
+}
+
+// IPAccess rejects the requests of clients filter does not allow with 403.
+// It goes after ClientIP, requests without a client address are only let
+// through when there is no allow list.
+func IPAccess(filter *IPFilter, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, ok := GetClientIPFromContext(r)
+			if ok && filter.Allowed(ip) || !ok && len(filter.lists.Load().allow) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			LoggerFromContext(r.Context(), logger).Warn("Request from a blocked address", slog.String("client_ip", ip.String()), slog.String("path", r.URL.Path))
+			utils.ResponseErrorCode(w, http.StatusForbidden, utils.CodeIPForbidden, "Forbidden")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package middleware_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := middleware.ParsePrefixes("10.0.0.0/8, 192.0.2.7 2001:db8::/32,::ffff:198.51.100.0/120")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.7/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+	}, prefixes)
+
+	prefixes, err = middleware.ParsePrefixes("")
+	require.NoError(t, err)
+	assert.Empty(t, prefixes)
+
+	_, err = middleware.ParsePrefixes("10.0.0.0/33")
+	assert.Error(t, err)
+
+	_, err = middleware.ParsePrefixes("localhost")
+	assert.Error(t, err)
+}
+
+func TestClientIPResolver(t *testing.T) {
+	trusted, _ := middleware.ParsePrefixes("10.0.0.0/8")
+	resolver := middleware.NewClientIPResolver(trusted, middleware.ProxyHeaderXForwardedFor)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"Direct", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"Untrusted proxy", "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "192.0.2.1"},
+		{"Trusted proxy", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"Spoofed hops", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9, 198.51.100.7"}}, "198.51.100.7"},
+		{"Proxy chain", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7, 10.0.0.2"}}, "198.51.100.7"},
+		{"Split headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7", "10.0.0.2"}}, "198.51.100.7"},
+		{"Only proxies", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"Garbage hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"unknown"}}, "10.0.0.1"},
+		{"Spoofed Forwarded", "10.0.0.1:1234", http.Header{"Forwarded": {"for=203.0.113.9"}, "X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"Only Forwarded", "10.0.0.1:1234", http.Header{"Forwarded": {"for=203.0.113.9"}}, "10.0.0.1"},
+		{"Mapped address", "[::ffff:192.0.2.1]:1234", nil, "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			assert.Equal(t, tt.want, resolver.Resolve(req).String())
+		})
+	}
+}
+
+func TestClientIPResolverForwarded(t *testing.T) {
+	trusted, _ := middleware.ParsePrefixes("10.0.0.0/8")
+	resolver := middleware.NewClientIPResolver(trusted, middleware.ProxyHeaderForwarded)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"Forwarded", http.Header{"Forwarded": {`for=198.51.100.7;proto=https, for="[2001:db8::1]:4711"`}}, "2001:db8::1"},
+		{"Spoofed hops", http.Header{"Forwarded": {"for=203.0.113.9, for=198.51.100.7"}}, "198.51.100.7"},
+		{"Spoofed X-Forwarded-For", http.Header{"Forwarded": {"for=198.51.100.7"}, "X-Forwarded-For": {"203.0.113.9"}}, "198.51.100.7"},
+		{"Only X-Forwarded-For", http.Header{"X-Forwarded-For": {"203.0.113.9"}}, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			assert.Equal(t, tt.want, resolver.Resolve(req).String())
+		})
+	}
+}
+
+func TestParseProxyHeader(t *testing.T) {
+	header, err := middleware.ParseProxyHeader("X-Forwarded-For")
+	assert.NoError(t, err)
+	assert.Equal(t, middleware.ProxyHeaderXForwardedFor, header)
+
+	header, err = middleware.ParseProxyHeader("forwarded")
+	assert.NoError(t, err)
+	assert.Equal(t, middleware.ProxyHeaderForwarded, header)
+
+	_, err = middleware.ParseProxyHeader("x-real-ip")
+	assert.Error(t, err)
+}
+
+func TestIPAccess(t *testing.T) {
+	logger := slog.New(&TestLogger{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	allow, _ := middleware.ParsePrefixes("192.0.2.0/24")
+	deny, _ := middleware.ParsePrefixes("192.0.2.66")
+	filter := middleware.NewIPFilter(allow, deny)
+	handler := middleware.ClientIP(middleware.NewClientIPResolver(nil, middleware.ProxyHeaderXForwardedFor))(middleware.IPAccess(filter, logger)(ok))
+
+	status := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		return res.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, status("192.0.2.1:1234"))
+	assert.Equal(t, http.StatusForbidden, status("192.0.2.66:1234"))
+	assert.Equal(t, http.StatusForbidden, status("198.51.100.7:1234"))
+	assert.Equal(t, http.StatusForbidden, status("garbage"))
+
+	filter.Update(nil, deny)
+	assert.Equal(t, http.StatusNoContent, status("198.51.100.7:1234"))
+	assert.Equal(t, http.StatusForbidden, status("192.0.2.66:1234"))
+	assert.Equal(t, http.StatusNoContent, status("garbage"))
+}
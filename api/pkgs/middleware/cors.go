@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures which cross-origin requests browsers may make.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed to call the API, "*" allows
+	// any of them. No origins disables CORS.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization
+	// headers, the origin is then echoed back even when "*" is allowed.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the response to a preflight.
+	MaxAge time.Duration
+}
+
+func (o CORSOptions) allowsOrigin(origin string) bool {
+	return slices.Contains(o.AllowedOrigins, "*") || slices.Contains(o.AllowedOrigins, origin)
+}
+
+// CORS adds the CORS headers to the responses to allowed origins and
+// answers preflight requests with 204 before they reach the routes.
+// Preflights from other origins get 204 without CORS headers, which
+// browsers treat as a refusal.
+func CORS(options CORSOptions) func(next http.Handler) http.Handler {
+	allowedMethods := strings.Join(options.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(options.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(options.ExposedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		if len(options.AllowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !options.allowsOrigin(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if options.AllowCredentials || !slices.Contains(options.AllowedOrigins, "*") {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			if options.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposedHeaders != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			if options.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(options.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	options := middleware.CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	request := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/commands", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+
+		res := httptest.NewRecorder()
+		middleware.CORS(options)(ok).ServeHTTP(res, req)
+
+		return res
+	}
+
+	t.Run("Preflight from an allowed origin", func(t *testing.T) {
+		res := request(http.MethodOptions, "https://app.example.com", true)
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "https://app.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", res.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", res.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", res.Header().Get("Access-Control-Max-Age"))
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("Preflight from another origin", func(t *testing.T) {
+		res := request(http.MethodOptions, "https://evil.example.com", true)
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Request from an allowed origin", func(t *testing.T) {
+		res := request(http.MethodGet, "https://app.example.com", false)
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "https://app.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "ETag", res.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, res.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("Request without an origin", func(t *testing.T) {
+		res := request(http.MethodGet, "", false)
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Any origin", func(t *testing.T) {
+		options := options
+		options.AllowedOrigins = []string{"*"}
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://other.example.com")
+		res := httptest.NewRecorder()
+		middleware.CORS(options)(ok).ServeHTTP(res, req)
+		assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+
+		options.AllowCredentials = true
+		res = httptest.NewRecorder()
+		middleware.CORS(options)(ok).ServeHTTP(res, req)
+		assert.Equal(t, "https://other.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("Disabled without origins", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		res := httptest.NewRecorder()
+		middleware.CORS(middleware.CORSOptions{})(ok).ServeHTTP(res, req)
+
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, res.Header().Values("Vary"))
+	})
+}
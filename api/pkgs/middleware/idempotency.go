@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+)
+
+const (
+	// IdempotencyKeyHeader makes a POST safe to retry, every request with
+	// the same key gets the response of the first one.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotencyMemoryBody is the size up to which request bodies are
+	// kept in memory while they are hashed.
+	maxIdempotencyMemoryBody = 64 << 10
+)
+
+// idempotencyPollInterval is how often a duplicate of a request that is
+// still in flight checks whether its response is ready.
+var idempotencyPollInterval = 50 * time.Millisecond
+
+// IdempotentResponse is what is stored for the first request made with an
+// idempotency key. StatusCode is zero while that request is in flight.
+type IdempotentResponse struct {
+	RequestHash string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps the responses of idempotent requests per user and
+// key until they expire.
+type IdempotencyStore interface {
+	// Reserve claims key for a request until leaseUntil and reports false
+	// when the key has already been claimed and has not expired. A claim
+	// whose lease ran out before a response was stored, such as one left
+	// by a crash, can be claimed again.
+	Reserve(ctx context.Context, userId, key, requestHash string, leaseUntil time.Time) (bool, error)
+	// Find returns what is stored for key and false when there is nothing.
+	Find(ctx context.Context, userId, key string) (IdempotentResponse, bool, error)
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, userId, key string, response IdempotentResponse) error
+	// Release forgets key so that the request can be retried.
+	Release(ctx context.Context, userId, key string) error
+}
+
+// Idempotency honors the Idempotency-Key header of authenticated POST
+// requests. The first response for a key is stored and replayed verbatim on
+// retries. A duplicate that arrives while the first request is in flight
+// waits up to wait for its response and gets 409 Conflict after that. A key
+// reused with a different method, path or body is rejected with 422.
+//
+// Responses with a 5xx status code are not stored, the key is released so
+// that the request can be retried. A key whose request never finished, as
+// when the server crashed, is released once its lease is over: lease, or
+// the timeout of its "METHOD /path" in routeLeases, as long as the request
+// may run.
+func Idempotency(store IdempotencyStore, wait, lease time.Duration, routeLeases map[string]time.Duration, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userId, ok := GetUserFromContext(r)
+			if r.Method != http.MethodPost || key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				utils.ResponseError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, requestHash, err := spoolRequest(r)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					utils.ResponseError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must be at most %d bytes", maxBytesErr.Limit))
+					return
+				}
+
+				LoggerFromContext(r.Context(), logger).Error("Failed to read request body", slog.String("ERROR", err.Error()))
+				utils.ResponseError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			defer body.Close()
+			r.Body = body
+
+			ctx := r.Context()
+
+			requestLease := lease
+			if routeLease, ok := routeLeases[r.Method+" "+r.URL.Path]; ok {
+				requestLease = routeLease
+			}
+
+			reserved, err := store.Reserve(ctx, userId, key, requestHash, time.Now().Add(requestLease))
+			if err != nil {
+				LoggerFromContext(r.Context(), logger).Error("Failed to reserve idempotency key", slog.String("ERROR", err.Error()))
+				utils.ResponseError(w, http.StatusInternalServerError, "Failed to process idempotency key")
+				return
+			}
+
+			if !reserved {
+				replayIdempotentResponse(w, r, store, userId, key, requestHash, wait, logger)
+				return
+			}
+
+			recorder := &recordingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			// The store is updated even when the client went away, and the
+			// key is released if the handler panics.
+			storeCtx := context.WithoutCancel(ctx)
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+
+				if err := store.Release(storeCtx, userId, key); err != nil {
+					LoggerFromContext(r.Context(), logger).Error("Failed to release idempotency key", slog.String("ERROR", err.Error()))
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.statusCode >= http.StatusInternalServerError {
+				return
+			}
+
+			err = store.Complete(storeCtx, userId, key, IdempotentResponse{
+				RequestHash: requestHash,
+				StatusCode:  recorder.statusCode,
+				Header:      recorder.header(),
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				LoggerFromContext(r.Context(), logger).Error("Failed to store idempotent response", slog.String("ERROR", err.Error()))
+				return
+			}
+
+			completed = true
+		})
+	}
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, store IdempotencyStore, userId, key, requestHash string, wait time.Duration, logger *slog.Logger) {
+	ctx := r.Context()
+	deadline := time.Now().Add(wait)
+
+	for {
+		response, found, err := store.Find(ctx, userId, key)
+		if err != nil {
+			LoggerFromContext(r.Context(), logger).Error("Failed to fetch idempotent response", slog.String("ERROR", err.Error()))
+			utils.ResponseError(w, http.StatusInternalServerError, "Failed to process idempotency key")
+			return
+		}
+
+		if found && response.RequestHash != requestHash {
+			utils.ResponseError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			return
+		}
+
+		if found && response.StatusCode != 0 {
+			for name, values := range response.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(response.StatusCode)
+			_, _ = w.Write(response.Body)
+			return
+		}
+
+		// The first request is still in flight, or failed and released the
+		// key in the meantime.
+		if !found || time.Now().After(deadline) {
+			utils.ResponseError(w, http.StatusConflict, "A request with the same Idempotency-Key is being processed, retry later")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// spoolRequest reads the body of r while hashing it together with the
+// method and path, so that a key cannot be reused for something else. The
+// returned body replays what was read. Bodies larger than
+// maxIdempotencyMemoryBody, such as imports, are spooled to a temporary file
+// instead of memory; closing the body removes it.
+func spoolRequest(r *http.Request) (io.ReadCloser, string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	body := io.TeeReader(r.Body, hash)
+
+	var buffer bytes.Buffer
+	if _, err := io.CopyN(&buffer, body, maxIdempotencyMemoryBody+1); err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	if buffer.Len() <= maxIdempotencyMemoryBody {
+		return io.NopCloser(&buffer), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.CreateTemp("", "termflow-request-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := &spooledBody{File: file}
+
+	if _, err := io.Copy(file, io.MultiReader(&buffer, body)); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+
+	return spooled, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// spooledBody is a request body read from a temporary file.
+type spooledBody struct {
+	*os.File
+	closeOnce sync.Once
+}
+
+func (b *spooledBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		err = b.File.Close()
+		if removeErr := os.Remove(b.File.Name()); err == nil {
+			err = removeErr
+		}
+	})
+
+	return err
+}
+
+// recordingResponseWriter passes a response through while keeping a copy of
+// it.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	headers     http.Header
+	body        bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.statusCode = code
+		rw.headers = rw.ResponseWriter.Header().Clone()
+	}
+
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingResponseWriter) header() http.Header {
+	if rw.headers == nil {
+		return rw.ResponseWriter.Header().Clone()
+	}
+
+	return rw.headers
+}
@@ -0,0 +1,250 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyStore is an IdempotencyStore without expiry.
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]middleware.IdempotentResponse
+	leases    map[string]time.Time
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		responses: map[string]middleware.IdempotentResponse{},
+		leases:    map[string]time.Time{},
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, userId, key, requestHash string, leaseUntil time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response, exists := s.responses[userId+"/"+key]
+	if exists && (response.StatusCode != 0 || time.Now().Before(s.leases[userId+"/"+key])) {
+		return false, nil
+	}
+
+	s.responses[userId+"/"+key] = middleware.IdempotentResponse{RequestHash: requestHash}
+	s.leases[userId+"/"+key] = leaseUntil
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Find(_ context.Context, userId, key string) (middleware.IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response, exists := s.responses[userId+"/"+key]
+	return response, exists, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, userId, key string, response middleware.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[userId+"/"+key] = response
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, userId, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, userId+"/"+key)
+	return nil
+}
+
+func idempotentRequest(userId, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/commands", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	if userId != "" {
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), userId))
+	}
+
+	return req
+}
+
+func TestIdempotency(t *testing.T) {
+	logger := slog.New(&TestLogger{})
+
+	var calls atomic.Int32
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	t.Run("Replays the first response", func(t *testing.T) {
+		calls.Store(0)
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(created)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, idempotentRequest("user", "key", `{"command":"ls"}`))
+
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, idempotentRequest("user", "key", `{"command":"ls"}`))
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+		assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+		assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("Keys are per user", func(t *testing.T) {
+		calls.Store(0)
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(created)
+
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("alice", "key", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("bob", "key", `{}`))
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Rejects a key reused for a different payload", func(t *testing.T) {
+		calls.Store(0)
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(created)
+
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "key", `{"command":"ls"}`))
+
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, idempotentRequest("user", "key", `{"command":"pwd"}`))
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, http.StatusUnprocessableEntity, retry.Code)
+	})
+
+	t.Run("Passes large bodies through", func(t *testing.T) {
+		calls.Store(0)
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(created)
+		body := strings.Repeat("a", 1<<20)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, idempotentRequest("user", "key", body))
+
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, idempotentRequest("user", "key", body[1:]+"b"))
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, body, first.Body.String())
+		assert.Equal(t, http.StatusUnprocessableEntity, retry.Code)
+	})
+
+	t.Run("Ignores requests without a key or user", func(t *testing.T) {
+		calls.Store(0)
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(created)
+
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("", "key", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("", "key", `{}`))
+
+		assert.Equal(t, int32(4), calls.Load())
+	})
+
+	t.Run("Does not store server errors", func(t *testing.T) {
+		var failures atomic.Int32
+		failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			failures.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		handler := middleware.Idempotency(newMemoryIdempotencyStore(), time.Second, time.Minute, nil, logger)(failing)
+
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "key", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "key", `{}`))
+
+		assert.Equal(t, int32(2), failures.Load())
+	})
+
+	t.Run("Concurrent duplicates", func(t *testing.T) {
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-finish
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("created"))
+		})
+
+		store := newMemoryIdempotencyStore()
+		handler := middleware.Idempotency(store, 20*time.Millisecond, time.Minute, nil, logger)(slow)
+		waiting := middleware.Idempotency(store, 5*time.Second, time.Minute, nil, logger)(slow)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "key", `{}`))
+		}()
+		<-started
+
+		// Gives up once the wait is over
+		conflict := httptest.NewRecorder()
+		handler.ServeHTTP(conflict, idempotentRequest("user", "key", `{}`))
+		assert.Equal(t, http.StatusConflict, conflict.Code)
+
+		// Gets the response once the first request is done
+		replayed := httptest.NewRecorder()
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(finish)
+		}()
+		waiting.ServeHTTP(replayed, idempotentRequest("user", "key", `{}`))
+		<-done
+
+		assert.Equal(t, http.StatusCreated, replayed.Code)
+		assert.Equal(t, "created", replayed.Body.String())
+	})
+
+	t.Run("Reclaims a key whose lease ran out", func(t *testing.T) {
+		calls.Store(0)
+		store := newMemoryIdempotencyStore()
+		handler := middleware.Idempotency(store, time.Second, time.Minute, nil, logger)(created)
+
+		// Reserved by a request that never finished, as when the server
+		// crashed
+		store.Reserve(context.Background(), "user", "key", "hash", time.Now().Add(-time.Second))
+
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, idempotentRequest("user", "key", `{}`))
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, http.StatusCreated, retry.Code)
+	})
+
+	t.Run("Leases keys for the timeout of their route", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		handler := middleware.Idempotency(store, time.Second, time.Minute, map[string]time.Duration{
+			"POST /api/import": time.Hour,
+		}, logger)(created)
+
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("user", "key", `{}`))
+
+		importRequest := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(`{}`))
+		importRequest.Header.Set(middleware.IdempotencyKeyHeader, "import")
+		importRequest = importRequest.WithContext(middleware.ContextWithUser(importRequest.Context(), "user"))
+		handler.ServeHTTP(httptest.NewRecorder(), importRequest)
+
+		assert.WithinDuration(t, time.Now().Add(time.Minute), store.leases["user/key"], 5*time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), store.leases["user/import"], 5*time.Second)
+	})
+}
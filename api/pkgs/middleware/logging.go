@@ -6,6 +6,8 @@ import (
 	"time"
 )
 
+// Logging logs every request once it is done, with the logger of the
+// request when RequestID runs before it.
 func Logging(logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -18,7 +20,7 @@ func Logging(logger *slog.Logger) func(next http.Handler) http.Handler {
 			next.ServeHTTP(rw, r)
 
 			// Log structured data
-			logger.Info("HTTP request",
+			LoggerFromContext(r.Context(), logger).Info("HTTP request",
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.Int("status", rw.statusCode),
@@ -39,3 +41,8 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the connection.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
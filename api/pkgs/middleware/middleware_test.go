@@ -97,3 +97,28 @@ func TestLoggingMiddleware(t *testing.T) {
 		t.Errorf("expected log to contain duration, got %s", logEntry)
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	handler := middleware.RequireRole("admin", slog.New(&TestLogger{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		role   string
+		status int
+	}{
+		{"admin", http.StatusNoContent},
+		{"user", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		req = req.WithContext(middleware.ContextWithRole(req.Context(), tc.role))
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		if res.Code != tc.status {
+			t.Errorf("Expected status %d for role %q, got %d", tc.status, tc.role, res.Code)
+		}
+	}
+}
@@ -1,67 +1,185 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
 )
 
-func RateLimit(next http.Handler) http.Handler {
-	ips := map[string]time.Time{}
+// Limit allows Requests requests in a burst, a token bucket that refills
+// evenly over Per. A zero Limit lets every request through.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			addr := r.Header.Get("X-Forwarded-For")
+// RateLimitResult is the state of a bucket after taking a token from it.
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long until the next token, zero when allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
 
-			xffSplits := strings.Split(addr, ",")
-			xffStr := ""
-			if len(xffSplits) > 0 {
-				xffStr = xffSplits[len(xffSplits)-1]
-			}
+// RateLimitStore keeps a token bucket per key. The in-memory store can be
+// replaced with one shared by every instance of the API.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, which starts full, and
+	// reports whether there was one.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+// rateLimitSweepInterval is how often MemoryRateLimitStore drops idle
+// buckets.
+const rateLimitSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// fullAt is when the bucket is full again, from then on it is the same as
+// no bucket at all.
+func (b *tokenBucket) fullAt() time.Time {
+	missing := float64(b.limit.Requests) - b.tokens
+	return b.updated.Add(time.Duration(missing / b.limit.rate() * float64(time.Second)))
+}
+
+// MemoryRateLimitStore keeps the buckets in memory, it is safe for
+// concurrent use. Buckets that refilled are dropped.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= rateLimitSweepInterval {
+		s.sweep(now)
+	}
+
+	bucket, exists := s.buckets[key]
+	if !exists || bucket.limit != limit {
+		bucket = &tokenBucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		s.buckets[key] = bucket
+	}
+
+	rate := limit.rate()
+	if elapsed := now.Sub(bucket.updated).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+elapsed*rate)
+		bucket.updated = now
+	}
 
-			if xffStr == "" {
+	result := RateLimitResult{Allowed: bucket.tokens >= 1}
+	if result.Allowed {
+		bucket.tokens--
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(bucket.tokens)
+	result.Reset = bucket.fullAt().Sub(now)
+
+	return result, nil
+}
+
+// Len returns the number of buckets kept.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets)
+}
+
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt()) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+// RateLimit limits the requests of each client to limit, with a bucket per
+// authenticated user and per IP address for anonymous requests. Buckets are
+// kept apart per name, so that route groups each get their own limit.
+//
+// Every response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, requests over the limit are rejected with 429
+// and a Retry-After header. Requests go through when the store fails.
+func RateLimit(store RateLimitStore, name string, limit Limit, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Requests <= 0 || limit.Per <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := store.Take(r.Context(), rateLimitKey(r, name), limit, time.Now())
+			if err != nil {
+				LoggerFromContext(r.Context(), logger).Error("Failed to rate limit request", slog.String("ERROR", err.Error()))
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			ts, exists := ips[xffStr]
-			ips[xffStr] = time.Now()
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Requests, ceilSeconds(limit.Per)))
 
-			if exists && time.Since(ts) < time.Minute {
-				time.Sleep(time.Second * 30)
-				w.WriteHeader(420)
-				w.Write([]byte("Enhance your calm"))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+				utils.ResponseErrorCode(w, http.StatusTooManyRequests, utils.CodeRateLimited, "Too many requests, try again later")
 				return
 			}
-		}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func IPBlock(ips []string, next http.Handler) http.Handler {
-	ipList := map[string]struct{}{}
-	for _, ip := range ips {
-		ipList[ip] = struct{}{}
+// rateLimitKey is the bucket of the authenticated user, or of the client
+// address resolved by ClientIP, falling back on the remote address.
+func rateLimitKey(r *http.Request, name string) string {
+	if userId, ok := GetUserFromContext(r); ok && userId != "" {
+		return name + ":user:" + userId
 	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		addr := r.Header.Get("X-Forwarded-For")
-
-		xffSplits := strings.Split(addr, ",")
-		xffStr := ""
-		if len(xffSplits) > 0 {
-			xffStr = xffSplits[len(xffSplits)-1]
-		}
+	if ip, ok := GetClientIPFromContext(r); ok {
+		return name + ":ip:" + ip.String()
+	}
 
-		_, exists := ipList[xffStr]
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
 
-		if exists {
-			time.Sleep(5 * time.Minute)
-			w.WriteHeader(http.StatusTeapot)
-			return
-		}
+	return name + ":ip:" + ip
+}
 
-		next.ServeHTTP(w, r)
-	})
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
@@ -0,0 +1,170 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	limit := middleware.Limit{Requests: 2, Per: time.Minute}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Refills over time", func(t *testing.T) {
+		store := middleware.NewMemoryRateLimitStore()
+
+		first, _ := store.Take(ctx, "key", limit, now)
+		assert.True(t, first.Allowed)
+		assert.Equal(t, 1, first.Remaining)
+		assert.Equal(t, 30*time.Second, first.Reset)
+
+		second, _ := store.Take(ctx, "key", limit, now)
+		assert.True(t, second.Allowed)
+		assert.Equal(t, 0, second.Remaining)
+
+		third, _ := store.Take(ctx, "key", limit, now.Add(10*time.Second))
+		assert.False(t, third.Allowed)
+		assert.Equal(t, 20*time.Second, third.RetryAfter)
+
+		fourth, _ := store.Take(ctx, "key", limit, now.Add(30*time.Second))
+		assert.True(t, fourth.Allowed)
+	})
+
+	t.Run("Keys have their own bucket", func(t *testing.T) {
+		store := middleware.NewMemoryRateLimitStore()
+
+		store.Take(ctx, "alice", limit, now)
+		store.Take(ctx, "alice", limit, now)
+		result, _ := store.Take(ctx, "bob", limit, now)
+
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("Drops refilled buckets", func(t *testing.T) {
+		store := middleware.NewMemoryRateLimitStore()
+
+		store.Take(ctx, "idle", limit, now)
+		store.Take(ctx, "busy", limit, now.Add(2*time.Minute))
+		assert.Equal(t, 1, store.Len())
+	})
+
+	t.Run("Safe for concurrent use", func(t *testing.T) {
+		store := middleware.NewMemoryRateLimitStore()
+		burst := middleware.Limit{Requests: 50, Per: time.Hour}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, _ := store.Take(ctx, "key", burst, now)
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 50, allowed)
+	})
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, middleware.Limit, time.Time) (middleware.RateLimitResult, error) {
+	return middleware.RateLimitResult{}, errors.New("store is down")
+}
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(&TestLogger{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	limit := middleware.Limit{Requests: 1, Per: time.Minute}
+
+	request := func(userId, remoteAddr string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/commands", nil)
+		req.RemoteAddr = remoteAddr
+		if userId != "" {
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), userId))
+		}
+
+		return req
+	}
+
+	t.Run("Rejects requests over the limit", func(t *testing.T) {
+		handler := middleware.RateLimit(middleware.NewMemoryRateLimitStore(), "api", limit, logger)(ok)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, request("", "192.0.2.1:1234"))
+		assert.Equal(t, http.StatusNoContent, first.Code)
+		assert.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", first.Header().Get("RateLimit-Reset"))
+
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, request("", "192.0.2.1:5678"))
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+		assert.Equal(t, "60", second.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Too Many Requests","status":429,"code":"rate_limited","detail":"Too many requests, try again later"}`, second.Body.String())
+	})
+
+	t.Run("Buckets are per user and per address", func(t *testing.T) {
+		handler := middleware.RateLimit(middleware.NewMemoryRateLimitStore(), "api", limit, logger)(ok)
+
+		for _, req := range []*http.Request{
+			request("alice", "192.0.2.1:1234"),
+			request("bob", "192.0.2.1:1234"),
+			request("", "192.0.2.1:1234"),
+			request("", "192.0.2.2:1234"),
+		} {
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusNoContent, res.Code)
+		}
+	})
+
+	t.Run("Forwarding headers are ignored", func(t *testing.T) {
+		handler := middleware.RateLimit(middleware.NewMemoryRateLimitStore(), "api", limit, logger)(ok)
+
+		handler.ServeHTTP(httptest.NewRecorder(), request("", "192.0.2.1:1234"))
+
+		req := request("", "192.0.2.1:1234")
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	})
+
+	t.Run("Route groups have their own buckets", func(t *testing.T) {
+		store := middleware.NewMemoryRateLimitStore()
+		auth := middleware.RateLimit(store, "auth", limit, logger)(ok)
+		api := middleware.RateLimit(store, "api", limit, logger)(ok)
+
+		auth.ServeHTTP(httptest.NewRecorder(), request("", "192.0.2.1:1234"))
+		res := httptest.NewRecorder()
+		api.ServeHTTP(res, request("", "192.0.2.1:1234"))
+		assert.Equal(t, http.StatusNoContent, res.Code)
+	})
+
+	t.Run("Lets requests through when the store fails", func(t *testing.T) {
+		handler := middleware.RateLimit(failingRateLimitStore{}, "api", limit, logger)(ok)
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, request("", "192.0.2.1:1234"))
+		assert.Equal(t, http.StatusNoContent, res.Code)
+	})
+}
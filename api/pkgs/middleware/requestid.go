@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDContextKey contextKey = "requestID"
+	loggerContextKey    contextKey = "logger"
+
+	maxRequestIDLength = 128
+)
+
+// GetRequestIDFromContext returns the id RequestID gave the request.
+func GetRequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+
+	return requestID
+}
+
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// requestLogger is shared by the middlewares and handlers of a request, so
+// that attributes added further down, such as the user, also show up in
+// the log line Logging writes once the request is done.
+type requestLogger struct {
+	logger *slog.Logger
+}
+
+// ContextWithLogger returns a copy of ctx carrying logger, for
+// LoggerFromContext.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, &requestLogger{logger: logger})
+}
+
+// LoggerFromContext returns the logger of the request, or fallback when
+// there is none.
+func LoggerFromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if holder, ok := ctx.Value(loggerContextKey).(*requestLogger); ok {
+		return holder.logger
+	}
+
+	return fallback
+}
+
+// AddLogAttrs adds attributes to the logger of the request from then on.
+func AddLogAttrs(ctx context.Context, args ...any) {
+	if holder, ok := ctx.Value(loggerContextKey).(*requestLogger); ok {
+		holder.logger = holder.logger.With(args...)
+	}
+}
+
+// RequestID gives every request an id, the one sent in the X-Request-ID
+// header when it is a reasonable one, and echoes it in the response. The
+// request carries a logger with the id, see LoggerFromContext.
+func RequestID(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(utils.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			w.Header().Set(utils.RequestIDHeader, requestID)
+
+			ctx := ContextWithRequestID(r.Context(), requestID)
+			ctx = ContextWithLogger(ctx, logger.With(slog.String("request_id", requestID)))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID only accepts ids that are safe to echo and to log.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := middleware.RequestID(slog.New(&TestLogger{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = middleware.GetRequestIDFromContext(r.Context())
+		utils.ResponseError(w, http.StatusNotFound, "Not found")
+	}))
+
+	t.Run("Honors the incoming id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(utils.RequestIDHeader, "abc-123")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, "abc-123", seen)
+		assert.Equal(t, "abc-123", res.Header().Get(utils.RequestIDHeader))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found","detail":"Not found","request_id":"abc-123"}`, res.Body.String())
+	})
+
+	for name, incoming := range map[string]string{
+		"Generates one":           "",
+		"Replaces an unsafe one":  "abc\n123",
+		"Replaces a too long one": strings.Repeat("a", 129),
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(utils.RequestIDHeader, incoming)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			assert.NotEmpty(t, seen)
+			assert.NotEqual(t, incoming, seen)
+			assert.Equal(t, seen, res.Header().Get(utils.RequestIDHeader))
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := middleware.RequestID(logger)(middleware.Logging(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.AddLogAttrs(r.Context(), slog.String("user_id", "alice"))
+		middleware.LoggerFromContext(r.Context(), logger).Info("Handled")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/commands", nil)
+	req.Header.Set(utils.RequestIDHeader, "abc-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "abc-123", entry["request_id"])
+		assert.Equal(t, "alice", entry["user_id"])
+	}
+
+	assert.Same(t, logger, middleware.LoggerFromContext(req.Context(), logger))
+}
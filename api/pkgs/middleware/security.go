@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+)
+
+// SecurityHeaders sets the headers that keep browsers from sniffing
+// content types, framing responses or leaking the referrer. HSTS is only
+// sent with a positive hstsMaxAge, as it should only be on HTTPS.
+func SecurityHeaders(hstsMaxAge time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+			if hstsMaxAge > 0 {
+				w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int(hstsMaxAge.Seconds())))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodySize rejects requests whose body is larger than limit bytes with
+// 413. Bodies without a Content-Length fail with *http.MaxBytesError once
+// read past the limit. routeLimits overrides limit for requests matching a
+// "METHOD /path" key exactly.
+//
+// It goes before any middleware reading the body, such as Idempotency.
+func MaxBodySize(limit int64, routeLimits map[string]int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routeLimit := limit
+			if override, ok := routeLimits[r.Method+" "+r.URL.Path]; ok {
+				routeLimit = override
+			}
+
+			if r.ContentLength > routeLimit {
+				utils.ResponseError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must be at most %d bytes", routeLimit))
+				return
+			}
+
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, routeLimit)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RouteDeadlines gives requests matching a "METHOD /path" key of
+// routeTimeouts that long to read their body and write their response,
+// instead of the read and write timeouts of the server. It is meant for
+// routes streaming large bodies, such as imports and exports.
+//
+// It goes before any middleware reading the body, such as Idempotency.
+func RouteDeadlines(routeTimeouts map[string]time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout, ok := routeTimeouts[r.Method+" "+r.URL.Path]; ok {
+				// Writers that do not support deadlines, such as
+				// recorders, keep the timeouts they have
+				deadline := time.Now().Add(timeout)
+				controller := http.NewResponseController(w)
+				_ = controller.SetReadDeadline(deadline)
+				_ = controller.SetWriteDeadline(deadline)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	res := httptest.NewRecorder()
+	middleware.SecurityHeaders(time.Hour)(ok).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "nosniff", res.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", res.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "max-age=3600; includeSubDomains", res.Header().Get("Strict-Transport-Security"))
+
+	res = httptest.NewRecorder()
+	middleware.SecurityHeaders(0)(ok).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, res.Header().Get("Strict-Transport-Security"))
+}
+
+func TestMaxBodySize(t *testing.T) {
+	readAll := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.MaxBodySize(8, map[string]int64{"POST /api/import": 64})(readAll)
+
+	status := func(path, body string, chunked bool) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		return res.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, status("/api/commands", "12345678", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status("/api/commands", "123456789", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status("/api/commands", "123456789", true))
+	assert.Equal(t, http.StatusNoContent, status("/api/import", strings.Repeat("x", 64), false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status("/api/import", strings.Repeat("x", 65), true))
+}
+
+func TestRouteDeadlines(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	server := httptest.NewUnstartedServer(middleware.RouteDeadlines(map[string]time.Duration{
+		"GET /api/export": time.Second,
+	})(slow))
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	get := func(path string) (string, error) {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		return string(body), err
+	}
+
+	body, err := get("/api/export")
+	assert.NoError(t, err)
+	assert.Equal(t, "done", body)
+
+	_, err = get("/api/commands")
+	assert.Error(t, err)
+}
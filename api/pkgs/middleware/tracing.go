@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request with tracer, continuing
+// the trace of the caller when it sends a traceparent header. Spans are
+// named after the chi route pattern the request matched once it is served.
+//
+// It goes after RequestID and ClientIP so that the span carries the
+// request id and the client address, and the logger of the request the
+// trace id.
+func Tracing(tracer trace.Tracer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			attrs := []attribute.KeyValue{
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			}
+			if requestID := GetRequestIDFromContext(ctx); requestID != "" {
+				attrs = append(attrs, attribute.String("http.request.id", requestID))
+			}
+			if ip, ok := GetClientIPFromContext(r); ok {
+				attrs = append(attrs, semconv.ClientAddress(ip.String()))
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			if spanContext := span.SpanContext(); spanContext.IsValid() {
+				AddLogAttrs(ctx, slog.String("trace_id", spanContext.TraceID().String()))
+			}
+
+			rw := &responseWriter{w, http.StatusOK}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if route := chi.RouteContext(r.Context()); route != nil && route.RoutePattern() != "" {
+				span.SetName(fmt.Sprintf("%s %s", r.Method, route.RoutePattern()))
+				span.SetAttributes(semconv.HTTPRoute(route.RoutePattern()))
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rw.statusCode))
+			if rw.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+			}
+		})
+	}
+}
+
+// setSpanUser records userId on the span of the request.
+func setSpanUser(r *http.Request, userId string) {
+	trace.SpanFromContext(r.Context()).SetAttributes(semconv.EnduserID(userId))
+}
@@ -0,0 +1,52 @@
+package openapi
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// docs is a self-contained UI rendering a document, it loads nothing from
+// other origins.
+//
+//go:embed docs
+var docs embed.FS
+
+var docsPage = template.Must(template.ParseFS(docs, "docs/index.html"))
+
+// docsPolicy lets the UI load its own script and stylesheet and call the
+// API, which the policy set by middleware.SecurityHeaders forbids.
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self' data:; frame-ancestors 'none'"
+
+// DocsHandler serves the docs UI mounted at prefix, rendering the document
+// served at specURL.
+func DocsHandler(prefix, title, specURL string, logger *slog.Logger) http.Handler {
+	assets, err := fs.Sub(docs, "docs")
+	if err != nil {
+		panic(err)
+	}
+	files := http.StripPrefix(prefix, http.FileServer(http.FS(assets)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, prefix)
+		if path == "" {
+			// The page refers to its assets relative to the directory
+			http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		w.Header().Set("Content-Security-Policy", docsPolicy)
+		if path != "/" && path != "/index.html" {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := docsPage.Execute(w, struct{ Title, SpecURL string }{title, specURL}); err != nil {
+			logger.Error("Failed to render the API docs", slog.String("ERROR", err.Error()))
+		}
+	})
+}
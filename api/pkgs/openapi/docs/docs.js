@@ -0,0 +1,243 @@
+// Renders the OpenAPI document named by the data-spec attribute of the
+// body, with a form to try each operation against this server.
+"use strict";
+
+(function () {
+	const tokenInput = document.getElementById("token");
+	tokenInput.value = sessionStorage.getItem("termflow-token") || "";
+	tokenInput.addEventListener("change", () => sessionStorage.setItem("termflow-token", tokenInput.value));
+
+	fetch(document.body.dataset.spec)
+		.then((res) => res.json())
+		.then(render)
+		.catch((err) => {
+			const operations = document.getElementById("operations");
+			operations.replaceChildren(el("p", { class: "muted" }, "Failed to load the specification: " + err));
+		});
+
+	function el(name, attrs, ...children) {
+		const node = document.createElement(name);
+		for (const [key, value] of Object.entries(attrs || {})) {
+			node.setAttribute(key, value);
+		}
+		for (const child of children) {
+			node.append(child);
+		}
+		return node;
+	}
+
+	function render(spec) {
+		document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+		document.title = spec.info.title;
+
+		const groups = new Map((spec.tags || []).map((tag) => [tag.name, []]));
+		for (const [path, item] of Object.entries(spec.paths).sort()) {
+			for (const [method, operation] of Object.entries(item)) {
+				const tag = (operation.tags || ["default"])[0];
+				if (!groups.has(tag)) {
+					groups.set(tag, []);
+				}
+				groups.get(tag).push({ path, method, operation });
+			}
+		}
+
+		const nav = document.getElementById("tags");
+		const operations = document.getElementById("operations");
+		operations.replaceChildren();
+		for (const [tag, entries] of groups) {
+			if (entries.length === 0) {
+				continue;
+			}
+			nav.append(el("a", { href: "#tag-" + tag }, tag));
+			operations.append(el("h2", { id: "tag-" + tag }, tag));
+			for (const entry of entries) {
+				operations.append(renderOperation(spec, entry));
+			}
+		}
+	}
+
+	function renderOperation(spec, { path, method, operation }) {
+		const summary = el("summary", {},
+			el("span", { class: "method " + method }, method.toUpperCase()),
+			el("code", {}, path),
+			el("span", { class: "muted" }, operation.summary || ""));
+		if (operation.security) {
+			summary.append(el("span", { class: "lock", title: "Needs a bearer token" }, "🔒"));
+		}
+
+		const body = el("div", { class: "body" });
+		const details = el("details", { id: operation.operationId }, summary, body);
+		details.addEventListener("toggle", () => {
+			if (details.open && body.childElementCount === 0) {
+				body.append(...renderDetails(spec, path, method, operation));
+			}
+		});
+
+		return details;
+	}
+
+	function renderDetails(spec, path, method, operation) {
+		const nodes = [];
+		const inputs = {};
+
+		const params = operation.parameters || [];
+		if (params.length > 0) {
+			const rows = params.map((param) => {
+				const input = el("input", { type: "text", placeholder: typeName(spec, param.schema) });
+				inputs[param.in + ":" + param.name] = input;
+				return el("tr", {},
+					el("td", {}, el("code", {}, param.name), param.required ? " *" : ""),
+					el("td", { class: "muted" }, param.in),
+					el("td", {}, param.description || ""),
+					el("td", {}, input));
+			});
+			nodes.push(el("h4", {}, "Parameters"), el("table", {}, ...rows));
+		}
+
+		let bodyInput = null;
+		if (operation.requestBody) {
+			const schema = operation.requestBody.content["application/json"].schema;
+			bodyInput = el("textarea", { spellcheck: "false" });
+			bodyInput.value = JSON.stringify(example(spec, schema, new Set()), null, 2);
+			nodes.push(el("h4", {}, "Request body"), bodyInput, el("h4", {}, "Request schema"), el("pre", {}, describe(spec, schema)));
+		}
+
+		nodes.push(el("h4", {}, "Responses"));
+		for (const [status, response] of Object.entries(operation.responses)) {
+			const content = Object.entries(response.content || {});
+			const text = content.length === 0 ? "" : content.map(([type, media]) => type + "\n" + describe(spec, media.schema)).join("\n");
+			nodes.push(el("details", {}, el("summary", {}, el("code", {}, status), response.description), el("pre", {}, text || "No body")));
+		}
+
+		const output = el("div");
+		const send = el("button", { type: "button" }, "Send request");
+		send.addEventListener("click", () => tryIt(path, method, params, inputs, bodyInput, output));
+		nodes.push(send, output);
+
+		return nodes;
+	}
+
+	async function tryIt(path, method, params, inputs, bodyInput, output) {
+		const query = new URLSearchParams();
+		const headers = {};
+		for (const param of params) {
+			const value = inputs[param.in + ":" + param.name].value;
+			if (value === "") {
+				continue;
+			}
+			if (param.in === "path") {
+				path = path.replace("{" + param.name + "}", encodeURIComponent(value));
+			} else if (param.in === "query") {
+				query.append(param.name, value);
+			} else if (param.in === "header") {
+				headers[param.name] = value;
+			}
+		}
+		if (tokenInput.value) {
+			headers["Authorization"] = "Bearer " + tokenInput.value;
+		}
+
+		const init = { method: method.toUpperCase(), headers };
+		if (bodyInput) {
+			headers["Content-Type"] = "application/json";
+			init.body = bodyInput.value;
+		}
+
+		const url = path + (query.toString() ? "?" + query : "");
+		try {
+			const res = await fetch(url, init);
+			let text = await res.text();
+			try {
+				text = JSON.stringify(JSON.parse(text), null, 2);
+			} catch (err) {
+				// Not JSON, shown as is
+			}
+			output.replaceChildren(el("p", { class: "status" }, res.status + " " + res.statusText), el("pre", {}, text));
+		} catch (err) {
+			output.replaceChildren(el("p", { class: "status" }, "Request failed: " + err));
+		}
+	}
+
+	function resolve(spec, schema) {
+		while (schema && schema.$ref) {
+			schema = spec.components.schemas[schema.$ref.split("/").pop()];
+		}
+		return schema || {};
+	}
+
+	function typeName(spec, schema) {
+		if (!schema) {
+			return "any";
+		}
+		if (schema.$ref) {
+			return schema.$ref.split("/").pop();
+		}
+		if (schema.anyOf) {
+			return schema.anyOf.map((s) => typeName(spec, s)).join(" | ");
+		}
+		if (schema.type === "array") {
+			return typeName(spec, schema.items) + "[]";
+		}
+		const types = [].concat(schema.type || "any");
+		return types.map((t) => (schema.format && t !== "null" ? t + "<" + schema.format + ">" : t)).join(" | ");
+	}
+
+	// describe lists the fields of schema, nested objects indented.
+	function describe(spec, schema, indent = "", seen = new Set()) {
+		const name = typeName(spec, schema);
+		const resolved = resolve(spec, schema);
+		const target = resolved.type === "array" ? resolve(spec, resolved.items) : resolved;
+		if (!target.properties || seen.has(target)) {
+			return indent + name;
+		}
+
+		seen = new Set(seen).add(target);
+		const required = new Set(target.required || []);
+		const lines = [indent + name];
+		for (const [field, property] of Object.entries(target.properties)) {
+			const nested = describe(spec, property, indent + "    ", seen).split("\n");
+			let line = indent + "  " + field + (required.has(field) ? "" : "?") + ": " + nested[0].trim();
+			if (property.enum) {
+				line += " (" + property.enum.join(", ") + ")";
+			}
+			lines.push(line, ...nested.slice(1));
+		}
+		return lines.join("\n");
+	}
+
+	function example(spec, schema, seen) {
+		if (schema.$ref) {
+			if (seen.has(schema.$ref)) {
+				return {};
+			}
+			return example(spec, resolve(spec, schema), new Set(seen).add(schema.$ref));
+		}
+		if (schema.anyOf) {
+			return example(spec, schema.anyOf[0], seen);
+		}
+		if (schema.enum) {
+			return schema.enum[0];
+		}
+
+		switch ([].concat(schema.type)[0]) {
+		case "object": {
+			const value = {};
+			for (const [field, property] of Object.entries(schema.properties || {})) {
+				value[field] = example(spec, property, seen);
+			}
+			return value;
+		}
+		case "array":
+			return [example(spec, schema.items || {}, seen)];
+		case "string":
+			return { "date-time": new Date().toISOString(), uuid: "00000000-0000-0000-0000-000000000000", email: "user@example.com" }[schema.format] || "";
+		case "integer":
+		case "number":
+			return schema.minimum || 0;
+		case "boolean":
+			return false;
+		default:
+			return null;
+		}
+	}
+})();
@@ -0,0 +1,284 @@
+// Package openapi builds the OpenAPI 3.1 document of the API from its route
+// table. Request and response schemas are generated from the Go types the
+// handlers decode and encode, so the document follows the code.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification documents follow.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document, limited to what the API uses.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lower case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// BearerAuth is the security scheme of the operations that need a token.
+const BearerAuth = "bearerAuth"
+
+// Content types of request and response bodies.
+const (
+	JSON    = "application/json"
+	Problem = "application/problem+json"
+)
+
+// Route is an operation of the API. Request and Response are zero values
+// of the types of the bodies, nil when there is none.
+type Route struct {
+	Method string
+	// Path is the chi pattern of the route, such as /api/tags/{id}.
+	Path    string
+	ID      string
+	Summary string
+	Tag     string
+	// Public routes do not need a bearer token.
+	Public bool
+	// Params are the query and header parameters. Path parameters are
+	// derived from Path unless they are declared here.
+	Params  []Parameter
+	Request interface{}
+	// RequestContentType is the media type of Request, JSON when empty.
+	RequestContentType string
+	// OptionalRequest is set when the request body may be left out.
+	OptionalRequest bool
+	Response        interface{}
+	// ContentType is the media type of Response, JSON when empty.
+	ContentType string
+	// Status of a successful response, 200 when zero.
+	Status int
+	// Alternatives are the other responses the route succeeds or fails
+	// with, by status, with the zero values of their bodies, nil for none.
+	Alternatives map[int]interface{}
+	// Errors are the statuses of the problems the route responds with,
+	// unless Alternatives has a body for them.
+	Errors []int
+}
+
+// Builder assembles a Document from routes.
+type Builder struct {
+	Info Info
+	Tags []Tag
+	// Problem is the type of error responses.
+	Problem interface{}
+	// PathParams are the schemas of path parameters by name, a string
+	// when missing.
+	PathParams map[string]*Schema
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Build returns the document of routes. Like chi with invalid patterns, it
+// panics when two routes have the same method and path or operation id, as
+// the route table is wrong.
+func (b Builder) Build(routes []Route) *Document {
+	generator := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    b.Info,
+		Tags:    b.Tags,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: generator.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	var problem *Schema
+	if b.Problem != nil {
+		problem = generator.schema(reflect.TypeOf(b.Problem), output)
+	}
+
+	ids := map[string]bool{}
+	for _, route := range routes {
+		path := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		method := strings.ToLower(route.Method)
+		if _, ok := (*item)[method]; ok {
+			panic(fmt.Sprintf("openapi: %s %s is documented twice", route.Method, route.Path))
+		}
+		if ids[route.ID] {
+			panic(fmt.Sprintf("openapi: operation id %q is used twice", route.ID))
+		}
+		ids[route.ID] = true
+
+		(*item)[method] = b.operation(generator, route, problem)
+	}
+
+	return doc
+}
+
+func (b Builder) operation(generator *generator, route Route, problem *Schema) *Operation {
+	operation := &Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+	if !route.Public {
+		operation.Security = []map[string][]string{{BearerAuth: {}}}
+	}
+
+	declared := map[string]bool{}
+	for _, param := range route.Params {
+		declared[param.In+" "+param.Name] = true
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		if declared["path "+match[1]] {
+			continue
+		}
+
+		schema, ok := b.PathParams[match[1]]
+		if !ok {
+			schema = &Schema{Type: "string"}
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	operation.Parameters = append(operation.Parameters, route.Params...)
+
+	if route.Request != nil {
+		contentType := route.RequestContentType
+		if contentType == "" {
+			contentType = JSON
+		}
+		operation.RequestBody = &RequestBody{
+			Required: !route.OptionalRequest,
+			Content:  map[string]*MediaType{contentType: {Schema: generator.schema(reflect.TypeOf(route.Request), input)}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = JSON
+		}
+		response.Content = map[string]*MediaType{contentType: {Schema: generator.schema(reflect.TypeOf(route.Response), output)}}
+	}
+	operation.Responses[strconv.Itoa(status)] = response
+
+	alternatives := make([]int, 0, len(route.Alternatives))
+	for status := range route.Alternatives {
+		alternatives = append(alternatives, status)
+	}
+	sort.Ints(alternatives)
+	for _, status := range alternatives {
+		response := &Response{Description: http.StatusText(status)}
+		if body := route.Alternatives[status]; body != nil {
+			response.Content = map[string]*MediaType{JSON: {Schema: generator.schema(reflect.TypeOf(body), output)}}
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+	}
+
+	errors := append([]int(nil), route.Errors...)
+	sort.Ints(errors)
+	for _, status := range errors {
+		// Alternatives take precedence over problems with the same status
+		if _, ok := operation.Responses[strconv.Itoa(status)]; ok {
+			continue
+		}
+
+		response := &Response{Description: http.StatusText(status)}
+		if problem != nil {
+			response.Content = map[string]*MediaType{Problem: {Schema: problem}}
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+	}
+
+	return operation
+}
+
+// Operations lists the method and path of each operation of doc, with
+// methods in upper case, sorted.
+func (doc *Document) Operations() []string {
+	var operations []string
+	for path, item := range doc.Paths {
+		for method := range *item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+
+	return operations
+}
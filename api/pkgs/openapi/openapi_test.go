@@ -0,0 +1,178 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/endalk200/termflow-api/pkgs/openapi"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type problem struct {
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type timestamps struct {
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type item struct {
+	ID          uuid.UUID   `json:"id"`
+	Description pgtype.Text `json:"description"`
+	Parent      *item       `json:"parent,omitempty"`
+	Secret      string      `json:"-"`
+	timestamps
+}
+
+type createItemRequestPayloadSchema struct {
+	Name  string   `json:"name" validate:"required,min=1,max=255"`
+	Role  string   `json:"role" validate:"required,oneof=owner viewer"`
+	Email string   `json:"email" validate:"omitempty,email"`
+	Tags  []string `json:"tags" validate:"required,min=1,dive,uuid"`
+	Count int      `json:"count" validate:"gte=0"`
+}
+
+func schemaJSON(t *testing.T, schema *openapi.Schema) string {
+	t.Helper()
+
+	body, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestBuild(t *testing.T) {
+	doc := openapi.Builder{
+		Info:       openapi.Info{Title: "Test", Version: "1"},
+		Problem:    problem{},
+		PathParams: map[string]*openapi.Schema{"id": {Type: "string", Format: "uuid"}},
+	}.Build([]openapi.Route{
+		{Method: http.MethodGet, Path: "/items/{id}", ID: "getItem", Tag: "items", Response: item{}, Errors: []int{404, 400}},
+		{Method: http.MethodPost, Path: "/items/", ID: "createItem", Public: true, Request: createItemRequestPayloadSchema{}, Response: item{}, Status: http.StatusCreated},
+	})
+
+	assert.Equal(t, []string{"GET /items/{id}", "POST /items/"}, doc.Operations())
+
+	get := (*doc.Paths["/items/{id}"])["get"]
+	assert.Equal(t, []map[string][]string{{openapi.BearerAuth: {}}}, get.Security)
+	assert.Equal(t, []openapi.Parameter{{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Format: "uuid"}}}, get.Parameters)
+	assert.Equal(t, []string{"200", "400", "404"}, keys(get.Responses))
+	assert.Equal(t, "#/components/schemas/Problem", get.Responses["404"].Content[openapi.Problem].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Item", get.Responses["200"].Content[openapi.JSON].Schema.Ref)
+
+	create := (*doc.Paths["/items/"])["post"]
+	assert.Empty(t, create.Security)
+	assert.Equal(t, "#/components/schemas/CreateItemRequest", create.RequestBody.Content[openapi.JSON].Schema.Ref)
+	assert.Contains(t, create.Responses, "201")
+
+	schemas := doc.Components.Schemas
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"description": {"type": ["string", "null"]},
+			"parent": {"anyOf": [{"$ref": "#/components/schemas/Item"}, {"type": "null"}]},
+			"created_at": {"type": "string", "format": "date-time"},
+			"updated_at": {"type": ["string", "null"], "format": "date-time"}
+		},
+		"required": ["id", "description", "created_at", "updated_at"]
+	}`, schemaJSON(t, schemas["Item"]))
+
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 255},
+			"role": {"type": "string", "enum": ["owner", "viewer"]},
+			"email": {"type": "string", "format": "email"},
+			"tags": {"type": "array", "items": {"type": "string", "format": "uuid"}, "minItems": 1},
+			"count": {"type": "integer", "format": "int64", "minimum": 0}
+		},
+		"required": ["name", "role", "tags"]
+	}`, schemaJSON(t, schemas["CreateItemRequest"]))
+}
+
+func keys(responses map[string]*openapi.Response) []string {
+	var statuses []string
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	return statuses
+}
+
+func TestBuildTypeInBothDirections(t *testing.T) {
+	doc := openapi.Builder{}.Build([]openapi.Route{
+		{Method: http.MethodPut, Path: "/items", ID: "putItem", Request: createItemRequestPayloadSchema{}, Response: createItemRequestPayloadSchema{}},
+	})
+
+	assert.Contains(t, doc.Components.Schemas, "CreateItemRequest")
+	assert.Contains(t, doc.Components.Schemas, "CreateItemRequestOutput")
+}
+
+func TestBuildOptionalBodies(t *testing.T) {
+	doc := openapi.Builder{Problem: problem{}}.Build([]openapi.Route{
+		{Method: http.MethodPost, Path: "/import", ID: "import", Request: json.RawMessage{}, RequestContentType: "application/x-ndjson", OptionalRequest: true,
+			Response: item{}, Alternatives: map[int]interface{}{http.StatusNotModified: nil, http.StatusUnprocessableEntity: item{}}, Errors: []int{http.StatusUnprocessableEntity}},
+	})
+
+	operation := (*doc.Paths["/import"])["post"]
+	assert.False(t, operation.RequestBody.Required)
+	assert.Contains(t, operation.RequestBody.Content, "application/x-ndjson")
+	assert.Empty(t, operation.Responses["304"].Content)
+	assert.Equal(t, "#/components/schemas/Item", operation.Responses["422"].Content[openapi.JSON].Schema.Ref)
+}
+
+func TestBuildPanics(t *testing.T) {
+	assert.PanicsWithValue(t, "openapi: GET /items is documented twice", func() {
+		openapi.Builder{}.Build([]openapi.Route{
+			{Method: http.MethodGet, Path: "/items", ID: "a"},
+			{Method: http.MethodGet, Path: "/items", ID: "b"},
+		})
+	})
+
+	assert.PanicsWithValue(t, `openapi: operation id "a" is used twice`, func() {
+		openapi.Builder{}.Build([]openapi.Route{
+			{Method: http.MethodGet, Path: "/items", ID: "a"},
+			{Method: http.MethodPost, Path: "/items", ID: "a"},
+		})
+	})
+}
+
+func TestDocsHandler(t *testing.T) {
+	handler := openapi.DocsHandler("/api/docs", "Termflow API", "/api/openapi.json", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	res := get("/api/docs")
+	assert.Equal(t, http.StatusMovedPermanently, res.Code)
+	assert.Equal(t, "/api/docs/", res.Header().Get("Location"))
+
+	res = get("/api/docs/")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Header().Get("Content-Security-Policy"), "script-src 'self'")
+	assert.Contains(t, res.Body.String(), `data-spec="/api/openapi.json"`)
+
+	res = get("/api/docs/docs.js")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Header().Get("Content-Type"), "javascript")
+
+	assert.Equal(t, http.StatusNotFound, get("/api/docs/missing.js").Code)
+}
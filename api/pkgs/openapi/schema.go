@@ -0,0 +1,361 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Schema is a JSON Schema, as OpenAPI 3.1 uses it. The zero value accepts
+// anything.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Ref returns a reference to the component schema name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Nullable returns schema also accepting null.
+func Nullable(schema *Schema) *Schema {
+	if schema.Ref != "" || schema.Type == nil {
+		return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+	}
+
+	nullable := *schema
+	if t, ok := schema.Type.(string); ok {
+		nullable.Type = []string{t, "null"}
+	}
+
+	return &nullable
+}
+
+// knownTypes are the types that do not encode as their Go kind. The pgtype
+// values encode as null when not valid.
+var knownTypes = map[reflect.Type]Schema{
+	reflect.TypeOf(time.Time{}):          {Type: "string", Format: "date-time"},
+	reflect.TypeOf(uuid.UUID{}):          {Type: "string", Format: "uuid"},
+	reflect.TypeOf(json.RawMessage{}):    {},
+	reflect.TypeOf(pgtype.Text{}):        {Type: []string{"string", "null"}},
+	reflect.TypeOf(pgtype.Bool{}):        {Type: []string{"boolean", "null"}},
+	reflect.TypeOf(pgtype.Int4{}):        {Type: []string{"integer", "null"}, Format: "int32"},
+	reflect.TypeOf(pgtype.Int8{}):        {Type: []string{"integer", "null"}, Format: "int64"},
+	reflect.TypeOf(pgtype.UUID{}):        {Type: []string{"string", "null"}, Format: "uuid"},
+	reflect.TypeOf(pgtype.Timestamptz{}): {Type: []string{"string", "null"}, Format: "date-time"},
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// mode tells whether a type is decoded from requests, where the validate
+// tag tells what is required, or encoded in responses, where every field
+// but omitempty ones is.
+type mode int
+
+const (
+	output mode = iota
+	input
+)
+
+type typeKey struct {
+	t    reflect.Type
+	mode mode
+}
+
+// generator turns Go types into schemas, named struct types becoming
+// components.
+type generator struct {
+	components map[string]*Schema
+	names      map[typeKey]string
+	owners     map[string]typeKey
+}
+
+func newGenerator() *generator {
+	return &generator{
+		components: map[string]*Schema{},
+		names:      map[typeKey]string{},
+		owners:     map[string]typeKey{},
+	}
+}
+
+func (g *generator) schema(t reflect.Type, m mode) *Schema {
+	if known, ok := knownTypes[t]; ok {
+		return &known
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return Nullable(g.schema(t.Elem(), m))
+	case reflect.Struct:
+		if t.Implements(jsonMarshaler) {
+			return &Schema{}
+		}
+		if t.Implements(textMarshaler) {
+			return &Schema{Type: "string"}
+		}
+		if t.Name() == "" {
+			return g.object(t, m)
+		}
+
+		return g.component(t, m)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), m)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), m)}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int32, reflect.Uint32, reflect.Int16, reflect.Uint16, reflect.Int8, reflect.Uint8:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers t as a component and returns a reference to it. A
+// type used both in requests and responses gets a component for each.
+func (g *generator) component(t reflect.Type, m mode) *Schema {
+	key := typeKey{t, m}
+	if name, ok := g.names[key]; ok {
+		return Ref(name)
+	}
+
+	name := componentName(t)
+	if owner, ok := g.owners[name]; ok && owner.t != t {
+		// Types of different packages may share a name, the one seen last
+		// is qualified with its package
+		name = capitalize(path.Base(t.PkgPath())) + name
+	}
+	if owner, ok := g.owners[name]; ok {
+		if owner.t != t {
+			panic(fmt.Sprintf("openapi: %s and %s are both named %s", owner.t, t, name))
+		}
+		if m == input {
+			name += "Input"
+		} else {
+			name += "Output"
+		}
+	}
+
+	// Register the name first for types referring to themselves
+	g.names[key] = name
+	g.owners[name] = key
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.object(t, m)
+
+	return Ref(name)
+}
+
+// componentName is the name of t without the suffixes of request payload
+// types, capitalized.
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	for _, suffix := range []string{"Schema", "Payload"} {
+		if trimmed := strings.TrimSuffix(name, suffix); trimmed != "" {
+			name = trimmed
+		}
+	}
+
+	return capitalize(name)
+}
+
+func capitalize(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+func (g *generator) object(t reflect.Type, m mode) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, m, schema)
+
+	return schema
+}
+
+func (g *generator) fields(t reflect.Type, m mode, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Fields of embedded structs are promoted, as encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, m, schema)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rules := field.Tag.Get("validate")
+		property := g.schema(field.Type, m)
+		if property.Ref == "" {
+			constrain(property, rules)
+		}
+		schema.Properties[name] = property
+
+		required := !hasOption(options, "omitempty")
+		if m == input {
+			required = hasOption(rules, "required")
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// constrain adds the constraints of the validate rules that JSON Schema
+// can express to schema. The rules after dive apply to the items.
+func constrain(schema *Schema, rules string) {
+	if rules == "" {
+		return
+	}
+
+	rule, rest, dive := strings.Cut(rules, ",dive")
+	if strings.HasPrefix(rules, "dive") {
+		rule, rest, dive = "", strings.TrimPrefix(rules, "dive"), true
+	}
+	if dive && schema.Items != nil && schema.Items.Ref == "" {
+		constrain(schema.Items, strings.TrimPrefix(rest, ","))
+	}
+
+	kind := baseType(schema)
+	for _, r := range strings.Split(rule, ",") {
+		name, value, _ := strings.Cut(r, "=")
+		switch name {
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "url":
+			schema.Format = "uri"
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				if n, err := strconv.ParseFloat(option, 64); err == nil && kind != "string" {
+					schema.Enum = append(schema.Enum, n)
+				} else {
+					schema.Enum = append(schema.Enum, option)
+				}
+			}
+		case "min", "gte", "max", "lte", "len", "gt", "lt":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			bound(schema, kind, name, n)
+		}
+	}
+}
+
+func bound(schema *Schema, kind, rule string, n float64) {
+	size := int(n)
+	lower := rule == "min" || rule == "gte" || rule == "len"
+	upper := rule == "max" || rule == "lte" || rule == "len"
+
+	switch kind {
+	case "string":
+		if lower {
+			schema.MinLength = &size
+		}
+		if upper {
+			schema.MaxLength = &size
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &size
+		}
+		if upper {
+			schema.MaxItems = &size
+		}
+	case "integer", "number":
+		switch {
+		case rule == "gt":
+			schema.ExclusiveMinimum = &n
+		case rule == "lt":
+			schema.ExclusiveMaximum = &n
+		case lower && upper:
+			schema.Minimum, schema.Maximum = &n, &n
+		case lower:
+			schema.Minimum = &n
+		case upper:
+			schema.Maximum = &n
+		}
+	}
+}
+
+// baseType is the type of schema other than null.
+func baseType(schema *Schema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []string:
+		for _, name := range t {
+			if name != "null" {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetUser", queryName("-- name: GetUser :one\nSELECT id FROM users WHERE id = $1"))
+	assert.Equal(t, "SELECT", queryName("  select\n  1"))
+	assert.Equal(t, "query", queryName(""))
+}
+
+func TestQueryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var tracer QueryTracer
+	trace := func(sql string, err error) {
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 2"), Err: err})
+	}
+
+	trace("-- name: UpdateTag :execrows\nUPDATE tags SET name = $1", nil)
+	trace("-- name: GetUser :one\nSELECT * FROM users", pgx.ErrNoRows)
+	trace("-- name: DeleteTag :exec\nDELETE FROM tags", errors.New("connection reset"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "UpdateTag", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), rowsAffected(2))
+
+	assert.Equal(t, "GetUser", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	assert.Equal(t, "DeleteTag", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, "connection reset", spans[2].Status().Description)
+}
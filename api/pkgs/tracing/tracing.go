@@ -0,0 +1,68 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider that
+// exports spans over OTLP and the tracer of the database queries.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName names the tracer of the spans the API starts itself.
+const TracerName = "github.com/endalk200/termflow-api"
+
+// Exporters for Setup.
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+)
+
+// Tracer returns the tracer of the global provider, a no-op one until
+// Setup installs an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Setup installs the W3C trace context propagator and, unless exporter is
+// "none", a tracer provider exporting spans of serviceName over OTLP/HTTP.
+// The exporter is configured with the standard OTEL_EXPORTER_OTLP_*
+// variables and sampling with OTEL_TRACES_SAMPLER.
+//
+// The returned function flushes the spans left and stops the exporter.
+func Setup(ctx context.Context, exporter, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	switch strings.ToLower(exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("invalid exporter %q, expected %s or %s", exporter, ExporterNone, ExporterOTLP)
+	}
+
+	spanExporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
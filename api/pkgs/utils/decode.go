@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecodeError explains why a request body could not be decoded, Status is
+// the status code to respond with.
+type DecodeError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeJSON decodes a single JSON value from body into payload. Fields
+// payload does not have and data after the value are rejected.
+func DecodeJSON(body io.Reader, payload interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(payload)
+	if err == nil {
+		if _, err := decoder.Token(); err != io.EOF {
+			return &DecodeError{Status: http.StatusBadRequest, Message: "Request body must hold a single JSON value", Err: err}
+		}
+
+		return nil
+	}
+
+	var (
+		maxBytesErr  *http.MaxBytesError
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		decodeErr    = &DecodeError{Status: http.StatusBadRequest, Err: err}
+		unknownField = "json: unknown field "
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		decodeErr.Status = http.StatusRequestEntityTooLarge
+		decodeErr.Message = fmt.Sprintf("Request body must be at most %d bytes", maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		decodeErr.Message = "Request body must not be empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		decodeErr.Message = "Request body is not valid JSON, it ends too early"
+	case errors.As(err, &syntaxErr):
+		decodeErr.Message = fmt.Sprintf("Request body is not valid JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		decodeErr.Message = fmt.Sprintf("Field %q must be %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()))
+	case errors.As(err, &typeErr):
+		decodeErr.Message = fmt.Sprintf("Request body must be %s", jsonTypeName(typeErr.Type.Kind().String()))
+	case strings.HasPrefix(err.Error(), unknownField):
+		decodeErr.Message = fmt.Sprintf("Unknown field %s", strings.TrimPrefix(err.Error(), unknownField))
+	default:
+		decodeErr.Message = "Invalid request payload"
+	}
+
+	return decodeErr
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "an array"
+	default:
+		return "an object"
+	}
+}
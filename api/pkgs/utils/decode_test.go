@@ -0,0 +1,69 @@
+package utils_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name string   `json:"name"`
+		Age  int      `json:"age"`
+		Tags []string `json:"tags"`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{"Valid", `{"name":"ls","age":3,"tags":["a"]}`, 0, ""},
+		{"Unknown field", `{"name":"ls","nmae":"x"}`, http.StatusBadRequest, `Unknown field "nmae"`},
+		{"Wrong type", `{"age":"three"}`, http.StatusBadRequest, `Field "age" must be a number`},
+		{"Wrong top level type", `[]`, http.StatusBadRequest, `Request body must be an object`},
+		{"Empty", ``, http.StatusBadRequest, `Request body must not be empty`},
+		{"Truncated", `{"name":`, http.StatusBadRequest, `Request body is not valid JSON, it ends too early`},
+		{"Syntax", `{"name" "ls"}`, http.StatusBadRequest, `Request body is not valid JSON at offset 9`},
+		{"Trailing data", `{"name":"ls"} {}`, http.StatusBadRequest, `Request body must hold a single JSON value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p payload
+			err := utils.DecodeJSON(strings.NewReader(tt.body), &p)
+			if tt.status == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var decodeErr *utils.DecodeError
+			if assert.True(t, errors.As(err, &decodeErr)) {
+				assert.Equal(t, tt.status, decodeErr.Status)
+				assert.Equal(t, tt.message, decodeErr.Message)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONTooLarge(t *testing.T) {
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`{"name":"a long name"}`)), 8)
+
+	var p struct {
+		Name string `json:"name"`
+	}
+	err := utils.DecodeJSON(body, &p)
+
+	var decodeErr *utils.DecodeError
+	if assert.True(t, errors.As(err, &decodeErr)) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, decodeErr.Status)
+		assert.Equal(t, "Request body must be at most 8 bytes", decodeErr.Message)
+	}
+}
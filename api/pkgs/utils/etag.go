@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag formats the version of a resource as a strong entity tag.
+func ETag(version int32) string {
+	return `"` + strconv.FormatInt(int64(version), 10) + `"`
+}
+
+// MatchesETag reports whether an If-Match or If-None-Match header value
+// matches the entity tag of version. The header may list several tags or be
+// "*", weak tags are compared by their opaque value.
+func MatchesETag(header string, version int32) bool {
+	etag := ETag(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
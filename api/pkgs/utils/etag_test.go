@@ -0,0 +1,36 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	assert.Equal(t, `"1"`, utils.ETag(1))
+	assert.Equal(t, `"42"`, utils.ETag(42))
+}
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int32
+		want    bool
+	}{
+		{"Exact", `"3"`, 3, true},
+		{"Stale", `"2"`, 3, false},
+		{"Wildcard", `*`, 3, true},
+		{"List", `"1", "3"`, 3, true},
+		{"Weak", `W/"3"`, 3, true},
+		{"Unquoted", `3`, 3, false},
+		{"Empty", ``, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, utils.MatchesETag(tt.header, tt.version))
+		})
+	}
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 func Response(w http.ResponseWriter, statusCode int, data interface{}) {
@@ -16,16 +18,119 @@ func Response(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 }
 
-func ResponseError(w http.ResponseWriter, statusCode int, msg string) {
-	// if statusCode > 499 {
-	// 	log.Println("Reponding with 5XX error", msg)
-	// }
+// RequestIDHeader carries the id of a request, it is echoed in the
+// response and in the body of errors.
+const RequestIDHeader = "X-Request-ID"
+
+// ProblemContentType is the media type of error responses, RFC 7807
+// problem details.
+const ProblemContentType = "application/problem+json"
+
+// Codes of the errors clients are expected to tell apart. Errors without
+// one of these get a code derived from their status, see StatusCode.
+const (
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeLoginThrottled     = "login_throttled"
+	CodeAccountLocked      = "account_locked"
+	CodeAccountInactive    = "account_inactive"
+	CodeSessionRevoked     = "session_revoked"
+	CodeRateLimited        = "rate_limited"
+	CodeIPForbidden        = "ip_forbidden"
+	CodeInvalidBody        = "invalid_body"
+	CodeBodyTooLarge       = "body_too_large"
+	CodeValidationFailed   = "validation_failed"
+	CodeAlreadyExists      = "already_exists"
+	CodeReferenceMissing   = "reference_missing"
+	CodeStillReferenced    = "still_referenced"
+	CodeInvalidValue       = "invalid_value"
+	CodeVersionMismatch    = "version_mismatch"
+	CodeInternal           = "internal_error"
+)
+
+// Error is an error the API responds with. It is sent as problem details
+// along with the id of the request.
+type Error struct {
+	Status int
+	// Code is machine readable, Detail is meant for people.
+	Code   string
+	Detail string
+	// Fields lists what is wrong with each field of a request payload.
+	Fields []FieldError
+	// Extensions are sent as extra members of the problem, such as the
+	// current representation of a resource with 412.
+	Extensions map[string]interface{}
+}
+
+// FieldError is what is wrong with a field, named as in JSON.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewError returns an Error with code, StatusCode(status) when empty.
+func NewError(status int, code, detail string) *Error {
+	if code == "" {
+		code = StatusCode(status)
+	}
+
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Detail)
+}
+
+// StatusCode is the code of errors that do not have a more specific one,
+// such as not_found for 404.
+func StatusCode(status int) string {
+	if status == http.StatusInternalServerError {
+		return CodeInternal
+	}
+
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// ResponseProblem responds with err as problem details.
+func ResponseProblem(w http.ResponseWriter, err *Error) {
+	problem := map[string]interface{}{}
+	for name, value := range err.Extensions {
+		problem[name] = value
+	}
 
-	type errResponse struct {
-		Message string `json:"message"`
+	// The status text stands for the title as the problem type is left as
+	// about:blank, the code tells problems apart instead
+	problem["type"] = "about:blank"
+	problem["title"] = http.StatusText(err.Status)
+	problem["status"] = err.Status
+	problem["code"] = err.Code
+	problem["detail"] = err.Detail
+	if requestID := w.Header().Get(RequestIDHeader); requestID != "" {
+		problem["request_id"] = requestID
+	}
+	if len(err.Fields) > 0 {
+		problem["errors"] = err.Fields
 	}
 
-	Response(w, statusCode, errResponse{
-		Message: msg,
-	})
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(err.Status)
+
+	if encodeErr := json.NewEncoder(w).Encode(problem); encodeErr != nil {
+		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+	}
+}
+
+// ResponseError responds with an error with the code of its status.
+func ResponseError(w http.ResponseWriter, statusCode int, msg string) {
+	ResponseProblem(w, NewError(statusCode, "", msg))
+}
+
+// ResponseErrorCode is ResponseError with a code of its own.
+func ResponseErrorCode(w http.ResponseWriter, statusCode int, code string, msg string) {
+	ResponseProblem(w, NewError(statusCode, code, msg))
 }
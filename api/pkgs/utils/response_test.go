@@ -0,0 +1,53 @@
+package utils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/endalk200/termflow-api/pkgs/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseProblem(t *testing.T) {
+	t.Run("Derives the code from the status", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		res.Header().Set(utils.RequestIDHeader, "abc-123")
+		utils.ResponseError(res, http.StatusNotFound, "Tag not found")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Equal(t, utils.ProblemContentType, res.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Not Found",
+			"status": 404,
+			"code": "not_found",
+			"detail": "Tag not found",
+			"request_id": "abc-123"
+		}`, res.Body.String())
+	})
+
+	t.Run("Sends fields and extensions", func(t *testing.T) {
+		problem := utils.NewError(http.StatusPreconditionFailed, utils.CodeVersionMismatch, "Modified")
+		problem.Fields = []utils.FieldError{{Field: "name", Code: "required", Message: "is required"}}
+		problem.Extensions = map[string]interface{}{"current": map[string]int{"version": 2}, "status": "ignored"}
+
+		res := httptest.NewRecorder()
+		utils.ResponseProblem(res, problem)
+
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Precondition Failed",
+			"status": 412,
+			"code": "version_mismatch",
+			"detail": "Modified",
+			"errors": [{"field": "name", "code": "required", "message": "is required"}],
+			"current": {"version": 2}
+		}`, res.Body.String())
+	})
+
+	t.Run("Internal errors", func(t *testing.T) {
+		assert.Equal(t, utils.CodeInternal, utils.StatusCode(http.StatusInternalServerError))
+		assert.Equal(t, "request_entity_too_large", utils.StatusCode(http.StatusRequestEntityTooLarge))
+	})
+}
@@ -1,35 +1,110 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
-type CustomValidationErrors struct {
-	FieldErrors map[string][]map[string]string `json:"field_name"`
+// NewValidator returns a validator that names fields as in JSON, which is
+// how FieldErrors reports them.
+func NewValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+
+		return name
+	})
+
+	return validate
 }
 
-// validateAndFormatErrors validates the struct and formats the errors.
-func ValidateAndFormatErrors(payload interface{}) (*CustomValidationErrors, error) {
-	validate := validator.New()
-	err := validate.Struct(payload)
-	if err != nil {
-		validationErrors := &CustomValidationErrors{
-			FieldErrors: make(map[string][]map[string]string),
-		}
+// ValidationError turns what a validator returned into a 400 Error that
+// lists what is wrong with each field, nil when err is.
+func ValidationError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return NewError(http.StatusBadRequest, CodeValidationFailed, err.Error())
+	}
+
+	problem := NewError(http.StatusBadRequest, CodeValidationFailed, "Request payload is invalid")
+	for _, fieldErr := range validationErrors {
+		problem.Fields = append(problem.Fields, FieldError{
+			Field:   fieldPath(fieldErr),
+			Code:    fieldErr.Tag(),
+			Message: fieldMessage(fieldErr),
+		})
+	}
 
-		for _, err := range err.(validator.ValidationErrors) {
-			fieldName := err.StructField()
-			errorType := err.Tag()
-			message := fmt.Sprintf("Field %s must be %s", fieldName, err.Tag())
+	return problem
+}
+
+// fieldPath is the path of the field from the payload, such as
+// steps[0].command, without the name of the payload type.
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+
+	return namespace
+}
 
-			// Append the error to the field
-			validationErrors.FieldErrors[fieldName] = append(validationErrors.FieldErrors[fieldName], map[string]string{
-				errorType: message,
-			})
+func fieldMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	case "min", "gte":
+		if isLength(fieldErr) {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+		return fmt.Sprintf("must be at least %s", param)
+	case "max", "lte":
+		if isLength(fieldErr) {
+			return fmt.Sprintf("must be at most %s characters long", param)
 		}
-		return validationErrors, nil
+		return fmt.Sprintf("must be at most %s", param)
+	case "len":
+		return fmt.Sprintf("must have a length of %s", param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	default:
+		return fmt.Sprintf("does not satisfy %s", fieldErr.Tag())
+	}
+}
+
+// isLength reports whether the bounds of the field apply to its length.
+func isLength(fieldErr validator.FieldError) bool {
+	switch fieldErr.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	default:
+		return false
 	}
-	return nil, nil
 }
@@ -1,120 +1,80 @@
 package utils_test
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/endalk200/termflow-api/pkgs/utils"
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-var validate *validator.Validate
+type testStep struct {
+	Command string `json:"command" validate:"required"`
+}
 
 // TestPayload represents a struct with various validation rules
 type TestPayload struct {
-	Command  string `validate:"required"`               // Required field
-	TagID    string `validate:"required"`               // Required field
-	Email    string `validate:"required,email"`         // Email validation
-	Age      int    `validate:"required,gte=18,lte=60"` // Age between 18 and 60
-	Password string `validate:"required,min=8"`         // Minimum length of 8
-	Website  string `validate:"required,url"`           // URL validation
+	Command  string     `json:"command" validate:"required"`
+	Email    string     `json:"email" validate:"required,email"`
+	Age      int        `json:"age" validate:"required,gte=18,lte=60"`
+	Password string     `json:"password" validate:"required,min=8"`
+	Website  string     `json:"website" validate:"required,url"`
+	Role     string     `json:"role" validate:"required,oneof=owner editor viewer"`
+	Steps    []testStep `json:"steps" validate:"dive"`
+	Internal string     `validate:"required"`
 }
 
-func init() {
-	validate = validator.New() // Initialize validator
+func validPayload() TestPayload {
+	return TestPayload{
+		Command:  "some-command",
+		Email:    "valid@example.com",
+		Age:      30,
+		Password: "validPassword",
+		Website:  "http://validurl.com",
+		Role:     "editor",
+		Steps:    []testStep{{Command: "ls"}},
+		Internal: "set",
+	}
 }
 
-func TestValidatePayload(t *testing.T) {
-	// Test case: All fields missing
-	t.Run("Missing Fields", func(t *testing.T) {
-		payload := TestPayload{}
-		customErrors, err := utils.ValidateAndFormatErrors(payload)
-
-		assert.Nil(t, err)
-		assert.NotNil(t, customErrors)
-
-		// Check specific error messages, ensuring the field exists and has at least one error
-		if errors, ok := customErrors.FieldErrors["Command"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Command must be required", errors[0]["required"])
-		}
-		if errors, ok := customErrors.FieldErrors["TagID"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field TagID must be required", errors[0]["required"])
-		}
-		if errors, ok := customErrors.FieldErrors["Email"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Email must be required", errors[0]["required"])
-		}
-		if errors, ok := customErrors.FieldErrors["Age"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Age must be required", errors[0]["required"])
-		}
-		if errors, ok := customErrors.FieldErrors["Password"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Password must be required", errors[0]["required"])
-		}
-		if errors, ok := customErrors.FieldErrors["Website"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Website must be required", errors[0]["required"])
-		}
-	})
-
-	// Test case: Invalid email and age out of range
-	t.Run("Invalid Email and Age", func(t *testing.T) {
-		payload := TestPayload{
-			Command:  "some-command",
-			TagID:    "some-tag-id",
-			Email:    "invalid-email", // Invalid email format
-			Age:      17,              // Below minimum age
-			Password: "validPassword",
-			Website:  "http://validurl.com",
-		}
-		customErrors, err := utils.ValidateAndFormatErrors(payload)
-
-		assert.Nil(t, err)
-		assert.NotNil(t, customErrors)
+func TestValidationError(t *testing.T) {
+	validate := utils.NewValidator()
 
-		// Check specific error messages
-		if errors, ok := customErrors.FieldErrors["Email"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Email must be email", errors[0]["email"])
-		}
-		if errors, ok := customErrors.FieldErrors["Age"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Age must be gte", errors[0]["gte"])
-		}
+	t.Run("Valid payload", func(t *testing.T) {
+		assert.Nil(t, utils.ValidationError(validate.Struct(validPayload())))
 	})
 
-	// Test case: Password too short and invalid URL
-	t.Run("Short Password and Invalid URL", func(t *testing.T) {
-		payload := TestPayload{
-			Command:  "some-command",
-			TagID:    "some-tag-id",
-			Email:    "valid@example.com",
-			Age:      30,
-			Password: "short",       // Too short
-			Website:  "invalid-url", // Invalid URL
-		}
-		customErrors, err := utils.ValidateAndFormatErrors(payload)
+	t.Run("Missing fields", func(t *testing.T) {
+		problem := utils.ValidationError(validate.Struct(TestPayload{}))
+		require.NotNil(t, problem)
 
-		assert.Nil(t, err)
-		assert.NotNil(t, customErrors)
-
-		// Check specific error messages
-		if errors, ok := customErrors.FieldErrors["Password"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Password must be min", errors[0]["min"])
-		}
-		if errors, ok := customErrors.FieldErrors["Website"]; ok && len(errors) > 0 {
-			assert.Equal(t, "Field Website must be url", errors[0]["url"])
-		}
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, utils.CodeValidationFailed, problem.Code)
+		assert.Contains(t, problem.Fields, utils.FieldError{Field: "command", Code: "required", Message: "is required"})
+		assert.Contains(t, problem.Fields, utils.FieldError{Field: "Internal", Code: "required", Message: "is required"})
+		assert.Len(t, problem.Fields, 7)
 	})
 
-	// Test case: Valid payload (all fields present and valid)
-	t.Run("Valid Payload", func(t *testing.T) {
-		payload := TestPayload{
-			Command:  "some-command",
-			TagID:    "some-tag-id",
-			Email:    "valid@example.com",
-			Age:      30,
-			Password: "validPassword",
-			Website:  "http://validurl.com",
-		}
-		customErrors, err := utils.ValidateAndFormatErrors(payload)
-
-		assert.Nil(t, err)          // Validation should pass
-		assert.Nil(t, customErrors) // No errors expected
+	t.Run("Invalid values", func(t *testing.T) {
+		payload := validPayload()
+		payload.Email = "invalid-email"
+		payload.Age = 17
+		payload.Password = "short"
+		payload.Website = "invalid-url"
+		payload.Role = "admin"
+		payload.Steps = []testStep{{Command: "ls"}, {}}
+
+		problem := utils.ValidationError(validate.Struct(payload))
+		require.NotNil(t, problem)
+
+		assert.ElementsMatch(t, []utils.FieldError{
+			{Field: "email", Code: "email", Message: "must be a valid email address"},
+			{Field: "age", Code: "gte", Message: "must be at least 18"},
+			{Field: "password", Code: "min", Message: "must be at least 8 characters long"},
+			{Field: "website", Code: "url", Message: "must be a valid URL"},
+			{Field: "role", Code: "oneof", Message: "must be one of owner, editor, viewer"},
+			{Field: "steps[1].command", Code: "required", Message: "is required"},
+		}, problem.Fields)
 	})
 }
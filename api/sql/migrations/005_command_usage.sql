@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE command_usage (
+  command_id   UUID PRIMARY KEY,
+  user_id      UUID NOT NULL,
+  use_count    BIGINT NOT NULL DEFAULT 0,
+  last_used_at TIMESTAMPTZ,
+
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down 
+DROP TABLE command_usage;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE collections (
+  id          UUID PRIMARY KEY,
+  user_id     UUID NOT NULL,
+  name        VARCHAR(255) NOT NULL,
+  description TEXT,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (user_id, name),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE collection_commands (
+  collection_id UUID NOT NULL,
+  command_id    UUID NOT NULL,
+  position      INTEGER NOT NULL,
+
+  PRIMARY KEY (collection_id, command_id),
+  FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE CASCADE
+);
+
+-- +goose Down 
+DROP TABLE collection_commands;
+DROP TABLE collections;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE runbooks (
+  id          UUID PRIMARY KEY,
+  user_id     UUID NOT NULL,
+  name        VARCHAR(255) NOT NULL,
+  description TEXT,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (user_id, name),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE runbook_steps (
+  id                UUID PRIMARY KEY,
+  runbook_id        UUID NOT NULL,
+  position          INTEGER NOT NULL,
+  name              TEXT,
+
+  -- A step either references a saved command or carries an inline command.
+  command_id        UUID,
+  command           TEXT,
+
+  confirm           BOOLEAN NOT NULL DEFAULT FALSE,
+  continue_on_error BOOLEAN NOT NULL DEFAULT FALSE,
+  capture_as        VARCHAR(255),
+
+  UNIQUE (runbook_id, position),
+  CHECK (command_id IS NOT NULL OR command IS NOT NULL),
+  FOREIGN KEY (runbook_id) REFERENCES runbooks(id) ON DELETE CASCADE,
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE runbook_variables (
+  runbook_id    UUID NOT NULL,
+  name          VARCHAR(255) NOT NULL,
+  default_value TEXT,
+
+  PRIMARY KEY (runbook_id, name),
+  FOREIGN KEY (runbook_id) REFERENCES runbooks(id) ON DELETE CASCADE
+);
+
+-- +goose Down 
+DROP TABLE runbook_variables;
+DROP TABLE runbook_steps;
+DROP TABLE runbooks;
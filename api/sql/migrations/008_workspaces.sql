@@ -0,0 +1,67 @@
+-- +goose Up
+CREATE TABLE workspaces (
+  id          UUID PRIMARY KEY,
+  name        VARCHAR(255) NOT NULL,
+  created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE workspace_members (
+  workspace_id  UUID NOT NULL,
+  user_id       UUID NOT NULL,
+  role          TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (workspace_id, user_id),
+  FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX workspace_members_user_id_idx ON workspace_members(user_id);
+
+CREATE TABLE workspace_invitations (
+  id            UUID PRIMARY KEY,
+  workspace_id  UUID NOT NULL,
+  email         VARCHAR(320) NOT NULL,
+  role          TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+  token_hash    TEXT NOT NULL UNIQUE,
+  invited_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+
+  expires_at  TIMESTAMPTZ NOT NULL,
+  accepted_at TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+-- Commands and tags owned by a workspace keep the user who created them in
+-- user_id.
+ALTER TABLE commands ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE tags ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX commands_workspace_id_idx ON commands(workspace_id);
+CREATE INDEX tags_workspace_id_idx ON tags(workspace_id);
+
+-- Tag names are unique per owner instead of globally.
+ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+CREATE UNIQUE INDEX tags_user_name_key ON tags(user_id, name) WHERE workspace_id IS NULL;
+CREATE UNIQUE INDEX tags_workspace_name_key ON tags(workspace_id, name) WHERE workspace_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX tags_workspace_name_key;
+DROP INDEX tags_user_name_key;
+DELETE FROM tags WHERE workspace_id IS NOT NULL;
+DELETE FROM commands WHERE workspace_id IS NOT NULL;
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
+
+DROP INDEX tags_workspace_id_idx;
+DROP INDEX commands_workspace_id_idx;
+ALTER TABLE tags DROP COLUMN workspace_id;
+ALTER TABLE commands DROP COLUMN workspace_id;
+
+DROP TABLE workspace_invitations;
+DROP TABLE workspace_members;
+DROP TABLE workspaces;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE share_links (
+  id            UUID PRIMARY KEY,
+  slug          TEXT NOT NULL UNIQUE,
+  user_id       UUID NOT NULL,
+  command_id    UUID,
+  collection_id UUID,
+
+  expires_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  -- A share link points at exactly one command or collection
+  CHECK ((command_id IS NULL) <> (collection_id IS NULL)),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE CASCADE,
+  FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX share_links_user_id_idx ON share_links(user_id);
+
+-- +goose Down
+DROP TABLE share_links;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE command_revisions (
+  id          UUID PRIMARY KEY,
+  command_id  UUID NOT NULL,
+  revision    INT NOT NULL,
+  author_id   UUID,
+
+  -- Snapshot of the command as it was saved in this revision
+  command     TEXT NOT NULL,
+  description TEXT NOT NULL,
+  tags        TEXT[] NOT NULL DEFAULT '{}',
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (command_id, revision),
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Existing commands start their history at revision 1
+INSERT INTO command_revisions (id, command_id, revision, author_id, command, description, tags, created_at)
+SELECT
+  uuid_generate_v4(), c.id, 1, c.user_id, c.command, c.description,
+  COALESCE((
+    SELECT array_agg(t.name ORDER BY t.name) FROM tags t
+    JOIN command_tags ct ON ct.tag_id = t.id
+    WHERE ct.command_id = c.id
+  ), '{}'),
+  c.updated_at
+FROM commands c;
+
+-- +goose Down
+DROP TABLE command_revisions;
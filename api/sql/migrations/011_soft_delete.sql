@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE commands ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE tags ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX commands_deleted_at_idx ON commands(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX tags_deleted_at_idx ON tags(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Tags in the trash do not hold on to their name.
+DROP INDEX tags_user_name_key;
+DROP INDEX tags_workspace_name_key;
+CREATE UNIQUE INDEX tags_user_name_key ON tags(user_id, name) WHERE workspace_id IS NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX tags_workspace_name_key ON tags(workspace_id, name) WHERE workspace_id IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DELETE FROM tags WHERE deleted_at IS NOT NULL;
+DELETE FROM commands WHERE deleted_at IS NOT NULL;
+
+DROP INDEX tags_workspace_name_key;
+DROP INDEX tags_user_name_key;
+CREATE UNIQUE INDEX tags_user_name_key ON tags(user_id, name) WHERE workspace_id IS NULL;
+CREATE UNIQUE INDEX tags_workspace_name_key ON tags(workspace_id, name) WHERE workspace_id IS NOT NULL;
+
+DROP INDEX tags_deleted_at_idx;
+DROP INDEX commands_deleted_at_idx;
+ALTER TABLE tags DROP COLUMN deleted_at;
+ALTER TABLE commands DROP COLUMN deleted_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- version is bumped on every change and backs the ETag of a command or tag.
+ALTER TABLE commands ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE tags DROP COLUMN version;
+ALTER TABLE commands DROP COLUMN version;
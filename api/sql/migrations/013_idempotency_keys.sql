@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE idempotency_keys (
+  user_id      UUID NOT NULL,
+  key          TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+
+  -- The response is NULL while the first request is still being handled
+  status_code      INT,
+  response_headers JSONB,
+  response_body    BYTEA,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (user_id, key),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys(created_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- A user asking for their account to be deleted keeps it until
+-- scheduled_for, the purge job removes it after that.
+CREATE TABLE account_deletions (
+  user_id       UUID PRIMARY KEY,
+  requested_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  scheduled_for TIMESTAMPTZ NOT NULL,
+
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX account_deletions_scheduled_for_idx ON account_deletions(scheduled_for);
+
+-- audit_events records the account events of a user, such as sign ins and
+-- exports. They are part of the account export and go with the account.
+CREATE TABLE audit_events (
+  id         UUID PRIMARY KEY,
+  user_id    UUID NOT NULL,
+  event      TEXT NOT NULL,
+  ip_address TEXT,
+  user_agent TEXT,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX audit_events_user_id_idx ON audit_events(user_id, created_at);
+
+-- +goose Down
+DROP TABLE audit_events;
+DROP TABLE account_deletions;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- A new email address replaces the current one once it is confirmed with
+-- the token sent to it. A user has at most one pending change.
+CREATE TABLE email_changes (
+  user_id    UUID PRIMARY KEY,
+  new_email  VARCHAR(320) NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Preferences the CLI pulls on login. Empty values leave the CLI defaults
+-- in place.
+CREATE TABLE user_preferences (
+  user_id            UUID PRIMARY KEY,
+  default_tags       TEXT[] NOT NULL DEFAULT '{}',
+  picker_theme       TEXT NOT NULL DEFAULT '',
+  dangerous_patterns TEXT[] NOT NULL DEFAULT '{}',
+
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE user_preferences;
+DROP TABLE email_changes;
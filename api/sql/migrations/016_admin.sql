@@ -0,0 +1,12 @@
+-- +goose Up
+-- Admins can use the /api/admin endpoints, the role is part of the access
+-- token.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+-- Access tokens issued before sessions_revoked_at are rejected, which signs
+-- the user out everywhere without waiting for their tokens to expire.
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN sessions_revoked_at;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Failed sign-in attempts of an account. Past a few of them every attempt
+-- has to wait for locked_until, which grows until the account is locked.
+-- The row is deleted on a successful sign-in.
+CREATE TABLE login_failures (
+  user_id        UUID PRIMARY KEY,
+  failed_count   INTEGER NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until   TIMESTAMPTZ,
+
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Sign-up used to store accounts as inactive, which nothing checked until
+-- now. Only accounts whose last admin action was a deactivation stay
+-- inactive.
+UPDATE users u
+SET is_active = TRUE
+WHERE u.is_active IS NOT TRUE
+AND COALESCE((
+  SELECT e.event FROM audit_events e
+  WHERE e.user_id = u.id AND e.event IN ('account.deactivated', 'account.reactivated')
+  ORDER BY e.created_at DESC
+  LIMIT 1
+), '') <> 'account.deactivated';
+
+-- +goose Down
+DROP TABLE login_failures;
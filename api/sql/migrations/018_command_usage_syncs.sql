@@ -0,0 +1,15 @@
+-- +goose Up
+-- The syncs usage counts were pushed in, so that a sync the CLI retries
+-- after failing to mark it as done is not counted twice.
+CREATE TABLE command_usage_syncs (
+  command_id UUID NOT NULL,
+  sync_id    TEXT NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (command_id, sync_id),
+  FOREIGN KEY (command_id) REFERENCES commands(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE command_usage_syncs;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Commands and tags of a workspace outlive the account of the user who
+-- created them; only personal ones are deleted along with the account.
+ALTER TABLE commands ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE commands DROP CONSTRAINT commands_user_id_fkey;
+ALTER TABLE commands ADD CONSTRAINT commands_user_id_fkey
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE commands ADD CONSTRAINT commands_owner_check
+  CHECK (user_id IS NOT NULL OR workspace_id IS NOT NULL);
+
+ALTER TABLE tags ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_fkey;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_fkey
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tags ADD CONSTRAINT tags_owner_check
+  CHECK (user_id IS NOT NULL OR workspace_id IS NOT NULL);
+
+-- +goose Down
+DELETE FROM tags WHERE user_id IS NULL;
+DELETE FROM commands WHERE user_id IS NULL;
+
+ALTER TABLE tags DROP CONSTRAINT tags_owner_check;
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_fkey;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_fkey
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE tags ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE commands DROP CONSTRAINT commands_owner_check;
+ALTER TABLE commands DROP CONSTRAINT commands_user_id_fkey;
+ALTER TABLE commands ADD CONSTRAINT commands_user_id_fkey
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE commands ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- A key is held for its request until lease_expires_at. A key still
+-- without a response after that was left by a request that never finished,
+-- as when the server crashed, and can be reserved again.
+ALTER TABLE idempotency_keys ADD COLUMN lease_expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN lease_expires_at;
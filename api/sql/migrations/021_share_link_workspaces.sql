@@ -0,0 +1,17 @@
+-- +goose Up
+-- Links to the commands of a workspace belong to the workspace, its
+-- editors list and revoke them, and are revoked along with the member who
+-- created them.
+ALTER TABLE share_links ADD COLUMN workspace_id UUID;
+ALTER TABLE share_links ADD CONSTRAINT share_links_workspace_id_fkey
+  FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+UPDATE share_links sl
+SET workspace_id = c.workspace_id
+FROM commands c
+WHERE c.id = sl.command_id AND c.workspace_id IS NOT NULL;
+
+CREATE INDEX share_links_workspace_id_idx ON share_links(workspace_id);
+
+-- +goose Down
+ALTER TABLE share_links DROP COLUMN workspace_id;
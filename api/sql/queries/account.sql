@@ -0,0 +1,92 @@
+-- name: ScheduleAccountDeletion :one
+-- A deletion that is scheduled already keeps its date.
+INSERT INTO account_deletions (
+  user_id, scheduled_for
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: FindAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE user_id = $1;
+
+-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletions
+WHERE user_id = $1;
+
+-- name: FindDueAccountDeletions :many
+SELECT user_id FROM account_deletions
+WHERE scheduled_for <= $1
+ORDER BY scheduled_for;
+
+-- name: CountSharedWorkspacesOwnedAlone :one
+-- Workspaces with other members in which the user is the only owner, they
+-- would be left without an owner.
+SELECT COUNT(*) FROM workspace_members m
+WHERE m.user_id = $1
+  AND m.role = 'owner'
+  AND NOT EXISTS (
+    SELECT 1 FROM workspace_members o
+    WHERE o.workspace_id = m.workspace_id AND o.user_id <> m.user_id AND o.role = 'owner'
+  )
+  AND EXISTS (
+    SELECT 1 FROM workspace_members o
+    WHERE o.workspace_id = m.workspace_id AND o.user_id <> m.user_id
+  );
+
+-- name: DeleteWorkspacesWithOnlyMember :execrows
+DELETE FROM workspaces w
+WHERE EXISTS (
+    SELECT 1 FROM workspace_members m
+    WHERE m.workspace_id = w.id AND m.user_id = $1
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM workspace_members m
+    WHERE m.workspace_id = w.id AND m.user_id <> $1
+  );
+
+-- name: DeleteRunbooksByUserID :exec
+-- Runbook steps restrict deleting the commands they use, the runbooks go
+-- before the commands.
+DELETE FROM runbooks
+WHERE user_id = $1;
+
+-- name: DeletePersonalCommands :exec
+-- Workspace commands only lose their author when the user is deleted.
+DELETE FROM commands
+WHERE user_id = sqlc.arg(user_id)::UUID AND workspace_id IS NULL;
+
+-- name: DeletePersonalTags :exec
+DELETE FROM tags
+WHERE user_id = sqlc.arg(user_id)::UUID AND workspace_id IS NULL;
+
+-- name: InsertAuditEvent :exec
+INSERT INTO audit_events (
+  id, user_id, event, ip_address, user_agent
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4
+);
+
+-- name: FindAuditEvents :many
+SELECT * FROM audit_events
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: FindAccountCommandRevisions :many
+-- The revisions the user made, in workspaces too, and those of their
+-- personal commands.
+SELECT * FROM command_revisions r
+WHERE r.author_id = sqlc.arg(user_id)::UUID
+  OR r.command_id IN (
+    SELECT c.id FROM commands c
+    WHERE c.user_id = sqlc.arg(user_id)::UUID AND c.workspace_id IS NULL
+  )
+ORDER BY r.command_id, r.revision;
+
+-- name: FindWorkspaceCommandsByAuthor :many
+SELECT * FROM commands
+WHERE user_id = sqlc.arg(user_id)::UUID AND workspace_id IS NOT NULL
+ORDER BY workspace_id, created_at;
@@ -0,0 +1,24 @@
+-- name: GetUserUsageCounts :one
+SELECT
+  (SELECT COUNT(*) FROM commands c WHERE c.user_id = sqlc.arg(user_id)::UUID AND c.deleted_at IS NULL) AS commands,
+  (SELECT COUNT(*) FROM tags t WHERE t.user_id = sqlc.arg(user_id)::UUID AND t.deleted_at IS NULL) AS tags,
+  (SELECT COUNT(*) FROM collections l WHERE l.user_id = sqlc.arg(user_id)::UUID) AS collections,
+  (SELECT COUNT(*) FROM runbooks b WHERE b.user_id = sqlc.arg(user_id)::UUID) AS runbooks,
+  (SELECT COUNT(*) FROM share_links s WHERE s.user_id = sqlc.arg(user_id)::UUID) AS share_links,
+  (SELECT COUNT(*) FROM workspace_members m WHERE m.user_id = sqlc.arg(user_id)::UUID) AS workspaces,
+  (SELECT COUNT(*) FROM refresh_tokens r WHERE r.user_id = sqlc.arg(user_id)::UUID AND r.expires_at > NOW()) AS sessions,
+  (SELECT COALESCE(SUM(u.use_count), 0) FROM command_usage u WHERE u.user_id = sqlc.arg(user_id)::UUID)::bigint AS command_uses,
+  (SELECT MAX(u.last_used_at) FROM command_usage u WHERE u.user_id = sqlc.arg(user_id)::UUID)::timestamptz AS last_used_at;
+
+-- name: GetUsageTotals :one
+SELECT
+  (SELECT COUNT(*) FROM users) AS users,
+  (SELECT COUNT(*) FROM users WHERE is_active) AS active_users,
+  (SELECT COUNT(*) FROM users WHERE role = 'admin') AS admins,
+  (SELECT COUNT(*) FROM commands WHERE deleted_at IS NULL) AS commands,
+  (SELECT COUNT(*) FROM tags WHERE deleted_at IS NULL) AS tags,
+  (SELECT COUNT(*) FROM collections) AS collections,
+  (SELECT COUNT(*) FROM runbooks) AS runbooks,
+  (SELECT COUNT(*) FROM share_links) AS share_links,
+  (SELECT COUNT(*) FROM workspaces) AS workspaces,
+  (SELECT COALESCE(SUM(use_count), 0) FROM command_usage)::bigint AS command_uses;
@@ -0,0 +1,54 @@
+-- name: FindCollections :many
+SELECT * FROM collections
+WHERE (user_id = $1)
+ORDER BY name;
+
+-- name: FindCollectionById :one
+SELECT * FROM collections
+WHERE (id = $1);
+
+-- name: InsertCollection :one
+INSERT INTO collections (
+  id, user_id, name, description
+) VALUES (
+  uuid_generate_v4(), $1, $2, sqlc.narg(description)::Text
+)
+RETURNING *;
+
+-- name: UpdateCollection :one
+UPDATE collections
+SET name = COALESCE(NULLIF(sqlc.arg(name)::Text, ''), name),
+    description = COALESCE(sqlc.narg(description)::Text, description),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteCollection :exec
+DELETE FROM collections
+WHERE id = $1;
+
+-- name: FindCollectionCommands :many
+SELECT
+    c.id, c.command, c.description, c.created_at, c.updated_at,
+    cc.position
+FROM collection_commands cc
+JOIN commands c ON c.id = cc.command_id
+WHERE cc.collection_id = $1 AND c.deleted_at IS NULL
+ORDER BY cc.position;
+
+-- name: AddCommandToCollection :one
+INSERT INTO collection_commands (collection_id, command_id, position)
+VALUES (
+  $1, $2,
+  (SELECT COALESCE(MAX(position) + 1, 0) FROM collection_commands WHERE collection_id = $1)
+)
+RETURNING *;
+
+-- name: RemoveCommandFromCollection :exec
+DELETE FROM collection_commands
+WHERE collection_id = $1 AND command_id = $2;
+
+-- name: UpdateCollectionCommandPosition :exec
+UPDATE collection_commands
+SET position = $3
+WHERE collection_id = $1 AND command_id = $2;
@@ -0,0 +1,35 @@
+-- name: InsertCommandRevision :one
+INSERT INTO command_revisions (
+  id, command_id, revision, author_id, command, description, tags
+)
+SELECT
+  uuid_generate_v4(), c.id,
+  COALESCE((SELECT MAX(r.revision) FROM command_revisions r WHERE r.command_id = c.id), 0) + 1,
+  sqlc.narg(author_id), c.command, c.description,
+  COALESCE((
+    SELECT array_agg(t.name ORDER BY t.name) FROM tags t
+    JOIN command_tags ct ON ct.tag_id = t.id
+    WHERE ct.command_id = c.id AND t.deleted_at IS NULL
+  ), '{}')::TEXT[]
+FROM commands c
+WHERE c.id = sqlc.arg(command_id)
+RETURNING *;
+
+-- name: FindCommandRevisions :many
+SELECT r.*, u.email AS author_email FROM command_revisions r
+LEFT JOIN users u ON u.id = r.author_id
+WHERE r.command_id = $1
+ORDER BY r.revision DESC;
+
+-- name: FindCommandRevision :one
+SELECT * FROM command_revisions
+WHERE command_id = $1 AND revision = $2;
+
+-- name: RestoreCommand :one
+UPDATE commands
+SET command = $2,
+    description = $3,
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND version = $4
+RETURNING *;
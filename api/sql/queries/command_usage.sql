@@ -0,0 +1,29 @@
+-- name: UpsertCommandUsage :one
+INSERT INTO command_usage (
+  command_id, user_id, use_count, last_used_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (command_id) DO UPDATE
+SET use_count = command_usage.use_count + EXCLUDED.use_count,
+    last_used_at = GREATEST(command_usage.last_used_at, EXCLUDED.last_used_at),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: FindCommandUsage :many
+SELECT * FROM command_usage
+WHERE (command_usage.user_id = $1)
+  AND command_usage.command_id IN (SELECT id FROM commands WHERE deleted_at IS NULL)
+ORDER BY use_count DESC;
+
+-- name: InsertCommandUsageSync :execrows
+INSERT INTO command_usage_syncs (
+  command_id, sync_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteExpiredCommandUsageSyncs :execrows
+DELETE FROM command_usage_syncs
+WHERE created_at < $1;
@@ -1,6 +1,7 @@
 -- name: FindTags :many
 SELECT * FROM tags
-WHERE (user_id = $1);
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND deleted_at IS NULL;
 
 -- name: FindTagById :one
 SELECT * FROM tags
@@ -16,8 +17,8 @@ SELECT
     c.id AS command_id, c.command AS command_name, c.description AS command_description
 FROM tags t
 LEFT JOIN command_tags ct ON t.id = ct.tag_id
-LEFT JOIN commands c ON ct.command_id = c.id
-WHERE t.user_id = $1;
+LEFT JOIN commands c ON ct.command_id = c.id AND c.deleted_at IS NULL
+WHERE t.user_id = sqlc.arg(user_id)::UUID AND t.workspace_id IS NULL AND t.deleted_at IS NULL;
 -- SELECT 
 --     c.id AS command_id, 
 --     c.command AS command_name, 
@@ -39,17 +40,19 @@ WHERE t.user_id = $1;
 
 -- name: InsertTag :one
 INSERT INTO tags (
-  id, user_id, name, description
+  id, user_id, name, description, workspace_id
 ) VALUES (
-  uuid_generate_v4(), $1, $2, sqlc.narg(description)::Text
+  uuid_generate_v4(), sqlc.arg(user_id)::UUID, sqlc.arg(name), sqlc.narg(description)::Text, sqlc.narg(workspace_id)::UUID
 )
 RETURNING *;
 
 -- name: UpdateTag :one
 UPDATE tags
 SET name = COALESCE(NULLIF($2, ''), name),
-    description = COALESCE(NULLIF($3, ''), description)
-WHERE id = $1
+    description = COALESCE(NULLIF($3, ''), description),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND version = $4
 RETURNING *;
 
 -- name: DeleteTag :exec
@@ -58,7 +61,11 @@ WHERE id = $1;
 
 -- name: FindCommands :many
 SELECT * FROM commands
-WHERE (user_id = $1);
+WHERE (user_id = sqlc.arg(user_id)::UUID AND workspace_id IS NULL AND deleted_at IS NULL);
+
+-- name: FindCommandById :one
+SELECT * FROM commands
+WHERE (id = $1);
 
 -- name: FindCommandsWithTags :many
 SELECT 
@@ -66,8 +73,9 @@ SELECT
     t.id AS tag_id, t.name AS tag_name, t.description AS tag_description, t.created_at AS tag_created_at, t.updated_at AS tag_updated_at
 FROM commands c
 LEFT JOIN command_tags ct ON c.id = ct.command_id
-LEFT JOIN tags t ON ct.tag_id = t.id
-WHERE c.user_id = $1;
+LEFT JOIN tags t ON ct.tag_id = t.id AND t.deleted_at IS NULL
+WHERE (c.workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND c.workspace_id IS NULL AND c.user_id = sqlc.arg(user_id)::UUID))
+  AND c.deleted_at IS NULL;
 
 -- name: FindCommandsByTagId :many
 SELECT 
@@ -76,21 +84,23 @@ SELECT
 FROM commands c
 LEFT JOIN command_tags ct ON c.id = ct.command_id
 LEFT JOIN tags t ON ct.tag_id = t.id
-WHERE t.id = $1;
+WHERE t.id = $1 AND c.deleted_at IS NULL;
 
 -- name: InsertCommands :one
 INSERT INTO commands (
-  id, user_id, command, description
+  id, user_id, command, description, workspace_id
 ) VALUES (
-  uuid_generate_v4(), $1, $2, sqlc.narg(description)::Text
+  uuid_generate_v4(), sqlc.arg(user_id)::UUID, sqlc.arg(command), sqlc.narg(description)::Text, sqlc.narg(workspace_id)::UUID
 )
 RETURNING *;
 
 -- name: UpdateCommand :one
 UPDATE commands
 SET command = COALESCE(NULLIF($2, ''), command),
-    description = COALESCE(NULLIF($3, ''), description)
-WHERE id = $1
+    description = COALESCE(NULLIF($3, ''), description),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $1 AND version = $4
 RETURNING *;
 
 -- name: DeleteCommand :exec
@@ -117,3 +127,9 @@ WITH deleted AS (
 )
 INSERT INTO command_tags (command_id, tag_id)
 VALUES ($1, $2);
+
+-- name: FindTagsByCommandId :many
+SELECT t.* FROM tags t
+JOIN command_tags ct ON ct.tag_id = t.id
+WHERE ct.command_id = $1 AND t.deleted_at IS NULL
+ORDER BY t.name;
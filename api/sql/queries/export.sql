@@ -0,0 +1,42 @@
+-- name: ExportTags :many
+-- Account exports page through each table by id so that they never hold
+-- more than a page in memory.
+SELECT * FROM tags
+WHERE user_id = sqlc.arg(user_id)::UUID AND workspace_id IS NULL AND deleted_at IS NULL AND id > sqlc.arg(id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ExportCommands :many
+SELECT
+    c.id, c.command, c.description,
+    COALESCE(array_agg(t.name ORDER BY t.name) FILTER (WHERE t.id IS NOT NULL), '{}')::TEXT[] AS tags
+FROM commands c
+LEFT JOIN command_tags ct ON ct.command_id = c.id
+LEFT JOIN tags t ON t.id = ct.tag_id AND t.deleted_at IS NULL
+WHERE c.user_id = sqlc.arg(user_id)::UUID AND c.workspace_id IS NULL AND c.deleted_at IS NULL AND c.id > sqlc.arg(id)
+GROUP BY c.id
+ORDER BY c.id
+LIMIT sqlc.arg('limit');
+
+-- name: ExportCollections :many
+SELECT
+    col.id, col.name, col.description,
+    COALESCE(array_agg(c.id ORDER BY cc.position) FILTER (WHERE c.id IS NOT NULL), '{}')::UUID[] AS command_ids
+FROM collections col
+LEFT JOIN collection_commands cc ON cc.collection_id = col.id
+LEFT JOIN commands c ON c.id = cc.command_id AND c.workspace_id IS NULL AND c.deleted_at IS NULL
+WHERE col.user_id = $1 AND col.id > $2
+GROUP BY col.id
+ORDER BY col.id
+LIMIT $3;
+
+-- name: ExportShareLinks :many
+SELECT * FROM share_links sl
+WHERE sl.user_id = $1 AND sl.id > $2
+  AND sl.revoked_at IS NULL AND (sl.expires_at IS NULL OR sl.expires_at > NOW())
+  AND (sl.command_id IS NULL OR EXISTS (
+    SELECT 1 FROM commands c
+    WHERE c.id = sl.command_id AND c.workspace_id IS NULL AND c.deleted_at IS NULL
+  ))
+ORDER BY sl.id
+LIMIT $3;
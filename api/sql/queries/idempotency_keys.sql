@@ -0,0 +1,36 @@
+-- name: ReserveIdempotencyKey :execrows
+-- A key that expired, or whose lease ran out before its request stored a
+-- response, is taken over as if it was never used.
+INSERT INTO idempotency_keys (
+  user_id, key, request_hash, lease_expires_at
+) VALUES (
+  $1, $2, $3, $5
+)
+ON CONFLICT (user_id, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+  status_code = NULL,
+  response_headers = NULL,
+  response_body = NULL,
+  lease_expires_at = EXCLUDED.lease_expires_at,
+  created_at = NOW()
+WHERE idempotency_keys.created_at < $4
+  OR (idempotency_keys.status_code IS NULL AND idempotency_keys.lease_expires_at < NOW());
+
+-- name: FindIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 AND created_at >= $3;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+  response_headers = $4,
+  response_body = $5
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
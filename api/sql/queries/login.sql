@@ -0,0 +1,32 @@
+-- name: FindLoginFailure :one
+SELECT * FROM login_failures
+WHERE user_id = $1;
+
+-- name: ReserveLoginAttempt :one
+-- Counts an attempt as failed before its password is checked, starting over
+-- when the previous failure is older than reset_before. While the account has
+-- to wait for locked_until the attempt is not counted and no row is returned.
+-- The row stays locked until the transaction ends, so concurrent attempts are
+-- counted one after the other.
+INSERT INTO login_failures (
+  user_id, failed_count, last_failed_at
+) VALUES (
+  sqlc.arg(user_id), 1, NOW()
+)
+ON CONFLICT (user_id) DO UPDATE
+SET failed_count = CASE
+      WHEN login_failures.last_failed_at < sqlc.arg(reset_before) THEN 1
+      ELSE login_failures.failed_count + 1
+    END,
+    last_failed_at = NOW()
+WHERE login_failures.locked_until IS NULL OR login_failures.locked_until <= NOW()
+RETURNING *;
+
+-- name: SetLoginLockedUntil :exec
+UPDATE login_failures
+SET locked_until = $2
+WHERE user_id = $1;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE user_id = $1;
@@ -0,0 +1,37 @@
+-- name: UpsertEmailChange :one
+INSERT INTO email_changes (
+  user_id, new_email, token_hash, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET new_email = EXCLUDED.new_email,
+    token_hash = EXCLUDED.token_hash,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+RETURNING *;
+
+-- name: FindEmailChangeByTokenHash :one
+SELECT * FROM email_changes
+WHERE token_hash = $1;
+
+-- name: DeleteEmailChange :exec
+DELETE FROM email_changes
+WHERE user_id = $1;
+
+-- name: FindUserPreferences :one
+SELECT * FROM user_preferences
+WHERE user_id = $1;
+
+-- name: UpsertUserPreferences :one
+INSERT INTO user_preferences (
+  user_id, default_tags, picker_theme, dangerous_patterns
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET default_tags = EXCLUDED.default_tags,
+    picker_theme = EXCLUDED.picker_theme,
+    dangerous_patterns = EXCLUDED.dangerous_patterns,
+    updated_at = NOW()
+RETURNING *;
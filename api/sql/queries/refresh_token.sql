@@ -25,3 +25,7 @@ WHERE id = $1;
 -- name: CleanupExpiredTokens :exec
 DELETE FROM refresh_tokens
 WHERE expires_at < NOW();
+
+-- name: DeleteRefreshTokensByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1;
@@ -0,0 +1,67 @@
+-- name: FindRunbooks :many
+SELECT * FROM runbooks
+WHERE (user_id = $1)
+ORDER BY name;
+
+-- name: FindRunbookById :one
+SELECT * FROM runbooks
+WHERE (id = $1);
+
+-- name: InsertRunbook :one
+INSERT INTO runbooks (
+  id, user_id, name, description
+) VALUES (
+  uuid_generate_v4(), $1, $2, sqlc.narg(description)::Text
+)
+RETURNING *;
+
+-- name: UpdateRunbook :one
+UPDATE runbooks
+SET name = sqlc.arg(name),
+    description = sqlc.narg(description)::Text,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteRunbook :exec
+DELETE FROM runbooks
+WHERE id = $1;
+
+-- name: IsCommandUsedByRunbook :one
+SELECT EXISTS (
+  SELECT 1 FROM runbook_steps
+  WHERE command_id = $1
+);
+
+-- name: FindRunbookSteps :many
+SELECT * FROM runbook_steps
+WHERE (runbook_id = $1)
+ORDER BY position;
+
+-- name: InsertRunbookStep :one
+INSERT INTO runbook_steps (
+  id, runbook_id, position, name, command_id, command, confirm, continue_on_error, capture_as
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: DeleteRunbookSteps :exec
+DELETE FROM runbook_steps
+WHERE runbook_id = $1;
+
+-- name: FindRunbookVariables :many
+SELECT * FROM runbook_variables
+WHERE (runbook_id = $1)
+ORDER BY name;
+
+-- name: InsertRunbookVariable :exec
+INSERT INTO runbook_variables (
+  runbook_id, name, default_value
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: DeleteRunbookVariables :exec
+DELETE FROM runbook_variables
+WHERE runbook_id = $1;
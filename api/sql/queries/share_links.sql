@@ -0,0 +1,29 @@
+-- name: InsertShareLink :one
+INSERT INTO share_links (
+  id, slug, user_id, command_id, collection_id, expires_at, workspace_id
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: FindShareLinkBySlug :one
+SELECT * FROM share_links
+WHERE slug = $1;
+
+-- name: FindShareLinks :many
+SELECT * FROM share_links
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC;
+
+-- name: RevokeShareLink :execrows
+UPDATE share_links
+SET revoked_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND revoked_at IS NULL;
+
+-- name: RevokeWorkspaceMemberShareLinks :exec
+UPDATE share_links
+SET revoked_at = NOW()
+WHERE workspace_id = sqlc.arg(workspace_id)::UUID AND user_id = sqlc.arg(user_id) AND revoked_at IS NULL;
@@ -0,0 +1,69 @@
+-- name: SoftDeleteCommand :execrows
+-- Commands a runbook step uses stay out of the trash, the step would point
+-- at a command that is gone and purging it would fail.
+UPDATE commands
+SET deleted_at = NOW(),
+    version = version + 1
+WHERE commands.id = $1 AND commands.version = $2 AND commands.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM runbook_steps s WHERE s.command_id = commands.id);
+
+-- name: SoftDeleteTag :execrows
+UPDATE tags
+SET deleted_at = NOW(),
+    version = version + 1
+WHERE id = $1 AND version = $2 AND deleted_at IS NULL;
+
+-- name: FindDeletedCommands :many
+SELECT * FROM commands
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: FindDeletedTags :many
+SELECT * FROM tags
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: UndeleteCommand :one
+UPDATE commands
+SET deleted_at = NULL,
+    version = version + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UndeleteTag :one
+UPDATE tags
+SET deleted_at = NULL,
+    version = version + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: PurgeCommand :exec
+DELETE FROM commands
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeTag :exec
+DELETE FROM tags
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeDeletedCommands :execrows
+DELETE FROM commands
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND deleted_at IS NOT NULL;
+
+-- name: PurgeDeletedTags :execrows
+DELETE FROM tags
+WHERE (workspace_id = sqlc.narg(workspace_id) OR (sqlc.narg(workspace_id) IS NULL AND workspace_id IS NULL AND user_id = sqlc.arg(user_id)::UUID))
+  AND deleted_at IS NOT NULL;
+
+-- name: PurgeExpiredCommands :execrows
+-- Commands trashed while a runbook step used them wait until the step is
+-- gone, deleting them would fail the whole purge.
+DELETE FROM commands
+WHERE deleted_at < $1
+  AND NOT EXISTS (SELECT 1 FROM runbook_steps s WHERE s.command_id = commands.id);
+
+-- name: PurgeExpiredTags :execrows
+DELETE FROM tags
+WHERE deleted_at < $1;
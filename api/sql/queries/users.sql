@@ -10,10 +10,15 @@ LEFT JOIN refresh_tokens rt ON u.id = rt.user_id
 WHERE u.id = $1;
 
 -- name: ListUsers :many
+-- The search is matched literally, % and _ in it are not wildcards
 SELECT * FROM users
 WHERE (sqlc.arg(is_active) IS NULL OR is_active = sqlc.arg(is_active))
 AND (sqlc.arg(is_email_verified) IS NULL OR is_email_verified = sqlc.arg(is_email_verified))
-ORDER BY first_name;
+AND (sqlc.arg(search)::text = ''
+  OR strpos(lower(email), lower(sqlc.arg(search)::text)) > 0
+  OR strpos(lower(first_name || ' ' || last_name), lower(sqlc.arg(search)::text)) > 0)
+ORDER BY first_name, id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
 
 -- name: InsertUser :one
 INSERT INTO users (
@@ -23,12 +28,42 @@ INSERT INTO users (
 )
 RETURNING *;
 
--- name: UpdateUser :exec
+-- name: UpdateUser :one
 UPDATE users 
   set first_name = $2,
-  last_name = $3
+  last_name = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ChangeUserEmail :exec
+UPDATE users
+SET email = $2,
+    is_email_verified = TRUE,
+    updated_at = NOW()
 WHERE id = $1;
 
 -- name: DeleteUser :exec
 DELETE FROM users 
 WHERE id = $1;
+
+-- name: SetUserActive :execrows
+UPDATE users
+SET is_active = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeUserSessions :execrows
+UPDATE users
+SET sessions_revoked_at = NOW()
+WHERE id = $1;
+
+-- name: GetUserSessionState :one
+SELECT is_active, sessions_revoked_at FROM users
+WHERE id = $1;
@@ -0,0 +1,87 @@
+-- name: InsertWorkspace :one
+INSERT INTO workspaces (
+  id, name, created_by
+) VALUES (
+  uuid_generate_v4(), $1, $2
+)
+RETURNING *;
+
+-- name: FindWorkspaceById :one
+SELECT * FROM workspaces
+WHERE (id = $1);
+
+-- name: FindWorkspacesByUser :many
+SELECT w.id, w.name, w.created_by, w.created_at, w.updated_at, m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = $1
+ORDER BY w.name;
+
+-- name: UpdateWorkspace :one
+UPDATE workspaces
+SET name = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWorkspace :exec
+DELETE FROM workspaces
+WHERE id = $1;
+
+-- name: FindWorkspaceMember :one
+SELECT * FROM workspace_members
+WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: FindWorkspaceMembers :many
+SELECT m.user_id, m.role, m.created_at, u.email, u.first_name, u.last_name
+FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = $1
+ORDER BY m.created_at;
+
+-- name: InsertWorkspaceMember :exec
+INSERT INTO workspace_members (
+  workspace_id, user_id, role
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: UpdateWorkspaceMemberRole :exec
+UPDATE workspace_members
+SET role = $3
+WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: DeleteWorkspaceMember :exec
+DELETE FROM workspace_members
+WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: LockWorkspaceOwners :many
+SELECT user_id FROM workspace_members
+WHERE workspace_id = $1 AND role = 'owner'
+FOR UPDATE;
+
+-- name: InsertWorkspaceInvitation :one
+INSERT INTO workspace_invitations (
+  id, workspace_id, email, role, token_hash, invited_by, expires_at
+) VALUES (
+  uuid_generate_v4(), $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: FindWorkspaceInvitations :many
+SELECT * FROM workspace_invitations
+WHERE workspace_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+ORDER BY created_at;
+
+-- name: FindWorkspaceInvitationByTokenHash :one
+SELECT * FROM workspace_invitations
+WHERE token_hash = $1;
+
+-- name: AcceptWorkspaceInvitation :exec
+UPDATE workspace_invitations
+SET accepted_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteWorkspaceInvitation :exec
+DELETE FROM workspace_invitations
+WHERE id = $1 AND workspace_id = $2;
@@ -0,0 +1,40 @@
+// Package sql embeds the goose migrations of the database, so that the API
+// can tell whether the database it runs against is up to date.
+package sql
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// LatestMigration returns the version of the last migration, the one goose
+// records in goose_db_version once the database is up to date. Migrations
+// are named after their version, such as 017_login_lockout.sql.
+func LatestMigration() (int64, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
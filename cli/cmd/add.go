@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/project"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <command>",
+	Short: "Save a command",
+	Long: `Save a command in the local database.
+
+With --project the command is written to the closest .termflow.yaml instead,
+or to a new one in the current directory, so it can be committed alongside
+the code.
+
+Without --tag the command is filed under the add.default_tags of the config,
+which termflow login pulls from your account.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description, _ := cmd.Flags().GetString("description")
+		name, _ := cmd.Flags().GetString("name")
+		tag, _ := cmd.Flags().GetString("tag")
+		toProject, _ := cmd.Flags().GetBool("project")
+
+		tagNames := []string{tag}
+		if tag == "" {
+			tagNames = viper.GetStringSlice("add.default_tags")
+		}
+		if len(tagNames) == 0 {
+			return errors.New("a tag is required, pass --tag or set add.default_tags")
+		}
+
+		if toProject {
+			return addProjectCommand(project.Command{
+				Name:        name,
+				Command:     args[0],
+				Description: description,
+				Tags:        tagNames,
+			})
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		command, err := commands.AddCommandWithTags(commands.AddCommandWithTagsArgs{
+			Db:          db,
+			Ctx:         cmd.Context(),
+			Queries:     queries,
+			Command:     args[0],
+			Description: description,
+			Name:        name,
+			Tag:         tagNames[0],
+			ExtraTags:   tagNames[1:],
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved command %d\n", command.ID)
+		return nil
+	},
+}
+
+func addProjectCommand(command project.Command) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	path, err := project.Find(wd)
+	if errors.Is(err, project.ErrNotFound) {
+		path = filepath.Join(wd, project.FileName)
+	} else if err != nil {
+		return err
+	}
+
+	if err := project.Add(path, command); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved command to %s\n", path)
+	return nil
+}
+
+func init() {
+	addCmd.Flags().StringP("description", "d", "", "what the command does")
+	addCmd.Flags().StringP("name", "n", "", "unique name to refer to the command by")
+	addCmd.Flags().StringP("tag", "t", "", "tag to file the command under, defaults to add.default_tags")
+	addCmd.Flags().Bool("project", false, "save the command to the project's "+project.FileName)
+
+	rootCmd.AddCommand(addCmd)
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/endalk200/termflow-cli/collections"
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var collectionCmd = &cobra.Command{
+	Use:     "collection",
+	Aliases: []string{"collections"},
+	Short:   "Manage ordered, named groups of commands",
+}
+
+var collectionCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a collection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description, _ := cmd.Flags().GetString("description")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		collection, err := collections.CreateCollection(collections.CreateCollectionArgs{
+			Ctx:         cmd.Context(),
+			Queries:     queries,
+			Name:        args[0],
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created collection %d\n", collection.ID)
+		return nil
+	},
+}
+
+var collectionAddCmd = &cobra.Command{
+	Use:   "add <collection> <id|name>...",
+	Short: "Append commands to a collection",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		collection, err := getCollection(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, idOrName := range args[1:] {
+			command, err := getCommand(cmd, queries, idOrName)
+			if err != nil {
+				return err
+			}
+
+			err = collections.AddCommand(collections.AddCommandArgs{
+				Ctx:          cmd.Context(),
+				Queries:      queries,
+				CollectionID: collection.ID,
+				CommandID:    command.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+var collectionRemoveCmd = &cobra.Command{
+	Use:   "remove <collection> <id|name>",
+	Short: "Remove a command from a collection",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		collection, err := getCollection(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		command, err := getCommand(cmd, queries, args[1])
+		if err != nil {
+			return err
+		}
+
+		return collections.RemoveCommand(collections.RemoveCommandArgs{
+			Db:           db,
+			Ctx:          cmd.Context(),
+			Queries:      queries,
+			CollectionID: collection.ID,
+			CommandID:    command.ID,
+		})
+	},
+}
+
+var collectionMoveCmd = &cobra.Command{
+	Use:   "move <collection> <id|name> <position>",
+	Short: "Move a command to a position within a collection",
+	Long:  "Move a command to a position within a collection. Positions start at 1.",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		position, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid position %q", args[2])
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		collection, err := getCollection(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		command, err := getCommand(cmd, queries, args[1])
+		if err != nil {
+			return err
+		}
+
+		return collections.MoveCommand(collections.MoveCommandArgs{
+			Db:           db,
+			Ctx:          cmd.Context(),
+			Queries:      queries,
+			CollectionID: collection.ID,
+			CommandID:    command.ID,
+			Position:     position - 1,
+		})
+	},
+}
+
+var collectionListCmd = &cobra.Command{
+	Use:   "list [collection]",
+	Short: "List collections, or the commands of a collection in order",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		if len(args) == 0 {
+			all, err := queries.ListCollections(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION")
+			for _, collection := range all {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", collection.ID, collection.Name, collection.Description.String)
+			}
+
+			return w.Flush()
+		}
+
+		collection, err := getCollection(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		rows, err := collections.ListCommands(collections.ListCommandsArgs{
+			Ctx:          cmd.Context(),
+			Queries:      queries,
+			CollectionID: collection.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, "#\tID\tNAME\tCOMMAND\tDESCRIPTION")
+		for _, row := range rows {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n",
+				row.Position+1,
+				row.ID,
+				row.Name.String,
+				row.Command.String,
+				row.Description.String,
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+var collectionDeleteCmd = &cobra.Command{
+	Use:   "delete <collection>",
+	Short: "Delete a collection, keeping its commands",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		collection, err := getCollection(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		return queries.DeleteCollection(cmd.Context(), collection.ID)
+	},
+}
+
+func getCollection(cmd *cobra.Command, queries *database.Queries, idOrName string) (database.Collection, error) {
+	collection, err := collections.GetCollection(collections.GetCollectionArgs{
+		Ctx:      cmd.Context(),
+		Queries:  queries,
+		IdOrName: idOrName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Collection{}, fmt.Errorf("no collection with id or name %q", idOrName)
+	}
+
+	return collection, err
+}
+
+func getCommand(cmd *cobra.Command, queries *database.Queries, idOrName string) (database.Command, error) {
+	command, err := commands.GetCommand(commands.GetCommandArgs{
+		Ctx:      cmd.Context(),
+		Queries:  queries,
+		IdOrName: idOrName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Command{}, fmt.Errorf("no command with id or name %q", idOrName)
+	}
+
+	return command, err
+}
+
+func init() {
+	collectionCreateCmd.Flags().StringP("description", "d", "", "what the collection is for")
+
+	collectionCmd.AddCommand(
+		collectionCreateCmd,
+		collectionAddCmd,
+		collectionRemoveCmd,
+		collectionMoveCmd,
+		collectionListCmd,
+		collectionDeleteCmd,
+	)
+	rootCmd.AddCommand(collectionCmd)
+}
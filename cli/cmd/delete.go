@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <id|name>...",
+	Short: "Move saved commands or tags to the trash",
+	Long: `Move saved commands to the trash, or tags with --tag.
+
+Deleted commands and tags can be brought back with termflow undo or
+termflow trash restore until they are purged.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		isTag, _ := cmd.Flags().GetBool("tag")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := purgeExpiredTrash(cmd, db, queries); err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			if isTag {
+				tag, err := commands.DeleteTag(commands.DeleteTagArgs{
+					Db:      db,
+					Ctx:     cmd.Context(),
+					Queries: queries,
+					Name:    arg,
+				})
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("no tag named %q", arg)
+				}
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("Moved tag %s to the trash\n", tag.Name)
+				continue
+			}
+
+			command, err := getCommand(cmd, queries, arg)
+			if err != nil {
+				return err
+			}
+
+			err = commands.DeleteCommand(commands.DeleteCommandArgs{
+				Db:      db,
+				Ctx:     cmd.Context(),
+				Queries: queries,
+				ID:      command.ID,
+			})
+			if errors.Is(err, commands.ErrUsedByRunbook) {
+				return fmt.Errorf("command %d is used by a runbook, remove it from the runbook first", command.ID)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Moved command %d to the trash\n", command.ID)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	deleteCmd.Flags().BoolP("tag", "t", false, "delete tags by name instead of commands")
+
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id|name>",
+	Short: "Change a saved command",
+	Long: `Change the text or description of a saved command.
+
+Every edit is kept as a revision, see termflow history.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text, _ := cmd.Flags().GetString("command")
+		description, _ := cmd.Flags().GetString("description")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		command, err := getCommand(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		revision, err := commands.EditCommand(commands.EditCommandArgs{
+			Db:          db,
+			Ctx:         cmd.Context(),
+			Queries:     queries,
+			ID:          command.ID,
+			Command:     text,
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved revision %d of command %d\n", revision.Revision, command.ID)
+		return nil
+	},
+}
+
+func init() {
+	editCmd.Flags().StringP("command", "c", "", "new command text")
+	editCmd.Flags().StringP("description", "d", "", "new description")
+
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <id|name>",
+	Short: "Show the revisions of a saved command",
+	Long: `Show the revisions of a saved command, newest first.
+
+With --diff the given revision is compared with the one before it, with
+--restore the command is put back to how it was at that revision.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		diffRevision, _ := cmd.Flags().GetInt64("diff")
+		restoreRevision, _ := cmd.Flags().GetInt64("restore")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		command, err := getCommand(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		if restoreRevision > 0 {
+			restored, err := commands.RestoreRevision(commands.RestoreRevisionArgs{
+				Db:        db,
+				Ctx:       cmd.Context(),
+				Queries:   queries,
+				CommandID: command.ID,
+				Revision:  restoreRevision,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("command %d has no revision %d", command.ID, restoreRevision)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Restored revision %d as revision %d\n", restoreRevision, restored.Revision)
+			return nil
+		}
+
+		if diffRevision > 0 {
+			return printRevisionDiff(cmd, queries, command.ID, diffRevision)
+		}
+
+		revisions, err := commands.ListRevisions(commands.ListRevisionsArgs{
+			Ctx:       cmd.Context(),
+			Queries:   queries,
+			CommandID: command.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REV\tSAVED\tCOMMAND\tDESCRIPTION\tTAGS")
+		for _, revision := range revisions {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				revision.Revision,
+				revision.Createdat.Local().Format("2006-01-02 15:04"),
+				revision.Command.String,
+				revision.Description.String,
+				revision.Tags,
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+func printRevisionDiff(cmd *cobra.Command, queries *database.Queries, commandId, revisionNumber int64) error {
+	getRevision := func(revisionNumber int64) (database.Commandrevision, error) {
+		revision, err := commands.GetRevision(commands.GetRevisionArgs{
+			Ctx:       cmd.Context(),
+			Queries:   queries,
+			CommandID: commandId,
+			Revision:  revisionNumber,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return revision, fmt.Errorf("command %d has no revision %d", commandId, revisionNumber)
+		}
+
+		return revision, err
+	}
+
+	revision, err := getRevision(revisionNumber)
+	if err != nil {
+		return err
+	}
+
+	// The first revision is compared with an empty command
+	var previous database.Commandrevision
+	if revisionNumber > 1 {
+		if previous, err = getRevision(revisionNumber - 1); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("revision %d -> %d\n", revisionNumber-1, revisionNumber)
+	printFieldDiff("command", previous.Command.String, revision.Command.String)
+	printFieldDiff("description", previous.Description.String, revision.Description.String)
+
+	printFieldDiff("tags", previous.Tags, revision.Tags)
+
+	return nil
+}
+
+func printFieldDiff(field, before, after string) {
+	if before == after {
+		return
+	}
+
+	if before != "" {
+		fmt.Printf("%s\n  - %s\n", field, strings.ReplaceAll(before, "\n", "\n  - "))
+	} else {
+		fmt.Println(field)
+	}
+	if after != "" {
+		fmt.Printf("  + %s\n", strings.ReplaceAll(after, "\n", "\n  + "))
+	}
+}
+
+func init() {
+	historyCmd.Flags().Int64("diff", 0, "show what changed in a revision")
+	historyCmd.Flags().Int64("restore", 0, "restore the command to a revision")
+	historyCmd.MarkFlagsMutuallyExclusive("diff", "restore")
+
+	rootCmd.AddCommand(historyCmd)
+}
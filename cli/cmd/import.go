@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/shares"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Copy shared commands into the local store",
+	Long: `Copy a shared command, or a shared collection with its commands, into
+the local store.
+
+--share takes a share link or its slug. Slugs are fetched from the
+configured api.url.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		link, _ := cmd.Flags().GetString("share")
+		tag, _ := cmd.Flags().GetString("tag")
+
+		baseURL, slug, err := shares.ParseLink(link)
+		if err != nil {
+			return err
+		}
+
+		apiClient := newClient()
+		if baseURL != "" {
+			apiClient = client.New(baseURL, "")
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		imported, err := shares.Import(shares.ImportArgs{
+			Db:      db,
+			Ctx:     cmd.Context(),
+			Queries: queries,
+			Client:  apiClient,
+			Slug:    slug,
+			Tag:     tag,
+		})
+		if err != nil {
+			return err
+		}
+
+		if imported.Collection != nil {
+			fmt.Printf("Imported collection %d with %d commands\n", imported.Collection.ID, len(imported.Commands))
+			return nil
+		}
+
+		fmt.Printf("Imported command %d\n", imported.Commands[0].ID)
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().String("share", "", "share link or slug to import")
+	importCmd.Flags().StringP("tag", "t", "", "tag to file the imported commands under (default: the first shared tag, or \""+shares.DefaultTag+"\")")
+	_ = importCmd.MarkFlagRequired("share")
+
+	rootCmd.AddCommand(importCmd)
+}
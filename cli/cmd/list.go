@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/project"
+	"github.com/endalk200/termflow-cli/usage"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved commands, most frecently used first",
+	Long: `List saved commands, most frecently used first.
+
+Commands from the closest .termflow.yaml are listed first with the project
+scope.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sortBy, _ := cmd.Flags().GetString("sort")
+		tag, _ := cmd.Flags().GetString("tag")
+
+		if sortBy != "frecency" && sortBy != "id" {
+			return fmt.Errorf("invalid sort %q, expected frecency or id", sortBy)
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		ctx := cmd.Context()
+		rows, err := usage.ListByFrecency(usage.ListByFrecencyArgs{Ctx: ctx, Queries: queries})
+		if err != nil {
+			return err
+		}
+
+		tagNames, err := commands.ListTagNamesByCommand(commands.ListTagNamesByCommandArgs{Ctx: ctx, Queries: queries})
+		if err != nil {
+			return err
+		}
+
+		projectFile, _, err := project.LoadCurrent()
+		if err != nil {
+			return err
+		}
+
+		if sortBy == "id" {
+			sort.SliceStable(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSCOPE\tCOMMAND\tDESCRIPTION\tTAGS\tUSES")
+		for _, command := range projectFile.Commands {
+			if tag != "" && !contains(command.Tags, tag) {
+				continue
+			}
+
+			fmt.Fprintf(w, "-\tproject\t%s\t%s\t%s\t-\n",
+				command.Command,
+				command.Description,
+				strings.Join(command.Tags, ","),
+			)
+		}
+
+		for _, row := range rows {
+			if tag != "" && !contains(tagNames[row.ID], tag) {
+				continue
+			}
+
+			fmt.Fprintf(w, "%d\tlocal\t%s\t%s\t%s\t%d\n",
+				row.ID,
+				row.Command.String,
+				row.Description.String,
+				strings.Join(tagNames[row.ID], ","),
+				row.UseCount,
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	listCmd.Flags().String("sort", "frecency", "sort order: frecency or id")
+	listCmd.Flags().StringP("tag", "t", "", "only list commands with this tag")
+
+	rootCmd.AddCommand(listCmd)
+}
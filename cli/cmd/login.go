@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Sign in to the API and pull your preferences",
+	Long: `Sign in to the API and save the access token to the config file.
+
+The preferences of your account are pulled along: default tags are saved to
+add.default_tags, the picker theme to pick.theme and dangerous-command
+patterns to run.dangerous_patterns. Dangerous-command patterns are added to
+the built-in ones rather than replacing them. Preferences that are not set on
+your account leave the config file alone.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiURL, _ := cmd.Flags().GetString("url")
+		email, _ := cmd.Flags().GetString("email")
+
+		if apiURL == "" {
+			apiURL = viper.GetString("api.url")
+		}
+		if apiURL == "" {
+			return errors.New("api url is not configured, pass --url or set api.url")
+		}
+
+		var password string
+		fields := []huh.Field{}
+		if email == "" {
+			fields = append(fields, huh.NewInput().Title("Email").Value(&email))
+		}
+		fields = append(fields, huh.NewInput().Title("Password").EchoMode(huh.EchoModePassword).Value(&password))
+
+		if err := huh.NewForm(huh.NewGroup(fields...)).WithOutput(os.Stderr).Run(); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		token, err := client.New(apiURL, "").SignIn(ctx, strings.TrimSpace(email), password)
+		if err != nil {
+			return err
+		}
+
+		preferences, err := client.New(apiURL, token).GetPreferences(ctx)
+		if err != nil {
+			return fmt.Errorf("signed in but could not pull preferences: %v", err)
+		}
+
+		values := map[string]interface{}{
+			"api.url":   apiURL,
+			"api.token": token,
+		}
+		if len(preferences.DefaultTags) > 0 {
+			values["add.default_tags"] = preferences.DefaultTags
+		}
+		if preferences.PickerTheme != "" {
+			values["pick.theme"] = preferences.PickerTheme
+		}
+		if len(preferences.DangerousPatterns) > 0 {
+			values["run.dangerous_patterns"] = withDefaultPatterns(preferences.DangerousPatterns)
+		}
+
+		path, err := updateConfigFile(values)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Signed in, saved to %s\n", path)
+		return nil
+	},
+}
+
+// withDefaultPatterns returns runner.DefaultDangerousPatterns followed by
+// the patterns of the account that are not among them.
+func withDefaultPatterns(patterns []string) []string {
+	merged := slices.Clone(runner.DefaultDangerousPatterns)
+	for _, pattern := range patterns {
+		if !slices.Contains(merged, pattern) {
+			merged = append(merged, pattern)
+		}
+	}
+
+	return merged
+}
+
+func init() {
+	loginCmd.Flags().String("url", "", "API url, defaults to api.url")
+	loginCmd.Flags().StringP("email", "e", "", "email of your account, asked when not given")
+
+	rootCmd.AddCommand(loginCmd)
+}
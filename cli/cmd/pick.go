@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/huh"
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/project"
+	"github.com/endalk200/termflow-cli/usage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Interactively pick a command and copy it to the clipboard",
+	Long: `Interactively pick a saved command, most frecently used first.
+
+The picked command is copied to the clipboard. With --print it is written to
+stdout instead so a shell widget can insert it into the prompt. Commands from
+the closest .termflow.yaml are offered first, marked with (project).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		print, _ := cmd.Flags().GetBool("print")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		ctx := cmd.Context()
+		rows, err := usage.ListByFrecency(usage.ListByFrecencyArgs{Ctx: ctx, Queries: queries})
+		if err != nil {
+			return err
+		}
+
+		projectFile, _, err := project.LoadCurrent()
+		if err != nil {
+			return err
+		}
+
+		if len(rows) == 0 && len(projectFile.Commands) == 0 {
+			return errors.New("no saved commands, add one with termflow add")
+		}
+
+		tagNames, err := commands.ListTagNamesByCommand(commands.ListTagNamesByCommandArgs{Ctx: ctx, Queries: queries})
+		if err != nil {
+			return err
+		}
+
+		// Project commands have no id and their use is not recorded.
+		type pickable struct {
+			id      int64
+			command string
+		}
+
+		pickables := make([]pickable, 0, len(projectFile.Commands)+len(rows))
+		options := make([]huh.Option[int], 0, len(projectFile.Commands)+len(rows))
+		for _, command := range projectFile.Commands {
+			label := "(project) " + pickLabel(command.Command, command.Description, command.Tags)
+
+			options = append(options, huh.NewOption(label, len(pickables)))
+			pickables = append(pickables, pickable{command: command.Command})
+		}
+
+		for _, row := range rows {
+			label := pickLabel(row.Command.String, row.Description.String, tagNames[row.ID])
+
+			options = append(options, huh.NewOption(label, len(pickables)))
+			pickables = append(pickables, pickable{id: row.ID, command: row.Command.String})
+		}
+
+		theme, err := pickerTheme(viper.GetString("pick.theme"))
+		if err != nil {
+			return err
+		}
+
+		var picked int
+		err = huh.NewForm(huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Pick a command").
+				Options(options...).
+				Filtering(true).
+				Value(&picked),
+		)).WithTheme(theme).WithOutput(os.Stderr).Run()
+		if err != nil {
+			return err
+		}
+
+		command := pickables[picked]
+		action := usage.ActionCopy
+		if print {
+			action = usage.ActionInsert
+			fmt.Println(command.command)
+		} else if err := clipboard.WriteAll(command.command); err != nil {
+			return fmt.Errorf("error copying to clipboard: %v", err)
+		}
+
+		if command.id == 0 {
+			return nil
+		}
+
+		_, err = usage.Record(usage.RecordArgs{
+			Ctx:       ctx,
+			Queries:   queries,
+			CommandID: command.id,
+			Action:    action,
+		})
+
+		return err
+	},
+}
+
+func pickLabel(command, description string, tags []string) string {
+	label := command
+	if description != "" {
+		label += "  # " + description
+	}
+	if len(tags) > 0 {
+		label += "  [" + strings.Join(tags, ",") + "]"
+	}
+
+	return label
+}
+
+// pickerTheme returns the picker theme called name, as set in pick.theme.
+func pickerTheme(name string) (*huh.Theme, error) {
+	switch name {
+	case "", "charm":
+		return huh.ThemeCharm(), nil
+	case "base":
+		return huh.ThemeBase(), nil
+	case "base16":
+		return huh.ThemeBase16(), nil
+	case "catppuccin":
+		return huh.ThemeCatppuccin(), nil
+	case "dracula":
+		return huh.ThemeDracula(), nil
+	default:
+		return nil, fmt.Errorf("unknown pick.theme %q, expected base, base16, catppuccin, charm or dracula", name)
+	}
+}
+
+func init() {
+	pickCmd.Flags().Bool("print", false, "print the picked command instead of copying it")
+
+	rootCmd.AddCommand(pickCmd)
+}
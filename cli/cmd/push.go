@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	resolveKeepLocal  = "local"
+	resolveTakeRemote = "remote"
+	resolveCancel     = "cancel"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push <id|name>",
+	Short: "Push a saved command to the API",
+	Long: `Push a saved command to the API, creating it there the first time.
+
+Later pushes only update the remote command when it was not changed since
+the last push. When it was, you choose to keep the local command, take the
+remote one or cancel.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tag, _ := cmd.Flags().GetString("tag")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		command, err := getCommand(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		pushArgs := commands.PushArgs{
+			Ctx:     cmd.Context(),
+			Queries: queries,
+			Client:  newClient(),
+			Command: command,
+			Tag:     tag,
+		}
+
+		pushed, err := commands.Push(pushArgs)
+
+		var conflict *client.ConflictError
+		if !errors.As(err, &conflict) {
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Pushed command %d as %s (version %d)\n", command.ID, pushed.ID, pushed.Version)
+			return nil
+		}
+
+		fmt.Printf("Command %d was changed remotely since it was last pushed\n\n", command.ID)
+		fmt.Printf("  local:  %s\n", command.Command.String)
+		fmt.Printf("          %s\n", command.Description.String)
+		fmt.Printf("  remote: %s\n", conflict.Current.Command)
+		fmt.Printf("          %s\n\n", conflict.Current.Description)
+
+		var choice string
+		err = huh.NewForm(huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Resolve the conflict").
+				Options(
+					huh.NewOption("Keep local, overwrite the remote command", resolveKeepLocal),
+					huh.NewOption("Take remote, overwrite the local command", resolveTakeRemote),
+					huh.NewOption("Cancel", resolveCancel),
+				).
+				Value(&choice),
+		)).WithOutput(os.Stderr).Run()
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case resolveKeepLocal:
+			pushArgs.Version = conflict.Current.Version
+			pushed, err := commands.Push(pushArgs)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Pushed command %d as %s (version %d)\n", command.ID, pushed.ID, pushed.Version)
+		case resolveTakeRemote:
+			err := commands.TakeRemote(commands.TakeRemoteArgs{
+				Db:      db,
+				Ctx:     cmd.Context(),
+				Queries: queries,
+				Command: command,
+				Remote:  conflict.Current,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated command %d from the remote version %d\n", command.ID, conflict.Current.Version)
+		default:
+			fmt.Println("Push cancelled")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	pushCmd.Flags().StringP("tag", "t", "", "tag to file the command under when it is first pushed (default: its first tag)")
+
+	rootCmd.AddCommand(pushCmd)
+}
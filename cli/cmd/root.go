@@ -1,15 +1,28 @@
 package cmd
 
 import (
+	"database/sql"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/runner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+var cfgFile string
+
 var rootCmd = &cobra.Command{
 	Use:   "termflow",
-	Short: "termflow-cli short description",
-	Long:  `Long description of termflow-cli`,
+	Short: "Bookmark, organize and reuse terminal commands",
+	Long: `Termflow saves the terminal commands you use the most, organizes them
+with tags and gets them back to you when you need them.`,
+	SilenceUsage: true,
 }
 
 func Execute() {
@@ -19,4 +32,89 @@ func Execute() {
 	}
 }
 
-func init() {}
+func init() {
+	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/termflow/config.yaml)")
+}
+
+// initConfig reads the config file and TERMFLOW_* environment variables.
+func initConfig() {
+	configDir, err := os.UserConfigDir()
+	cobra.CheckErr(err)
+
+	viper.SetDefault("database", filepath.Join(configDir, "termflow", "termflow.db"))
+	viper.SetDefault("api.url", "")
+	viper.SetDefault("api.token", "")
+	viper.SetDefault("run.dangerous_patterns", runner.DefaultDangerousPatterns)
+	viper.SetDefault("trash.retention_days", 30)
+	viper.SetDefault("add.default_tags", []string{})
+	viper.SetDefault("pick.theme", "charm")
+
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		viper.AddConfigPath(filepath.Join(configDir, "termflow"))
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+	}
+
+	viper.SetEnvPrefix("termflow")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	// The config file is optional, defaults are enough to work locally.
+	_ = viper.ReadInConfig()
+}
+
+func openDatabase() (*sql.DB, *database.Queries, error) {
+	db, err := database.Open(viper.GetString("database"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, database.New(db), nil
+}
+
+func newClient() *client.Client {
+	return client.New(viper.GetString("api.url"), viper.GetString("api.token"))
+}
+
+// updateConfigFile sets values in the config file, creating it when there is
+// none yet. Unlike viper.WriteConfig it leaves out defaults and environment
+// variables. It returns the path of the file.
+func updateConfigFile(values map[string]interface{}) (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+
+		path = filepath.Join(configDir, "termflow", "config.yaml")
+	}
+
+	file := viper.New()
+	file.SetConfigFile(path)
+	// The file may hold the API token
+	file.SetConfigPermissions(0o600)
+
+	if _, err := os.Stat(path); err == nil {
+		if err := file.ReadInConfig(); err != nil {
+			return "", err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	for key, value := range values {
+		file.Set(key, value)
+		viper.Set(key, value)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	return path, file.WriteConfigAs(path)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/endalk200/termflow-cli/runner"
+	"github.com/endalk200/termflow-cli/usage"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <id|name>",
+	Short: "Run a saved command through your shell",
+	Long: `Run a saved command through your shell and exit with its exit code.
+
+Commands tagged "dangerous" or matching one of the run.dangerous_patterns
+regular expressions from the config file ask for confirmation first. Pass
+--yes to skip the confirmation in scripts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		ctx := cmd.Context()
+		command, err := getCommand(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		tags, err := queries.GetTagsForCommand(ctx, sql.NullInt64{Int64: command.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		tagNames := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tagNames = append(tagNames, tag.Name)
+		}
+
+		dangerous, reason, err := runner.IsDangerous(command.Command.String, tagNames, viper.GetStringSlice("run.dangerous_patterns"))
+		if err != nil {
+			return err
+		}
+
+		if dangerous && !yes {
+			if !isatty.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("refusing to run dangerous command (%s) without confirmation, pass --yes", reason)
+			}
+
+			confirmed := false
+			err := huh.NewConfirm().
+				Title("This command is dangerous (" + reason + "). Run it?").
+				Description(command.Command.String).
+				Affirmative("Run").
+				Negative("Cancel").
+				Value(&confirmed).
+				Run()
+			if err != nil {
+				return err
+			}
+
+			if !confirmed {
+				return errors.New("aborted")
+			}
+		}
+
+		exitCode, err := runner.Execute(ctx, command.Command.String)
+		if err != nil {
+			return err
+		}
+
+		_, err = usage.Record(usage.RecordArgs{
+			Ctx:       ctx,
+			Queries:   queries,
+			CommandID: command.ID,
+			Action:    usage.ActionExecute,
+			ExitCode:  &exitCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		if exitCode != 0 {
+			db.Close()
+			os.Exit(exitCode)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().BoolP("yes", "y", false, "run dangerous commands without asking for confirmation")
+
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/huh"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/runbooks"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var runbookCmd = &cobra.Command{
+	Use:     "runbook",
+	Aliases: []string{"runbooks"},
+	Short:   "Manage and run multi-step workflows",
+	Long: `Runbooks are ordered steps running saved or inline commands.
+
+Steps may use variables with {{name}} placeholders. Variables are declared
+with "runbook var", passed with --var on every run, or captured from the
+output of an earlier step with --capture.`,
+}
+
+var runbookCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a runbook",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description, _ := cmd.Flags().GetString("description")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := runbooks.CreateRunbook(runbooks.CreateRunbookArgs{
+			Ctx:         cmd.Context(),
+			Queries:     queries,
+			Name:        args[0],
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created runbook %d\n", runbook.ID)
+		return nil
+	},
+}
+
+var runbookAddStepCmd = &cobra.Command{
+	Use:   "add-step <runbook> [id|name]",
+	Short: "Append a step running a saved command or an inline --command",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		inline, _ := cmd.Flags().GetString("command")
+		confirm, _ := cmd.Flags().GetBool("confirm")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		captureAs, _ := cmd.Flags().GetString("capture")
+
+		if (len(args) == 2) == (inline != "") {
+			return errors.New("pass either a saved command or --command")
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := getRunbook(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		var commandID int64
+		if len(args) == 2 {
+			command, err := getCommand(cmd, queries, args[1])
+			if err != nil {
+				return err
+			}
+
+			commandID = command.ID
+		}
+
+		step, err := runbooks.AddStep(runbooks.AddStepArgs{
+			Ctx:             cmd.Context(),
+			Queries:         queries,
+			RunbookID:       runbook.ID,
+			Name:            name,
+			CommandID:       commandID,
+			Command:         inline,
+			Confirm:         confirm,
+			ContinueOnError: continueOnError,
+			CaptureAs:       captureAs,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Added step %d\n", step.Position+1)
+		return nil
+	},
+}
+
+var runbookVarCmd = &cobra.Command{
+	Use:   "var <runbook> <name> [default]",
+	Short: "Declare a runbook variable, optionally with a default value",
+	Args:  cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := getRunbook(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		var defaultValue *string
+		if len(args) == 3 {
+			defaultValue = &args[2]
+		}
+
+		return runbooks.SetVariable(runbooks.SetVariableArgs{
+			Ctx:       cmd.Context(),
+			Queries:   queries,
+			RunbookID: runbook.ID,
+			Name:      args[1],
+			Default:   defaultValue,
+		})
+	},
+}
+
+var runbookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List runbooks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		all, err := queries.ListRunbooks(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION")
+		for _, runbook := range all {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", runbook.ID, runbook.Name, runbook.Description.String)
+		}
+
+		return w.Flush()
+	},
+}
+
+var runbookShowCmd = &cobra.Command{
+	Use:   "show <runbook>",
+	Short: "Show the variables and steps of a runbook",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := getRunbook(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		variables, err := queries.ListRunbookVariables(cmd.Context(), runbook.ID)
+		if err != nil {
+			return err
+		}
+
+		steps, err := runbooks.ListSteps(runbooks.ListStepsArgs{
+			Ctx:       cmd.Context(),
+			Queries:   queries,
+			RunbookID: runbook.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		if len(variables) > 0 {
+			fmt.Fprintln(w, "VARIABLE\tDEFAULT")
+			for _, variable := range variables {
+				defaultValue := "(required)"
+				if variable.Defaultvalue.Valid {
+					defaultValue = variable.Defaultvalue.String
+				}
+
+				fmt.Fprintf(w, "%s\t%s\n", variable.Name, defaultValue)
+			}
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintln(w, "#\tNAME\tCOMMAND\tOPTIONS")
+		for _, step := range steps {
+			var options []string
+			if step.Saved != nil {
+				options = append(options, fmt.Sprintf("saved:%d", step.Saved.ID))
+			}
+			if step.Confirm {
+				options = append(options, "confirm")
+			}
+			if step.Continueonerror {
+				options = append(options, "continue-on-error")
+			}
+			if step.Captureas.Valid {
+				options = append(options, "capture:"+step.Captureas.String)
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+				step.Position+1,
+				step.Name.String,
+				step.Text(),
+				strings.Join(options, ","),
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+var runbookRunCmd = &cobra.Command{
+	Use:   "run <runbook>",
+	Short: "Run the steps of a runbook in order",
+	Long: `Run the steps of a runbook in order and stop at the first failing step,
+unless it allows the runbook to continue.
+
+Run again with --resume to continue a failed run from the step that failed,
+with the variables of that run. Steps marked for confirmation and dangerous
+commands ask first, pass --yes to skip the confirmations in scripts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resume, _ := cmd.Flags().GetBool("resume")
+		yes, _ := cmd.Flags().GetBool("yes")
+		pairs, _ := cmd.Flags().GetStringArray("var")
+
+		variables := map[string]string{}
+		for _, pair := range pairs {
+			name, value, found := strings.Cut(pair, "=")
+			if !found || name == "" {
+				return fmt.Errorf("invalid variable %q, expected name=value", pair)
+			}
+
+			variables[name] = value
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := getRunbook(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		err = runbooks.Run(runbooks.RunArgs{
+			Ctx:               cmd.Context(),
+			Queries:           queries,
+			Runbook:           runbook,
+			Variables:         variables,
+			Resume:            resume,
+			DangerousPatterns: viper.GetStringSlice("run.dangerous_patterns"),
+			Confirm: func(step runbooks.Step, command string, reason string) (bool, error) {
+				if yes {
+					return true, nil
+				}
+
+				if !isatty.IsTerminal(os.Stdin.Fd()) {
+					return false, fmt.Errorf("refusing to run step %d without confirmation, pass --yes", step.Position+1)
+				}
+
+				title := fmt.Sprintf("Run step %d (%s)?", step.Position+1, step.Title())
+				if reason != "" {
+					title = fmt.Sprintf("Step %d is dangerous (%s). Run it?", step.Position+1, reason)
+				}
+
+				confirmed := false
+				err := huh.NewConfirm().
+					Title(title).
+					Description(command).
+					Affirmative("Run").
+					Negative("Cancel").
+					Value(&confirmed).
+					Run()
+
+				return confirmed, err
+			},
+			Out: os.Stderr,
+		})
+
+		var stepErr *runbooks.StepError
+		if errors.As(err, &stepErr) {
+			fmt.Fprintf(os.Stderr, "%v, run again with --resume to continue from it\n", stepErr)
+			db.Close()
+			os.Exit(stepErr.ExitCode)
+		}
+
+		return err
+	},
+}
+
+var runbookPushCmd = &cobra.Command{
+	Use:   "push [runbook]",
+	Short: "Push runbooks to the API",
+	Long:  "Push a runbook, or every runbook, to the API. Runbooks pushed before are replaced.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		var pushed []database.Runbook
+		if len(args) == 1 {
+			runbook, err := getRunbook(cmd, queries, args[0])
+			if err != nil {
+				return err
+			}
+
+			pushed = append(pushed, runbook)
+		} else {
+			pushed, err = queries.ListRunbooks(cmd.Context())
+			if err != nil {
+				return err
+			}
+		}
+
+		client := newClient()
+		for _, runbook := range pushed {
+			_, err := runbooks.Push(runbooks.PushArgs{
+				Ctx:     cmd.Context(),
+				Queries: queries,
+				Client:  client,
+				Runbook: runbook,
+			})
+			if err != nil {
+				return fmt.Errorf("pushing runbook %q: %w", runbook.Name, err)
+			}
+		}
+
+		fmt.Printf("Pushed %d runbooks\n", len(pushed))
+		return nil
+	},
+}
+
+var runbookDeleteCmd = &cobra.Command{
+	Use:   "delete <runbook>",
+	Short: "Delete a runbook, keeping the commands it references",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		runbook, err := getRunbook(cmd, queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		return queries.DeleteRunbook(cmd.Context(), runbook.ID)
+	},
+}
+
+func getRunbook(cmd *cobra.Command, queries *database.Queries, idOrName string) (database.Runbook, error) {
+	runbook, err := runbooks.GetRunbook(runbooks.GetRunbookArgs{
+		Ctx:      cmd.Context(),
+		Queries:  queries,
+		IdOrName: idOrName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Runbook{}, fmt.Errorf("no runbook with id or name %q", idOrName)
+	}
+
+	return runbook, err
+}
+
+func init() {
+	runbookCreateCmd.Flags().StringP("description", "d", "", "what the runbook is for")
+
+	runbookAddStepCmd.Flags().StringP("name", "n", "", "name of the step")
+	runbookAddStepCmd.Flags().StringP("command", "c", "", "inline command to run instead of a saved command")
+	runbookAddStepCmd.Flags().Bool("confirm", false, "ask for confirmation before running the step")
+	runbookAddStepCmd.Flags().Bool("continue-on-error", false, "keep running the runbook when the step fails")
+	runbookAddStepCmd.Flags().String("capture", "", "store the output of the step in this variable")
+
+	runbookRunCmd.Flags().StringArray("var", nil, "set a variable, as name=value")
+	runbookRunCmd.Flags().Bool("resume", false, "continue the last failed run from the step that failed")
+	runbookRunCmd.Flags().BoolP("yes", "y", false, "run steps without asking for confirmation")
+
+	runbookCmd.AddCommand(
+		runbookCreateCmd,
+		runbookAddStepCmd,
+		runbookVarCmd,
+		runbookListCmd,
+		runbookShowCmd,
+		runbookRunCmd,
+		runbookPushCmd,
+		runbookDeleteCmd,
+	)
+	rootCmd.AddCommand(runbookCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/endalk200/termflow-cli/usage"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the most used commands and tags",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		period, _ := cmd.Flags().GetString("since")
+		limit, _ := cmd.Flags().GetInt64("limit")
+
+		duration, err := parsePeriod(period)
+		if err != nil {
+			return err
+		}
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		stats, err := usage.GetStats(usage.GetStatsArgs{
+			Ctx:     cmd.Context(),
+			Queries: queries,
+			Since:   time.Now().Add(-duration),
+			Limit:   limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Most used commands in the last %s\n", period)
+		fmt.Fprintln(w, "ID\tCOMMAND\tUSES")
+		for _, command := range stats.Commands {
+			fmt.Fprintf(w, "%d\t%s\t%d\n", command.ID, command.Command.String, command.UseCount)
+		}
+
+		fmt.Fprintf(w, "\nMost used tags in the last %s\n", period)
+		fmt.Fprintln(w, "TAG\tUSES")
+		for _, tag := range stats.Tags {
+			fmt.Fprintf(w, "%s\t%d\n", tag.Name, tag.UseCount)
+		}
+
+		return w.Flush()
+	},
+}
+
+var statsPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push usage counts to the API",
+	Long: `Push usage counts to the API.
+
+Used commands that were never pushed are created on the API first, filed
+under their first tag or under --tag when they have none.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tag, _ := cmd.Flags().GetString("tag")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		apiClient := newClient()
+
+		created, err := usage.PushCommands(usage.PushCommandsArgs{
+			Ctx:     cmd.Context(),
+			Queries: queries,
+			Client:  apiClient,
+			Tag:     tag,
+		})
+		if created > 0 {
+			fmt.Printf("Pushed %d commands\n", created)
+		}
+		if err != nil {
+			return err
+		}
+
+		pushed, err := usage.Sync(usage.SyncArgs{
+			Db:      db,
+			Ctx:     cmd.Context(),
+			Queries: queries,
+			Client:  apiClient,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pushed %d usage records\n", pushed)
+		return nil
+	},
+}
+
+// parsePeriod parses periods such as 7d or 2w in addition to the units
+// understood by time.ParseDuration.
+func parsePeriod(period string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+	for suffix, unit := range units {
+		if value, found := strings.CutSuffix(period, suffix); found {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid period %q", period)
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid period %q", period)
+	}
+
+	return duration, nil
+}
+
+func init() {
+	statsCmd.Flags().String("since", "30d", "period to report on, e.g. 7d, 2w or 12h")
+	statsCmd.Flags().Int64("limit", 10, "number of commands and tags to show")
+
+	statsPushCmd.Flags().StringP("tag", "t", "", "tag to file used commands without tags under when they are first pushed")
+
+	statsCmd.AddCommand(statsPushCmd)
+	rootCmd.AddCommand(statsCmd)
+}
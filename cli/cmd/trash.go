@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List deleted commands and tags",
+	Long: `List deleted commands and tags, most recently deleted first.
+
+Anything that has been in the trash for longer than trash.retention_days
+(30 by default) is purged the next time the trash is used.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := purgeExpiredTrash(cmd, db, queries); err != nil {
+			return err
+		}
+
+		trash, err := commands.ListTrash(commands.ListTrashArgs{Ctx: cmd.Context(), Queries: queries})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tNAME\tDELETED")
+		for _, command := range trash.Commands {
+			fmt.Fprintf(w, "%d\tcommand\t%s\t%s\n",
+				command.ID,
+				command.Command.String,
+				command.Deletedat.Time.Local().Format("2006-01-02 15:04"),
+			)
+		}
+		for _, tag := range trash.Tags {
+			fmt.Fprintf(w, "%d\ttag\t%s\t%s\n",
+				tag.ID,
+				tag.Name,
+				tag.Deletedat.Time.Local().Format("2006-01-02 15:04"),
+			)
+		}
+
+		return w.Flush()
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <id>...",
+	Short: "Take commands, or tags with --tag, out of the trash",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		isTag, _ := cmd.Flags().GetBool("tag")
+
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid id %q", arg)
+			}
+
+			if isTag {
+				err = commands.RestoreTag(commands.RestoreTagArgs{Ctx: cmd.Context(), Queries: queries, ID: id})
+			} else {
+				err = commands.RestoreCommand(commands.RestoreCommandArgs{Ctx: cmd.Context(), Queries: queries, ID: id})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete everything in the trash",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		purgedCommands, purgedTags, err := commands.PurgeTrash(commands.PurgeTrashArgs{
+			Db:      db,
+			Ctx:     cmd.Context(),
+			Queries: queries,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted %d commands and %d tags permanently\n", purgedCommands, purgedTags)
+		return nil
+	},
+}
+
+// purgeExpiredTrash removes what has been in the trash for longer than the
+// configured retention, a retention of zero or less keeps everything.
+func purgeExpiredTrash(cmd *cobra.Command, db *sql.DB, queries *database.Queries) error {
+	retentionDays := viper.GetInt("trash.retention_days")
+	if retentionDays <= 0 {
+		return nil
+	}
+
+	_, _, err := commands.PurgeTrash(commands.PurgeTrashArgs{
+		Db:            db,
+		Ctx:           cmd.Context(),
+		Queries:       queries,
+		RetentionDays: retentionDays,
+	})
+
+	return err
+}
+
+func init() {
+	trashRestoreCmd.Flags().BoolP("tag", "t", false, "restore tags instead of commands")
+
+	trashCmd.AddCommand(trashRestoreCmd, trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Reverse the most recent delete or edit",
+	Long: `Reverse the most recent destructive operation: a deleted command or tag
+is taken out of the trash and an edited command goes back to its previous
+revision. Run it again to keep going back.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, queries, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		operation, err := commands.Undo(commands.UndoArgs{
+			Db:      db,
+			Ctx:     cmd.Context(),
+			Queries: queries,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch operation.Kind {
+		case commands.OperationDeleteCommand:
+			fmt.Printf("Restored command %d from the trash\n", operation.Targetid)
+		case commands.OperationDeleteTag:
+			fmt.Printf("Restored tag %d from the trash\n", operation.Targetid)
+		case commands.OperationEditCommand:
+			fmt.Printf("Restored command %d to revision %d\n", operation.Targetid, operation.Revision.Int64)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
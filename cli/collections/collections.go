@@ -0,0 +1,186 @@
+package collections
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+type CreateCollectionArgs struct {
+	Ctx         context.Context
+	Queries     *database.Queries
+	Name        string
+	Description string
+}
+
+func CreateCollection(args CreateCollectionArgs) (database.Collection, error) {
+	collection, err := args.Queries.CreateCollection(args.Ctx, database.CreateCollectionParams{
+		Name:        args.Name,
+		Description: sql.NullString{String: args.Description, Valid: args.Description != ""},
+	})
+	if err != nil {
+		return database.Collection{}, err
+	}
+
+	return collection, nil
+}
+
+type GetCollectionArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	// IdOrName is either the numeric id or the unique name of the collection.
+	IdOrName string
+}
+
+func GetCollection(args GetCollectionArgs) (database.Collection, error) {
+	if id, err := strconv.ParseInt(args.IdOrName, 10, 64); err == nil {
+		return args.Queries.GetCollection(args.Ctx, id)
+	}
+
+	return args.Queries.GetCollectionByName(args.Ctx, args.IdOrName)
+}
+
+type AddCommandArgs struct {
+	Ctx          context.Context
+	Queries      *database.Queries
+	CollectionID int64
+	CommandID    int64
+}
+
+// AddCommand appends a command at the end of a collection.
+func AddCommand(args AddCommandArgs) error {
+	return args.Queries.AddCommandToCollection(args.Ctx, database.AddCommandToCollectionParams{
+		Collectionid: args.CollectionID,
+		Commandid:    args.CommandID,
+	})
+}
+
+type RemoveCommandArgs struct {
+	Db           *sql.DB
+	Ctx          context.Context
+	Queries      *database.Queries
+	CollectionID int64
+	CommandID    int64
+}
+
+// RemoveCommand removes a command from a collection and closes the gap it
+// leaves in the order.
+func RemoveCommand(args RemoveCommandArgs) error {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	err = qtx.RemoveCommandFromCollection(args.Ctx, database.RemoveCommandFromCollectionParams{
+		Collectionid: args.CollectionID,
+		Commandid:    args.CommandID,
+	})
+	if err != nil {
+		return err
+	}
+
+	commands, err := qtx.ListCollectionCommands(args.Ctx, args.CollectionID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(commands))
+	for _, command := range commands {
+		ids = append(ids, command.ID)
+	}
+
+	if err := setPositions(args.Ctx, qtx, args.CollectionID, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type MoveCommandArgs struct {
+	Db           *sql.DB
+	Ctx          context.Context
+	Queries      *database.Queries
+	CollectionID int64
+	CommandID    int64
+	// Position is the zero based position the command is moved to.
+	Position int
+}
+
+// MoveCommand moves a command to a new position within a collection,
+// shifting the commands in between.
+func MoveCommand(args MoveCommandArgs) error {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	commands, err := qtx.ListCollectionCommands(args.Ctx, args.CollectionID)
+	if err != nil {
+		return err
+	}
+
+	if args.Position < 0 || args.Position >= len(commands) {
+		return fmt.Errorf("position must be between 1 and %d", len(commands))
+	}
+
+	ids := make([]int64, 0, len(commands))
+	found := false
+	for _, command := range commands {
+		if command.ID == args.CommandID {
+			found = true
+			continue
+		}
+		ids = append(ids, command.ID)
+	}
+
+	if !found {
+		return fmt.Errorf("command %d is not in the collection", args.CommandID)
+	}
+
+	ids = append(ids[:args.Position], append([]int64{args.CommandID}, ids[args.Position:]...)...)
+
+	if err := setPositions(args.Ctx, qtx, args.CollectionID, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func setPositions(ctx context.Context, queries *database.Queries, collectionID int64, commandIDs []int64) error {
+	for position, commandID := range commandIDs {
+		err := queries.UpdateCollectionCommandPosition(ctx, database.UpdateCollectionCommandPositionParams{
+			Position:     int64(position),
+			Collectionid: collectionID,
+			Commandid:    commandID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ListCommandsArgs struct {
+	Ctx          context.Context
+	Queries      *database.Queries
+	CollectionID int64
+}
+
+func ListCommands(args ListCommandsArgs) ([]database.ListCollectionCommandsRow, error) {
+	commands, err := args.Queries.ListCollectionCommands(args.Ctx, args.CollectionID)
+	if err != nil {
+		return []database.ListCollectionCommandsRow{}, err
+	}
+
+	return commands, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
+	"strconv"
 
 	"github.com/endalk200/termflow-cli/internal/database"
 	"github.com/endalk200/termflow-cli/tags"
@@ -14,12 +16,14 @@ type AddCommandArgs struct {
 	queries     *database.Queries
 	command     string
 	description string
+	name        string
 }
 
 func AddCommands(arg AddCommandArgs) (database.Command, error) {
 	newCommand, err := arg.queries.AddCommand(arg.ctx, database.AddCommandParams{
 		Command:     sql.NullString{String: arg.command, Valid: true},
 		Description: sql.NullString{String: arg.description, Valid: true},
+		Name:        sql.NullString{String: arg.name, Valid: arg.name != ""},
 	})
 	if err != nil {
 		return database.Command{}, err
@@ -34,7 +38,10 @@ type AddCommandWithTagsArgs struct {
 	Queries     *database.Queries
 	Command     string
 	Description string
+	Name        string
 	Tag         string
+	// ExtraTags are more tags to file the command under along with Tag.
+	ExtraTags []string
 }
 
 func AddCommandWithTags(arg AddCommandWithTagsArgs) (database.Command, error) {
@@ -49,43 +56,52 @@ func AddCommandWithTags(arg AddCommandWithTagsArgs) (database.Command, error) {
 	defer tx.Rollback()
 	qtx := arg.Queries.WithTx(tx)
 
-	var tagId int64
-
-	tag, err := tags.GetTag(tags.GetTagArgs{
-		Ctx:     arg.Ctx,
-		Queries: qtx,
-		Name:    arg.Tag,
-	})
-	if err != nil {
+	tagIds := make([]int64, 0, 1+len(arg.ExtraTags))
+	for _, name := range append([]string{arg.Tag}, arg.ExtraTags...) {
+		tag, err := tags.GetTag(tags.GetTagArgs{
+			Ctx:     arg.Ctx,
+			Queries: qtx,
+			Name:    name,
+		})
 		if errors.Is(err, sql.ErrNoRows) {
-			newTag, _ := tags.CreateTag(tags.CreateTagArgs{
+			tag, err = tags.CreateTag(tags.CreateTagArgs{
 				Ctx:         arg.Ctx,
 				Queries:     qtx,
-				Name:        arg.Tag,
+				Name:        name,
 				Description: "",
 			})
+		}
+		if err != nil {
+			return database.Command{}, err
+		}
 
-			tagId = newTag.ID
+		if !slices.Contains(tagIds, tag.ID) {
+			tagIds = append(tagIds, tag.ID)
 		}
-	} else {
-		tagId = tag.ID
 	}
 
 	newCommand, err := AddCommands(AddCommandArgs{
 		ctx:         arg.Ctx,
 		queries:     qtx,
-		command:     arg.Description,
+		command:     arg.Command,
 		description: arg.Description,
+		name:        arg.Name,
 	})
 	if err != nil {
 		return database.Command{}, err
 	}
 
-	err = qtx.AddCommandTag(arg.Ctx, database.AddCommandTagParams{
-		Commandid: sql.NullInt64{Int64: newCommand.ID, Valid: true},
-		Tagid:     sql.NullInt64{Int64: tagId, Valid: true},
-	})
-	if err != nil {
+	for _, tagId := range tagIds {
+		err = qtx.AddCommandTag(arg.Ctx, database.AddCommandTagParams{
+			Commandid: sql.NullInt64{Int64: newCommand.ID, Valid: true},
+			Tagid:     sql.NullInt64{Int64: tagId, Valid: true},
+		})
+		if err != nil {
+			return database.Command{}, err
+		}
+	}
+
+	if _, err := qtx.AddCommandRevision(arg.Ctx, newCommand.ID); err != nil {
 		return database.Command{}, err
 	}
 
@@ -97,6 +113,21 @@ func AddCommandWithTags(arg AddCommandWithTagsArgs) (database.Command, error) {
 	return newCommand, nil
 }
 
+type GetCommandArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	// IdOrName is either the numeric id or the unique name of the command.
+	IdOrName string
+}
+
+func GetCommand(args GetCommandArgs) (database.Command, error) {
+	if id, err := strconv.ParseInt(args.IdOrName, 10, 64); err == nil {
+		return args.Queries.GetCommand(args.Ctx, id)
+	}
+
+	return args.Queries.GetCommandByName(args.Ctx, sql.NullString{String: args.IdOrName, Valid: true})
+}
+
 type GetCommandsForTagArgs struct {
 	Ctx     context.Context
 	Queries *database.Queries
@@ -111,3 +142,26 @@ func GetCommandsForTag(args GetCommandsForTagArgs) ([]database.ListCommandsWithT
 
 	return commands, nil
 }
+
+type ListTagNamesByCommandArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+}
+
+// ListTagNamesByCommand returns the names of the tags attached to each command
+// keyed by command id.
+func ListTagNamesByCommand(args ListTagNamesByCommandArgs) (map[int64][]string, error) {
+	rows, err := args.Queries.ListCommandsWithTags(args.Ctx)
+	if err != nil {
+		return map[int64][]string{}, err
+	}
+
+	tagNames := make(map[int64][]string)
+	for _, row := range rows {
+		if row.TagName.Valid {
+			tagNames[row.CommandID] = append(tagNames[row.CommandID], row.TagName.String)
+		}
+	}
+
+	return tagNames, nil
+}
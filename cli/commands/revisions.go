@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/tags"
+)
+
+type EditCommandArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	ID      int64
+	// Command and Description are left unchanged when empty.
+	Command     string
+	Description string
+}
+
+// EditCommand updates a command and records the result as a new revision.
+// The edit can be reversed with Undo.
+func EditCommand(args EditCommandArgs) (database.Commandrevision, error) {
+	if args.Command == "" && args.Description == "" {
+		return database.Commandrevision{}, errors.New("Nothing to change, pass a command or a description")
+	}
+
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	command, err := qtx.GetCommand(args.Ctx, args.ID)
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	if args.Command != "" {
+		command.Command = sql.NullString{String: args.Command, Valid: true}
+	}
+	if args.Description != "" {
+		command.Description = sql.NullString{String: args.Description, Valid: true}
+	}
+
+	err = qtx.UpdateCommand(args.Ctx, database.UpdateCommandParams{
+		Command:     command.Command,
+		Description: command.Description,
+		ID:          command.ID,
+	})
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	revision, err := qtx.AddCommandRevision(args.Ctx, command.ID)
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	if err := recordEdit(args.Ctx, qtx, revision); err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	return revision, tx.Commit()
+}
+
+type ListRevisionsArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	CommandID int64
+}
+
+// ListRevisions returns the revisions of a command, newest first.
+func ListRevisions(args ListRevisionsArgs) ([]database.Commandrevision, error) {
+	revisions, err := args.Queries.ListCommandRevisions(args.Ctx, args.CommandID)
+	if err != nil {
+		return []database.Commandrevision{}, err
+	}
+
+	return revisions, nil
+}
+
+type GetRevisionArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	CommandID int64
+	Revision  int64
+}
+
+func GetRevision(args GetRevisionArgs) (database.Commandrevision, error) {
+	return args.Queries.GetCommandRevision(args.Ctx, database.GetCommandRevisionParams{
+		Commandid: args.CommandID,
+		Revision:  args.Revision,
+	})
+}
+
+type RestoreRevisionArgs struct {
+	Db        *sql.DB
+	Ctx       context.Context
+	Queries   *database.Queries
+	CommandID int64
+	Revision  int64
+}
+
+// RestoreRevision puts a command back to how it was at a revision,
+// recreating tags that have since been deleted. The restore is recorded as
+// a new revision and can be reversed with Undo.
+func RestoreRevision(args RestoreRevisionArgs) (database.Commandrevision, error) {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	restored, err := restoreRevision(args.Ctx, qtx, args.CommandID, args.Revision)
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	if err := recordEdit(args.Ctx, qtx, restored); err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	return restored, tx.Commit()
+}
+
+func restoreRevision(ctx context.Context, qtx *database.Queries, commandId, revisionNumber int64) (database.Commandrevision, error) {
+	revision, err := qtx.GetCommandRevision(ctx, database.GetCommandRevisionParams{
+		Commandid: commandId,
+		Revision:  revisionNumber,
+	})
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	err = qtx.UpdateCommand(ctx, database.UpdateCommandParams{
+		Command:     revision.Command,
+		Description: revision.Description,
+		ID:          commandId,
+	})
+	if err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	nullCommandId := sql.NullInt64{Int64: commandId, Valid: true}
+	if err := qtx.RemoveCommandTags(ctx, nullCommandId); err != nil {
+		return database.Commandrevision{}, err
+	}
+
+	for _, name := range RevisionTags(revision) {
+		tag, err := tags.GetTag(tags.GetTagArgs{Ctx: ctx, Queries: qtx, Name: name})
+		if errors.Is(err, sql.ErrNoRows) {
+			tag, err = tags.CreateTag(tags.CreateTagArgs{Ctx: ctx, Queries: qtx, Name: name})
+		}
+		if err != nil {
+			return database.Commandrevision{}, err
+		}
+
+		err = qtx.AddCommandTag(ctx, database.AddCommandTagParams{
+			Commandid: nullCommandId,
+			Tagid:     sql.NullInt64{Int64: tag.ID, Valid: true},
+		})
+		if err != nil {
+			return database.Commandrevision{}, err
+		}
+	}
+
+	return qtx.AddCommandRevision(ctx, commandId)
+}
+
+// RevisionTags splits the tag names stored with a revision.
+func RevisionTags(revision database.Commandrevision) []string {
+	if revision.Tags == "" {
+		return []string{}
+	}
+
+	return strings.Split(revision.Tags, ",")
+}
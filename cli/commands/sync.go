@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+type PushArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Client  *client.Client
+	Command database.Command
+	// Tag files the command when it is created remotely, it defaults to the
+	// first tag of the command.
+	Tag string
+	// Version replaces the remote version the update is conditioned on. It
+	// is set to overwrite a remote change after a conflict.
+	Version int64
+}
+
+// Push creates the command on the API, or updates it when it was pushed
+// before. An update fails with a *client.ConflictError when the command was
+// changed remotely since it was last pushed.
+func Push(args PushArgs) (client.Command, error) {
+	remote := client.Command{
+		ID:          args.Command.Remoteid.String,
+		Command:     args.Command.Command.String,
+		Description: args.Command.Description.String,
+		Version:     args.Command.Remoteversion.Int64,
+	}
+	if args.Version != 0 {
+		remote.Version = args.Version
+	}
+
+	var pushed client.Command
+	var err error
+	if remote.ID != "" {
+		pushed, err = args.Client.UpdateCommand(args.Ctx, remote)
+
+		// The remote command is gone, push it as a new one
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			remote.ID = ""
+		} else if err != nil {
+			return client.Command{}, err
+		}
+	}
+
+	if remote.ID == "" {
+		tagName, err := pushTag(args)
+		if err != nil {
+			return client.Command{}, err
+		}
+
+		tag, err := args.Client.EnsureTag(args.Ctx, tagName)
+		if err != nil {
+			return client.Command{}, err
+		}
+
+		pushed, err = args.Client.CreateCommand(args.Ctx, remote, tag.ID)
+		if err != nil {
+			return client.Command{}, err
+		}
+	}
+
+	return pushed, setRemote(args.Ctx, args.Queries, args.Command.ID, pushed)
+}
+
+type TakeRemoteArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	Command database.Command
+	Remote  client.Command
+}
+
+// TakeRemote resolves a conflict in favour of the remote command. The local
+// command is edited to match it, which is recorded as a revision, and is
+// marked as pushed at the remote version.
+func TakeRemote(args TakeRemoteArgs) error {
+	if args.Remote.Command != args.Command.Command.String || args.Remote.Description != args.Command.Description.String {
+		_, err := EditCommand(EditCommandArgs{
+			Db:          args.Db,
+			Ctx:         args.Ctx,
+			Queries:     args.Queries,
+			ID:          args.Command.ID,
+			Command:     args.Remote.Command,
+			Description: args.Remote.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return setRemote(args.Ctx, args.Queries, args.Command.ID, args.Remote)
+}
+
+func pushTag(args PushArgs) (string, error) {
+	if args.Tag != "" {
+		return args.Tag, nil
+	}
+
+	tags, err := args.Queries.GetTagsForCommand(args.Ctx, sql.NullInt64{Int64: args.Command.ID, Valid: true})
+	if err != nil {
+		return "", err
+	}
+
+	if len(tags) == 0 {
+		return "", fmt.Errorf("command %d has no tags, pass the tag to file it under", args.Command.ID)
+	}
+
+	return tags[0].Name, nil
+}
+
+func setRemote(ctx context.Context, queries *database.Queries, id int64, remote client.Command) error {
+	return queries.SetCommandRemote(ctx, database.SetCommandRemoteParams{
+		Remoteid:      sql.NullString{String: remote.ID, Valid: true},
+		Remoteversion: sql.NullInt64{Int64: remote.Version, Valid: true},
+		ID:            id,
+	})
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrNameTaken is returned when restoring a command whose name has been
+// given to another command in the meantime.
+var ErrNameTaken = errors.New("another command already uses this name")
+
+// ErrUsedByRunbook is returned when deleting a command that a runbook step
+// runs.
+var ErrUsedByRunbook = errors.New("the command is used by a runbook step")
+
+type DeleteCommandArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	ID      int64
+}
+
+// DeleteCommand moves a command to the trash. It keeps its tags, revisions
+// and usage until it is purged. Commands used by a runbook stay where they
+// are.
+func DeleteCommand(args DeleteCommandArgs) error {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	used, err := qtx.IsCommandUsedByRunbook(args.Ctx, sql.NullInt64{Int64: args.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	if used != 0 {
+		return ErrUsedByRunbook
+	}
+
+	deleted, err := qtx.SoftDeleteCommand(args.Ctx, args.ID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = qtx.AddOperation(args.Ctx, database.AddOperationParams{
+		Kind:     OperationDeleteCommand,
+		Targetid: args.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type DeleteTagArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	Name    string
+}
+
+// DeleteTag moves a tag to the trash, commands filed under it stay where
+// they are.
+func DeleteTag(args DeleteTagArgs) (database.Tag, error) {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return database.Tag{}, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	tag, err := qtx.GetTagByName(args.Ctx, args.Name)
+	if err != nil {
+		return database.Tag{}, err
+	}
+
+	if _, err := qtx.SoftDeleteTag(args.Ctx, tag.ID); err != nil {
+		return database.Tag{}, err
+	}
+
+	err = qtx.AddOperation(args.Ctx, database.AddOperationParams{
+		Kind:     OperationDeleteTag,
+		Targetid: tag.ID,
+	})
+	if err != nil {
+		return database.Tag{}, err
+	}
+
+	return tag, tx.Commit()
+}
+
+type Trash struct {
+	Commands []database.Command
+	Tags     []database.Tag
+}
+
+type ListTrashArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+}
+
+// ListTrash returns the deleted commands and tags, most recently deleted
+// first.
+func ListTrash(args ListTrashArgs) (Trash, error) {
+	commands, err := args.Queries.ListDeletedCommands(args.Ctx)
+	if err != nil {
+		return Trash{}, err
+	}
+
+	tags, err := args.Queries.ListDeletedTags(args.Ctx)
+	if err != nil {
+		return Trash{}, err
+	}
+
+	return Trash{Commands: commands, Tags: tags}, nil
+}
+
+type RestoreCommandArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	ID      int64
+}
+
+// RestoreCommand takes a command out of the trash.
+func RestoreCommand(args RestoreCommandArgs) error {
+	restored, err := args.Queries.UndeleteCommand(args.Ctx, args.ID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return ErrNameTaken
+		}
+
+		return err
+	}
+	if restored == 0 {
+		return fmt.Errorf("command %d is not in the trash", args.ID)
+	}
+
+	return nil
+}
+
+type RestoreTagArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	ID      int64
+}
+
+// RestoreTag takes a tag out of the trash.
+func RestoreTag(args RestoreTagArgs) error {
+	restored, err := args.Queries.UndeleteTag(args.Ctx, args.ID)
+	if err != nil {
+		return err
+	}
+	if restored == 0 {
+		return fmt.Errorf("tag %d is not in the trash", args.ID)
+	}
+
+	return nil
+}
+
+type PurgeTrashArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	// RetentionDays limits the purge to what has been in the trash for at
+	// least that many days, zero empties the trash.
+	RetentionDays int
+}
+
+// PurgeTrash permanently deletes commands and tags from the trash and
+// returns how many of each were removed. Commands a runbook step still uses
+// are left in the trash.
+func PurgeTrash(args PurgeTrashArgs) (int64, int64, error) {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	age := fmt.Sprintf("-%d days", args.RetentionDays)
+
+	commands, err := qtx.PurgeDeletedCommands(args.Ctx, age)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tags, err := qtx.PurgeDeletedTags(args.Ctx, age)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return commands, tags, tx.Commit()
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+// Kinds of operations recorded for Undo.
+const (
+	OperationDeleteCommand = "delete-command"
+	OperationDeleteTag     = "delete-tag"
+	OperationEditCommand   = "edit-command"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+type UndoArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+}
+
+// Undo reverses the most recent destructive operation that has not been
+// undone yet: deleted commands and tags come back from the trash and edited
+// commands go back to their previous revision. Operations are undone one at a
+// time, newest first.
+func Undo(args UndoArgs) (database.Operation, error) {
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return database.Operation{}, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	operation, err := qtx.GetLastOperation(args.Ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Operation{}, ErrNothingToUndo
+	}
+	if err != nil {
+		return database.Operation{}, err
+	}
+
+	var undoErr error
+	switch operation.Kind {
+	case OperationDeleteCommand:
+		undoErr = RestoreCommand(RestoreCommandArgs{Ctx: args.Ctx, Queries: qtx, ID: operation.Targetid})
+	case OperationDeleteTag:
+		undoErr = RestoreTag(RestoreTagArgs{Ctx: args.Ctx, Queries: qtx, ID: operation.Targetid})
+	case OperationEditCommand:
+		_, undoErr = restoreRevision(args.Ctx, qtx, operation.Targetid, operation.Revision.Int64)
+		if errors.Is(undoErr, sql.ErrNoRows) {
+			undoErr = fmt.Errorf("command %d no longer exists", operation.Targetid)
+		}
+	default:
+		undoErr = fmt.Errorf("unknown operation %q", operation.Kind)
+	}
+
+	// An operation that can no longer be undone, because what it touched has
+	// been purged for example, is skipped so that the next undo moves on.
+	if err := qtx.MarkOperationUndone(args.Ctx, operation.ID); err != nil {
+		return database.Operation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Operation{}, err
+	}
+
+	return operation, undoErr
+}
+
+// recordEdit logs the edit that produced revision so that Undo can go back
+// to the revision before it.
+func recordEdit(ctx context.Context, qtx *database.Queries, revision database.Commandrevision) error {
+	return qtx.AddOperation(ctx, database.AddOperationParams{
+		Kind:     OperationEditCommand,
+		Targetid: revision.Commandid,
+		Revision: sql.NullInt64{Int64: revision.Revision - 1, Valid: revision.Revision > 1},
+	})
+}
@@ -0,0 +1,362 @@
+// Package client is a small HTTP client for the termflow API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is returned when the API responds with a non 2xx status code, it
+// holds the problem details the API sends. Code tells apart errors such as
+// a locked or deactivated account, Fields lists what is wrong with each
+// field of an invalid payload. Current is the representation the API has
+// now, sent along with 412 Precondition Failed.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     []FieldError
+	RequestID  string
+	Current    json.RawMessage
+}
+
+// FieldError is what is wrong with a field of a payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("api responded with %d: %s", e.StatusCode, e.Message)
+	for _, field := range e.Fields {
+		msg += fmt.Sprintf("\n  %s %s", field.Field, field.Message)
+	}
+
+	return msg
+}
+
+const (
+	// maxAttempts bounds how often a request that failed with a network
+	// error is sent, retryBackoff grows linearly between attempts.
+	maxAttempts  = 3
+	retryBackoff = 500 * time.Millisecond
+)
+
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	return c.doWithHeaders(ctx, method, path, nil, body, out)
+}
+
+func (c *Client) doWithHeaders(ctx context.Context, method, path string, headers http.Header, body, out interface{}) error {
+	if c.BaseURL == "" {
+		return fmt.Errorf("api url is not configured")
+	}
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	// POSTs carry an idempotency key so that retrying them after a network
+	// error does not create duplicates.
+	var idempotencyKey string
+	if method == http.MethodPost {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return err
+		}
+
+		idempotencyKey = key
+	}
+
+	var res *http.Response
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(payload.Bytes()))
+		if err != nil {
+			return err
+		}
+
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+
+		res, err = c.HTTPClient.Do(req)
+		if err == nil {
+			break
+		}
+
+		if attempt == maxAttempts || (method != http.MethodPost && method != http.MethodGet) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * retryBackoff):
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var problem struct {
+			Code      string          `json:"code"`
+			Detail    string          `json:"detail"`
+			Errors    []FieldError    `json:"errors"`
+			RequestID string          `json:"request_id"`
+			Current   json.RawMessage `json:"current"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&problem)
+
+		return &Error{
+			StatusCode: res.StatusCode,
+			Code:       problem.Code,
+			Message:    problem.Detail,
+			Fields:     problem.Errors,
+			RequestID:  problem.RequestID,
+			Current:    problem.Current,
+		}
+	}
+
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+
+	return nil
+}
+
+type CommandUsage struct {
+	CommandID  string    `json:"command_id"`
+	Count      int64     `json:"count"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// SyncID lets the API tell a retried sync apart from a new one.
+	SyncID string `json:"sync_id,omitempty"`
+}
+
+// PushUsage sends aggregated usage counts for synced commands to the API.
+func (c *Client) PushUsage(ctx context.Context, usage []CommandUsage) error {
+	return c.do(ctx, http.MethodPost, "/api/commands/usage", map[string]interface{}{
+		"usage": usage,
+	}, nil)
+}
+
+type RunbookVariable struct {
+	Name    string  `json:"name"`
+	Default *string `json:"default,omitempty"`
+}
+
+type RunbookStep struct {
+	Name            string `json:"name,omitempty"`
+	CommandID       string `json:"command_id,omitempty"`
+	Command         string `json:"command,omitempty"`
+	Confirm         bool   `json:"confirm"`
+	ContinueOnError bool   `json:"continue_on_error"`
+	CaptureAs       string `json:"capture_as,omitempty"`
+}
+
+type Runbook struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Variables   []RunbookVariable `json:"variables"`
+	Steps       []RunbookStep     `json:"steps"`
+}
+
+// PushRunbook replaces the runbook with remoteID, or creates it when
+// remoteID is empty or no longer exists, and returns its remote id.
+func (c *Client) PushRunbook(ctx context.Context, remoteID string, runbook Runbook) (string, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+
+	if remoteID != "" {
+		err := c.do(ctx, http.MethodPut, "/api/runbooks/"+remoteID, runbook, &response)
+
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return response.ID, err
+		}
+	}
+
+	err := c.do(ctx, http.MethodPost, "/api/runbooks", runbook, &response)
+	return response.ID, err
+}
+
+type SharedCommand struct {
+	Command     string   `json:"command"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+type SharedCollection struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Commands    []SharedCommand `json:"commands"`
+}
+
+// Shared is the read-only view of a share link. Type is either "command" or
+// "collection" and tells which of Command or Collection is set.
+type Shared struct {
+	Type       string            `json:"type"`
+	Command    *SharedCommand    `json:"command"`
+	Collection *SharedCollection `json:"collection"`
+}
+
+// GetShared fetches the command or collection behind a share link slug.
+func (c *Client) GetShared(ctx context.Context, slug string) (Shared, error) {
+	var shared Shared
+	err := c.do(ctx, http.MethodGet, "/api/shared/"+url.PathEscape(slug), nil, &shared)
+	return shared, err
+}
+
+type Tag struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Command is a command as stored by the API. Version changes on every write
+// and is sent back as If-Match to detect concurrent changes.
+type Command struct {
+	ID          string `json:"id"`
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Version     int64  `json:"version"`
+}
+
+// ConflictError is returned when a conditional write is rejected because the
+// command changed on the API since it was last pushed.
+type ConflictError struct {
+	Current Command
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("command %s was changed remotely, it is at version %d", e.Current.ID, e.Current.Version)
+}
+
+// EnsureTag returns the tag with name, creating it when it does not exist.
+func (c *Client) EnsureTag(ctx context.Context, name string) (Tag, error) {
+	var tags []Tag
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, &tags); err != nil {
+		return Tag{}, err
+	}
+
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag, nil
+		}
+	}
+
+	var tag Tag
+	err := c.do(ctx, http.MethodPost, "/api/tags", map[string]interface{}{
+		"name": name,
+	}, &tag)
+	return tag, err
+}
+
+// CreateCommand creates a command filed under the tag with tagID.
+func (c *Client) CreateCommand(ctx context.Context, command Command, tagID string) (Command, error) {
+	var response struct {
+		Command Command `json:"command"`
+	}
+
+	err := c.do(ctx, http.MethodPost, "/api/commands", map[string]interface{}{
+		"command":     command.Command,
+		"description": command.Description,
+		"tag_id":      tagID,
+	}, &response)
+	return response.Command, err
+}
+
+// UpdateCommand replaces the command with command.ID if it is still at
+// command.Version, and returns a *ConflictError otherwise.
+func (c *Client) UpdateCommand(ctx context.Context, command Command) (Command, error) {
+	headers := http.Header{}
+	headers.Set("If-Match", strconv.Quote(strconv.FormatInt(command.Version, 10)))
+
+	var updated Command
+	err := c.doWithHeaders(ctx, http.MethodPut, "/api/commands/"+url.PathEscape(command.ID), headers, map[string]interface{}{
+		"command":     command.Command,
+		"description": command.Description,
+	}, &updated)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed {
+		conflict := &ConflictError{}
+		if err := json.Unmarshal(apiErr.Current, &conflict.Current); err != nil {
+			return Command{}, apiErr
+		}
+
+		return Command{}, conflict
+	}
+
+	return updated, err
+}
+
+// SignIn exchanges the credentials of a user for an access token.
+func (c *Client) SignIn(ctx context.Context, email, password string) (string, error) {
+	var response struct {
+		Token string `json:"token"`
+	}
+
+	err := c.do(ctx, http.MethodPost, "/api/auth/signin", map[string]interface{}{
+		"email":    email,
+		"password": password,
+	}, &response)
+	return response.Token, err
+}
+
+// Preferences are the settings a user keeps on the API. Empty fields are
+// not set and leave the local configuration alone.
+type Preferences struct {
+	DefaultTags       []string `json:"default_tags"`
+	PickerTheme       string   `json:"picker_theme"`
+	DangerousPatterns []string `json:"dangerous_patterns"`
+}
+
+func (c *Client) GetPreferences(ctx context.Context) (Preferences, error) {
+	var preferences Preferences
+	err := c.do(ctx, http.MethodGet, "/api/auth/me/preferences", nil, &preferences)
+	return preferences, err
+}
@@ -10,6 +10,24 @@ import (
 	"database/sql"
 )
 
+const addCommandToCollection = `-- name: AddCommandToCollection :exec
+INSERT INTO CollectionCommand (
+  collectionId, commandId, position
+) VALUES (
+  ?1, ?2, (SELECT COALESCE(MAX(position) + 1, 0) FROM CollectionCommand WHERE collectionId = ?1)
+)
+`
+
+type AddCommandToCollectionParams struct {
+	Collectionid int64
+	Commandid    int64
+}
+
+func (q *Queries) AddCommandToCollection(ctx context.Context, arg AddCommandToCollectionParams) error {
+	_, err := q.db.ExecContext(ctx, addCommandToCollection, arg.Collectionid, arg.Commandid)
+	return err
+}
+
 const createCollection = `-- name: CreateCollection :one
 INSERT INTO Collection (
   name, description
@@ -53,6 +71,63 @@ func (q *Queries) GetCollection(ctx context.Context, id int64) (Collection, erro
 	return i, err
 }
 
+const getCollectionByName = `-- name: GetCollectionByName :one
+SELECT id, name, description FROM Collection
+WHERE name = ? LIMIT 1
+`
+
+func (q *Queries) GetCollectionByName(ctx context.Context, name string) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, getCollectionByName, name)
+	var i Collection
+	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	return i, err
+}
+
+const listCollectionCommands = `-- name: ListCollectionCommands :many
+SELECT c.id, c.command, c.description, c.name, cc.position
+FROM CollectionCommand cc
+JOIN Command c ON c.id = cc.commandId
+WHERE cc.collectionId = ? AND c.deletedAt IS NULL
+ORDER BY cc.position
+`
+
+type ListCollectionCommandsRow struct {
+	ID          int64
+	Command     sql.NullString
+	Description sql.NullString
+	Name        sql.NullString
+	Position    int64
+}
+
+func (q *Queries) ListCollectionCommands(ctx context.Context, collectionid int64) ([]ListCollectionCommandsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCollectionCommands, collectionid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionCommandsRow
+	for rows.Next() {
+		var i ListCollectionCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Command,
+			&i.Description,
+			&i.Name,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCollections = `-- name: ListCollections :many
 SELECT id, name, description FROM Collection
 ORDER BY name
@@ -81,6 +156,21 @@ func (q *Queries) ListCollections(ctx context.Context) ([]Collection, error) {
 	return items, nil
 }
 
+const removeCommandFromCollection = `-- name: RemoveCommandFromCollection :exec
+DELETE FROM CollectionCommand
+WHERE collectionId = ? AND commandId = ?
+`
+
+type RemoveCommandFromCollectionParams struct {
+	Collectionid int64
+	Commandid    int64
+}
+
+func (q *Queries) RemoveCommandFromCollection(ctx context.Context, arg RemoveCommandFromCollectionParams) error {
+	_, err := q.db.ExecContext(ctx, removeCommandFromCollection, arg.Collectionid, arg.Commandid)
+	return err
+}
+
 const updateCollection = `-- name: UpdateCollection :exec
 UPDATE Collection
 set name = ?,
@@ -98,3 +188,20 @@ func (q *Queries) UpdateCollection(ctx context.Context, arg UpdateCollectionPara
 	_, err := q.db.ExecContext(ctx, updateCollection, arg.Name, arg.Description, arg.ID)
 	return err
 }
+
+const updateCollectionCommandPosition = `-- name: UpdateCollectionCommandPosition :exec
+UPDATE CollectionCommand
+SET position = ?
+WHERE collectionId = ? AND commandId = ?
+`
+
+type UpdateCollectionCommandPositionParams struct {
+	Position     int64
+	Collectionid int64
+	Commandid    int64
+}
+
+func (q *Queries) UpdateCollectionCommandPosition(ctx context.Context, arg UpdateCollectionCommandPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateCollectionCommandPosition, arg.Position, arg.Collectionid, arg.Commandid)
+	return err
+}
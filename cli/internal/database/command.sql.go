@@ -10,25 +10,80 @@ import (
 	"database/sql"
 )
 
-const addCommand = `-- name: AddCommand :exec
+const addCommand = `-- name: AddCommand :one
 INSERT INTO Command (
-  name, description, command
+  command, description, name
 ) VALUES (
   ?, ?, ?
-)
+) RETURNING id, command, description, remoteId, name, deletedAt, remoteVersion
 `
 
 type AddCommandParams struct {
-	Name        string
-	Description sql.NullString
 	Command     sql.NullString
+	Description sql.NullString
+	Name        sql.NullString
+}
+
+func (q *Queries) AddCommand(ctx context.Context, arg AddCommandParams) (Command, error) {
+	row := q.db.QueryRowContext(ctx, addCommand, arg.Command, arg.Description, arg.Name)
+	var i Command
+	err := row.Scan(
+		&i.ID,
+		&i.Command,
+		&i.Description,
+		&i.Remoteid,
+		&i.Name,
+		&i.Deletedat,
+		&i.Remoteversion,
+	)
+	return i, err
 }
 
-func (q *Queries) AddCommand(ctx context.Context, arg AddCommandParams) error {
-	_, err := q.db.ExecContext(ctx, addCommand, arg.Name, arg.Description, arg.Command)
+const addCommandTag = `-- name: AddCommandTag :exec
+INSERT INTO CommandTag (
+  commandId, tagId
+) VALUES (
+  ?, ?
+)
+`
+
+type AddCommandTagParams struct {
+	Commandid sql.NullInt64
+	Tagid     sql.NullInt64
+}
+
+func (q *Queries) AddCommandTag(ctx context.Context, arg AddCommandTagParams) error {
+	_, err := q.db.ExecContext(ctx, addCommandTag, arg.Commandid, arg.Tagid)
 	return err
 }
 
+const addTag = `-- name: AddTag :one
+INSERT INTO Tag (
+  name, description
+) VALUES (
+  ?, ?
+)
+ON CONFLICT (name) DO UPDATE SET deletedAt = NULL
+RETURNING id, name, description, deletedAt
+`
+
+type AddTagParams struct {
+	Name        string
+	Description sql.NullString
+}
+
+func (q *Queries) AddTag(ctx context.Context, arg AddTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, addTag, arg.Name, arg.Description)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Deletedat,
+	)
+	return i, err
+}
+
 const deleteCommand = `-- name: DeleteCommand :exec
 DELETE FROM Command
 WHERE id = ?
@@ -39,9 +94,19 @@ func (q *Queries) DeleteCommand(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteTag = `-- name: DeleteTag :exec
+DELETE FROM Tag
+WHERE id = ?
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTag, id)
+	return err
+}
+
 const getCommand = `-- name: GetCommand :one
-SELECT id, name, description, command FROM Command
-WHERE id = ? LIMIT 1
+SELECT id, command, description, remoteId, name, deletedAt, remoteVersion FROM Command
+WHERE id = ? AND deletedAt IS NULL LIMIT 1
 `
 
 func (q *Queries) GetCommand(ctx context.Context, id int64) (Command, error) {
@@ -49,16 +114,129 @@ func (q *Queries) GetCommand(ctx context.Context, id int64) (Command, error) {
 	var i Command
 	err := row.Scan(
 		&i.ID,
+		&i.Command,
+		&i.Description,
+		&i.Remoteid,
 		&i.Name,
+		&i.Deletedat,
+		&i.Remoteversion,
+	)
+	return i, err
+}
+
+const getCommandByName = `-- name: GetCommandByName :one
+SELECT id, command, description, remoteId, name, deletedAt, remoteVersion FROM Command
+WHERE name = ? AND deletedAt IS NULL LIMIT 1
+`
+
+func (q *Queries) GetCommandByName(ctx context.Context, name sql.NullString) (Command, error) {
+	row := q.db.QueryRowContext(ctx, getCommandByName, name)
+	var i Command
+	err := row.Scan(
+		&i.ID,
+		&i.Command,
 		&i.Description,
+		&i.Remoteid,
+		&i.Name,
+		&i.Deletedat,
+		&i.Remoteversion,
+	)
+	return i, err
+}
+
+const getCommandIncludingDeleted = `-- name: GetCommandIncludingDeleted :one
+SELECT id, command, description, remoteId, name, deletedAt, remoteVersion FROM Command
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetCommandIncludingDeleted(ctx context.Context, id int64) (Command, error) {
+	row := q.db.QueryRowContext(ctx, getCommandIncludingDeleted, id)
+	var i Command
+	err := row.Scan(
+		&i.ID,
 		&i.Command,
+		&i.Description,
+		&i.Remoteid,
+		&i.Name,
+		&i.Deletedat,
+		&i.Remoteversion,
+	)
+	return i, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, name, description, deletedAt FROM Tag
+WHERE id = ? AND deletedAt IS NULL LIMIT 1
+`
+
+func (q *Queries) GetTag(ctx context.Context, id int64) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTag, id)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Deletedat,
 	)
 	return i, err
 }
 
+const getTagByName = `-- name: GetTagByName :one
+SELECT id, name, description, deletedAt FROM Tag
+WHERE name = ? AND deletedAt IS NULL LIMIT 1
+`
+
+func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTagByName, name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Deletedat,
+	)
+	return i, err
+}
+
+const getTagsForCommand = `-- name: GetTagsForCommand :many
+SELECT t.id, t.name, t.description, t.deletedAt
+FROM Tag t
+JOIN CommandTag ct ON t.id = ct.tagId
+WHERE ct.commandId = ? AND t.deletedAt IS NULL
+`
+
+func (q *Queries) GetTagsForCommand(ctx context.Context, commandid sql.NullInt64) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, getTagsForCommand, commandid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Deletedat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCommands = `-- name: ListCommands :many
-SELECT id, name, description, command FROM Command
-ORDER BY name
+SELECT id, command, description, remoteId, name, deletedAt, remoteVersion FROM Command
+WHERE deletedAt IS NULL
+ORDER BY id
 `
 
 func (q *Queries) ListCommands(ctx context.Context) ([]Command, error) {
@@ -72,9 +250,191 @@ func (q *Queries) ListCommands(ctx context.Context) ([]Command, error) {
 		var i Command
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.Command,
 			&i.Description,
+			&i.Remoteid,
+			&i.Name,
+			&i.Deletedat,
+			&i.Remoteversion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommandsForTagByName = `-- name: ListCommandsForTagByName :many
+SELECT c.id, c.command, c.description
+FROM Command c
+JOIN CommandTag ct ON c.id = ct.commandId
+JOIN Tag t ON ct.tagId = t.id
+WHERE t.name = ? AND c.deletedAt IS NULL AND t.deletedAt IS NULL
+`
+
+type ListCommandsForTagByNameRow struct {
+	ID          int64
+	Command     sql.NullString
+	Description sql.NullString
+}
+
+func (q *Queries) ListCommandsForTagByName(ctx context.Context, name string) ([]ListCommandsForTagByNameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCommandsForTagByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommandsForTagByNameRow
+	for rows.Next() {
+		var i ListCommandsForTagByNameRow
+		if err := rows.Scan(&i.ID, &i.Command, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommandsWithTags = `-- name: ListCommandsWithTags :many
+SELECT 
+    c.id AS command_id, 
+    c.command, 
+    c.description AS command_description,
+    t.id AS tag_id, 
+    t.name AS tag_name, 
+    t.description AS tag_description
+FROM Command c
+LEFT JOIN CommandTag ct ON c.id = ct.commandId
+LEFT JOIN Tag t ON ct.tagId = t.id AND t.deletedAt IS NULL
+WHERE c.deletedAt IS NULL
+ORDER BY c.id, t.name
+`
+
+type ListCommandsWithTagsRow struct {
+	CommandID          int64
+	Command            sql.NullString
+	CommandDescription sql.NullString
+	TagID              sql.NullInt64
+	TagName            sql.NullString
+	TagDescription     sql.NullString
+}
+
+func (q *Queries) ListCommandsWithTags(ctx context.Context) ([]ListCommandsWithTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCommandsWithTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommandsWithTagsRow
+	for rows.Next() {
+		var i ListCommandsWithTagsRow
+		if err := rows.Scan(
+			&i.CommandID,
+			&i.Command,
+			&i.CommandDescription,
+			&i.TagID,
+			&i.TagName,
+			&i.TagDescription,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommandsWithTagsByTagName = `-- name: ListCommandsWithTagsByTagName :many
+SELECT 
+  c.id AS command_id, 
+  c.command, 
+  c.description AS command_description,
+  t.id AS tag_id, 
+  t.name AS tag_name, 
+  t.description AS tag_description
+FROM Command c
+LEFT JOIN CommandTag ct ON c.id = ct.commandId
+LEFT JOIN Tag t ON ct.tagId = t.id
+WHERE t.name = ? AND c.deletedAt IS NULL AND t.deletedAt IS NULL
+ORDER BY c.id, t.name
+`
+
+type ListCommandsWithTagsByTagNameRow struct {
+	CommandID          int64
+	Command            sql.NullString
+	CommandDescription sql.NullString
+	TagID              sql.NullInt64
+	TagName            sql.NullString
+	TagDescription     sql.NullString
+}
+
+func (q *Queries) ListCommandsWithTagsByTagName(ctx context.Context, name string) ([]ListCommandsWithTagsByTagNameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCommandsWithTagsByTagName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommandsWithTagsByTagNameRow
+	for rows.Next() {
+		var i ListCommandsWithTagsByTagNameRow
+		if err := rows.Scan(
+			&i.CommandID,
 			&i.Command,
+			&i.CommandDescription,
+			&i.TagID,
+			&i.TagName,
+			&i.TagDescription,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, name, description, deletedAt FROM Tag
+WHERE deletedAt IS NULL
+ORDER BY name
+`
+
+func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Deletedat,
 		); err != nil {
 			return nil, err
 		}
@@ -89,27 +449,71 @@ func (q *Queries) ListCommands(ctx context.Context) ([]Command, error) {
 	return items, nil
 }
 
+const removeCommandTag = `-- name: RemoveCommandTag :exec
+DELETE FROM CommandTag
+WHERE commandId = ? AND tagId = ?
+`
+
+type RemoveCommandTagParams struct {
+	Commandid sql.NullInt64
+	Tagid     sql.NullInt64
+}
+
+func (q *Queries) RemoveCommandTag(ctx context.Context, arg RemoveCommandTagParams) error {
+	_, err := q.db.ExecContext(ctx, removeCommandTag, arg.Commandid, arg.Tagid)
+	return err
+}
+
+const setCommandRemote = `-- name: SetCommandRemote :exec
+UPDATE Command
+SET remoteId = ?,
+remoteVersion = ?
+WHERE id = ?
+`
+
+type SetCommandRemoteParams struct {
+	Remoteid      sql.NullString
+	Remoteversion sql.NullInt64
+	ID            int64
+}
+
+func (q *Queries) SetCommandRemote(ctx context.Context, arg SetCommandRemoteParams) error {
+	_, err := q.db.ExecContext(ctx, setCommandRemote, arg.Remoteid, arg.Remoteversion, arg.ID)
+	return err
+}
+
 const updateCommand = `-- name: UpdateCommand :exec
 UPDATE Command
-set name = ?,
-description = ?,
-command = ?
+set command = ?,
+description = ?
 WHERE id = ?
 `
 
 type UpdateCommandParams struct {
-	Name        string
-	Description sql.NullString
 	Command     sql.NullString
+	Description sql.NullString
 	ID          int64
 }
 
 func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) error {
-	_, err := q.db.ExecContext(ctx, updateCommand,
-		arg.Name,
-		arg.Description,
-		arg.Command,
-		arg.ID,
-	)
+	_, err := q.db.ExecContext(ctx, updateCommand, arg.Command, arg.Description, arg.ID)
+	return err
+}
+
+const updateTag = `-- name: UpdateTag :exec
+UPDATE Tag
+SET name = ?,
+description = ?
+WHERE id = ?
+`
+
+type UpdateTagParams struct {
+	Name        string
+	Description sql.NullString
+	ID          int64
+}
+
+func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) error {
+	_, err := q.db.ExecContext(ctx, updateTag, arg.Name, arg.Description, arg.ID)
 	return err
 }
@@ -6,6 +6,7 @@ package database
 
 import (
 	"database/sql"
+	"time"
 )
 
 type Collection struct {
@@ -14,9 +15,95 @@ type Collection struct {
 	Description sql.NullString
 }
 
+type Collectioncommand struct {
+	Collectionid int64
+	Commandid    int64
+	Position     int64
+}
+
 type Command struct {
+	ID            int64
+	Command       sql.NullString
+	Description   sql.NullString
+	Remoteid      sql.NullString
+	Name          sql.NullString
+	Deletedat     sql.NullTime
+	Remoteversion sql.NullInt64
+}
+
+type Commandrevision struct {
+	ID          int64
+	Commandid   int64
+	Revision    int64
+	Command     sql.NullString
+	Description sql.NullString
+	Tags        string
+	Createdat   time.Time
+}
+
+type Commandtag struct {
+	Commandid sql.NullInt64
+	Tagid     sql.NullInt64
+}
+
+type Commandusage struct {
+	ID               int64
+	Commandid        int64
+	Action           string
+	Workingdirectory sql.NullString
+	Exitcode         sql.NullInt64
+	Usedat           time.Time
+	Synced           bool
+	Syncid           sql.NullString
+}
+
+type Operation struct {
+	ID        int64
+	Kind      string
+	Targetid  int64
+	Revision  sql.NullInt64
+	Createdat time.Time
+	Undoneat  sql.NullTime
+}
+
+type Runbook struct {
 	ID          int64
 	Name        string
 	Description sql.NullString
-	Command     sql.NullString
+	Remoteid    sql.NullString
+}
+
+type Runbookrun struct {
+	ID         int64
+	Runbookid  int64
+	Status     string
+	Step       int64
+	Variables  string
+	Startedat  time.Time
+	Finishedat sql.NullTime
+}
+
+type Runbookstep struct {
+	ID              int64
+	Runbookid       int64
+	Position        int64
+	Name            sql.NullString
+	Commandid       sql.NullInt64
+	Command         sql.NullString
+	Confirm         bool
+	Continueonerror bool
+	Captureas       sql.NullString
+}
+
+type Runbookvariable struct {
+	Runbookid    int64
+	Name         string
+	Defaultvalue sql.NullString
+}
+
+type Tag struct {
+	ID          int64
+	Name        string
+	Description sql.NullString
+	Deletedat   sql.NullTime
 }
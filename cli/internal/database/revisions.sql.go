@@ -0,0 +1,117 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: revisions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const addCommandRevision = `-- name: AddCommandRevision :one
+INSERT INTO CommandRevision (
+  commandId, revision, command, description, tags
+)
+SELECT c.id,
+  COALESCE((SELECT MAX(r.revision) FROM CommandRevision r WHERE r.commandId = c.id), 0) + 1,
+  c.command, c.description, COALESCE((
+    SELECT group_concat(name, ',') FROM (
+      SELECT t.name FROM Tag t
+      JOIN CommandTag ct ON ct.tagId = t.id
+      WHERE ct.commandId = c.id AND t.deletedAt IS NULL
+      ORDER BY t.name
+    )
+  ), '')
+FROM Command c
+WHERE c.id = ?
+RETURNING id, commandId, revision, command, description, tags, createdAt
+`
+
+func (q *Queries) AddCommandRevision(ctx context.Context, id int64) (Commandrevision, error) {
+	row := q.db.QueryRowContext(ctx, addCommandRevision, id)
+	var i Commandrevision
+	err := row.Scan(
+		&i.ID,
+		&i.Commandid,
+		&i.Revision,
+		&i.Command,
+		&i.Description,
+		&i.Tags,
+		&i.Createdat,
+	)
+	return i, err
+}
+
+const getCommandRevision = `-- name: GetCommandRevision :one
+SELECT id, commandId, revision, command, description, tags, createdAt FROM CommandRevision
+WHERE commandId = ? AND revision = ? LIMIT 1
+`
+
+type GetCommandRevisionParams struct {
+	Commandid int64
+	Revision  int64
+}
+
+func (q *Queries) GetCommandRevision(ctx context.Context, arg GetCommandRevisionParams) (Commandrevision, error) {
+	row := q.db.QueryRowContext(ctx, getCommandRevision, arg.Commandid, arg.Revision)
+	var i Commandrevision
+	err := row.Scan(
+		&i.ID,
+		&i.Commandid,
+		&i.Revision,
+		&i.Command,
+		&i.Description,
+		&i.Tags,
+		&i.Createdat,
+	)
+	return i, err
+}
+
+const listCommandRevisions = `-- name: ListCommandRevisions :many
+SELECT id, commandId, revision, command, description, tags, createdAt FROM CommandRevision
+WHERE commandId = ?
+ORDER BY revision DESC
+`
+
+func (q *Queries) ListCommandRevisions(ctx context.Context, commandid int64) ([]Commandrevision, error) {
+	rows, err := q.db.QueryContext(ctx, listCommandRevisions, commandid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Commandrevision
+	for rows.Next() {
+		var i Commandrevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.Commandid,
+			&i.Revision,
+			&i.Command,
+			&i.Description,
+			&i.Tags,
+			&i.Createdat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeCommandTags = `-- name: RemoveCommandTags :exec
+DELETE FROM CommandTag
+WHERE commandId = ?
+`
+
+func (q *Queries) RemoveCommandTags(ctx context.Context, commandid sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, removeCommandTags, commandid)
+	return err
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/endalk200/termflow-cli/sql/migrations"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+)
+
+// Open opens the SQLite database at path, creating its parent directory if
+// needed, and applies any pending migrations.
+func Open(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating database directory: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	goose.SetBaseFS(migrations.FS)
+	goose.SetLogger(goose.NopLogger())
+
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := goose.Up(db, "."); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error applying migrations: %v", err)
+	}
+
+	return db, nil
+}
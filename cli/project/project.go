@@ -0,0 +1,120 @@
+// Package project reads and writes the project-scoped commands a repository
+// shares through a .termflow.yaml file.
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project file looked up from the working
+// directory.
+const FileName = ".termflow.yaml"
+
+// ErrNotFound is returned by Find when no project file exists in the
+// directory or any of its parents.
+var ErrNotFound = errors.New("no " + FileName + " found")
+
+type Command struct {
+	Name        string   `yaml:"name,omitempty"`
+	Command     string   `yaml:"command"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+type File struct {
+	Commands []Command `yaml:"commands"`
+}
+
+// Find walks up from dir and returns the path of the closest project file.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNotFound
+		}
+
+		dir = parent
+	}
+}
+
+// Load reads the project file at path.
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("invalid %s: %v", path, err)
+	}
+
+	return file, nil
+}
+
+// LoadCurrent loads the project file found from the working directory. It
+// returns an empty file and path when there is none.
+func LoadCurrent() (File, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return File{}, "", err
+	}
+
+	path, err := Find(wd)
+	if errors.Is(err, ErrNotFound) {
+		return File{}, "", nil
+	}
+	if err != nil {
+		return File{}, "", err
+	}
+
+	file, err := Load(path)
+	return file, path, err
+}
+
+// Add appends a command to the project file at path, creating the file when
+// it does not exist. Names must be unique within the file.
+func Add(path string, command Command) error {
+	file, err := Load(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if command.Name != "" {
+		for _, existing := range file.Commands {
+			if existing.Name == command.Name {
+				return fmt.Errorf("a command named %q already exists in %s", command.Name, path)
+			}
+		}
+	}
+
+	file.Commands = append(file.Commands, command)
+
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data.Bytes(), 0o644)
+}
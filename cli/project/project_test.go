@@ -0,0 +1,64 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/endalk200/termflow-cli/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "services", "api", "internal")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	t.Run("NotFound", func(t *testing.T) {
+		// The temporary directory may itself sit below a project file
+		if _, err := project.Find(filepath.Dir(root)); err == nil {
+			t.Skip("a parent of the temporary directory has a project file")
+		}
+
+		_, err := project.Find(nested)
+		assert.ErrorIs(t, err, project.ErrNotFound)
+	})
+
+	rootFile := filepath.Join(root, project.FileName)
+	require.NoError(t, os.WriteFile(rootFile, []byte("commands: []\n"), 0o644))
+
+	t.Run("WalksUp", func(t *testing.T) {
+		path, err := project.Find(nested)
+		require.NoError(t, err)
+		assert.Equal(t, rootFile, path)
+	})
+
+	serviceFile := filepath.Join(root, "services", "api", project.FileName)
+	require.NoError(t, os.WriteFile(serviceFile, []byte("commands: []\n"), 0o644))
+
+	t.Run("Closest", func(t *testing.T) {
+		path, err := project.Find(nested)
+		require.NoError(t, err)
+		assert.Equal(t, serviceFile, path)
+
+		path, err = project.Find(filepath.Join(root, "services"))
+		require.NoError(t, err)
+		assert.Equal(t, rootFile, path)
+	})
+}
+
+func TestAddAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), project.FileName)
+
+	require.NoError(t, project.Add(path, project.Command{Name: "test", Command: "go test ./...", Tags: []string{"go"}}))
+	require.NoError(t, project.Add(path, project.Command{Command: "go vet ./..."}))
+	assert.Error(t, project.Add(path, project.Command{Name: "test", Command: "make test"}), "names are unique")
+
+	file, err := project.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []project.Command{
+		{Name: "test", Command: "go test ./...", Tags: []string{"go"}},
+		{Command: "go vet ./..."},
+	}, file.Commands)
+}
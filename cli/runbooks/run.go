@@ -0,0 +1,252 @@
+package runbooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/runner"
+	"github.com/endalk200/termflow-cli/usage"
+)
+
+// Statuses of a runbook run.
+const (
+	StatusRunning   = "running"
+	StatusFailed    = "failed"
+	StatusSucceeded = "succeeded"
+)
+
+// ErrAborted is returned when a step that needs confirmation is declined.
+var ErrAborted = errors.New("aborted")
+
+// ErrNothingToResume is returned when resuming a runbook whose last run
+// succeeded or that never ran.
+var ErrNothingToResume = errors.New("no unfinished run to resume")
+
+// StepError is returned when a step exits with a non zero code and does not
+// allow the runbook to continue.
+type StepError struct {
+	// Position is the zero-based position of the failed step.
+	Position int64
+	Title    string
+	ExitCode int
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %d (%s) failed with exit code %d", e.Position+1, e.Title, e.ExitCode)
+}
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+
+// Expand replaces every {{name}} placeholder of command with its value.
+// Values are inserted as is, without any shell quoting.
+func Expand(command string, values map[string]string) (string, error) {
+	var missing []string
+	expanded := variablePattern.ReplaceAllStringFunc(command, func(placeholder string) string {
+		name := variablePattern.FindStringSubmatch(placeholder)[1]
+		value, exists := values[name]
+		if !exists {
+			missing = append(missing, name)
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("variable %q has no value", missing[0])
+	}
+
+	return expanded, nil
+}
+
+type RunArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Runbook database.Runbook
+	// Variables override the defaults declared by the runbook.
+	Variables map[string]string
+	// Resume continues the last unfinished run from the step it stopped at,
+	// with the variables it had then.
+	Resume            bool
+	DangerousPatterns []string
+	// Confirm is asked before steps marked for confirmation and dangerous
+	// steps. reason is empty when the step is not dangerous.
+	Confirm func(step Step, command string, reason string) (bool, error)
+	// Out receives a header before each step.
+	Out io.Writer
+}
+
+// Run executes the steps of a runbook in order and records the run so a
+// failed run can be resumed.
+func Run(args RunArgs) error {
+	steps, err := ListSteps(ListStepsArgs{Ctx: args.Ctx, Queries: args.Queries, RunbookID: args.Runbook.ID})
+	if err != nil {
+		return err
+	}
+
+	variables, err := args.Queries.ListRunbookVariables(args.Ctx, args.Runbook.ID)
+	if err != nil {
+		return err
+	}
+
+	start := int64(0)
+	values := map[string]string{}
+	for _, variable := range variables {
+		if variable.Defaultvalue.Valid {
+			values[variable.Name] = variable.Defaultvalue.String
+		}
+	}
+
+	if args.Resume {
+		last, err := args.Queries.GetLastRunbookRun(args.Ctx, args.Runbook.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNothingToResume
+		}
+		if err != nil {
+			return err
+		}
+
+		if last.Status == StatusSucceeded {
+			return ErrNothingToResume
+		}
+
+		if err := json.Unmarshal([]byte(last.Variables), &values); err != nil {
+			return fmt.Errorf("invalid variables stored for run %d: %v", last.ID, err)
+		}
+
+		start = last.Step
+	}
+
+	for name, value := range args.Variables {
+		values[name] = value
+	}
+
+	captured := map[string]bool{}
+	for _, step := range steps {
+		if step.Captureas.Valid {
+			captured[step.Captureas.String] = true
+		}
+	}
+
+	for _, variable := range variables {
+		if _, exists := values[variable.Name]; !exists && !captured[variable.Name] {
+			return fmt.Errorf("variable %q has no value, pass --var %s=<value>", variable.Name, variable.Name)
+		}
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	run, err := args.Queries.CreateRunbookRun(args.Ctx, database.CreateRunbookRunParams{
+		Runbookid: args.Runbook.ID,
+		Status:    StatusRunning,
+		Step:      start,
+		Variables: string(encoded),
+	})
+	if err != nil {
+		return err
+	}
+
+	// finish stores where the run stopped. The variables are stored along
+	// so that captured values survive a resume.
+	finish := func(status string, position int64) error {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+
+		finishedAt := sql.NullTime{}
+		if status != StatusRunning {
+			finishedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		}
+
+		return args.Queries.UpdateRunbookRun(args.Ctx, database.UpdateRunbookRunParams{
+			Status:     status,
+			Step:       position,
+			Variables:  string(encoded),
+			Finishedat: finishedAt,
+			ID:         run.ID,
+		})
+	}
+
+	for _, step := range steps[min(start, int64(len(steps))):] {
+		if err := finish(StatusRunning, step.Position); err != nil {
+			return err
+		}
+
+		command, err := Expand(step.Text(), values)
+		if err != nil {
+			_ = finish(StatusFailed, step.Position)
+			return fmt.Errorf("step %d (%s): %v", step.Position+1, step.Title(), err)
+		}
+
+		dangerous, reason, err := runner.IsDangerous(command, step.Tags, args.DangerousPatterns)
+		if err != nil {
+			_ = finish(StatusFailed, step.Position)
+			return err
+		}
+
+		if step.Confirm || dangerous {
+			confirmed, err := args.Confirm(step, command, reason)
+			if err == nil && !confirmed {
+				err = ErrAborted
+			}
+
+			if err != nil {
+				_ = finish(StatusFailed, step.Position)
+				return err
+			}
+		}
+
+		fmt.Fprintf(args.Out, "==> [%d/%d] %s\n", step.Position+1, len(steps), step.Title())
+
+		var exitCode int
+		if step.Captureas.Valid {
+			var output string
+			exitCode, output, err = runner.ExecuteCapture(args.Ctx, command)
+			values[step.Captureas.String] = output
+		} else {
+			exitCode, err = runner.Execute(args.Ctx, command)
+		}
+		if err != nil {
+			_ = finish(StatusFailed, step.Position)
+			return err
+		}
+
+		if step.Saved != nil {
+			_, err = usage.Record(usage.RecordArgs{
+				Ctx:       args.Ctx,
+				Queries:   args.Queries,
+				CommandID: step.Saved.ID,
+				Action:    usage.ActionExecute,
+				ExitCode:  &exitCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if exitCode != 0 {
+			if step.Continueonerror {
+				fmt.Fprintf(args.Out, "==> step %d exited with %d, continuing\n", step.Position+1, exitCode)
+				continue
+			}
+
+			if err := finish(StatusFailed, step.Position); err != nil {
+				return err
+			}
+
+			return &StepError{Position: step.Position, Title: step.Title(), ExitCode: exitCode}
+		}
+	}
+
+	return finish(StatusSucceeded, int64(len(steps)))
+}
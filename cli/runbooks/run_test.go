@@ -0,0 +1,86 @@
+package runbooks_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/runbooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"env": "staging", "tag": "v1.2.0"}
+
+	expanded, err := runbooks.Expand("deploy --env {{env}} --tag {{ tag }}", values)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy --env staging --tag v1.2.0", expanded)
+
+	expanded, err = runbooks.Expand("echo {{}} {{1st}}", values)
+	require.NoError(t, err)
+	assert.Equal(t, "echo {{}} {{1st}}", expanded, "invalid names are left as is")
+
+	_, err = runbooks.Expand("deploy --region {{region}}", values)
+	assert.EqualError(t, err, `variable "region" has no value`)
+}
+
+func TestRunResume(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+
+	ctx := context.Background()
+	db, err := database.Open(filepath.Join(t.TempDir(), "termflow.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	queries := database.New(db)
+
+	runbook, err := runbooks.CreateRunbook(runbooks.CreateRunbookArgs{Ctx: ctx, Queries: queries, Name: "release"})
+	require.NoError(t, err)
+	require.NoError(t, runbooks.SetVariable(runbooks.SetVariableArgs{Ctx: ctx, Queries: queries, RunbookID: runbook.ID, Name: "dir"}))
+
+	for _, command := range []string{
+		"echo build >> {{dir}}/log",
+		"test -f {{dir}}/ready",
+		"echo publish >> {{dir}}/log",
+	} {
+		_, err := runbooks.AddStep(runbooks.AddStepArgs{Ctx: ctx, Queries: queries, RunbookID: runbook.ID, Command: command})
+		require.NoError(t, err)
+	}
+
+	run := func(resume bool, variables map[string]string) error {
+		return runbooks.Run(runbooks.RunArgs{
+			Ctx:       ctx,
+			Queries:   queries,
+			Runbook:   runbook,
+			Variables: variables,
+			Resume:    resume,
+			Confirm: func(runbooks.Step, string, string) (bool, error) {
+				t.Fatal("no step needs confirmation")
+				return false, nil
+			},
+			Out: &bytes.Buffer{},
+		})
+	}
+
+	assert.ErrorIs(t, run(true, nil), runbooks.ErrNothingToResume, "the runbook never ran")
+
+	dir := t.TempDir()
+	err = run(false, map[string]string{"dir": dir})
+	var stepErr *runbooks.StepError
+	require.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, int64(1), stepErr.Position)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ready"), nil, 0o644))
+
+	// The run resumes at the failed step with the variables it had
+	require.NoError(t, run(true, nil))
+
+	log, err := os.ReadFile(filepath.Join(dir, "log"))
+	require.NoError(t, err)
+	assert.Equal(t, "build\npublish\n", string(log))
+
+	assert.ErrorIs(t, run(true, nil), runbooks.ErrNothingToResume, "the last run succeeded")
+}
@@ -0,0 +1,244 @@
+package runbooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+type CreateRunbookArgs struct {
+	Ctx         context.Context
+	Queries     *database.Queries
+	Name        string
+	Description string
+}
+
+func CreateRunbook(args CreateRunbookArgs) (database.Runbook, error) {
+	runbook, err := args.Queries.CreateRunbook(args.Ctx, database.CreateRunbookParams{
+		Name:        args.Name,
+		Description: sql.NullString{String: args.Description, Valid: args.Description != ""},
+	})
+	if err != nil {
+		return database.Runbook{}, err
+	}
+
+	return runbook, nil
+}
+
+type GetRunbookArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	// IdOrName is either the numeric id or the unique name of the runbook.
+	IdOrName string
+}
+
+func GetRunbook(args GetRunbookArgs) (database.Runbook, error) {
+	if id, err := strconv.ParseInt(args.IdOrName, 10, 64); err == nil {
+		return args.Queries.GetRunbook(args.Ctx, id)
+	}
+
+	return args.Queries.GetRunbookByName(args.Ctx, args.IdOrName)
+}
+
+type AddStepArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	RunbookID int64
+	Name      string
+	// CommandID references a saved command. When it is zero the step runs
+	// Command instead.
+	CommandID       int64
+	Command         string
+	Confirm         bool
+	ContinueOnError bool
+	// CaptureAs stores the stdout of the step in a variable of that name.
+	CaptureAs string
+}
+
+// AddStep appends a step at the end of a runbook.
+func AddStep(args AddStepArgs) (database.Runbookstep, error) {
+	step, err := args.Queries.AddRunbookStep(args.Ctx, database.AddRunbookStepParams{
+		Runbookid:       args.RunbookID,
+		Name:            sql.NullString{String: args.Name, Valid: args.Name != ""},
+		Commandid:       sql.NullInt64{Int64: args.CommandID, Valid: args.CommandID != 0},
+		Command:         sql.NullString{String: args.Command, Valid: args.CommandID == 0},
+		Confirm:         args.Confirm,
+		Continueonerror: args.ContinueOnError,
+		Captureas:       sql.NullString{String: args.CaptureAs, Valid: args.CaptureAs != ""},
+	})
+	if err != nil {
+		return database.Runbookstep{}, err
+	}
+
+	return step, nil
+}
+
+type SetVariableArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	RunbookID int64
+	Name      string
+	// Default is nil for variables that must be passed on every run.
+	Default *string
+}
+
+// SetVariable declares a runbook variable or changes its default value.
+func SetVariable(args SetVariableArgs) error {
+	defaultValue := sql.NullString{}
+	if args.Default != nil {
+		defaultValue = sql.NullString{String: *args.Default, Valid: true}
+	}
+
+	return args.Queries.SetRunbookVariable(args.Ctx, database.SetRunbookVariableParams{
+		Runbookid:    args.RunbookID,
+		Name:         args.Name,
+		Defaultvalue: defaultValue,
+	})
+}
+
+// Step is a runbook step along with the saved command it references.
+type Step struct {
+	database.Runbookstep
+	// Saved is nil for steps running an inline command.
+	Saved *database.Command
+	Tags  []string
+}
+
+// Text returns the command the step runs, before variables are expanded.
+func (s Step) Text() string {
+	if s.Saved != nil {
+		return s.Saved.Command.String
+	}
+
+	return s.Command.String
+}
+
+// Title returns the name of the step, falling back to its command.
+func (s Step) Title() string {
+	if s.Name.Valid {
+		return s.Name.String
+	}
+
+	if s.Saved != nil && s.Saved.Name.Valid {
+		return s.Saved.Name.String
+	}
+
+	return s.Text()
+}
+
+type ListStepsArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	RunbookID int64
+}
+
+// ListSteps returns the steps of a runbook in order. It fails when a step
+// uses a command that is in the trash.
+func ListSteps(args ListStepsArgs) ([]Step, error) {
+	rows, err := args.Queries.ListRunbookSteps(args.Ctx, args.RunbookID)
+	if err != nil {
+		return []Step{}, err
+	}
+
+	steps := make([]Step, 0, len(rows))
+	for _, row := range rows {
+		step := Step{Runbookstep: row}
+
+		if row.Commandid.Valid {
+			command, err := args.Queries.GetCommandIncludingDeleted(args.Ctx, row.Commandid.Int64)
+			if err != nil {
+				return []Step{}, err
+			}
+			if command.Deletedat.Valid {
+				return []Step{}, fmt.Errorf("step %d uses a deleted command, restore it with termflow trash restore %d", row.Position+1, command.ID)
+			}
+
+			tags, err := args.Queries.GetTagsForCommand(args.Ctx, row.Commandid)
+			if err != nil {
+				return []Step{}, err
+			}
+
+			step.Saved = &command
+			for _, tag := range tags {
+				step.Tags = append(step.Tags, tag.Name)
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+type PushArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Client  *client.Client
+	Runbook database.Runbook
+}
+
+// Push creates or replaces the runbook on the API and remembers its remote
+// id. Steps referencing commands that were never synced are pushed inline.
+func Push(args PushArgs) (string, error) {
+	steps, err := ListSteps(ListStepsArgs{Ctx: args.Ctx, Queries: args.Queries, RunbookID: args.Runbook.ID})
+	if err != nil {
+		return "", err
+	}
+
+	variables, err := args.Queries.ListRunbookVariables(args.Ctx, args.Runbook.ID)
+	if err != nil {
+		return "", err
+	}
+
+	payload := client.Runbook{
+		Name:        args.Runbook.Name,
+		Description: args.Runbook.Description.String,
+		Variables:   make([]client.RunbookVariable, 0, len(variables)),
+		Steps:       make([]client.RunbookStep, 0, len(steps)),
+	}
+
+	for _, variable := range variables {
+		pushed := client.RunbookVariable{Name: variable.Name}
+		if variable.Defaultvalue.Valid {
+			pushed.Default = &variable.Defaultvalue.String
+		}
+
+		payload.Variables = append(payload.Variables, pushed)
+	}
+
+	for _, step := range steps {
+		pushed := client.RunbookStep{
+			Name:            step.Name.String,
+			Confirm:         step.Confirm,
+			ContinueOnError: step.Continueonerror,
+			CaptureAs:       step.Captureas.String,
+		}
+
+		if step.Saved != nil && step.Saved.Remoteid.Valid {
+			pushed.CommandID = step.Saved.Remoteid.String
+		} else {
+			pushed.Command = step.Text()
+		}
+
+		payload.Steps = append(payload.Steps, pushed)
+	}
+
+	remoteID, err := args.Client.PushRunbook(args.Ctx, args.Runbook.Remoteid.String, payload)
+	if err != nil {
+		return "", err
+	}
+
+	err = args.Queries.SetRunbookRemoteId(args.Ctx, database.SetRunbookRemoteIdParams{
+		Remoteid: sql.NullString{String: remoteID, Valid: true},
+		ID:       args.Runbook.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return remoteID, nil
+}
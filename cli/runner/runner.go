@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// DangerousTag marks a command as requiring confirmation before it runs,
+// whatever its text.
+const DangerousTag = "dangerous"
+
+// DefaultDangerousPatterns are used when no patterns are configured. They
+// are regular expressions matched case-insensitively against the command.
+// Recursive forced removals are always dangerous, whatever the patterns,
+// see IsForcedRecursiveRemove.
+var DefaultDangerousPatterns = []string{
+	`drop\s+table`,
+	`kubectl\s+delete`,
+	`terraform\s+destroy`,
+}
+
+// IsDangerous reports whether command must be confirmed before running and
+// why. A command is dangerous when it is tagged DangerousTag, removes files
+// recursively and forcibly or matches one of patterns.
+func IsDangerous(command string, tags []string, patterns []string) (bool, string, error) {
+	for _, tag := range tags {
+		if tag == DangerousTag {
+			return true, fmt.Sprintf("tagged %q", DangerousTag), nil
+		}
+	}
+
+	if IsForcedRecursiveRemove(command) {
+		return true, "removes files recursively and forcibly", nil
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid dangerous pattern %q: %v", pattern, err)
+		}
+
+		if re.MatchString(command) {
+			return true, fmt.Sprintf("matches %q", pattern), nil
+		}
+	}
+
+	return false, "", nil
+}
+
+// commandSeparators split a command line into the simple commands it runs.
+var commandSeparators = regexp.MustCompile(`[;&|()\n]+`)
+
+// IsForcedRecursiveRemove reports whether command runs rm with both the
+// recursive and the force flags, in any order and spelling: rm -rf,
+// rm -rvf, rm -r -f, rm --recursive --force or rm dir -fR.
+func IsForcedRecursiveRemove(command string) bool {
+	for _, segment := range commandSeparators.Split(command, -1) {
+		words := strings.Fields(segment)
+
+		for i, word := range words {
+			// Also catches rm behind sudo, xargs or a path such as /bin/rm
+			if path.Base(strings.Trim(word, `"'`)) != "rm" {
+				continue
+			}
+
+			recursive, force := false, false
+			for _, arg := range words[i+1:] {
+				if arg == "--" {
+					break
+				}
+
+				switch {
+				case arg == "--recursive":
+					recursive = true
+				case arg == "--force":
+					force = true
+				case strings.HasPrefix(arg, "--"):
+				case strings.HasPrefix(arg, "-"):
+					recursive = recursive || strings.ContainsAny(arg[1:], "rR")
+					force = force || strings.Contains(arg[1:], "f")
+				}
+			}
+
+			if recursive && force {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Shell returns the user's shell, falling back to /bin/sh.
+func Shell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+
+	return "/bin/sh"
+}
+
+// Execute runs command through the user's shell with the standard streams
+// of the current process attached and returns its exit code. Interrupts are
+// left to the child so termflow survives to record the run.
+func Execute(ctx context.Context, command string) (int, error) {
+	return execute(ctx, command, os.Stdout)
+}
+
+// ExecuteCapture works like Execute and also returns what the command wrote
+// to stdout, without the trailing newline.
+func ExecuteCapture(ctx context.Context, command string) (int, string, error) {
+	var output bytes.Buffer
+	exitCode, err := execute(ctx, command, io.MultiWriter(os.Stdout, &output))
+
+	return exitCode, strings.TrimRight(output.String(), "\r\n"), err
+}
+
+func execute(ctx context.Context, command string, stdout io.Writer) (int, error) {
+	cmd := exec.CommandContext(ctx, Shell(), "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitCode(exitErr), nil
+		}
+
+		return -1, err
+	}
+
+	return 0, nil
+}
+
+// exitCode returns the exit code of a finished command. A command killed by
+// a signal exits with 128 plus the signal number, as shells report it.
+func exitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,87 @@
+package runner_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/endalk200/termflow-cli/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsForcedRecursiveRemove(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"rm -rf /", true},
+		{"rm -fr build", true},
+		{"rm -rvf /", true},
+		{"rm -r -f /tmp/cache", true},
+		{"rm --recursive --force /", true},
+		{"rm -R --force node_modules", true},
+		{"rm build -rf", true},
+		{"sudo rm -rf /var/lib/app", true},
+		{"/bin/rm -rf dist", true},
+		{"find . -name '*.o' | xargs rm -rf", true},
+		{"make clean && rm -rf build", true},
+		{"rm -r build", false},
+		{"rm -f build.log", false},
+		{"rm file-rf", false},
+		{"rm -- -rf", false},
+		{"rmdir -p a/b", false},
+		{"git rm -r --cached dist", false},
+		{"ls -rf", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			assert.Equal(t, test.want, runner.IsForcedRecursiveRemove(test.command))
+		})
+	}
+}
+
+func TestIsDangerous(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		tags      []string
+		patterns  []string
+		dangerous bool
+		reason    string
+	}{
+		{"Safe", "ls -la", nil, runner.DefaultDangerousPatterns, false, ""},
+		{"Tagged", "ls -la", []string{"ops", runner.DangerousTag}, nil, true, `tagged "dangerous"`},
+		{"ForcedRecursiveRemove", "rm -rvf /", nil, nil, true, "removes files recursively and forcibly"},
+		{"DefaultPattern", "psql -c 'DROP TABLE users'", nil, runner.DefaultDangerousPatterns, true, `matches "drop\\s+table"`},
+		{"CustomPattern", "git push --force", nil, []string{`push\s+--force`}, true, `matches "push\\s+--force"`},
+		{"PatternsReplaceDefaults", "kubectl delete pod web", nil, []string{`push\s+--force`}, false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dangerous, reason, err := runner.IsDangerous(test.command, test.tags, test.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, test.dangerous, dangerous)
+			assert.Equal(t, test.reason, reason)
+		})
+	}
+}
+
+func TestIsDangerousInvalidPattern(t *testing.T) {
+	_, _, err := runner.IsDangerous("ls", nil, []string{"("})
+	assert.Error(t, err)
+}
+
+func TestExecuteExitCode(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+
+	exitCode, err := runner.Execute(context.Background(), "exit 3")
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+
+	// Killed by SIGTERM, which is 15
+	exitCode, err = runner.Execute(context.Background(), "kill -TERM $$")
+	require.NoError(t, err)
+	assert.Equal(t, 128+15, exitCode)
+}
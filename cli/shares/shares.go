@@ -0,0 +1,125 @@
+package shares
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/endalk200/termflow-cli/collections"
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+// DefaultTag files imported commands that were shared without tags.
+const DefaultTag = "shared"
+
+// ParseLink splits a share link into the API base URL and the slug. A bare
+// slug has no base URL.
+func ParseLink(link string) (string, string, error) {
+	if !strings.Contains(link, "/") {
+		return "", link, nil
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", "", fmt.Errorf("invalid share link %q", link)
+	}
+
+	prefix, slug, found := strings.Cut(parsed.Path, "/api/shared/")
+	if !found || slug == "" || strings.Contains(slug, "/") {
+		return "", "", fmt.Errorf("invalid share link %q", link)
+	}
+
+	return parsed.Scheme + "://" + parsed.Host + prefix, slug, nil
+}
+
+type ImportArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	Client  *client.Client
+	Slug    string
+	// Tag files the imported commands, it defaults to the first shared tag.
+	Tag string
+}
+
+type Imported struct {
+	Commands   []database.Command
+	Collection *database.Collection
+}
+
+// Import copies a shared command, or a shared collection with its commands,
+// into the local store.
+func Import(args ImportArgs) (Imported, error) {
+	shared, err := args.Client.GetShared(args.Ctx, args.Slug)
+	if err != nil {
+		return Imported{}, err
+	}
+
+	var imported Imported
+	switch {
+	case shared.Command != nil:
+		command, err := importCommand(args, *shared.Command)
+		if err != nil {
+			return Imported{}, err
+		}
+
+		imported.Commands = append(imported.Commands, command)
+	case shared.Collection != nil:
+		collection, err := collections.CreateCollection(collections.CreateCollectionArgs{
+			Ctx:         args.Ctx,
+			Queries:     args.Queries,
+			Name:        shared.Collection.Name,
+			Description: shared.Collection.Description,
+		})
+		if err != nil {
+			return Imported{}, err
+		}
+
+		imported.Collection = &collection
+		for _, sharedCommand := range shared.Collection.Commands {
+			command, err := importCommand(args, sharedCommand)
+			if err != nil {
+				return imported, err
+			}
+
+			err = collections.AddCommand(collections.AddCommandArgs{
+				Ctx:          args.Ctx,
+				Queries:      args.Queries,
+				CollectionID: collection.ID,
+				CommandID:    command.ID,
+			})
+			if err != nil {
+				return imported, err
+			}
+
+			imported.Commands = append(imported.Commands, command)
+		}
+	default:
+		return Imported{}, fmt.Errorf("unsupported shared type %q", shared.Type)
+	}
+
+	return imported, nil
+}
+
+func importCommand(args ImportArgs, shared client.SharedCommand) (database.Command, error) {
+	tag := args.Tag
+	if tag == "" && len(shared.Tags) > 0 {
+		tag = shared.Tags[0]
+	}
+	if tag == "" {
+		tag = DefaultTag
+	}
+
+	return commands.AddCommandWithTags(commands.AddCommandWithTagsArgs{
+		Db:          args.Db,
+		Ctx:         args.Ctx,
+		Queries:     args.Queries,
+		Command:     shared.Command,
+		Description: shared.Description,
+		Tag:         tag,
+	})
+}
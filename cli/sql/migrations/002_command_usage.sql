@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE Command ADD COLUMN remoteId text;
+
+CREATE TABLE CommandUsage (
+  id INTEGER PRIMARY KEY,
+  commandId INTEGER NOT NULL,
+  action text NOT NULL,
+  workingDirectory text,
+  exitCode INTEGER,
+  usedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  synced BOOLEAN NOT NULL DEFAULT FALSE,
+
+  FOREIGN KEY (commandId) REFERENCES Command(id) ON DELETE CASCADE
+);
+
+CREATE INDEX CommandUsage_commandId_idx ON CommandUsage(commandId);
+
+-- +goose Down
+DROP INDEX CommandUsage_commandId_idx;
+DROP TABLE CommandUsage;
+ALTER TABLE Command DROP COLUMN remoteId;
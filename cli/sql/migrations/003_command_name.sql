@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE Command ADD COLUMN name text;
+
+CREATE UNIQUE INDEX Command_name_idx ON Command(name);
+
+-- +goose Down
+DROP INDEX Command_name_idx;
+ALTER TABLE Command DROP COLUMN name;
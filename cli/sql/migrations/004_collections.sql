@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE Collection (
+  id INTEGER PRIMARY KEY,
+  name text NOT NULL UNIQUE,
+  description text
+);
+
+CREATE TABLE CollectionCommand (
+  collectionId INTEGER NOT NULL,
+  commandId INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+
+  PRIMARY KEY (collectionId, commandId),
+  FOREIGN KEY (collectionId) REFERENCES Collection(id) ON DELETE CASCADE,
+  FOREIGN KEY (commandId) REFERENCES Command(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE CollectionCommand;
+DROP TABLE Collection;
@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE Runbook (
+  id INTEGER PRIMARY KEY,
+  name text NOT NULL UNIQUE,
+  description text,
+  remoteId text
+);
+
+CREATE TABLE RunbookStep (
+  id INTEGER PRIMARY KEY,
+  runbookId INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+  name text,
+  commandId INTEGER,
+  command text,
+  confirm BOOLEAN NOT NULL DEFAULT FALSE,
+  continueOnError BOOLEAN NOT NULL DEFAULT FALSE,
+  captureAs text,
+
+  UNIQUE (runbookId, position),
+  CHECK (commandId IS NOT NULL OR command IS NOT NULL),
+  FOREIGN KEY (runbookId) REFERENCES Runbook(id) ON DELETE CASCADE,
+  FOREIGN KEY (commandId) REFERENCES Command(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE RunbookVariable (
+  runbookId INTEGER NOT NULL,
+  name text NOT NULL,
+  defaultValue text,
+
+  PRIMARY KEY (runbookId, name),
+  FOREIGN KEY (runbookId) REFERENCES Runbook(id) ON DELETE CASCADE
+);
+
+CREATE TABLE RunbookRun (
+  id INTEGER PRIMARY KEY,
+  runbookId INTEGER NOT NULL,
+  status text NOT NULL,
+  step INTEGER NOT NULL DEFAULT 0,
+  variables text NOT NULL,
+  startedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  finishedAt DATETIME,
+
+  FOREIGN KEY (runbookId) REFERENCES Runbook(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE RunbookRun;
+DROP TABLE RunbookVariable;
+DROP TABLE RunbookStep;
+DROP TABLE Runbook;
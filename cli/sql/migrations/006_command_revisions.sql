@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE CommandRevision (
+  id INTEGER PRIMARY KEY,
+  commandId INTEGER NOT NULL,
+  revision INTEGER NOT NULL,
+  command text,
+  description text,
+  -- Comma separated, sorted tag names
+  tags text NOT NULL DEFAULT '',
+  createdAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE (commandId, revision),
+  FOREIGN KEY (commandId) REFERENCES Command(id) ON DELETE CASCADE
+);
+
+-- Existing commands start their history at revision 1
+INSERT INTO CommandRevision (commandId, revision, command, description, tags)
+SELECT c.id, 1, c.command, c.description, COALESCE((
+  SELECT group_concat(name, ',') FROM (
+    SELECT t.name FROM Tag t
+    JOIN CommandTag ct ON ct.tagId = t.id
+    WHERE ct.commandId = c.id
+    ORDER BY t.name
+  )
+), '')
+FROM Command c;
+
+-- +goose Down
+DROP TABLE CommandRevision;
@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE Command ADD COLUMN deletedAt DATETIME;
+ALTER TABLE Tag ADD COLUMN deletedAt DATETIME;
+
+-- Commands in the trash do not hold on to their name.
+DROP INDEX Command_name_idx;
+CREATE UNIQUE INDEX Command_name_idx ON Command(name) WHERE deletedAt IS NULL;
+
+-- Destructive operations, newest last, so that termflow undo can reverse
+-- them. revision is the revision of the command before an edit.
+CREATE TABLE Operation (
+  id INTEGER PRIMARY KEY,
+  kind text NOT NULL,
+  targetId INTEGER NOT NULL,
+  revision INTEGER,
+  createdAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  undoneAt DATETIME
+);
+
+-- +goose Down
+DROP TABLE Operation;
+
+DELETE FROM Command WHERE deletedAt IS NOT NULL;
+DELETE FROM Tag WHERE deletedAt IS NOT NULL;
+
+DROP INDEX Command_name_idx;
+CREATE UNIQUE INDEX Command_name_idx ON Command(name);
+
+ALTER TABLE Tag DROP COLUMN deletedAt;
+ALTER TABLE Command DROP COLUMN deletedAt;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- The version of the remote command the local one was last pushed as, sent
+-- as If-Match so that changes made elsewhere are not overwritten.
+ALTER TABLE Command ADD COLUMN remoteVersion INTEGER;
+
+-- +goose Down
+ALTER TABLE Command DROP COLUMN remoteVersion;
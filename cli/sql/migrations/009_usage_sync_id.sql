@@ -0,0 +1,8 @@
+-- +goose Up
+-- The sync unsynced usage was assigned to. It is kept until the usage is
+-- marked as synced, so that a sync retried after a failure is sent with the
+-- same id and the API does not count it twice.
+ALTER TABLE CommandUsage ADD COLUMN syncId text;
+
+-- +goose Down
+ALTER TABLE CommandUsage DROP COLUMN syncId;
@@ -0,0 +1,8 @@
+// Package migrations embeds the goose migrations of the local SQLite store so
+// they can be applied when the CLI opens its database.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,52 @@
+-- name: GetCollection :one
+SELECT * FROM Collection
+WHERE id = ? LIMIT 1;
+
+-- name: GetCollectionByName :one
+SELECT * FROM Collection
+WHERE name = ? LIMIT 1;
+
+-- name: ListCollections :many
+SELECT * FROM Collection
+ORDER BY name;
+
+-- name: CreateCollection :one
+INSERT INTO Collection (
+  name, description
+) VALUES (
+  ?, ?
+)
+RETURNING *;
+
+-- name: UpdateCollection :exec
+UPDATE Collection
+set name = ?,
+description = ?
+WHERE id = ?;
+
+-- name: DeleteCollection :exec
+DELETE FROM Collection
+WHERE id = ?;
+
+-- name: ListCollectionCommands :many
+SELECT c.id, c.command, c.description, c.name, cc.position
+FROM CollectionCommand cc
+JOIN Command c ON c.id = cc.commandId
+WHERE cc.collectionId = ? AND c.deletedAt IS NULL
+ORDER BY cc.position;
+
+-- name: AddCommandToCollection :exec
+INSERT INTO CollectionCommand (
+  collectionId, commandId, position
+) VALUES (
+  ?1, ?2, (SELECT COALESCE(MAX(position) + 1, 0) FROM CollectionCommand WHERE collectionId = ?1)
+);
+
+-- name: RemoveCommandFromCollection :exec
+DELETE FROM CollectionCommand
+WHERE collectionId = ? AND commandId = ?;
+
+-- name: UpdateCollectionCommandPosition :exec
+UPDATE CollectionCommand
+SET position = ?
+WHERE collectionId = ? AND commandId = ?;
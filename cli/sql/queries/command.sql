@@ -1,9 +1,18 @@
 -- name: GetCommand :one
 SELECT * FROM Command
+WHERE id = ? AND deletedAt IS NULL LIMIT 1;
+
+-- name: GetCommandByName :one
+SELECT * FROM Command
+WHERE name = ? AND deletedAt IS NULL LIMIT 1;
+
+-- name: GetCommandIncludingDeleted :one
+SELECT * FROM Command
 WHERE id = ? LIMIT 1;
 
 -- name: ListCommands :many
 SELECT * FROM Command
+WHERE deletedAt IS NULL
 ORDER BY id;
 
 -- name: ListCommandsForTagByName :many
@@ -11,7 +20,7 @@ SELECT c.id, c.command, c.description
 FROM Command c
 JOIN CommandTag ct ON c.id = ct.commandId
 JOIN Tag t ON ct.tagId = t.id
-WHERE t.name = ?;
+WHERE t.name = ? AND c.deletedAt IS NULL AND t.deletedAt IS NULL;
 
 -- name: ListCommandsWithTagsByTagName :many
 SELECT 
@@ -24,7 +33,7 @@ SELECT
 FROM Command c
 LEFT JOIN CommandTag ct ON c.id = ct.commandId
 LEFT JOIN Tag t ON ct.tagId = t.id
-WHERE t.name = ?
+WHERE t.name = ? AND c.deletedAt IS NULL AND t.deletedAt IS NULL
 ORDER BY c.id, t.name;
 
 -- name: ListCommandsWithTags :many
@@ -37,14 +46,15 @@ SELECT
     t.description AS tag_description
 FROM Command c
 LEFT JOIN CommandTag ct ON c.id = ct.commandId
-LEFT JOIN Tag t ON ct.tagId = t.id
+LEFT JOIN Tag t ON ct.tagId = t.id AND t.deletedAt IS NULL
+WHERE c.deletedAt IS NULL
 ORDER BY c.id, t.name;
 
 -- name: AddCommand :one
 INSERT INTO Command (
-  command, description
+  command, description, name
 ) VALUES (
-  ?, ?
+  ?, ?, ?
 ) RETURNING *;
 
 -- name: UpdateCommand :exec
@@ -53,28 +63,38 @@ set command = ?,
 description = ?
 WHERE id = ?;
 
+-- name: SetCommandRemote :exec
+UPDATE Command
+SET remoteId = ?,
+remoteVersion = ?
+WHERE id = ?;
+
 -- name: DeleteCommand :exec
 DELETE FROM Command
 WHERE id = ?;
 
 -- name: GetTag :one
 SELECT * FROM Tag
-WHERE id = ? LIMIT 1;
+WHERE id = ? AND deletedAt IS NULL LIMIT 1;
 
 -- name: GetTagByName :one
 SELECT * FROM Tag
-WHERE name = ? LIMIT 1;
+WHERE name = ? AND deletedAt IS NULL LIMIT 1;
 
 -- name: ListTags :many
 SELECT * FROM Tag
+WHERE deletedAt IS NULL
 ORDER BY name;
 
 -- name: AddTag :one
+-- Adding a tag with the name of one in the trash brings that tag back.
 INSERT INTO Tag (
   name, description
 ) VALUES (
   ?, ?
-) RETURNING *;
+)
+ON CONFLICT (name) DO UPDATE SET deletedAt = NULL
+RETURNING *;
 
 -- name: UpdateTag :exec
 UPDATE Tag
@@ -98,10 +118,10 @@ DELETE FROM CommandTag
 WHERE commandId = ? AND tagId = ?;
 
 -- name: GetTagsForCommand :many
-SELECT t.id, t.name, t.description
+SELECT t.*
 FROM Tag t
 JOIN CommandTag ct ON t.id = ct.tagId
-WHERE ct.commandId = ?;
+WHERE ct.commandId = ? AND t.deletedAt IS NULL;
 
 
 
@@ -0,0 +1,30 @@
+-- name: AddCommandRevision :one
+INSERT INTO CommandRevision (
+  commandId, revision, command, description, tags
+)
+SELECT c.id,
+  COALESCE((SELECT MAX(r.revision) FROM CommandRevision r WHERE r.commandId = c.id), 0) + 1,
+  c.command, c.description, COALESCE((
+    SELECT group_concat(name, ',') FROM (
+      SELECT t.name FROM Tag t
+      JOIN CommandTag ct ON ct.tagId = t.id
+      WHERE ct.commandId = c.id AND t.deletedAt IS NULL
+      ORDER BY t.name
+    )
+  ), '')
+FROM Command c
+WHERE c.id = ?
+RETURNING *;
+
+-- name: GetCommandRevision :one
+SELECT * FROM CommandRevision
+WHERE commandId = ? AND revision = ? LIMIT 1;
+
+-- name: ListCommandRevisions :many
+SELECT * FROM CommandRevision
+WHERE commandId = ?
+ORDER BY revision DESC;
+
+-- name: RemoveCommandTags :exec
+DELETE FROM CommandTag
+WHERE commandId = ?;
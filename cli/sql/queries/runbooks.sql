@@ -0,0 +1,81 @@
+-- name: GetRunbook :one
+SELECT * FROM Runbook
+WHERE id = ? LIMIT 1;
+
+-- name: GetRunbookByName :one
+SELECT * FROM Runbook
+WHERE name = ? LIMIT 1;
+
+-- name: ListRunbooks :many
+SELECT * FROM Runbook
+ORDER BY name;
+
+-- name: CreateRunbook :one
+INSERT INTO Runbook (
+  name, description
+) VALUES (
+  ?, ?
+)
+RETURNING *;
+
+-- name: SetRunbookRemoteId :exec
+UPDATE Runbook
+SET remoteId = ?
+WHERE id = ?;
+
+-- name: DeleteRunbook :exec
+DELETE FROM Runbook
+WHERE id = ?;
+
+-- name: ListRunbookSteps :many
+SELECT * FROM RunbookStep
+WHERE runbookId = ?
+ORDER BY position;
+
+-- name: AddRunbookStep :one
+INSERT INTO RunbookStep (
+  runbookId, position, name, commandId, command, confirm, continueOnError, captureAs
+) VALUES (
+  ?1, (SELECT COALESCE(MAX(position) + 1, 0) FROM RunbookStep WHERE runbookId = ?1), ?2, ?3, ?4, ?5, ?6, ?7
+)
+RETURNING *;
+
+-- name: IsCommandUsedByRunbook :one
+SELECT EXISTS (
+  SELECT 1 FROM RunbookStep
+  WHERE commandId = ?
+);
+
+-- name: ListRunbookVariables :many
+SELECT * FROM RunbookVariable
+WHERE runbookId = ?
+ORDER BY name;
+
+-- name: SetRunbookVariable :exec
+INSERT INTO RunbookVariable (
+  runbookId, name, defaultValue
+) VALUES (
+  ?, ?, ?
+)
+ON CONFLICT (runbookId, name) DO UPDATE SET defaultValue = excluded.defaultValue;
+
+-- name: GetLastRunbookRun :one
+SELECT * FROM RunbookRun
+WHERE runbookId = ?
+ORDER BY id DESC LIMIT 1;
+
+-- name: CreateRunbookRun :one
+INSERT INTO RunbookRun (
+  runbookId, status, step, variables
+) VALUES (
+  ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: UpdateRunbookRun :exec
+UPDATE RunbookRun
+SET status = ?,
+step = ?,
+variables = ?,
+finishedAt = ?
+WHERE id = ?;
@@ -0,0 +1,65 @@
+-- name: SoftDeleteCommand :execrows
+UPDATE Command
+SET deletedAt = CURRENT_TIMESTAMP
+WHERE id = ? AND deletedAt IS NULL;
+
+-- name: SoftDeleteTag :execrows
+UPDATE Tag
+SET deletedAt = CURRENT_TIMESTAMP
+WHERE id = ? AND deletedAt IS NULL;
+
+-- name: UndeleteCommand :execrows
+UPDATE Command
+SET deletedAt = NULL
+WHERE id = ? AND deletedAt IS NOT NULL;
+
+-- name: UndeleteTag :execrows
+UPDATE Tag
+SET deletedAt = NULL
+WHERE id = ? AND deletedAt IS NOT NULL;
+
+-- name: ListDeletedCommands :many
+SELECT * FROM Command
+WHERE deletedAt IS NOT NULL
+ORDER BY deletedAt DESC, id DESC;
+
+-- name: ListDeletedTags :many
+SELECT * FROM Tag
+WHERE deletedAt IS NOT NULL
+ORDER BY deletedAt DESC, id DESC;
+
+-- name: PurgeCommand :execrows
+DELETE FROM Command
+WHERE id = ? AND deletedAt IS NOT NULL;
+
+-- name: PurgeTag :execrows
+DELETE FROM Tag
+WHERE id = ? AND deletedAt IS NOT NULL;
+
+-- name: PurgeDeletedCommands :execrows
+-- Commands trashed while a runbook step used them stay until the step is
+-- gone, deleting them would fail the whole purge.
+DELETE FROM Command
+WHERE deletedAt <= datetime('now', CAST(sqlc.arg(age) AS TEXT))
+  AND NOT EXISTS (SELECT 1 FROM RunbookStep WHERE RunbookStep.commandId = Command.id);
+
+-- name: PurgeDeletedTags :execrows
+DELETE FROM Tag
+WHERE deletedAt <= datetime('now', CAST(sqlc.arg(age) AS TEXT));
+
+-- name: AddOperation :exec
+INSERT INTO Operation (
+  kind, targetId, revision
+) VALUES (
+  ?, ?, ?
+);
+
+-- name: GetLastOperation :one
+SELECT * FROM Operation
+WHERE undoneAt IS NULL
+ORDER BY id DESC LIMIT 1;
+
+-- name: MarkOperationUndone :exec
+UPDATE Operation
+SET undoneAt = CURRENT_TIMESTAMP
+WHERE id = ?;
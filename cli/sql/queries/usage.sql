@@ -0,0 +1,72 @@
+-- name: RecordCommandUsage :one
+INSERT INTO CommandUsage (
+  commandId, action, workingDirectory, exitCode
+) VALUES (
+  ?, ?, ?, ?
+) RETURNING *;
+
+-- name: ListCommandsByFrecency :many
+SELECT
+  c.id,
+  c.command,
+  c.description,
+  CAST(COALESCE(SUM(
+    CASE
+      WHEN u.id IS NULL THEN 0
+      WHEN u.usedAt >= datetime('now', '-4 days') THEN 100
+      WHEN u.usedAt >= datetime('now', '-14 days') THEN 70
+      WHEN u.usedAt >= datetime('now', '-31 days') THEN 50
+      WHEN u.usedAt >= datetime('now', '-90 days') THEN 30
+      ELSE 10
+    END
+  ), 0) AS INTEGER) AS frecency,
+  COUNT(u.id) AS use_count
+FROM Command c
+LEFT JOIN CommandUsage u ON u.commandId = c.id
+WHERE c.deletedAt IS NULL
+GROUP BY c.id
+ORDER BY frecency DESC, c.id;
+
+-- name: ListMostUsedCommands :many
+SELECT c.id, c.command, c.description, COUNT(u.id) AS use_count
+FROM CommandUsage u
+JOIN Command c ON c.id = u.commandId
+WHERE u.usedAt >= sqlc.arg(since) AND c.deletedAt IS NULL
+GROUP BY c.id
+ORDER BY use_count DESC, c.id
+LIMIT sqlc.arg(limit);
+
+-- name: ListMostUsedTags :many
+SELECT t.id, t.name, COUNT(u.id) AS use_count
+FROM CommandUsage u
+JOIN CommandTag ct ON ct.commandId = u.commandId
+JOIN Tag t ON t.id = ct.tagId
+WHERE u.usedAt >= sqlc.arg(since) AND t.deletedAt IS NULL
+GROUP BY t.id
+ORDER BY use_count DESC, t.name
+LIMIT sqlc.arg(limit);
+
+-- name: AssignUsageSyncId :exec
+UPDATE CommandUsage
+SET syncId = ?
+WHERE synced = FALSE
+  AND syncId IS NULL
+  AND commandId IN (SELECT id FROM Command WHERE remoteId IS NOT NULL);
+
+-- name: ListUnsyncedUsage :many
+SELECT u.id, u.usedAt, u.syncId, c.remoteId
+FROM CommandUsage u
+JOIN Command c ON c.id = u.commandId
+WHERE u.synced = FALSE AND u.syncId IS NOT NULL AND c.remoteId IS NOT NULL
+ORDER BY u.id;
+
+-- name: MarkUsageSynced :exec
+UPDATE CommandUsage
+SET synced = TRUE
+WHERE id = ?;
+
+-- name: ListUnpushedCommandsWithUsage :many
+SELECT c.* FROM Command c
+WHERE c.remoteId IS NULL AND c.deletedAt IS NULL
+  AND EXISTS (SELECT 1 FROM CommandUsage u WHERE u.commandId = c.id AND u.synced = FALSE)
+ORDER BY c.id;
@@ -0,0 +1,56 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+type GetTagArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Name    string
+}
+
+func GetTag(args GetTagArgs) (database.Tag, error) {
+	tag, err := args.Queries.GetTagByName(args.Ctx, args.Name)
+	if err != nil {
+		return database.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+type CreateTagArgs struct {
+	Ctx         context.Context
+	Queries     *database.Queries
+	Name        string
+	Description string
+}
+
+func CreateTag(args CreateTagArgs) (database.Tag, error) {
+	newTag, err := args.Queries.AddTag(args.Ctx, database.AddTagParams{
+		Name:        args.Name,
+		Description: sql.NullString{String: args.Description, Valid: args.Description != ""},
+	})
+	if err != nil {
+		return database.Tag{}, err
+	}
+
+	return newTag, nil
+}
+
+type ListTagsArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+}
+
+func ListTags(args ListTagsArgs) ([]database.Tag, error) {
+	tags, err := args.Queries.ListTags(args.Ctx)
+	if err != nil {
+		return []database.Tag{}, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,234 @@
+package usage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"github.com/endalk200/termflow-cli/commands"
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+)
+
+// Actions recorded for every use of a saved command.
+const (
+	ActionCopy    = "copy"
+	ActionInsert  = "insert"
+	ActionExecute = "execute"
+)
+
+type RecordArgs struct {
+	Ctx       context.Context
+	Queries   *database.Queries
+	CommandID int64
+	Action    string
+	// ExitCode is only known when the command was executed by termflow.
+	ExitCode *int
+}
+
+func Record(args RecordArgs) (database.Commandusage, error) {
+	var workingDirectory sql.NullString
+	if wd, err := os.Getwd(); err == nil {
+		workingDirectory = sql.NullString{String: wd, Valid: true}
+	}
+
+	var exitCode sql.NullInt64
+	if args.ExitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*args.ExitCode), Valid: true}
+	}
+
+	record, err := args.Queries.RecordCommandUsage(args.Ctx, database.RecordCommandUsageParams{
+		Commandid:        args.CommandID,
+		Action:           args.Action,
+		Workingdirectory: workingDirectory,
+		Exitcode:         exitCode,
+	})
+	if err != nil {
+		return database.Commandusage{}, err
+	}
+
+	return record, nil
+}
+
+type ListByFrecencyArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+}
+
+// ListByFrecency returns every command ordered by its frecency score. Each
+// use adds a weight that decays with its age, so commands used often and
+// recently rank first.
+func ListByFrecency(args ListByFrecencyArgs) ([]database.ListCommandsByFrecencyRow, error) {
+	commands, err := args.Queries.ListCommandsByFrecency(args.Ctx)
+	if err != nil {
+		return []database.ListCommandsByFrecencyRow{}, err
+	}
+
+	return commands, nil
+}
+
+type GetStatsArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Since   time.Time
+	Limit   int64
+}
+
+type Stats struct {
+	Commands []database.ListMostUsedCommandsRow
+	Tags     []database.ListMostUsedTagsRow
+}
+
+func GetStats(args GetStatsArgs) (Stats, error) {
+	since := args.Since.UTC().Truncate(time.Second)
+
+	commands, err := args.Queries.ListMostUsedCommands(args.Ctx, database.ListMostUsedCommandsParams{
+		Since: since,
+		Limit: args.Limit,
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	tags, err := args.Queries.ListMostUsedTags(args.Ctx, database.ListMostUsedTagsParams{
+		Since: since,
+		Limit: args.Limit,
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return Stats{Commands: commands, Tags: tags}, nil
+}
+
+type PushCommandsArgs struct {
+	Ctx     context.Context
+	Queries *database.Queries
+	Client  *client.Client
+	// Tag files the commands without tags on the API.
+	Tag string
+}
+
+// PushCommands creates the commands whose usage was not synced yet on the
+// API when they were never pushed, so that Sync can send their usage. It
+// returns the number of commands that were pushed.
+func PushCommands(args PushCommandsArgs) (int, error) {
+	unpushed, err := args.Queries.ListUnpushedCommandsWithUsage(args.Ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, command := range unpushed {
+		_, err := commands.Push(commands.PushArgs{
+			Ctx:     args.Ctx,
+			Queries: args.Queries,
+			Client:  args.Client,
+			Command: command,
+			Tag:     args.Tag,
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(unpushed), nil
+}
+
+type SyncArgs struct {
+	Db      *sql.DB
+	Ctx     context.Context
+	Queries *database.Queries
+	Client  *client.Client
+}
+
+// Sync pushes usage of commands that exist on the API and marks it as synced,
+// PushCommands creates the others. It returns the number of usage records
+// that were pushed.
+//
+// Usage is assigned a sync id before it is pushed. Usage that was pushed but
+// could not be marked as synced keeps its id, so the next sync sends it with
+// the same id and the API does not count it again.
+func Sync(args SyncArgs) (int, error) {
+	syncID, err := newSyncID()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := args.Queries.AssignUsageSyncId(args.Ctx, sql.NullString{String: syncID, Valid: true}); err != nil {
+		return 0, err
+	}
+
+	records, err := args.Queries.ListUnsyncedUsage(args.Ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	// Usage is counted per command and sync, retried syncs are sent apart
+	// from the new one
+	type countKey struct {
+		remoteID string
+		syncID   string
+	}
+
+	counts := map[countKey]*client.CommandUsage{}
+	pushed := []*client.CommandUsage{}
+	for _, record := range records {
+		key := countKey{remoteID: record.Remoteid.String, syncID: record.Syncid.String}
+		count, exists := counts[key]
+		if !exists {
+			count = &client.CommandUsage{CommandID: key.remoteID, SyncID: key.syncID}
+			counts[key] = count
+			pushed = append(pushed, count)
+		}
+
+		count.Count++
+		if record.Usedat.After(count.LastUsedAt) {
+			count.LastUsedAt = record.Usedat
+		}
+	}
+
+	payload := make([]client.CommandUsage, 0, len(pushed))
+	for _, count := range pushed {
+		payload = append(payload, *count)
+	}
+
+	if err := args.Client.PushUsage(args.Ctx, payload); err != nil {
+		return 0, err
+	}
+
+	tx, err := args.Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+	qtx := args.Queries.WithTx(tx)
+
+	for _, record := range records {
+		if err := qtx.MarkUsageSynced(args.Ctx, record.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
+
+func newSyncID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
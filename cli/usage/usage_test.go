@@ -0,0 +1,133 @@
+package usage_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/endalk200/termflow-cli/internal/client"
+	"github.com/endalk200/termflow-cli/internal/database"
+	"github.com/endalk200/termflow-cli/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListByFrecency(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open(filepath.Join(t.TempDir(), "termflow.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	queries := database.New(db)
+
+	addCommand := func(command string) database.Command {
+		t.Helper()
+		saved, err := queries.AddCommand(ctx, database.AddCommandParams{Command: sql.NullString{String: command, Valid: true}})
+		require.NoError(t, err)
+		return saved
+	}
+
+	record := func(commandID int64, age string) {
+		t.Helper()
+		used, err := usage.Record(usage.RecordArgs{Ctx: ctx, Queries: queries, CommandID: commandID, Action: usage.ActionCopy})
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, "UPDATE CommandUsage SET usedAt = datetime('now', ?) WHERE id = ?", age, used.ID)
+		require.NoError(t, err)
+	}
+
+	unused := addCommand("make clean")
+	often := addCommand("make test")
+	recent := addCommand("make deploy")
+
+	// Three uses two months ago weigh less than one use today
+	for range 3 {
+		record(often.ID, "-60 days")
+	}
+	record(recent.ID, "-1 hours")
+
+	commands, err := usage.ListByFrecency(usage.ListByFrecencyArgs{Ctx: ctx, Queries: queries})
+	require.NoError(t, err)
+	require.Len(t, commands, 3)
+
+	assert.Equal(t, []int64{recent.ID, often.ID, unused.ID}, []int64{commands[0].ID, commands[1].ID, commands[2].ID})
+	assert.Equal(t, int64(100), commands[0].Frecency)
+	assert.Equal(t, int64(90), commands[1].Frecency)
+	assert.Equal(t, int64(3), commands[1].UseCount)
+	assert.Equal(t, int64(0), commands[2].Frecency)
+
+	// Enough old uses outweigh a recent one
+	record(often.ID, "-60 days")
+	record(often.ID, "-60 days")
+
+	commands, err = usage.ListByFrecency(usage.ListByFrecencyArgs{Ctx: ctx, Queries: queries})
+	require.NoError(t, err)
+	assert.Equal(t, often.ID, commands[0].ID)
+	assert.Equal(t, int64(150), commands[0].Frecency)
+}
+
+func TestPushCommandsAndSync(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open(filepath.Join(t.TempDir(), "termflow.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	queries := database.New(db)
+
+	var pushedUsage []client.CommandUsage
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/tags":
+			_ = json.NewEncoder(w).Encode([]client.Tag{})
+		case "POST /api/tags":
+			_ = json.NewEncoder(w).Encode(client.Tag{ID: "tag-1", Name: "ops"})
+		case "POST /api/commands":
+			_ = json.NewEncoder(w).Encode(map[string]client.Command{
+				"command": {ID: "command-1", Command: "make deploy", Version: 1},
+			})
+		case "POST /api/commands/usage":
+			var payload struct {
+				Usage []client.CommandUsage `json:"usage"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			pushedUsage = append(pushedUsage, payload.Usage...)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+	apiClient := client.New(api.URL, "token")
+
+	command, err := queries.AddCommand(ctx, database.AddCommandParams{Command: sql.NullString{String: "make deploy", Valid: true}})
+	require.NoError(t, err)
+	for range 2 {
+		_, err := usage.Record(usage.RecordArgs{Ctx: ctx, Queries: queries, CommandID: command.ID, Action: usage.ActionExecute})
+		require.NoError(t, err)
+	}
+
+	// Usage of commands that were never pushed is not sent
+	pushed, err := usage.Sync(usage.SyncArgs{Db: db, Ctx: ctx, Queries: queries, Client: apiClient})
+	require.NoError(t, err)
+	assert.Zero(t, pushed)
+
+	created, err := usage.PushCommands(usage.PushCommandsArgs{Ctx: ctx, Queries: queries, Client: apiClient, Tag: "ops"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	saved, err := queries.GetCommand(ctx, command.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "command-1", saved.Remoteid.String)
+
+	pushed, err = usage.Sync(usage.SyncArgs{Db: db, Ctx: ctx, Queries: queries, Client: apiClient})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pushed)
+	require.Len(t, pushedUsage, 1)
+	assert.Equal(t, "command-1", pushedUsage[0].CommandID)
+	assert.Equal(t, int64(2), pushedUsage[0].Count)
+
+	// Commands are only pushed while they have usage to sync
+	created, err = usage.PushCommands(usage.PushCommandsArgs{Ctx: ctx, Queries: queries, Client: apiClient, Tag: "ops"})
+	require.NoError(t, err)
+	assert.Zero(t, created)
+}